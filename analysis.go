@@ -33,6 +33,7 @@ const (
 	FileFastq
 	FileText
 	FileInterop
+	FileMultiQC
 )
 
 var validAnalysisFileTypes = map[string]AnalysisFileType{
@@ -46,6 +47,7 @@ var validAnalysisFileTypes = map[string]AnalysisFileType{
 	"fastq":   FileFastq,
 	"text":    FileText,
 	"interop": FileInterop,
+	"multiqc": FileMultiQC,
 }
 
 func (t AnalysisFileType) String() string {
@@ -70,13 +72,15 @@ func (t AnalysisFileType) String() string {
 		return "text"
 	case FileInterop:
 		return "interop"
+	case FileMultiQC:
+		return "multiqc"
 	default:
 		return ""
 	}
 }
 
 func (t AnalysisFileType) IsValid() bool {
-	return t > FileInvalid && t <= FileInterop
+	return t > FileInvalid && t <= FileMultiQC
 }
 
 func (t AnalysisFileType) IsZero() bool {
@@ -355,6 +359,50 @@ type Analysis struct {
 	StateHistory    StateHistory         `bson:"state_history" json:"state_history"`
 	InputFiles      []AnalysisFileFilter `bson:"input_files" json:"input_files"`
 	OutputFiles     AnalysisFiles        `bson:"output_files" json:"output_files"`
+	// SampleSheetId is the UUID of the samplesheet used by the analysis, if it differs
+	// from the samplesheet of the run.
+	SampleSheetId *uuid.UUID `bson:"samplesheet_id,omitempty" json:"samplesheet_id,omitempty"`
+	// Detector is the name of the [AnalysisDetector] that found the analysis, if any.
+	Detector string `bson:"detector,omitempty" json:"detector,omitempty"`
+}
+
+// SampleSheetPath returns the path to the samplesheet used by a Dragen analysis, i.e.
+// Data/SampleSheet.csv in the analysis directory. If the analysis is not a Dragen
+// analysis, or if the samplesheet does not exist, an empty string is returned.
+func (a *Analysis) SampleSheetPath() string {
+	if !strings.HasPrefix(strings.ToLower(a.Software), "dragen") {
+		return ""
+	}
+	path := filepath.Join(a.Path, "Data", "SampleSheet.csv")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// ReadSampleSheet reads the samplesheet used by the analysis and links it to the
+// analysis. The samplesheet is identified by the SampleSheetId of the analysis,
+// which is assigned if it is not already set, regardless of any UUID defined in the
+// samplesheet itself. This keeps the samplesheet of the analysis separate from that
+// of the run.
+func (a *Analysis) ReadSampleSheet() (SampleSheet, error) {
+	path := a.SampleSheetPath()
+	if path == "" {
+		return SampleSheet{}, fmt.Errorf("no samplesheet found for analysis %s: %w", a.AnalysisId, os.ErrNotExist)
+	}
+	sampleSheet, err := ReadSampleSheet(path)
+	if err != nil {
+		return sampleSheet, err
+	}
+	if a.SampleSheetId == nil {
+		id := uuid.New()
+		a.SampleSheetId = &id
+	}
+	analysisId := a.AnalysisId
+	sampleSheet.UUID = a.SampleSheetId
+	sampleSheet.RunID = nil
+	sampleSheet.AnalysisId = &analysisId
+	return sampleSheet, nil
 }
 
 // GetFiles returns all output files of the analysis for which the supplied filter is true.
@@ -505,6 +553,11 @@ func NewDragenAnalysis(path string, run *Run) (Analysis, error) {
 	}
 	analysis.SoftwareVersion = dragenVersion
 
+	if analysis.SampleSheetPath() != "" {
+		id := uuid.New()
+		analysis.SampleSheetId = &id
+	}
+
 	if state != StateReady {
 		analysis.StateHistory.Add(state)
 		return analysis, nil
@@ -543,38 +596,33 @@ func NewDragenAnalysis(path string, run *Run) (Analysis, error) {
 	return analysis, nil
 }
 
-// DetectState detects the state of a Dragen analysis. If the analysis is not
-// a Dragen analysis, the last known state of the analysis is returned.
+// DetectState detects the state of an analysis using the detector that found it, see
+// [AnalysisDetectorFor]. Other Dragen analyses get the state of the Dragen analysis
+// directory, and for all other analyses the last known state is returned.
 // A caveat of this method is that the Dragen state will only represent the
 // overall state of the analysis, not that of individual samples in the analysis.
 func (a *Analysis) DetectState() State {
+	if d := AnalysisDetectorFor(a); d != nil {
+		return d.State(a)
+	}
 	if strings.HasPrefix(strings.ToLower(a.Software), "dragen") {
 		return dragenAnalysisState(a.Path)
 	}
 	return a.StateHistory.LastState()
 }
 
-// UpdateOutputFiles updates the output files for Dragen analyses. Nothing is done
-// for other types of analyses.
+// UpdateOutputFiles updates the output files of an analysis using the detector that
+// found it, see [AnalysisDetectorFor]. Nothing is done for other analyses.
 func (a *Analysis) UpdateOutputFiles() error {
-	if strings.HasPrefix(strings.ToLower(a.Software), "dragen") {
-		if strings.Contains(strings.ToLower(a.Software), "bclconvert") {
-			var summary DragenAnalysisSummary
-			f, err := os.Open(filepath.Join(a.Path, "Data", "summary", a.SoftwareVersion, "detailed_summary.json"))
-			if err != nil {
-				return fmt.Errorf("failed to read dragen analysis summary: %w", err)
-			}
-			summary, err = ParseDragenAnalysisSummary(f)
-			if err != nil {
-				return fmt.Errorf("failed to parse dragen analysis summary: %w", err)
-			}
-			files, err := bclConvertFiles(a, summary)
-			if err != nil {
-				return err
-			}
-			a.OutputFiles = files
-		}
+	d := AnalysisDetectorFor(a)
+	if d == nil {
+		return nil
 	}
+	files, err := d.OutputFiles(a)
+	if err != nil {
+		return err
+	}
+	a.OutputFiles = files
 	return nil
 }
 
@@ -620,26 +668,56 @@ func bclConvertFiles(analysis *Analysis, summary DragenAnalysisSummary) ([]Analy
 		slog.Warn("dragen manifest", "error", err, "name", "IndexMetricsOut.bin", "analysis_id", analysis.AnalysisId, "path", analysis.Path)
 	}
 
+	for _, sampleId := range analysisSampleIds(analysis, summary) {
+		// Add the fastq files to the analysis
+		fqRegex, err := regexp.Compile(`^` + regexp.QuoteMeta(sampleId) + `.*\.f(ast)?q(\.gz)?$`)
+		if err != nil {
+			return files, fmt.Errorf("failed to compile regex for sample fastq files: %w", err)
+		}
+		for _, f := range manifest.FindFiles(fqRegex) {
+			files = append(files, AnalysisFile{
+				partOfAnalysis: true,
+				Path:           f,
+				FileType:       FileFastq,
+				Level:          LevelSample,
+				ParentId:       sampleId,
+			})
+		}
+	}
+
+	return files, nil
+}
+
+// analysisSampleIds returns the IDs of the samples in a Dragen analysis. The samples
+// are taken from the samplesheet used by the analysis if there is one, otherwise
+// from the analysis summary.
+func analysisSampleIds(analysis *Analysis, summary DragenAnalysisSummary) []string {
+	var ids []string
+	if analysis.SampleSheetPath() != "" {
+		sampleSheet, err := ReadSampleSheet(analysis.SampleSheetPath())
+		if err == nil {
+			var samples []SampleSheetSample
+			samples, err = sampleSheet.Samples()
+			for _, s := range samples {
+				if !slices.Contains(ids, s.SampleId) {
+					ids = append(ids, s.SampleId)
+				}
+			}
+		}
+		if err == nil && len(ids) > 0 {
+			return ids
+		}
+		slog.Warn("failed to get samples from analysis samplesheet, using analysis summary", "error", err, "analysis_id", analysis.AnalysisId, "path", analysis.Path)
+		ids = nil
+	}
 	for _, wf := range summary.Workflows {
 		for _, sample := range wf.Samples {
-			// Add the fastq files to the analysis
-			fqRegex, err := regexp.Compile(`^` + regexp.QuoteMeta(sample.SampleID) + `.*\.f(ast)?q(\.gz)?$`)
-			if err != nil {
-				return files, fmt.Errorf("failed to compile regex for sample fastq files: %w", err)
-			}
-			for _, f := range manifest.FindFiles(fqRegex) {
-				files = append(files, AnalysisFile{
-					partOfAnalysis: true,
-					Path:           f,
-					FileType:       FileFastq,
-					Level:          LevelSample,
-					ParentId:       sample.SampleID,
-				})
+			if !slices.Contains(ids, sample.SampleID) {
+				ids = append(ids, sample.SampleID)
 			}
 		}
 	}
-
-	return files, nil
+	return ids
 }
 
 // dragenAnalysisState identifies the state of a Dragen analysis. This is just
@@ -0,0 +1,284 @@
+package cleve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// AnalysisDetector detects analyses of a specific kind on disk. Detectors are
+// registered by name with [RegisterAnalysisDetector], and the name is stored on the
+// analyses that a detector creates so that their state and output files can be
+// derived by the same detector later on.
+type AnalysisDetector interface {
+	// Name identifies the detector, e.g. in the configuration.
+	Name() string
+	// Match returns true if the directory is an analysis handled by the detector.
+	Match(path string) bool
+	// New creates an analysis from a directory that matches the detector. The run
+	// is nil if the directory is not associated with a sequencing run.
+	New(path string, run *Run) (Analysis, error)
+	// State derives the current state of an analysis.
+	State(analysis *Analysis) State
+	// OutputFiles enumerates the output files of an analysis that is ready.
+	OutputFiles(analysis *Analysis) (AnalysisFiles, error)
+}
+
+var (
+	analysisDetectorsMu sync.RWMutex
+	analysisDetectors   = []AnalysisDetector{
+		DragenBclConvertDetector{},
+		DragenSecondaryDetector{},
+		NextflowDetector{},
+	}
+)
+
+// RegisterAnalysisDetector makes an analysis detector available by its name. An
+// error is returned if a detector with the same name is already registered.
+func RegisterAnalysisDetector(d AnalysisDetector) error {
+	analysisDetectorsMu.Lock()
+	defer analysisDetectorsMu.Unlock()
+	if slices.ContainsFunc(analysisDetectors, func(x AnalysisDetector) bool { return x.Name() == d.Name() }) {
+		return fmt.Errorf("analysis detector %q already registered", d.Name())
+	}
+	analysisDetectors = append(analysisDetectors, d)
+	return nil
+}
+
+// ReplaceAnalysisDetector replaces the registered analysis detector with the same name,
+// e.g. to configure one of the built-in detectors. An error is returned if there is no
+// detector with the name.
+func ReplaceAnalysisDetector(d AnalysisDetector) error {
+	analysisDetectorsMu.Lock()
+	defer analysisDetectorsMu.Unlock()
+	i := slices.IndexFunc(analysisDetectors, func(x AnalysisDetector) bool { return x.Name() == d.Name() })
+	if i < 0 {
+		return fmt.Errorf("analysis detector %q is not registered", d.Name())
+	}
+	analysisDetectors[i] = d
+	return nil
+}
+
+// AnalysisDetectorByName returns the registered analysis detector with the given
+// name. The second return value is false if there is no such detector.
+func AnalysisDetectorByName(name string) (AnalysisDetector, bool) {
+	analysisDetectorsMu.RLock()
+	defer analysisDetectorsMu.RUnlock()
+	for _, d := range analysisDetectors {
+		if d.Name() == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// AnalysisDetectorNames returns the names of all registered analysis detectors.
+func AnalysisDetectorNames() []string {
+	analysisDetectorsMu.RLock()
+	defer analysisDetectorsMu.RUnlock()
+	names := make([]string, len(analysisDetectors))
+	for i, d := range analysisDetectors {
+		names[i] = d.Name()
+	}
+	return names
+}
+
+// AnalysisDetectorFor returns the detector that created the analysis. Dragen
+// BCLConvert analyses created before detectors were recorded on analyses are
+// identified by their software. If the analysis was not created by a detector,
+// nil is returned.
+func AnalysisDetectorFor(a *Analysis) AnalysisDetector {
+	if a.Detector != "" {
+		d, _ := AnalysisDetectorByName(a.Detector)
+		return d
+	}
+	software := strings.ToLower(a.Software)
+	if strings.HasPrefix(software, "dragen") && strings.Contains(software, "bclconvert") {
+		d, _ := AnalysisDetectorByName(DragenBclConvertDetector{}.Name())
+		return d
+	}
+	return nil
+}
+
+// DragenBclConvertDetector detects the Dragen analyses of a sequencing run, i.e. the
+// numbered directories in the Analysis directory of the run. Every directory is
+// considered an analysis, even before the analysis has produced any output.
+type DragenBclConvertDetector struct{}
+
+func (DragenBclConvertDetector) Name() string {
+	return "dragen_bclconvert"
+}
+
+func (DragenBclConvertDetector) Match(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func (DragenBclConvertDetector) New(path string, run *Run) (Analysis, error) {
+	if run == nil {
+		return Analysis{}, fmt.Errorf("dragen bclconvert analyses must belong to a run")
+	}
+	return NewDragenAnalysis(path, run)
+}
+
+func (DragenBclConvertDetector) State(a *Analysis) State {
+	return dragenAnalysisState(a.Path)
+}
+
+func (DragenBclConvertDetector) OutputFiles(a *Analysis) (AnalysisFiles, error) {
+	var summary DragenAnalysisSummary
+	f, err := os.Open(filepath.Join(a.Path, "Data", "summary", a.SoftwareVersion, "detailed_summary.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dragen analysis summary: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	summary, err = ParseDragenAnalysisSummary(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dragen analysis summary: %w", err)
+	}
+	return bclConvertFiles(a, summary)
+}
+
+// DragenSecondaryDetector detects the secondary analysis workflows of a Dragen
+// analysis, e.g. `Analysis/1/Data/DragenGermline`. The state of a workflow is that
+// of the Dragen analysis it is part of, and its output files are found through the
+// manifest of that analysis.
+type DragenSecondaryDetector struct{}
+
+var (
+	dragenSnvVcfRegex = regexp.MustCompile(`\.hard-filtered\.vcf\.gz$`)
+	dragenSvVcfRegex  = regexp.MustCompile(`\.sv\.vcf\.gz$`)
+	dragenBamRegex    = regexp.MustCompile(`\.bam$`)
+)
+
+func (DragenSecondaryDetector) Name() string {
+	return "dragen_secondary"
+}
+
+func (DragenSecondaryDetector) Match(path string) bool {
+	name := filepath.Base(path)
+	if !strings.HasPrefix(name, "Dragen") || len(name) == len("Dragen") {
+		return false
+	}
+	if filepath.Base(filepath.Dir(path)) != "Data" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func (d DragenSecondaryDetector) New(path string, run *Run) (Analysis, error) {
+	workflow := strings.TrimPrefix(filepath.Base(path), "Dragen")
+	analysis := Analysis{
+		AnalysisId: uuid.New(),
+		Runs:       []string{},
+		Software:   "Dragen " + workflow,
+		Path:       path,
+	}
+
+	if !filepath.IsAbs(path) {
+		return analysis, fmt.Errorf("path must be absolute")
+	}
+
+	if run != nil {
+		analysis.Runs = []string{run.RunID}
+		for _, sw := range run.RunParameters.Software {
+			if strings.ToLower(sw.Name) == "dragen" {
+				analysis.SoftwareVersion = sw.Version
+				break
+			}
+		}
+	}
+	if analysis.SoftwareVersion == "" {
+		versions, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "summary", "*"))
+		if len(versions) > 0 {
+			analysis.SoftwareVersion = filepath.Base(versions[len(versions)-1])
+		}
+	}
+	if analysis.SoftwareVersion == "" {
+		return analysis, fmt.Errorf("failed to identify dragen version")
+	}
+
+	state := d.State(&analysis)
+	analysis.StateHistory.Add(state)
+	if state == StateReady {
+		files, err := d.OutputFiles(&analysis)
+		if err != nil {
+			return analysis, err
+		}
+		analysis.OutputFiles = files
+	}
+	return analysis, nil
+}
+
+func (DragenSecondaryDetector) State(a *Analysis) State {
+	if _, err := os.Stat(a.Path); os.IsNotExist(err) {
+		return StateMoved
+	}
+	return dragenAnalysisState(dragenSecondaryParent(a.Path))
+}
+
+// OutputFiles returns the BAM files, and the SNV and SV VCF files, of the samples in
+// the workflow. Files are expected in a directory per sample, and are named after the
+// sample.
+func (DragenSecondaryDetector) OutputFiles(a *Analysis) (AnalysisFiles, error) {
+	parent := dragenSecondaryParent(a.Path)
+	f, err := os.Open(filepath.Join(parent, "Manifest.tsv"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	manifest, err := ReadDragenManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dragen manifest: %w", err)
+	}
+
+	prefix, err := filepath.Rel(parent, a.Path)
+	if err != nil {
+		return nil, err
+	}
+	prefix = filepath.ToSlash(prefix) + "/"
+
+	var files AnalysisFiles
+	for _, mf := range manifest.Files {
+		rel, ok := strings.CutPrefix(filepath.ToSlash(mf.Name), prefix)
+		if !ok {
+			continue
+		}
+		sampleId, _, ok := strings.Cut(rel, "/")
+		if !ok || !strings.HasPrefix(filepath.Base(rel), sampleId+".") {
+			continue
+		}
+		var fileType AnalysisFileType
+		switch name := filepath.Base(rel); {
+		case dragenSnvVcfRegex.MatchString(name):
+			fileType = FileSnvVcf
+		case dragenSvVcfRegex.MatchString(name):
+			fileType = FileSvVcf
+		case dragenBamRegex.MatchString(name):
+			fileType = FileBam
+		default:
+			continue
+		}
+		files = append(files, AnalysisFile{
+			partOfAnalysis: true,
+			Path:           filepath.FromSlash(rel),
+			FileType:       fileType,
+			Level:          LevelSample,
+			ParentId:       sampleId,
+		})
+	}
+	return files, nil
+}
+
+// dragenSecondaryParent returns the directory of the Dragen analysis that a secondary
+// analysis workflow directory is part of.
+func dragenSecondaryParent(path string) string {
+	return filepath.Dir(filepath.Dir(path))
+}
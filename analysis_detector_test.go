@@ -0,0 +1,178 @@
+package cleve
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type mockAnalysisDetector struct {
+	DragenBclConvertDetector
+	name string
+}
+
+func (d mockAnalysisDetector) Name() string {
+	return d.name
+}
+
+func TestAnalysisDetectorRegistry(t *testing.T) {
+	for _, name := range []string{"dragen_bclconvert", "dragen_secondary", "nextflow"} {
+		d, ok := AnalysisDetectorByName(name)
+		if !ok {
+			t.Fatalf("expected detector %q to be registered", name)
+		}
+		if d.Name() != name {
+			t.Errorf("expected detector %q, got %q", name, d.Name())
+		}
+	}
+	if _, ok := AnalysisDetectorByName("snakemake"); ok {
+		t.Error("expected snakemake detector to not be registered")
+	}
+	if err := RegisterAnalysisDetector(mockAnalysisDetector{name: "nextflow"}); err == nil {
+		t.Error("expected an error when registering a duplicate detector")
+	}
+	if err := RegisterAnalysisDetector(mockAnalysisDetector{name: "mock_registry"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(AnalysisDetectorNames(), "mock_registry") {
+		t.Error("expected registered detector to be listed")
+	}
+	if err := ReplaceAnalysisDetector(mockAnalysisDetector{name: "mock_replaced"}); err == nil {
+		t.Error("expected an error when replacing an unregistered detector")
+	}
+	replacement := &mockAnalysisDetector{name: "mock_registry"}
+	if err := ReplaceAnalysisDetector(replacement); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := AnalysisDetectorByName("mock_registry"); d != AnalysisDetector(replacement) {
+		t.Error("expected the replacement detector to be registered")
+	}
+}
+
+func TestAnalysisDetectorFor(t *testing.T) {
+	testcases := []struct {
+		name     string
+		analysis Analysis
+		detector string
+	}{
+		{"detector", Analysis{Software: "nf-core/sarek", Detector: "nextflow"}, "nextflow"},
+		{"legacy bclconvert", Analysis{Software: "Dragen BCLConvert"}, "dragen_bclconvert"},
+		{"api analysis", Analysis{Software: "dragen germline"}, ""},
+		{"unknown detector", Analysis{Software: "snakemake", Detector: "snakemake"}, ""},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			d := AnalysisDetectorFor(&c.analysis)
+			switch {
+			case d == nil && c.detector != "":
+				t.Errorf("expected detector %q, got nil", c.detector)
+			case d != nil && d.Name() != c.detector:
+				t.Errorf("expected detector %q, got %q", c.detector, d.Name())
+			}
+		})
+	}
+}
+
+func TestDragenSecondaryDetector(t *testing.T) {
+	analysisDir := filepath.Join(t.TempDir(), "Analysis", "1")
+	files := []string{
+		"Data/summary/4.3.16/detailed_summary.json",
+		"Data/BCLConvert/fastq/sample1_S1_L001_R1_001.fastq.gz",
+		"Data/DragenGermline/sample1/germline_seq/sample1.hard-filtered.vcf.gz",
+		"Data/DragenGermline/sample1/germline_seq/sample1.sv.vcf.gz",
+		"Data/DragenGermline/sample1/germline_seq/sample1.bam",
+		"Data/DragenGermline/sample1/germline_seq/sample1.mapping_metrics.csv",
+		"Data/DragenGermline/sample2/germline_seq/sample2.hard-filtered.vcf.gz",
+		"Data/DragenGermline/multiqc_report.html",
+	}
+	var manifest string
+	for _, name := range files {
+		path := filepath.Join(analysisDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := mockFile(path, ""); err != nil {
+			t.Fatal(err)
+		}
+		manifest += name + "\thash\n"
+	}
+	if err := mockFile(filepath.Join(analysisDir, "Manifest.tsv"), manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	d := DragenSecondaryDetector{}
+	workflowDir := filepath.Join(analysisDir, "Data", "DragenGermline")
+	for path, match := range map[string]bool{
+		workflowDir: true,
+		filepath.Join(analysisDir, "Data", "BCLConvert"):                false,
+		filepath.Join(analysisDir, "Data", "summary"):                   false,
+		filepath.Join(analysisDir, "Data", "DragenGermline", "sample1"): false,
+		filepath.Join(analysisDir, "Data", "DragenEnrichment"):          false,
+	} {
+		if d.Match(path) != match {
+			t.Errorf("expected match to be %t for %s", match, path)
+		}
+	}
+
+	a, err := d.New(workflowDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Software != "Dragen Germline" || a.SoftwareVersion != "4.3.16" {
+		t.Errorf("expected Dragen Germline 4.3.16, got %s %s", a.Software, a.SoftwareVersion)
+	}
+	if state := a.StateHistory.LastState(); state != StatePending {
+		t.Errorf("expected state pending, got %s", state)
+	}
+
+	for _, f := range []string{"CopyComplete.txt", "Data/Secondary_Analysis_Complete.txt"} {
+		if err := mockFile(filepath.Join(analysisDir, f), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err = d.New(workflowDir, &Run{RunID: "run1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := a.StateHistory.LastState(); state != StateReady {
+		t.Fatalf("expected state ready, got %s", state)
+	}
+	if len(a.Runs) != 1 || a.Runs[0] != "run1" {
+		t.Errorf("expected analysis to belong to run1, got %v", a.Runs)
+	}
+	expected := map[string]AnalysisFileType{
+		"sample1/germline_seq/sample1.hard-filtered.vcf.gz": FileSnvVcf,
+		"sample1/germline_seq/sample1.sv.vcf.gz":            FileSvVcf,
+		"sample1/germline_seq/sample1.bam":                  FileBam,
+		"sample2/germline_seq/sample2.hard-filtered.vcf.gz": FileSnvVcf,
+	}
+	if len(a.OutputFiles) != len(expected) {
+		t.Fatalf("expected %d output files, got %d: %+v", len(expected), len(a.OutputFiles), a.OutputFiles)
+	}
+	for _, f := range a.OutputFiles {
+		if expected[filepath.ToSlash(f.Path)] != f.FileType {
+			t.Errorf("unexpected file %s of type %s", f.Path, f.FileType)
+		}
+		if f.Level != LevelSample || f.ParentId != filepath.Base(filepath.Dir(filepath.Dir(f.Path))) {
+			t.Errorf("expected sample level file for %s, got %s %s", f.Path, f.Level, f.ParentId)
+		}
+		if err := f.Validate(); err != nil {
+			t.Errorf("invalid output file %s: %s", f.Path, err)
+		}
+	}
+
+	// The state of the analysis is derived from the Dragen analysis, and the output
+	// files are the same as when the analysis was created.
+	a.Detector = d.Name()
+	if state := a.DetectState(); state != StateReady {
+		t.Errorf("expected detected state ready, got %s", state)
+	}
+	outputFiles := a.OutputFiles
+	if err := a.UpdateOutputFiles(); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.OutputFiles) != len(outputFiles) {
+		t.Errorf("expected %d output files after update, got %d", len(outputFiles), len(a.OutputFiles))
+	}
+}
@@ -26,6 +26,7 @@ type analysisDir struct {
 	samples          int
 	lanes            int
 	dragenVersion    string
+	sampleSheet      []string
 }
 
 func createMockAnalysisDir(t *testing.T, options ...analysisDirOption) string {
@@ -79,6 +80,14 @@ func withLanes(l int) analysisDirOption {
 	}
 }
 
+// withSampleSheet adds a samplesheet to the Data directory of the analysis with the
+// given sample IDs.
+func withSampleSheet(sampleIds ...string) analysisDirOption {
+	return func(d *analysisDir) {
+		d.sampleSheet = sampleIds
+	}
+}
+
 func withDragenVersion(v string) analysisDirOption {
 	return func(d *analysisDir) {
 		d.dragenVersion = v
@@ -136,6 +145,15 @@ func mockAnalysisDirectory(t *testing.T, config analysisDir) (string, error) {
 	if err := os.MkdirAll(filepath.Join(analysisDir, "Data", "summary", config.dragenVersion), 0o755); err != nil {
 		return analysisDir, err
 	}
+	if config.sampleSheet != nil {
+		content := "[Header]\nFileFormatVersion,2\n[Reads]\nRead1Cycles,151\n[BCLConvert_Data]\nSample_ID,Index\n"
+		for _, id := range config.sampleSheet {
+			content += id + ",ACGTACGT\n"
+		}
+		if err := mockFile(filepath.Join(analysisDir, "Data", "SampleSheet.csv"), content); err != nil {
+			return analysisDir, err
+		}
+	}
 	if config.copyComplete {
 		if err := mockFile(filepath.Join(analysisDir, "CopyComplete.txt"), ""); err != nil {
 			return analysisDir, err
@@ -198,6 +216,7 @@ func TestDragenAnalysis(t *testing.T) {
 		state         State
 		expectedFiles int
 		analysisDir   string
+		sampleSheet   bool
 	}{
 		{
 			name: "analysis ready",
@@ -220,6 +239,29 @@ func TestDragenAnalysis(t *testing.T) {
 			expectedFiles: 3*8*2 + 3, // 2 fastq per sample per lane + 3 stats files
 			state:         StateReady,
 		},
+		{
+			name: "analysis with its own samplesheet",
+			run: Run{
+				RunID: "run1",
+				RunParameters: interop.RunParameters{
+					Software: []interop.Software{
+						{Name: "Dragen", Version: "4.3.16"},
+					},
+				},
+			},
+			analysisDir: createMockAnalysisDir(
+				t,
+				withCopyComplete(),
+				withAnalysisComplete(),
+				withDragenVersion("4.3.16"),
+				withSamples(3),
+				withLanes(8),
+				withSampleSheet("sample1", "sample3"),
+			),
+			expectedFiles: 2*8*2 + 3, // only samples in the analysis samplesheet
+			state:         StateReady,
+			sampleSheet:   true,
+		},
 		{
 			name: "analysis pending",
 			run: Run{
@@ -270,6 +312,25 @@ func TestDragenAnalysis(t *testing.T) {
 			if len(analysis.OutputFiles) != c.expectedFiles {
 				t.Errorf("expected %d files, got %d", c.expectedFiles, len(analysis.OutputFiles))
 			}
+			if (analysis.SampleSheetId != nil) != c.sampleSheet {
+				t.Errorf("expected samplesheet %t, got samplesheet id %v", c.sampleSheet, analysis.SampleSheetId)
+			}
+			if !c.sampleSheet {
+				return
+			}
+			sampleSheet, err := analysis.ReadSampleSheet()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sampleSheet.UUID == nil || *sampleSheet.UUID != *analysis.SampleSheetId {
+				t.Errorf("expected samplesheet uuid %s, got %v", analysis.SampleSheetId, sampleSheet.UUID)
+			}
+			if sampleSheet.AnalysisId == nil || *sampleSheet.AnalysisId != analysis.AnalysisId {
+				t.Errorf("expected samplesheet analysis id %s, got %v", analysis.AnalysisId, sampleSheet.AnalysisId)
+			}
+			if sampleSheet.RunID != nil {
+				t.Errorf("expected no run id, got %s", *sampleSheet.RunID)
+			}
 		})
 	}
 }
@@ -944,6 +1005,12 @@ func TestAnalysisFileType(t *testing.T) {
 			isValid:    true,
 			isZero:     false,
 		},
+		{
+			name:       "multiqc",
+			typeString: "multiqc",
+			isValid:    true,
+			isZero:     false,
+		},
 		{
 			name:       "empty",
 			typeString: "",
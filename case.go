@@ -0,0 +1,131 @@
+package cleve
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SampleRole is the role of a sample within a case.
+type SampleRole string
+
+const (
+	RoleProband SampleRole = "proband"
+	RoleMother  SampleRole = "mother"
+	RoleFather  SampleRole = "father"
+	RoleTumour  SampleRole = "tumour"
+	RoleNormal  SampleRole = "normal"
+)
+
+var sampleRoles = []SampleRole{RoleProband, RoleMother, RoleFather, RoleTumour, RoleNormal}
+
+// IsValid returns true if the role is one of the known sample roles.
+func (r SampleRole) IsValid() bool {
+	return slices.Contains(sampleRoles, r)
+}
+
+// unique returns true if a case can have at most one sample with this role.
+func (r SampleRole) unique() bool {
+	return r != RoleTumour
+}
+
+// Sex is the declared sex of an individual.
+type Sex string
+
+const (
+	SexMale    Sex = "male"
+	SexFemale  Sex = "female"
+	SexUnknown Sex = "unknown"
+)
+
+// IsValid returns true if the sex is male, female or unknown.
+func (s Sex) IsValid() bool {
+	return slices.Contains([]Sex{SexMale, SexFemale, SexUnknown}, s)
+}
+
+// Phenotype is the affection status of an individual.
+type Phenotype string
+
+const (
+	PhenotypeAffected   Phenotype = "affected"
+	PhenotypeUnaffected Phenotype = "unaffected"
+	PhenotypeUnknown    Phenotype = "unknown"
+)
+
+// IsValid returns true if the phenotype is affected, unaffected or unknown.
+func (p Phenotype) IsValid() bool {
+	return slices.Contains([]Phenotype{PhenotypeAffected, PhenotypeUnaffected, PhenotypeUnknown}, p)
+}
+
+// CaseSample is a sample together with its role in a case.
+type CaseSample struct {
+	SampleId  string     `bson:"sample_id" json:"sample_id" binding:"required"`
+	Role      SampleRole `bson:"role" json:"role" binding:"required"`
+	Sex       Sex        `bson:"sex" json:"sex"`
+	Phenotype Phenotype  `bson:"phenotype" json:"phenotype"`
+}
+
+// Case groups samples that are analysed together, such as a trio or a tumour/normal
+// pair. Case level analysis files refer to the case by its ID.
+type Case struct {
+	Id          string       `bson:"id" json:"id" binding:"required"`
+	Description string       `bson:"description,omitempty" json:"description,omitempty"`
+	Samples     []CaseSample `bson:"samples" json:"samples" binding:"required"`
+	Created     time.Time    `bson:"created" json:"created"`
+	Updated     time.Time    `bson:"updated" json:"updated"`
+}
+
+// SetDefaults sets the sex and phenotype of samples where they are missing to unknown.
+func (c *Case) SetDefaults() {
+	for i := range c.Samples {
+		if c.Samples[i].Sex == "" {
+			c.Samples[i].Sex = SexUnknown
+		}
+		if c.Samples[i].Phenotype == "" {
+			c.Samples[i].Phenotype = PhenotypeUnknown
+		}
+	}
+}
+
+// Validate checks that the case has an ID and at least one sample, that the samples
+// are unique and have valid roles, sex and phenotype, and that there is at most one
+// sample with each role except tumour.
+func (c Case) Validate() error {
+	var errs []error
+	if c.Id == "" {
+		errs = append(errs, fmt.Errorf("case id must not be empty"))
+	}
+	if len(c.Samples) == 0 {
+		errs = append(errs, fmt.Errorf("case must have at least one sample"))
+	}
+	sampleIds := make(map[string]bool)
+	roles := make(map[SampleRole]bool)
+	for _, s := range c.Samples {
+		if s.SampleId == "" {
+			errs = append(errs, fmt.Errorf("sample id must not be empty"))
+		} else if sampleIds[s.SampleId] {
+			errs = append(errs, fmt.Errorf("sample %s is listed more than once", s.SampleId))
+		}
+		sampleIds[s.SampleId] = true
+		if !s.Role.IsValid() {
+			errs = append(errs, fmt.Errorf("invalid role %q for sample %s", s.Role, s.SampleId))
+		} else if s.Role.unique() && roles[s.Role] {
+			errs = append(errs, fmt.Errorf("case can only have one %s", s.Role))
+		}
+		roles[s.Role] = true
+		if !s.Sex.IsValid() {
+			errs = append(errs, fmt.Errorf("invalid sex %q for sample %s", s.Sex, s.SampleId))
+		}
+		if !s.Phenotype.IsValid() {
+			errs = append(errs, fmt.Errorf("invalid phenotype %q for sample %s", s.Phenotype, s.SampleId))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CaseResult is a paginated list of cases.
+type CaseResult struct {
+	PaginationMetadata `bson:"metadata" json:"metadata"`
+	Cases              []Case `bson:"cases" json:"cases"`
+}
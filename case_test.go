@@ -0,0 +1,104 @@
+package cleve
+
+import "testing"
+
+func TestCaseValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		c     Case
+		error bool
+	}{
+		{
+			name: "trio",
+			c: Case{
+				Id: "case1",
+				Samples: []CaseSample{
+					{SampleId: "S1", Role: RoleProband, Sex: SexFemale, Phenotype: PhenotypeAffected},
+					{SampleId: "S2", Role: RoleMother, Sex: SexFemale, Phenotype: PhenotypeUnaffected},
+					{SampleId: "S3", Role: RoleFather, Sex: SexMale, Phenotype: PhenotypeUnaffected},
+				},
+			},
+		},
+		{
+			name: "tumour normal with several tumours",
+			c: Case{
+				Id: "case1",
+				Samples: []CaseSample{
+					{SampleId: "S1", Role: RoleTumour, Sex: SexUnknown, Phenotype: PhenotypeUnknown},
+					{SampleId: "S2", Role: RoleTumour, Sex: SexUnknown, Phenotype: PhenotypeUnknown},
+					{SampleId: "S3", Role: RoleNormal, Sex: SexUnknown, Phenotype: PhenotypeUnknown},
+				},
+			},
+		},
+		{
+			name:  "missing id",
+			c:     Case{Samples: []CaseSample{{SampleId: "S1", Role: RoleProband, Sex: SexMale, Phenotype: PhenotypeAffected}}},
+			error: true,
+		},
+		{
+			name:  "no samples",
+			c:     Case{Id: "case1"},
+			error: true,
+		},
+		{
+			name: "duplicate sample",
+			c: Case{
+				Id: "case1",
+				Samples: []CaseSample{
+					{SampleId: "S1", Role: RoleProband, Sex: SexMale, Phenotype: PhenotypeAffected},
+					{SampleId: "S1", Role: RoleMother, Sex: SexFemale, Phenotype: PhenotypeUnaffected},
+				},
+			},
+			error: true,
+		},
+		{
+			name: "two probands",
+			c: Case{
+				Id: "case1",
+				Samples: []CaseSample{
+					{SampleId: "S1", Role: RoleProband, Sex: SexMale, Phenotype: PhenotypeAffected},
+					{SampleId: "S2", Role: RoleProband, Sex: SexFemale, Phenotype: PhenotypeAffected},
+				},
+			},
+			error: true,
+		},
+		{
+			name:  "invalid role",
+			c:     Case{Id: "case1", Samples: []CaseSample{{SampleId: "S1", Role: "sibling", Sex: SexMale, Phenotype: PhenotypeAffected}}},
+			error: true,
+		},
+		{
+			name:  "invalid sex",
+			c:     Case{Id: "case1", Samples: []CaseSample{{SampleId: "S1", Role: RoleProband, Sex: "M", Phenotype: PhenotypeAffected}}},
+			error: true,
+		},
+		{
+			name:  "missing sex and phenotype",
+			c:     Case{Id: "case1", Samples: []CaseSample{{SampleId: "S1", Role: RoleProband}}},
+			error: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.c.Validate()
+			if (err != nil) != c.error {
+				t.Errorf("expected error to be %t, got %v", c.error, err)
+			}
+		})
+	}
+}
+
+func TestCaseSetDefaults(t *testing.T) {
+	c := Case{Id: "case1", Samples: []CaseSample{{SampleId: "S1", Role: RoleProband, Sex: SexMale}}}
+	c.SetDefaults()
+	if c.Samples[0].Sex != SexMale {
+		t.Errorf("expected sex to be kept, got %s", c.Samples[0].Sex)
+	}
+	if c.Samples[0].Phenotype != PhenotypeUnknown {
+		t.Errorf("expected unknown phenotype, got %s", c.Samples[0].Phenotype)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("expected valid case, got %v", err)
+	}
+}
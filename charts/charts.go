@@ -144,3 +144,61 @@ func ScatterChart[T cmp.Ordered](d ScatterData[T]) *charts.Scatter {
 	}
 	return chart
 }
+
+// HeatmapData represents a dataset for a heatmap chart where both axes are
+// categorical. Categories are plotted in the order they are given.
+type HeatmapData struct {
+	Data        []HeatmapDatum
+	XLabel      string
+	YLabel      string
+	XCategories []string
+	YCategories []string
+}
+
+// HeatmapDatum represents a single cell in a heatmap.
+type HeatmapDatum struct {
+	X     string
+	Y     string
+	Value float64
+}
+
+func (d HeatmapData) Plot() (render.Renderer, error) {
+	return HeatmapChart(d), nil
+}
+
+func HeatmapChart(d HeatmapData) *charts.HeatMap {
+	chart := charts.NewHeatMap()
+	maxValue := 0.0
+	xIndex := make(map[string]int, len(d.XCategories))
+	for i, x := range d.XCategories {
+		xIndex[x] = i
+	}
+	yIndex := make(map[string]int, len(d.YCategories))
+	for i, y := range d.YCategories {
+		yIndex[y] = i
+	}
+	heatmapData := make([]opts.HeatMapData, 0, len(d.Data))
+	for _, k := range d.Data {
+		x, okX := xIndex[k.X]
+		y, okY := yIndex[k.Y]
+		if !okX || !okY {
+			continue
+		}
+		maxValue = max(maxValue, k.Value)
+		heatmapData = append(heatmapData, opts.HeatMapData{Value: [3]any{x, y, k.Value}})
+	}
+	chart.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{Width: "900px", Height: "500px"}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
+		charts.WithXAxisOpts(opts.XAxis{Name: d.XLabel, Type: "category", Data: d.XCategories, SplitArea: &opts.SplitArea{Show: opts.Bool(true)}}),
+		charts.WithYAxisOpts(opts.YAxis{Name: d.YLabel, Type: "category", Data: d.YCategories, SplitArea: &opts.SplitArea{Show: opts.Bool(true)}}),
+		charts.WithVisualMapOpts(opts.VisualMap{
+			Calculable: opts.Bool(true),
+			Min:        0,
+			Max:        float32(maxValue),
+			InRange:    &opts.VisualMapInRange{Color: []string{"#f7fbff", "#08306b"}},
+		}),
+	)
+	chart.AddSeries("", heatmapData)
+	return chart
+}
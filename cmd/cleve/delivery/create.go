@@ -0,0 +1,91 @@
+package delivery
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/delivery"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/spf13/cobra"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create [flags]",
+	Short: "Create a data delivery",
+	Long: `Create a delivery record for the analysis files of a project or a set of samples.
+
+Sample level analysis files of the given type are looked up for the listed samples,
+or for all samples in the project if no samples are given. The checksums of the files
+are computed, the delivery is stored in the database, and then a tab-separated
+manifest of the delivered files is written.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		project, _ := cmd.Flags().GetString("project")
+		samples, _ := cmd.Flags().GetStringSlice("sample")
+		runId, _ := cmd.Flags().GetString("run")
+		fileType, _ := cmd.Flags().GetString("type")
+		recipients, _ := cmd.Flags().GetStringSlice("recipient")
+		destination, _ := cmd.Flags().GetString("destination")
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+
+		req := cleve.DeliveryRequest{
+			Project:     project,
+			Samples:     samples,
+			RunId:       runId,
+			FileType:    cleve.AnalysisFileTypeFromString(fileType),
+			Recipients:  recipients,
+			Destination: destination,
+		}
+		cobra.CheckErr(req.Validate())
+
+		db, err := mongo.Connect()
+		if err != nil {
+			slog.Error("failed to connect to database", "error", err)
+			os.Exit(1)
+		}
+
+		d, err := delivery.New(db, req)
+		if err != nil {
+			slog.Error("failed to create delivery", "error", err)
+			os.Exit(1)
+		}
+
+		if err := db.CreateDelivery(d); err != nil {
+			slog.Error("failed to store delivery", "error", err)
+			os.Exit(1)
+		}
+
+		if manifestPath == "" {
+			manifestPath = fmt.Sprintf("%s.manifest.tsv", d.DeliveryId)
+		}
+		f, err := os.Create(manifestPath)
+		if err != nil {
+			slog.Error("failed to create manifest", "error", err)
+			os.Exit(1)
+		}
+		if err := d.WriteManifest(f); err != nil {
+			_ = f.Close()
+			slog.Error("failed to write manifest", "error", err)
+			os.Exit(1)
+		}
+		if err := f.Close(); err != nil {
+			slog.Error("failed to write manifest", "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("delivery %s: %d files for %d samples, manifest written to %s\n", d.DeliveryId, len(d.Files), len(d.Samples), manifestPath)
+	},
+}
+
+func init() {
+	createCmd.Flags().StringP("project", "p", "", "project to deliver files for")
+	createCmd.Flags().StringSliceP("sample", "s", []string{}, "sample to deliver files for, can be given multiple times")
+	createCmd.Flags().StringP("run", "r", "", "only deliver files from this run")
+	createCmd.Flags().StringP("type", "t", "", "type of files to deliver, e.g. fastq")
+	createCmd.Flags().StringSlice("recipient", []string{}, "recipient of the delivery, can be given multiple times")
+	createCmd.Flags().StringP("destination", "d", "", "where the files are delivered")
+	createCmd.Flags().StringP("manifest", "m", "", "path of the manifest file, defaults to <delivery-id>.manifest.tsv")
+	_ = createCmd.MarkFlagRequired("type")
+}
@@ -0,0 +1,13 @@
+package delivery
+
+import "github.com/spf13/cobra"
+
+func init() {
+	DeliveryCmd.AddCommand(createCmd)
+	DeliveryCmd.AddCommand(listCmd)
+}
+
+var DeliveryCmd = &cobra.Command{
+	Use:   "delivery",
+	Short: "Manage data deliveries",
+}
@@ -0,0 +1,42 @@
+package delivery
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list [flags]",
+	Short: "List deliveries",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := mongo.Connect()
+		cobra.CheckErr(err)
+
+		filter := cleve.NewDeliveryFilter()
+		filter.PageSize = 0
+		filter.Project, _ = cmd.Flags().GetString("project")
+		filter.SampleId, _ = cmd.Flags().GetString("sample")
+
+		deliveries, err := db.Deliveries(filter)
+		cobra.CheckErr(err)
+
+		w := tabwriter.NewWriter(os.Stdout, 5, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "id\tcreated\tproject\ttype\tfiles\trecipients")
+		_, _ = fmt.Fprintln(w, "--\t-------\t-------\t----\t-----\t----------")
+		for _, d := range deliveries.Deliveries {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", d.DeliveryId, d.Created.Local().Format("2006-01-02 15:04"), d.Project, d.FileType, len(d.Files), strings.Join(d.Recipients, ","))
+		}
+		_ = w.Flush()
+	},
+}
+
+func init() {
+	listCmd.Flags().StringP("project", "p", "", "only list deliveries for this project")
+	listCmd.Flags().StringP("sample", "s", "", "only list deliveries including this sample")
+}
@@ -0,0 +1,67 @@
+package indexkit
+
+import (
+	"log"
+	"os"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/spf13/cobra"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add [flags] name csv_path",
+	Short: "Register an index kit",
+	Long: `Register an index kit from a CSV file.
+
+The CSV file must have a header with the column "id" and at least one of
+"index" and "index2", which can also be called "i7" and "i5". The plate
+position of each index can be given in the column "well". For combinatorial
+kits, such as Nextera XT, i7 and i5 indexes are listed on separate rows.
+Index sequences should be given in the forward strand orientation. If a kit
+with the same name already exists, it is replaced.
+
+By default, index2 is expected in the reverse complement orientation in
+samplesheets for NextSeq 5x0, and in the forward orientation for other
+platforms. This can be overridden per platform with --i5-orientation, e.g.
+--i5-orientation "MiSeq=reverse_complement".`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := os.Open(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+
+		kit, err := cleve.ParseIndexKit(args[0], f)
+		if err != nil {
+			log.Fatalf("error: %s", err)
+		}
+
+		orientations, _ := cmd.Flags().GetStringToString("i5-orientation")
+		for platform, o := range orientations {
+			orientation := cleve.I5Orientation(o)
+			if !orientation.IsValid() {
+				log.Fatalf("error: invalid i5 orientation %q for %s, must be %q or %q", o, platform, cleve.I5Forward, cleve.I5ReverseComplement)
+			}
+			if kit.I5Orientations == nil {
+				kit.I5Orientations = make(map[string]cleve.I5Orientation)
+			}
+			kit.I5Orientations[platform] = orientation
+		}
+
+		db, err := mongo.Connect()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := db.CreateIndexKit(kit); err != nil {
+			log.Fatalf("error: %s", err)
+		}
+		log.Printf("registered index kit %q with %d indexes", kit.Name, len(kit.Indexes))
+	},
+}
+
+func init() {
+	addCmd.Flags().StringToString("i5-orientation", nil, "orientation of index2 in samplesheets for a platform, as platform=orientation")
+}
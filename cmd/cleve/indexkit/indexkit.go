@@ -0,0 +1,15 @@
+package indexkit
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var IndexKitCmd = &cobra.Command{
+	Use:   "indexkit [command]",
+	Short: "Manage index kits",
+}
+
+func init() {
+	IndexKitCmd.AddCommand(addCmd)
+	IndexKitCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,51 @@
+package indexkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	jsonOutput bool
+	listCmd    = &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List registered index kits",
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := mongo.Connect()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			kits, err := db.IndexKits()
+			if err != nil {
+				log.Fatalf("error: %s", err)
+			}
+
+			if jsonOutput {
+				jsonString, err := json.Marshal(&kits)
+				if err != nil {
+					log.Fatalf("error: %s", err)
+				}
+				fmt.Println(string(jsonString))
+			} else {
+				w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+				_, _ = fmt.Fprint(w, "name\tindexes\n")
+				_, _ = fmt.Fprint(w, "----\t-------\n")
+				for _, k := range kits {
+					_, _ = fmt.Fprintf(w, "%s\t%d\n", k.Name, len(k.Indexes))
+				}
+				_ = w.Flush()
+			}
+		},
+	}
+)
+
+func init() {
+	listCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output json")
+}
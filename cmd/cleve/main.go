@@ -11,9 +11,12 @@ import (
 
 	"github.com/gmc-norr/cleve"
 	"github.com/gmc-norr/cleve/cmd/cleve/db"
+	"github.com/gmc-norr/cleve/cmd/cleve/delivery"
+	"github.com/gmc-norr/cleve/cmd/cleve/indexkit"
 	"github.com/gmc-norr/cleve/cmd/cleve/key"
 	"github.com/gmc-norr/cleve/cmd/cleve/panel"
 	"github.com/gmc-norr/cleve/cmd/cleve/platform"
+	"github.com/gmc-norr/cleve/cmd/cleve/qc"
 	"github.com/gmc-norr/cleve/cmd/cleve/run"
 	"github.com/gmc-norr/cleve/cmd/cleve/samplesheet"
 	"github.com/maehler/webhook"
@@ -128,9 +131,12 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(run.RunCmd)
 	rootCmd.AddCommand(db.DbCmd)
+	rootCmd.AddCommand(delivery.DeliveryCmd)
+	rootCmd.AddCommand(indexkit.IndexKitCmd)
 	rootCmd.AddCommand(key.KeyCmd)
 	rootCmd.AddCommand(panel.PanelCmd)
 	rootCmd.AddCommand(platform.PlatformCmd)
+	rootCmd.AddCommand(qc.QcCmd)
 	rootCmd.AddCommand(samplesheet.SampleSheetCmd)
 
 	rootCmd.SetVersionTemplate(`{{with .Name}}{{printf "%s " .}}{{end}}{{printf "%s\n" .Version}}`)
@@ -0,0 +1,14 @@
+package qc
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var QcCmd = &cobra.Command{
+	Use:   "qc [command]",
+	Short: "Manage sequencing QC data",
+}
+
+func init() {
+	QcCmd.AddCommand(recomputeCmd)
+}
@@ -0,0 +1,142 @@
+package qc
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/gmc-norr/cleve/recompute"
+	"github.com/spf13/cobra"
+)
+
+var recomputeCmd = &cobra.Command{
+	Use:   "recompute [flags]",
+	Short: "Recompute QC data for sequencing runs",
+	Long: `Recompute QC data for sequencing runs from InterOp data.
+
+Runs are selected using the filter flags, and only runs in the ready state
+are considered by default. Use --stale to only recompute runs where the QC
+data was computed with an older version of cleve.
+
+If a checkpoint file is given, runs that have been recomputed are recorded
+in it, and they will be skipped if the command is run again with the same
+checkpoint. This makes it possible to resume an interrupted recomputation.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		platform, _ := cmd.Flags().GetString("platform")
+		state, _ := cmd.Flags().GetString("state")
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		staleOnly, _ := cmd.Flags().GetBool("stale")
+		workers, _ := cmd.Flags().GetInt("workers")
+		checkpointPath, _ := cmd.Flags().GetString("checkpoint")
+
+		if workers < 1 {
+			cobra.CheckErr("number of workers must be at least 1")
+		}
+
+		filter := cleve.NewRunFilter()
+		filter.PageSize = 0
+		filter.Platform = platform
+		filter.State = state
+		if from != "" {
+			t, err := time.ParseInLocation(time.DateOnly, from, time.Local)
+			cobra.CheckErr(err)
+			filter.From = t
+		}
+		if to != "" {
+			t, err := time.ParseInLocation(time.DateOnly, to, time.Local)
+			cobra.CheckErr(err)
+			// Include the whole day
+			filter.To = t.Add(24*time.Hour - time.Nanosecond)
+		}
+
+		db, err := mongo.Connect()
+		if err != nil {
+			slog.Error("failed to connect to database", "error", err)
+			os.Exit(1)
+		}
+
+		runs, err := db.Runs(filter)
+		if err != nil {
+			slog.Error("failed to fetch runs", "error", err)
+			os.Exit(1)
+		}
+
+		selected := runs.Runs
+		if staleOnly {
+			stale, err := db.StaleRunQC()
+			if err != nil {
+				slog.Error("failed to fetch runs with stale qc", "error", err)
+				os.Exit(1)
+			}
+			staleRuns := make(map[string]bool, len(stale))
+			for _, s := range stale {
+				staleRuns[s.RunId] = true
+			}
+			selected = selected[:0]
+			for _, r := range runs.Runs {
+				if staleRuns[r.RunID] {
+					selected = append(selected, r)
+				}
+			}
+		}
+
+		if len(selected) == 0 {
+			fmt.Fprintln(os.Stderr, "no matching runs")
+			return
+		}
+
+		r := recompute.New(db, workers)
+		if checkpointPath != "" {
+			r.Checkpoint, err = recompute.OpenCheckpoint(checkpointPath)
+			cobra.CheckErr(err)
+			if n := r.Checkpoint.Len(); n > 0 {
+				fmt.Fprintf(os.Stderr, "resuming from checkpoint with %d completed runs\n", n)
+			}
+		}
+		r.Progress = func(p recompute.Progress) {
+			status := "ok"
+			switch {
+			case p.Result.Skipped:
+				status = "skipped (in checkpoint)"
+			case p.Result.Err != nil:
+				status = fmt.Sprintf("failed: %s", p.Result.Err)
+			}
+			done := p.Completed + p.Failed + p.Skipped
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s: %s\n", done, p.Total, p.Result.RunId, status)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		fmt.Fprintf(os.Stderr, "recomputing qc for %d runs using %d workers (computation version %d)\n", len(selected), workers, interop.SummaryComputationVersion)
+		results := r.Run(ctx, selected)
+
+		failed := 0
+		for _, res := range results {
+			if res.Err != nil {
+				failed++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "done: %d succeeded, %d failed\n", len(results)-failed, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	recomputeCmd.Flags().String("platform", "", "Only recompute runs from this platform")
+	recomputeCmd.Flags().String("state", cleve.StateReady.String(), "Only recompute runs in this state")
+	recomputeCmd.Flags().String("from", "", "Only recompute runs sequenced on or after this date (YYYY-MM-DD)")
+	recomputeCmd.Flags().String("to", "", "Only recompute runs sequenced on or before this date (YYYY-MM-DD)")
+	recomputeCmd.Flags().Bool("stale", false, "Only recompute runs where the QC data is stale")
+	recomputeCmd.Flags().IntP("workers", "j", 4, "Number of runs to process concurrently")
+	recomputeCmd.Flags().String("checkpoint", "", "Checkpoint file for resuming an interrupted recomputation")
+}
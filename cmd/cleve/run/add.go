@@ -93,10 +93,15 @@ var addCmd = &cobra.Command{
 
 		if run.StateHistory.LastState() == cleve.StateReady {
 			slog.Info("adding qc data for run")
-			if err := db.CreateRunQC(run.RunID, interopData.Summarise()); err != nil {
+			summary := interopData.Summarise()
+			if err := db.CreateRunQC(run.RunID, summary); err != nil {
 				slog.Error("failed to save qc data", "error", err)
 				os.Exit(1)
 			}
+			if err := db.UpdateFromIndexSummary(run.RunID, summary.IndexSummary); err != nil {
+				slog.Error("failed to save sample read counts", "error", err)
+				os.Exit(1)
+			}
 		}
 
 		_ = cli.SendWebhookMessage(ctx, webhookClient, cleve.NewRunMessage(&run, "new run added", cleve.MessageStateUpdate))
@@ -0,0 +1,86 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/gmc-norr/cleve/report"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [flags] run_id",
+	Short: "Generate a QC report for a sequencing run",
+	Long: `Generate a QC report for a sequencing run.
+
+The report is written in HTML or PDF format. If no format is given, it is
+inferred from the extension of the output file, and defaults to HTML.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		formatString, _ := cmd.Flags().GetString("format")
+		if formatString == "" {
+			formatString = "html"
+			if strings.EqualFold(filepath.Ext(output), ".pdf") {
+				formatString = "pdf"
+			}
+		}
+		format, err := report.FormatFromString(formatString)
+		cobra.CheckErr(err)
+
+		thresholds, err := report.ThresholdsFromConfig()
+		cobra.CheckErr(err)
+
+		db, err := mongo.Connect()
+		if err != nil {
+			slog.Error("failed to connect to database", "error", err)
+			os.Exit(1)
+		}
+
+		run, err := db.Run(args[0])
+		if err != nil {
+			slog.Error("failed to fetch run information", "run", args[0], "error", err)
+			os.Exit(1)
+		}
+		qc, err := db.RunQC(args[0])
+		if err != nil {
+			slog.Error("failed to fetch run qc", "run", args[0], "error", err)
+			os.Exit(1)
+		}
+		sexChecks, err := db.RunSexChecks(args[0])
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			slog.Error("failed to fetch sex checks", "run", args[0], "error", err)
+			os.Exit(1)
+		}
+
+		var w io.Writer = os.Stdout
+		var f *os.File
+		if output != "" && output != "-" {
+			f, err = os.Create(output)
+			cobra.CheckErr(err)
+			w = f
+		}
+		if err := report.New(run, qc, thresholds).WithSexChecks(sexChecks).Write(w, format); err != nil {
+			if f != nil {
+				_ = f.Close()
+			}
+			cobra.CheckErr(fmt.Errorf("failed to write report: %w", err))
+		}
+		if f != nil {
+			if err := f.Close(); err != nil {
+				cobra.CheckErr(fmt.Errorf("failed to write report: %w", err))
+			}
+		}
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	reportCmd.Flags().String("format", "", "Report format (html or pdf)")
+}
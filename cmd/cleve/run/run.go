@@ -9,6 +9,7 @@ func init() {
 	RunCmd.AddCommand(listCmd)
 	RunCmd.AddCommand(updateCmd)
 	RunCmd.AddCommand(deleteCmd)
+	RunCmd.AddCommand(reportCmd)
 }
 
 var RunCmd = &cobra.Command{
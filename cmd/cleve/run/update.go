@@ -114,10 +114,15 @@ var (
 					slog.Error("failed to read qc data", "path", run.Path, "error", err)
 					os.Exit(1)
 				}
-				if err := db.UpdateRunQC(qc.Summarise()); err != nil {
+				summary := qc.Summarise()
+				if err := db.UpdateRunQC(summary); err != nil {
 					slog.Error("failed to update qc data", "run", run.RunID, "error", err)
 					os.Exit(1)
 				}
+				if err := db.UpdateFromIndexSummary(run.RunID, summary.IndexSummary); err != nil {
+					slog.Error("failed to update sample read counts", "run", run.RunID, "error", err)
+					os.Exit(1)
+				}
 				didSomething = true
 			} else if updateQc {
 				slog.Warn("run is not ready, qc data will not be updated")
@@ -0,0 +1,70 @@
+package samplesheet
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/spf13/cobra"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert [flags] samplesheet_path",
+	Short: "Convert a SampleSheet to another version",
+	Long: `Convert a SampleSheet to another version.
+
+Currently only conversion to v2 is supported, which can be used for converting
+SampleSheets from Illumina Experiment Manager (IEM) to SampleSheets for BCL
+Convert. Sample names and index IDs are not part of v2 SampleSheets and are
+dropped, and the orientation of index2 should be checked for the target
+instrument.
+
+The converted SampleSheet is validated, and nothing is written if it has
+errors.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		to, _ := cmd.Flags().GetString("to")
+		output, _ := cmd.Flags().GetString("output")
+		bclConvertVersion, _ := cmd.Flags().GetString("bclconvert-version")
+
+		if to != "v2" && to != "2" {
+			cobra.CheckErr(fmt.Errorf("unsupported target version %q, only v2 is supported", to))
+		}
+
+		sampleSheet, err := cleve.ReadSampleSheet(args[0])
+		cobra.CheckErr(err)
+
+		converted, err := sampleSheet.ConvertToV2(bclConvertVersion)
+		cobra.CheckErr(err)
+
+		var b strings.Builder
+		cobra.CheckErr(converted.WriteCSV(&b))
+
+		findings := cleve.ValidateSampleSheetCSV(strings.NewReader(b.String()))
+		for _, f := range findings {
+			fmt.Fprintln(os.Stderr, f)
+		}
+		if !findings.Valid() {
+			fmt.Fprintf(os.Stderr, "converted SampleSheet is invalid (%d errors, %d warnings)\n", findings.Errors(), findings.Warnings())
+			os.Exit(1)
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			cobra.CheckErr(err)
+			defer func() { _ = f.Close() }()
+			w = f
+		}
+		_, err = io.WriteString(w, b.String())
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	convertCmd.Flags().String("to", "v2", "Target SampleSheet version")
+	convertCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	convertCmd.Flags().String("bclconvert-version", "", "BCL Convert version to put in BCLConvert_Settings (required when converting from v1)")
+}
@@ -0,0 +1,91 @@
+package samplesheet
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/go-yaml/yaml"
+	"github.com/spf13/cobra"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render [flags] plan_path",
+	Short: "Generate a SampleSheet from a run plan",
+	Long: `Generate a v2 SampleSheet from a YAML run plan.
+
+Indexes referenced by ID are looked up in the index kits registered in the
+database. Index kits can also be read from CSV files with --index-kit, in
+which case the database is only used for kits that are not given on the
+command line.
+
+The generated SampleSheet is validated, and nothing is written if it has
+errors.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		kitFiles, _ := cmd.Flags().GetStringToString("index-kit")
+
+		data, err := os.ReadFile(args[0])
+		cobra.CheckErr(err)
+
+		var plan cleve.SampleSheetPlan
+		if err := yaml.UnmarshalStrict(data, &plan); err != nil {
+			cobra.CheckErr(fmt.Errorf("invalid run plan: %w", err))
+		}
+
+		var db *mongo.DB
+		sampleSheet, err := plan.SampleSheet(func(name string) (cleve.IndexKit, error) {
+			if path, ok := kitFiles[name]; ok {
+				f, err := os.Open(path)
+				if err != nil {
+					return cleve.IndexKit{}, err
+				}
+				defer func() { _ = f.Close() }()
+				return cleve.ParseIndexKit(name, f)
+			}
+			if db == nil {
+				var err error
+				if db, err = mongo.Connect(); err != nil {
+					return cleve.IndexKit{}, err
+				}
+			}
+			kit, err := db.IndexKit(name)
+			if err == mongo.ErrNoDocuments {
+				return kit, fmt.Errorf("index kit %q is not registered", name)
+			}
+			return kit, err
+		})
+		cobra.CheckErr(err)
+
+		var b strings.Builder
+		cobra.CheckErr(sampleSheet.WriteCSV(&b))
+
+		findings := cleve.ValidateSampleSheetCSV(strings.NewReader(b.String()))
+		for _, f := range findings {
+			fmt.Fprintln(os.Stderr, f)
+		}
+		if !findings.Valid() {
+			fmt.Fprintf(os.Stderr, "generated SampleSheet is invalid (%d errors, %d warnings)\n", findings.Errors(), findings.Warnings())
+			os.Exit(1)
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			cobra.CheckErr(err)
+			defer func() { _ = f.Close() }()
+			w = f
+		}
+		_, err = io.WriteString(w, b.String())
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	renderCmd.Flags().StringToString("index-kit", nil, "Index kit CSV file to use instead of the database (\"<name>=<path>\"), can be repeated")
+}
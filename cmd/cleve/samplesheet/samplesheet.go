@@ -6,6 +6,9 @@ import (
 
 func init() {
 	SampleSheetCmd.AddCommand(addCmd)
+	SampleSheetCmd.AddCommand(convertCmd)
+	SampleSheetCmd.AddCommand(renderCmd)
+	SampleSheetCmd.AddCommand(validateCmd)
 }
 
 var SampleSheetCmd = &cobra.Command{
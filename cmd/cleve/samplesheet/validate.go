@@ -0,0 +1,87 @@
+package samplesheet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [flags] samplesheet_path...",
+	Short: "Validate SampleSheets",
+	Long: `Validate one or more SampleSheets.
+
+Findings are printed for each SampleSheet, and the command exits with a
+non-zero exit status if any SampleSheet has errors. If a RunInfo.xml file is
+given, the reads of the SampleSheets, including OverrideCycles, are also
+checked against the reads of the run.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		runInfoPath, _ := cmd.Flags().GetString("run-info")
+		var runInfo *interop.RunInfo
+		if runInfoPath != "" {
+			ri, err := interop.ReadRunInfo(runInfoPath)
+			cobra.CheckErr(err)
+			runInfo = &ri
+		}
+		type result struct {
+			Path     string                   `json:"path"`
+			Valid    bool                     `json:"valid"`
+			Findings cleve.ValidationFindings `json:"findings"`
+		}
+
+		results := make([]result, 0, len(args))
+		valid := true
+		for _, path := range args {
+			var findings cleve.ValidationFindings
+			sampleSheet, err := cleve.ReadSampleSheet(path)
+			if err != nil {
+				findings = cleve.ValidationFindings{{
+					Severity: cleve.SeverityError,
+					Code:     cleve.FindingParseError,
+					Message:  err.Error(),
+				}}
+			} else if runInfo != nil {
+				findings = sampleSheet.ValidateRunInfo(*runInfo)
+			} else {
+				findings = sampleSheet.Validate()
+			}
+			if findings == nil {
+				findings = cleve.ValidationFindings{}
+			}
+			valid = valid && findings.Valid()
+			results = append(results, result{Path: path, Valid: findings.Valid(), Findings: findings})
+		}
+
+		if jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			cobra.CheckErr(enc.Encode(results))
+		} else {
+			for _, r := range results {
+				status := "valid"
+				if !r.Valid {
+					status = "invalid"
+				}
+				fmt.Printf("%s: %s (%d errors, %d warnings)\n", r.Path, status, r.Findings.Errors(), r.Findings.Warnings())
+				for _, f := range r.Findings {
+					fmt.Printf("  %s\n", f)
+				}
+			}
+		}
+
+		if !valid {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	validateCmd.Flags().Bool("json", false, "Output findings as JSON")
+	validateCmd.Flags().String("run-info", "", "RunInfo.xml of the run to validate the reads against")
+}
@@ -1,20 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gmc-norr/cleve"
 	"github.com/gmc-norr/cleve/cmd/cleve/internal/cli"
+	"github.com/gmc-norr/cleve/delivery"
+	"github.com/gmc-norr/cleve/fingerprint"
 	"github.com/gmc-norr/cleve/gin"
 	"github.com/gmc-norr/cleve/interop"
 	"github.com/gmc-norr/cleve/mongo"
+	"github.com/gmc-norr/cleve/recompute"
 	"github.com/gmc-norr/cleve/watcher"
 	"github.com/maehler/webhook"
 	"github.com/spf13/cobra"
@@ -96,8 +102,11 @@ var (
 							if err != nil {
 								slog.Error("failed to read qc data", "run", e.Id, "error", err)
 							}
-							if err := db.UpdateRunQC(qc.Summarise()); err != nil {
+							summary := qc.Summarise()
+							if err := db.UpdateRunQC(summary); err != nil {
 								slog.Error("failed to load qc data", "run", e.Id, "error", err)
+							} else if err := db.UpdateFromIndexSummary(e.Id, summary.IndexSummary); err != nil {
+								slog.Error("failed to load sample read counts", "run", e.Id, "error", err)
 							}
 						}
 					}
@@ -110,20 +119,161 @@ var (
 				slog.Error("poll interval must be a positive, non-zero integer")
 				os.Exit(1)
 			}
-			analysisWatcher := watcher.NewDragenAnalysisWatcher(time.Duration(analysisPollInterval)*time.Second, db, watcherLogger.With("watcher", "DragenAnalysisWatcher"))
+			nextflowOutputs, err := gin.NextflowOutputsFromConfig()
+			if err != nil {
+				slog.Error("failed to read nextflow integration config", "error", err)
+				os.Exit(1)
+			}
+			if err := cleve.ReplaceAnalysisDetector(cleve.NextflowDetector{Outputs: nextflowOutputs}); err != nil {
+				slog.Error("failed to configure nextflow analysis detector", "error", err)
+				os.Exit(1)
+			}
+			analysisRoots, err := watcher.AnalysisRootsFromConfig()
+			if err != nil {
+				slog.Error("failed to read analysis roots", "error", err)
+				os.Exit(1)
+			}
+			analysisWatcher := watcher.NewAnalysisWatcher(time.Duration(analysisPollInterval)*time.Second, db, analysisRoots, watcherLogger.With("watcher", "AnalysisWatcher"))
 			defer analysisWatcher.Stop()
 			analysisEvents := analysisWatcher.Start()
 
+			loadSampleQC := func(analysis *cleve.Analysis) {
+				logger.Info("loading sample qc data", "analysis_id", analysis.AnalysisId)
+				multiqc, err := analysis.MultiQCMetrics()
+				if err != nil {
+					logger.Error("failed to read multiqc data", "analysis_id", analysis.AnalysisId, "path", analysis.Path, "error", err)
+				} else if len(multiqc) > 0 {
+					if err := db.UpdateMultiQCMetrics(multiqc); err != nil {
+						logger.Error("failed to load multiqc data", "analysis_id", analysis.AnalysisId, "error", err)
+					}
+				}
+				// Dragen metrics are found through the manifest of the BCLConvert analysis,
+				// which covers the secondary analysis workflows as well.
+				if !strings.Contains(strings.ToLower(analysis.Software), "bclconvert") {
+					return
+				}
+				if len(analysis.Runs) == 0 {
+					logger.Error("bclconvert analysis does not belong to a run", "analysis_id", analysis.AnalysisId, "path", analysis.Path)
+					return
+				}
+				metrics, err := cleve.DragenSampleMetricsFromAnalysis(analysis)
+				if err != nil {
+					logger.Error("failed to read sample qc data", "analysis_id", analysis.AnalysisId, "path", analysis.Path, "error", err)
+					return
+				}
+				if err := db.UpdateSampleQC(metrics); err != nil {
+					logger.Error("failed to load sample qc data", "analysis_id", analysis.AnalysisId, "error", err)
+				}
+				reads, err := cleve.DemuxStatsFromAnalysis(analysis)
+				if err != nil {
+					logger.Error("failed to read demultiplexing stats", "analysis_id", analysis.AnalysisId, "path", analysis.Path, "error", err)
+					return
+				}
+				if err := db.UpdateSampleReads(analysis.Runs[0], cleve.ReadsFromDemuxStats, reads); err != nil {
+					logger.Error("failed to load demultiplexing stats", "analysis_id", analysis.AnalysisId, "error", err)
+				}
+			}
+
+			snpPanel, err := fingerprint.PanelFromConfig()
+			if err != nil {
+				slog.Error("failed to read snp panel", "error", err)
+				os.Exit(1)
+			}
+			identityThresholds, err := fingerprint.ThresholdsFromConfig()
+			if err != nil {
+				slog.Error("failed to read fingerprint thresholds", "error", err)
+				os.Exit(1)
+			}
+
+			// Extract fingerprints from the sample level SNV VCF files of the analysis
+			// and compare them to all other fingerprints. Flagged comparisons are sent in
+			// a single webhook message for the analysis.
+			checkSampleIdentity := func(analysis *cleve.Analysis) {
+				if snpPanel == nil {
+					return
+				}
+				files := analysis.GetFiles(cleve.AnalysisFileFilter{FileType: cleve.FileSnvVcf, Level: cleve.LevelSample})
+				var flagged []string
+				for _, f := range files {
+					fp, err := fingerprint.New(analysis, f, snpPanel)
+					if err != nil {
+						logger.Error("failed to extract fingerprint", "analysis_id", analysis.AnalysisId, "sample_id", f.ParentId, "path", f.Path, "error", err)
+						continue
+					}
+					comparisons, err := fingerprint.Add(db, fp, identityThresholds)
+					if err != nil {
+						logger.Error("failed to save fingerprint", "analysis_id", analysis.AnalysisId, "sample_id", f.ParentId, "error", err)
+						continue
+					}
+					for _, c := range comparisons {
+						if c.Status.Flagged() {
+							flagged = append(flagged, c.String())
+						}
+					}
+				}
+				if len(flagged) == 0 {
+					return
+				}
+				logger.Warn("sample identity check flagged", "analysis_id", analysis.AnalysisId, "comparisons", flagged)
+				msg := cleve.NewAnalysisMessage(analysis, "sample identity check flagged: "+strings.Join(flagged, "; "), cleve.MessageIdentityAlert)
+				_ = cli.SendWebhookMessage(ctx, webhookClient, msg)
+			}
+
+			// Store the samplesheet that the analysis used. This assigns a samplesheet ID
+			// to the analysis if it does not already have one, so it must be called before
+			// the analysis is stored. A samplesheet that has already been stored is only
+			// stored again if the file has been modified since. Returns true if the
+			// samplesheet was stored.
+			storeAnalysisSampleSheet := func(analysis *cleve.Analysis) bool {
+				path := analysis.SampleSheetPath()
+				if path == "" {
+					return false
+				}
+				if analysis.SampleSheetId != nil {
+					info, err := os.Stat(path)
+					if err == nil {
+						path, err = filepath.Abs(path)
+					}
+					if err != nil {
+						logger.Error("failed to stat analysis samplesheet", "analysis_id", analysis.AnalysisId, "path", path, "error", err)
+						return false
+					}
+					stored, err := db.SampleSheet(mongo.SampleSheetWithUuid(analysis.SampleSheetId.String()))
+					if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+						logger.Error("failed to get analysis samplesheet", "analysis_id", analysis.AnalysisId, "error", err)
+						return false
+					}
+					if !stored.IsModified(cleve.SampleSheetInfo{Path: path, ModificationTime: info.ModTime()}) {
+						return false
+					}
+				}
+				sampleSheet, err := analysis.ReadSampleSheet()
+				if err != nil {
+					logger.Error("failed to read analysis samplesheet", "analysis_id", analysis.AnalysisId, "path", analysis.Path, "error", err)
+					return false
+				}
+				if _, err := db.CreateSampleSheet(sampleSheet, mongo.SampleSheetWithAnalysisId(analysis.AnalysisId.String())); err != nil {
+					logger.Error("failed to save analysis samplesheet", "analysis_id", analysis.AnalysisId, "error", err)
+					return false
+				}
+				return true
+			}
+
 			go func() {
 				for events := range analysisEvents {
 					for _, e := range events {
 						logger.Debug("analysis event", "analysis_id", e.Analysis.AnalysisId)
 						if e.New {
 							slog.Info("new analysis, adding", "path", e.Analysis.Path)
+							storeAnalysisSampleSheet(e.Analysis)
 							if err := db.CreateAnalysis(e.Analysis); err != nil {
 								logger.Error("failed to save analysis", "path", e.Analysis.Path, "analysis_id", e.Analysis.AnalysisId, "run_id", e.Analysis.AnalysisId, "error", err)
 								continue
 							}
+							if e.Analysis.StateHistory.LastState() == cleve.StateReady {
+								loadSampleQC(e.Analysis)
+								checkSampleIdentity(e.Analysis)
+							}
 							msg := cleve.NewAnalysisMessage(e.Analysis, "analysis state updated", cleve.MessageStateUpdate)
 							_ = cli.SendWebhookMessage(ctx, webhookClient, msg)
 							continue
@@ -131,6 +281,7 @@ var (
 						if e.StateChanged {
 							logger.Info("updating analysis state", "analysis_id", e.Analysis.AnalysisId, "path", e.Analysis.Path, "state", e.Analysis.StateHistory.LastState(), "new_state", e.State)
 							e.Analysis.StateHistory.Add(e.Analysis.DetectState())
+							storeAnalysisSampleSheet(e.Analysis)
 							if e.State == cleve.StateReady {
 								logger.Info("updating analysis files", "analysis_id", e.Analysis.AnalysisId)
 								if err := e.Analysis.UpdateOutputFiles(); err != nil {
@@ -142,6 +293,10 @@ var (
 								logger.Error("failed to update analysis", "analysis_id", e.Analysis.AnalysisId, "error", err)
 								continue
 							}
+							if e.State == cleve.StateReady {
+								loadSampleQC(e.Analysis)
+								checkSampleIdentity(e.Analysis)
+							}
 							if webhookClient != nil {
 								msg := cleve.NewAnalysisMessage(e.Analysis, "analysis state updated", cleve.MessageStateUpdate)
 								_ = cli.SendWebhookMessage(ctx, webhookClient, msg)
@@ -151,6 +306,119 @@ var (
 				}
 			}()
 
+			sampleSheetPollInterval := viper.GetInt("samplesheet_poll_interval")
+			if sampleSheetPollInterval < 1 {
+				slog.Error("poll interval must be a positive, non-zero integer")
+				os.Exit(1)
+			}
+			sampleSheetMaxAge := time.Duration(viper.GetInt("samplesheet_max_age_days")) * 24 * time.Hour
+			sampleSheetWatcher := watcher.NewSampleSheetWatcher(time.Duration(sampleSheetPollInterval)*time.Second, sampleSheetMaxAge, db, watcherLogger.With("watcher", "SampleSheetWatcher"))
+			defer sampleSheetWatcher.Stop()
+			sampleSheetEvents := sampleSheetWatcher.Start()
+
+			go func() {
+				for events := range sampleSheetEvents {
+					for _, e := range events {
+						updated := false
+						for _, f := range e.Files {
+							logger.Info("ingesting samplesheet", "run", e.RunId, "path", f.Path)
+							sampleSheet, err := cleve.ReadSampleSheet(f.Path)
+							if err != nil {
+								logger.Error("failed to read samplesheet", "run", e.RunId, "path", f.Path, "error", err)
+								continue
+							}
+							res, err := db.CreateSampleSheet(sampleSheet, mongo.SampleSheetWithRunId(e.RunId))
+							if err != nil {
+								logger.Error("failed to save samplesheet", "run", e.RunId, "path", f.Path, "error", err)
+								continue
+							}
+							updated = updated || res.ModifiedCount > 0 || res.UpsertedCount > 0
+						}
+						// The samplesheets of analyses are stored separately from that of
+						// the run, and an analysis that did not have one yet is updated
+						// with the ID of the new samplesheet.
+						for _, a := range e.Analyses {
+							logger.Info("ingesting analysis samplesheet", "run", e.RunId, "analysis_id", a.AnalysisId)
+							hadSampleSheet := a.SampleSheetId != nil
+							if !storeAnalysisSampleSheet(a) {
+								continue
+							}
+							if !hadSampleSheet {
+								if err := db.UpdateAnalysis(a); err != nil {
+									logger.Error("failed to update analysis", "analysis_id", a.AnalysisId, "error", err)
+								}
+							}
+							updated = true
+						}
+						if !updated {
+							continue
+						}
+						run, err := db.Run(e.RunId)
+						if err != nil {
+							logger.Error("failed to get run", "run", e.RunId, "error", err)
+							continue
+						}
+						msg := cleve.NewRunMessage(run, "samplesheet updated", cleve.MessageSampleSheetUpdate)
+						_ = cli.SendWebhookMessage(ctx, webhookClient, msg)
+					}
+				}
+				slog.Info("stop handling samplesheet watcher events")
+			}()
+
+			if workers := viper.GetInt("qc_recompute_workers"); workers > 0 {
+				go func() {
+					stale, err := db.StaleRunQC()
+					if err != nil {
+						logger.Error("failed to fetch runs with stale qc", "error", err)
+						return
+					}
+					if len(stale) == 0 {
+						return
+					}
+					runs := make([]*cleve.Run, 0, len(stale))
+					for _, s := range stale {
+						run, err := db.Run(s.RunId)
+						if err != nil {
+							logger.Error("failed to fetch run with stale qc", "run", s.RunId, "error", err)
+							continue
+						}
+						if run.StateHistory.LastState() != cleve.StateReady {
+							continue
+						}
+						runs = append(runs, run)
+					}
+					logger.Info("recomputing stale qc", "runs", len(runs), "workers", workers, "computation_version", interop.SummaryComputationVersion)
+					r := recompute.New(db, workers)
+					r.Progress = func(p recompute.Progress) {
+						if p.Result.Err != nil {
+							logger.Error("failed to recompute qc", "run", p.Result.RunId, "error", p.Result.Err)
+							return
+						}
+						logger.Info("recomputed qc", "run", p.Result.RunId, "completed", p.Completed, "failed", p.Failed, "total", p.Total)
+					}
+					r.Run(ctx, runs)
+				}()
+			}
+
+			// Checksums are computed in the background when deliveries are created, so
+			// deliveries that were still pending when the server stopped are resumed.
+			go func() {
+				pending, err := db.PendingDeliveries()
+				if err != nil {
+					logger.Error("failed to fetch pending deliveries", "error", err)
+					return
+				}
+				for _, d := range pending {
+					logger.Info("resuming delivery checksums", "delivery_id", d.DeliveryId, "files", len(d.Files))
+					if err := delivery.Checksums(d); err != nil {
+						logger.Error("failed to compute delivery checksums", "delivery_id", d.DeliveryId, "error", err)
+					}
+					if err := db.UpdateDelivery(d); err != nil {
+						logger.Error("failed to update delivery", "delivery_id", d.DeliveryId, "error", err)
+					}
+				}
+			}()
+
 			interrupt := make(chan os.Signal, 1)
 			signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
 			go func() {
@@ -158,10 +426,11 @@ var (
 				slog.Error("signal received, shutting down", "signal", s)
 				runWatcher.Stop()
 				analysisWatcher.Stop()
+				sampleSheetWatcher.Stop()
 				os.Exit(1)
 			}()
 
-			router := gin.NewRouter(db, debug, webhookClient)
+			router := gin.NewRouter(db, debug, webhookClient, nextflowOutputs)
 			logger.Info("serving cleve", "address", addr)
 			err = http.ListenAndServe(addr, router)
 			if err != nil {
@@ -178,11 +447,14 @@ func init() {
 	serveCmd.Flags().StringVar(&host, "host", "localhost", "host")
 	serveCmd.Flags().IntVarP(&port, "port", "p", 8080, "port")
 	serveCmd.Flags().StringVar(&logfile, "logfile", "", "file to write logs in")
-	serveCmd.Flags().Int("poll-interval", defaultPollInterval, "how often, in seconds, that state changes to runs and analyses should be checked")
+	serveCmd.Flags().Int("poll-interval", defaultPollInterval, "how often, in seconds, that state changes to runs and analyses, and changes to samplesheets, should be checked")
 	_ = viper.BindPFlag("host", serveCmd.Flags().Lookup("host"))
 	_ = viper.BindPFlag("port", serveCmd.Flags().Lookup("port"))
 	_ = viper.BindPFlag("logfile", serveCmd.Flags().Lookup("logfile"))
 	_ = viper.BindPFlag("run_poll_interval", serveCmd.Flags().Lookup("poll-interval"))
 	_ = viper.BindPFlag("analysis_poll_interval", serveCmd.Flags().Lookup("poll-interval"))
+	_ = viper.BindPFlag("samplesheet_poll_interval", serveCmd.Flags().Lookup("poll-interval"))
 	viper.SetDefault("run_poll_interval", defaultPollInterval)
+	viper.SetDefault("samplesheet_max_age_days", 30)
+	viper.SetDefault("qc_recompute_workers", 0)
 }
@@ -0,0 +1,121 @@
+package cleve
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryRequest describes what to deliver. Files of the given type are delivered for
+// the listed samples, or for all samples in the project if no samples are listed.
+type DeliveryRequest struct {
+	Project     string           `json:"project"`
+	Samples     []string         `json:"samples"`
+	RunId       string           `json:"run_id"`
+	FileType    AnalysisFileType `json:"type"`
+	Recipients  []string         `json:"recipients"`
+	Destination string           `json:"destination"`
+}
+
+// Validate checks that the request has a project or samples, a valid file type,
+// at least one recipient and a destination.
+func (r DeliveryRequest) Validate() error {
+	var errs []error
+	if r.Project == "" && len(r.Samples) == 0 {
+		errs = append(errs, fmt.Errorf("project or samples must be given"))
+	}
+	if !r.FileType.IsValid() {
+		errs = append(errs, fmt.Errorf("invalid file type"))
+	}
+	if len(r.Recipients) == 0 {
+		errs = append(errs, fmt.Errorf("at least one recipient must be given"))
+	}
+	if r.Destination == "" {
+		errs = append(errs, fmt.Errorf("destination must not be empty"))
+	}
+	return errors.Join(errs...)
+}
+
+// FileFilter returns a filter for the sample level analysis files of the samples that
+// should be delivered.
+func (r DeliveryRequest) FileFilter(sampleIds []string) AnalysisFileFilter {
+	filter := NewAnalysisFileFilter()
+	filter.RunId = r.RunId
+	filter.FileType = r.FileType
+	filter.Level = LevelSample
+	filter.ParentIds = sampleIds
+	return filter
+}
+
+// DeliveryFile is an analysis file that has been delivered, together with its
+// checksum and size at the time of delivery.
+type DeliveryFile struct {
+	AnalysisFile `bson:",inline" json:",inline"`
+	// Checksum is the hex encoded SHA-256 checksum of the file.
+	Checksum string `bson:"checksum" json:"checksum"`
+	Size     int64  `bson:"size" json:"size"`
+}
+
+// Delivery is a record of files delivered to one or more recipients. A delivery is
+// attached to a project and/or the samples that the files belong to.
+type Delivery struct {
+	DeliveryId  uuid.UUID        `bson:"delivery_id" json:"delivery_id"`
+	Project     string           `bson:"project,omitempty" json:"project,omitempty"`
+	Samples     []string         `bson:"samples" json:"samples"`
+	FileType    AnalysisFileType `bson:"type" json:"type"`
+	Recipients  []string         `bson:"recipients" json:"recipients"`
+	Destination string           `bson:"destination" json:"destination"`
+	Files       []DeliveryFile   `bson:"files" json:"files"`
+	Created     time.Time        `bson:"created" json:"created"`
+	// State is pending while the checksums of the files are computed, and ready or
+	// error when done.
+	State State  `bson:"state,omitempty" json:"state,omitempty"`
+	Error string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// HasChecksums returns true if the checksums of the files have been computed.
+func (d Delivery) HasChecksums() bool {
+	return d.State == StateReady
+}
+
+// WriteManifest writes a tab separated manifest of the delivered files. The manifest
+// starts with metadata lines on the form ##key=value, followed by a header line and
+// one line per file.
+func (d Delivery) WriteManifest(w io.Writer) error {
+	metadata := [][2]string{
+		{"delivery_id", d.DeliveryId.String()},
+		{"created", d.Created.Format(time.RFC3339)},
+		{"project", d.Project},
+		{"type", d.FileType.String()},
+		{"recipients", strings.Join(d.Recipients, ",")},
+		{"destination", d.Destination},
+	}
+	for _, m := range metadata {
+		if m[1] == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "##%s=%s\n", m[0], m[1]); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w, "#sha256\tsize\ttype\tlevel\tparent_id\tpath"); err != nil {
+		return err
+	}
+	for _, f := range d.Files {
+		_, err := fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", f.Checksum, f.Size, f.FileType, f.Level, f.ParentId, f.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeliveryResult is a paginated list of deliveries.
+type DeliveryResult struct {
+	PaginationMetadata `bson:"metadata" json:"metadata"`
+	Deliveries         []Delivery `bson:"deliveries" json:"deliveries"`
+}
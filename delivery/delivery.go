@@ -0,0 +1,139 @@
+// Package delivery resolves the analysis files to deliver for samples and projects, and
+// creates delivery records with checksums of the files.
+package delivery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/google/uuid"
+)
+
+// ErrNothingToDeliver is returned when a request does not match any samples or files.
+var ErrNothingToDeliver = errors.New("nothing to deliver")
+
+// Source is where samples and analysis files are looked up.
+type Source interface {
+	Samples(*cleve.SampleFilter) (*cleve.SampleResult, error)
+	AnalysesFiles(cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error)
+}
+
+// SampleIds returns the samples that files should be delivered for. These are the
+// samples of the request, or all samples in the project if the request lists none.
+func SampleIds(src Source, req cleve.DeliveryRequest) ([]string, error) {
+	if len(req.Samples) > 0 {
+		return req.Samples, nil
+	}
+	filter := cleve.NewSampleFilter()
+	filter.Project = req.Project
+	filter.PageSize = 0
+	samples, err := src.Samples(&filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(samples.Samples))
+	for _, s := range samples.Samples {
+		ids = append(ids, s.Id)
+	}
+	return ids, nil
+}
+
+// Resolve returns the analysis files to deliver for a request.
+func Resolve(src Source, req cleve.DeliveryRequest) ([]string, []cleve.AnalysisFile, error) {
+	sampleIds, err := SampleIds(src, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sampleIds) == 0 {
+		return nil, nil, fmt.Errorf("%w: no samples found for project %q", ErrNothingToDeliver, req.Project)
+	}
+	files, err := src.AnalysesFiles(req.FileFilter(sampleIds))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("%w: no %s files found", ErrNothingToDeliver, req.FileType)
+	}
+	return sampleIds, files, nil
+}
+
+// Checksum returns the hex encoded SHA-256 checksum and the size of a file.
+func Checksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// Prepare resolves the files of a request and creates a pending delivery without the
+// checksums of the files, see [Checksums]. The delivery is not stored.
+func Prepare(src Source, req cleve.DeliveryRequest) (*cleve.Delivery, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	sampleIds, files, err := Resolve(src, req)
+	if err != nil {
+		return nil, err
+	}
+	d := &cleve.Delivery{
+		DeliveryId:  uuid.New(),
+		Project:     req.Project,
+		Samples:     sampleIds,
+		FileType:    req.FileType,
+		Recipients:  req.Recipients,
+		Destination: req.Destination,
+		Files:       make([]cleve.DeliveryFile, 0, len(files)),
+		Created:     time.Now(),
+		State:       cleve.StatePending,
+	}
+	for _, f := range files {
+		d.Files = append(d.Files, cleve.DeliveryFile{AnalysisFile: f})
+	}
+	return d, nil
+}
+
+// Checksums computes the checksums and sizes of the files of a delivery, and marks
+// the delivery as ready. If a checksum cannot be computed, the delivery is marked as
+// failed and the error is returned.
+func Checksums(d *cleve.Delivery) error {
+	for i, f := range d.Files {
+		checksum, size, err := Checksum(f.Path)
+		if err != nil {
+			err = fmt.Errorf("failed to compute checksum: %w", err)
+			d.State = cleve.StateError
+			d.Error = err.Error()
+			return err
+		}
+		d.Files[i].Checksum = checksum
+		d.Files[i].Size = size
+	}
+	d.State = cleve.StateReady
+	d.Error = ""
+	return nil
+}
+
+// New resolves the files of a request and creates a delivery with the checksums of
+// the files. The delivery is not stored.
+func New(src Source, req cleve.DeliveryRequest) (*cleve.Delivery, error) {
+	d, err := Prepare(src, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := Checksums(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
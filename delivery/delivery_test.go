@@ -0,0 +1,163 @@
+package delivery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gmc-norr/cleve"
+)
+
+type testSource struct {
+	samples []cleve.Sample
+	files   map[string][]cleve.AnalysisFile
+	filters []cleve.AnalysisFileFilter
+}
+
+func (s *testSource) Samples(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
+	var samples []cleve.Sample
+	for _, sample := range s.samples {
+		if sample.Project == filter.Project {
+			samples = append(samples, sample)
+		}
+	}
+	return &cleve.SampleResult{Samples: samples}, nil
+}
+
+func (s *testSource) AnalysesFiles(filter cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error) {
+	s.filters = append(s.filters, filter)
+	var files []cleve.AnalysisFile
+	for _, id := range filter.ParentIds {
+		files = append(files, s.files[id]...)
+	}
+	return files, nil
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestChecksum(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "file.txt", "hello\n")
+	checksum, size, err := Checksum(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum != "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03" {
+		t.Errorf("unexpected checksum %s", checksum)
+	}
+	if size != 6 {
+		t.Errorf("expected size 6, got %d", size)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	s1 := writeFile(t, dir, "S1_R1.fastq.gz", "S1 reads")
+	s2 := writeFile(t, dir, "S2_R1.fastq.gz", "S2 reads")
+
+	src := &testSource{
+		samples: []cleve.Sample{
+			{Id: "S1", Project: "proj1"},
+			{Id: "S2", Project: "proj1"},
+			{Id: "S3", Project: "proj2"},
+		},
+		files: map[string][]cleve.AnalysisFile{
+			"S1": {{Path: s1, FileType: cleve.FileFastq, Level: cleve.LevelSample, ParentId: "S1"}},
+			"S2": {{Path: s2, FileType: cleve.FileFastq, Level: cleve.LevelSample, ParentId: "S2"}},
+		},
+	}
+
+	req := cleve.DeliveryRequest{
+		Project:     "proj1",
+		FileType:    cleve.FileFastq,
+		Recipients:  []string{"someone@example.com"},
+		Destination: "/data/outbox/proj1",
+	}
+	d, err := New(src, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Samples) != 2 || d.Samples[0] != "S1" || d.Samples[1] != "S2" {
+		t.Errorf("expected samples S1 and S2, got %v", d.Samples)
+	}
+	if len(src.filters) != 1 || len(src.filters[0].ParentIds) != 2 {
+		t.Errorf("expected files to be looked up for 2 samples in one query, got %+v", src.filters)
+	}
+	if len(d.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(d.Files))
+	}
+	for _, f := range d.Files {
+		if f.Checksum == "" || f.Size == 0 {
+			t.Errorf("expected checksum and size for %s", f.Path)
+		}
+	}
+	if d.Project != "proj1" || d.Created.IsZero() || d.State != cleve.StateReady {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		d, err := Prepare(src, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.State != cleve.StatePending || d.HasChecksums() {
+			t.Errorf("expected a pending delivery, got %s", d.State)
+		}
+		for _, f := range d.Files {
+			if f.Checksum != "" {
+				t.Errorf("expected no checksum for %s", f.Path)
+			}
+		}
+		if err := Checksums(d); err != nil {
+			t.Fatal(err)
+		}
+		if !d.HasChecksums() || d.Files[0].Checksum == "" {
+			t.Errorf("expected checksums, got %+v", d)
+		}
+	})
+
+	t.Run("listed samples", func(t *testing.T) {
+		req := req
+		req.Samples = []string{"S2"}
+		d, err := New(src, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Files) != 1 || d.Files[0].ParentId != "S2" {
+			t.Errorf("expected the files of S2, got %+v", d.Files)
+		}
+	})
+
+	t.Run("no samples", func(t *testing.T) {
+		req := req
+		req.Project = "proj3"
+		if _, err := New(src, req); !errors.Is(err, ErrNothingToDeliver) {
+			t.Errorf("expected ErrNothingToDeliver, got %v", err)
+		}
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		req := req
+		req.Project = "proj2"
+		if _, err := New(src, req); !errors.Is(err, ErrNothingToDeliver) {
+			t.Errorf("expected ErrNothingToDeliver, got %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		src.files["S3"] = []cleve.AnalysisFile{{Path: filepath.Join(dir, "missing.fastq.gz"), FileType: cleve.FileFastq, ParentId: "S3"}}
+		req := req
+		req.Project = "proj2"
+		_, err := New(src, req)
+		if err == nil || errors.Is(err, ErrNothingToDeliver) {
+			t.Errorf("expected an error for the missing file, got %v", err)
+		}
+	})
+}
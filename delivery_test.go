@@ -0,0 +1,145 @@
+package cleve
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDeliveryRequestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		request DeliveryRequest
+		valid   bool
+	}{
+		{
+			name: "project",
+			request: DeliveryRequest{
+				Project:     "proj1",
+				FileType:    FileFastq,
+				Recipients:  []string{"someone@example.com"},
+				Destination: "/data/outbox/proj1",
+			},
+			valid: true,
+		},
+		{
+			name: "samples",
+			request: DeliveryRequest{
+				Samples:     []string{"S1", "S2"},
+				FileType:    FileFastq,
+				Recipients:  []string{"someone@example.com"},
+				Destination: "/data/outbox/proj1",
+			},
+			valid: true,
+		},
+		{
+			name: "neither project nor samples",
+			request: DeliveryRequest{
+				FileType:    FileFastq,
+				Recipients:  []string{"someone@example.com"},
+				Destination: "/data/outbox/proj1",
+			},
+		},
+		{
+			name: "missing file type",
+			request: DeliveryRequest{
+				Project:     "proj1",
+				Recipients:  []string{"someone@example.com"},
+				Destination: "/data/outbox/proj1",
+			},
+		},
+		{
+			name: "missing recipients",
+			request: DeliveryRequest{
+				Project:     "proj1",
+				FileType:    FileFastq,
+				Destination: "/data/outbox/proj1",
+			},
+		},
+		{
+			name: "missing destination",
+			request: DeliveryRequest{
+				Project:    "proj1",
+				FileType:   FileFastq,
+				Recipients: []string{"someone@example.com"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.request.Validate()
+			if c.valid && err != nil {
+				t.Errorf("expected request to be valid, got %s", err)
+			}
+			if !c.valid && err == nil {
+				t.Error("expected request to be invalid")
+			}
+		})
+	}
+}
+
+func TestDeliveryRequestFileFilter(t *testing.T) {
+	r := DeliveryRequest{RunId: "run1", FileType: FileFastq}
+	f := r.FileFilter([]string{"S1", "S2"})
+	if !slices.Equal(f.ParentIds, []string{"S1", "S2"}) || f.Level != LevelSample || f.FileType != FileFastq || f.RunId != "run1" {
+		t.Errorf("unexpected filter %+v", f)
+	}
+	if err := f.Validate(); err != nil {
+		t.Errorf("expected filter to be valid, got %s", err)
+	}
+	for _, file := range []AnalysisFile{
+		{FileType: FileFastq, Level: LevelSample, ParentId: "S1"},
+		{FileType: FileFastq, Level: LevelSample, ParentId: "S2"},
+	} {
+		if !f.Apply(file) {
+			t.Errorf("expected the filter to match %+v", file)
+		}
+	}
+	if f.Apply(AnalysisFile{FileType: FileFastq, Level: LevelSample, ParentId: "S3"}) {
+		t.Error("expected the filter not to match the files of S3")
+	}
+}
+
+func TestDeliveryWriteManifest(t *testing.T) {
+	d := Delivery{
+		DeliveryId:  uuid.MustParse("e0ddc0f9-8c2c-4a60-9f86-7d8f4a6a8ed5"),
+		Project:     "proj1",
+		Samples:     []string{"S1"},
+		FileType:    FileFastq,
+		Recipients:  []string{"a@example.com", "b@example.com"},
+		Destination: "/data/outbox/proj1",
+		Files: []DeliveryFile{
+			{
+				AnalysisFile: AnalysisFile{
+					Path:     "/data/S1_R1.fastq.gz",
+					FileType: FileFastq,
+					Level:    LevelSample,
+					ParentId: "S1",
+				},
+				Checksum: "abc123",
+				Size:     42,
+			},
+		},
+		Created: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	var b strings.Builder
+	if err := d.WriteManifest(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := "##delivery_id=e0ddc0f9-8c2c-4a60-9f86-7d8f4a6a8ed5\n" +
+		"##created=2026-03-01T12:00:00Z\n" +
+		"##project=proj1\n" +
+		"##type=fastq\n" +
+		"##recipients=a@example.com,b@example.com\n" +
+		"##destination=/data/outbox/proj1\n" +
+		"#sha256\tsize\ttype\tlevel\tparent_id\tpath\n" +
+		"abc123\t42\tfastq\tsample\tS1\t/data/S1_R1.fastq.gz\n"
+	if b.String() != expected {
+		t.Errorf("expected manifest\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,362 @@
+package cleve
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/google/uuid"
+)
+
+// DragenMetric represents a single row in a Dragen metrics file, e.g.
+// `*.mapping_metrics.csv`. Sample is empty for metrics that are summarised
+// over all read groups, and Percent is NaN if the metric has no percentage.
+type DragenMetric struct {
+	Section string
+	Sample  string
+	Name    string
+	Value   string
+	Percent float64
+}
+
+// Float returns the value of the metric as a float. If the value cannot be
+// represented as a float, NaN is returned.
+func (m DragenMetric) Float() float64 {
+	v, err := strconv.ParseFloat(m.Value, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return v
+}
+
+// Int returns the value of the metric as an integer. If the value cannot be
+// represented as an integer, 0 is returned.
+func (m DragenMetric) Int() int {
+	v, err := strconv.Atoi(m.Value)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// DragenMetrics is the content of a Dragen metrics file.
+type DragenMetrics []DragenMetric
+
+// ParseDragenMetrics parses a Dragen metrics CSV file. These files have no
+// header, and each line has a section, an optional sample/read group, a metric
+// name, a value and an optional percentage.
+func ParseDragenMetrics(r io.Reader) (DragenMetrics, error) {
+	var metrics DragenMetrics
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	lines, err := csvReader.ReadAll()
+	if err != nil {
+		return metrics, err
+	}
+	for i, line := range lines {
+		if len(line) < 4 || len(line) > 5 {
+			return metrics, fmt.Errorf("expected 4 or 5 fields on line %d, got %d", i+1, len(line))
+		}
+		m := DragenMetric{
+			Section: line[0],
+			Sample:  line[1],
+			Name:    line[2],
+			Value:   line[3],
+			Percent: math.NaN(),
+		}
+		if len(line) == 5 && line[4] != "" {
+			p, err := strconv.ParseFloat(line[4], 64)
+			if err != nil {
+				return metrics, fmt.Errorf("invalid percentage on line %d: %w", i+1, err)
+			}
+			m.Percent = p
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ReadDragenMetrics reads and parses a Dragen metrics file.
+func ReadDragenMetrics(path string) (DragenMetrics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ParseDragenMetrics(f)
+}
+
+// Find returns the first metric in a section whose name matches the regular
+// expression. The second return value is false if no metric was found.
+func (m DragenMetrics) Find(section string, name *regexp.Regexp) (DragenMetric, bool) {
+	for _, metric := range m {
+		if metric.Section == section && name.MatchString(metric.Name) {
+			return metric, true
+		}
+	}
+	return DragenMetric{}, false
+}
+
+var (
+	totalReadsRegex     = regexp.MustCompile(`^Total input reads$`)
+	mappedReadsRegex    = regexp.MustCompile(`^Mapped reads$`)
+	duplicateReadsRegex = regexp.MustCompile(`^Number of duplicate marked reads$`)
+	meanCoverageRegex   = regexp.MustCompile(`^Average alignment coverage over `)
+	coverage20xRegex    = regexp.MustCompile(`^PCT of .+ with coverage \[\s*20x:\s*inf\)$`)
+	titvRegex           = regexp.MustCompile(`^Ti/Tv ratio$`)
+	xCoverageRatioRegex = regexp.MustCompile(`^XAvgCov/AutosomalAvgCov ratio over `)
+	yCoverageRatioRegex = regexp.MustCompile(`^YAvgCov/AutosomalAvgCov ratio over `)
+)
+
+// DragenSampleMetrics represents QC metrics for a single sample from a Dragen
+// secondary analysis. Metrics that are not available are represented by
+// NaN for floats.
+type DragenSampleMetrics struct {
+	SampleId          string                `bson:"sample_id" json:"sample_id"`
+	RunId             string                `bson:"run_id" json:"run_id"`
+	AnalysisId        uuid.UUID             `bson:"analysis_id" json:"analysis_id"`
+	Workflow          string                `bson:"workflow" json:"workflow"`
+	TotalReads        int                   `bson:"total_reads" json:"total_reads"`
+	MappedReads       int                   `bson:"mapped_reads" json:"mapped_reads"`
+	PercentMapped     interop.OptionalFloat `bson:"percent_mapped" json:"percent_mapped"`
+	DuplicateReads    int                   `bson:"duplicate_reads" json:"duplicate_reads"`
+	PercentDuplicates interop.OptionalFloat `bson:"percent_duplicates" json:"percent_duplicates"`
+	MeanCoverage      interop.OptionalFloat `bson:"mean_coverage" json:"mean_coverage"`
+	PercentTarget20x  interop.OptionalFloat `bson:"percent_target_20x" json:"percent_target_20x"`
+	TiTv              interop.OptionalFloat `bson:"titv" json:"titv"`
+	XCoverageRatio    interop.OptionalFloat `bson:"x_coverage_ratio" json:"x_coverage_ratio"`
+	YCoverageRatio    interop.OptionalFloat `bson:"y_coverage_ratio" json:"y_coverage_ratio"`
+	XHeterozygosity   interop.OptionalFloat `bson:"x_heterozygosity" json:"x_heterozygosity"`
+	InferredSex       Sex                   `bson:"inferred_sex" json:"inferred_sex"`
+	SexMethod         SexMethod             `bson:"sex_method,omitempty" json:"sex_method,omitempty"`
+}
+
+// NewDragenSampleMetrics returns sample metrics where all float metrics are
+// initialised to NaN.
+func NewDragenSampleMetrics(sampleId string) DragenSampleMetrics {
+	nan := interop.OptionalFloat(math.NaN())
+	return DragenSampleMetrics{
+		SampleId:          sampleId,
+		PercentMapped:     nan,
+		PercentDuplicates: nan,
+		MeanCoverage:      nan,
+		PercentTarget20x:  nan,
+		TiTv:              nan,
+		XCoverageRatio:    nan,
+		YCoverageRatio:    nan,
+		XHeterozygosity:   nan,
+		InferredSex:       SexUnknown,
+	}
+}
+
+// AddMappingMetrics populates the sample metrics from the content of a
+// `*.mapping_metrics.csv` file.
+func (s *DragenSampleMetrics) AddMappingMetrics(m DragenMetrics) {
+	section := "MAPPING/ALIGNING SUMMARY"
+	if metric, ok := m.Find(section, totalReadsRegex); ok {
+		s.TotalReads = metric.Int()
+	}
+	if metric, ok := m.Find(section, mappedReadsRegex); ok {
+		s.MappedReads = metric.Int()
+		s.PercentMapped = interop.OptionalFloat(metric.Percent)
+	}
+	if metric, ok := m.Find(section, duplicateReadsRegex); ok {
+		s.DuplicateReads = metric.Int()
+		s.PercentDuplicates = interop.OptionalFloat(metric.Percent)
+	}
+}
+
+// AddCoverageMetrics populates the sample metrics from the content of a
+// `*_coverage_metrics.csv` file.
+func (s *DragenSampleMetrics) AddCoverageMetrics(m DragenMetrics) {
+	section := "COVERAGE SUMMARY"
+	if metric, ok := m.Find(section, meanCoverageRegex); ok {
+		s.MeanCoverage = interop.OptionalFloat(metric.Float())
+	}
+	if metric, ok := m.Find(section, coverage20xRegex); ok {
+		s.PercentTarget20x = interop.OptionalFloat(metric.Float())
+	}
+	s.AddSexChromosomeCoverage(m)
+}
+
+// AddSexChromosomeCoverage populates the X and Y coverage ratios from the content of
+// a `*_coverage_metrics.csv` file, and infers the sex of the sample from them.
+func (s *DragenSampleMetrics) AddSexChromosomeCoverage(m DragenMetrics) {
+	section := "COVERAGE SUMMARY"
+	if metric, ok := m.Find(section, xCoverageRatioRegex); ok {
+		s.XCoverageRatio = interop.OptionalFloat(metric.Float())
+	}
+	if metric, ok := m.Find(section, yCoverageRatioRegex); ok {
+		s.YCoverageRatio = interop.OptionalFloat(metric.Float())
+	}
+	if sex := InferSexFromCoverage(float64(s.XCoverageRatio), float64(s.YCoverageRatio)); sex != SexUnknown {
+		s.InferredSex = sex
+		s.SexMethod = SexMethodCoverage
+	}
+}
+
+// AddXHeterozygosity populates the X heterozygosity from a sample VCF file, and infers
+// the sex of the sample from it if it could not be inferred from coverage.
+func (s *DragenSampleMetrics) AddXHeterozygosity(r io.Reader) error {
+	het, n, err := XHeterozygosity(r, s.SampleId)
+	if err != nil {
+		return err
+	}
+	s.XHeterozygosity = interop.OptionalFloat(het)
+	if s.InferredSex == SexUnknown || s.InferredSex == "" {
+		if sex := InferSexFromHeterozygosity(het, n); sex != SexUnknown {
+			s.InferredSex = sex
+			s.SexMethod = SexMethodHeterozygosity
+		}
+	}
+	return nil
+}
+
+// AddVariantCallingMetrics populates the sample metrics from the content of a
+// `*.vc_metrics.csv` file.
+func (s *DragenSampleMetrics) AddVariantCallingMetrics(m DragenMetrics) {
+	if metric, ok := m.Find("VARIANT CALLER POSTFILTER", titvRegex); ok {
+		s.TiTv = interop.OptionalFloat(metric.Float())
+	}
+}
+
+// DragenSampleMetricsFromAnalysis finds and parses the mapping, coverage and
+// variant calling metrics for all samples in a Dragen secondary analysis. The
+// files are found via the Dragen manifest in the analysis directory. Each
+// sample is identified by a mapping metrics file, and coverage and variant
+// calling metrics are looked for in the same directory. If the analysis does
+// not contain any mapping metrics, an empty slice is returned.
+//
+// The sex of each sample is inferred from the X and Y coverage ratios, and if
+// that is inconclusive, from the X heterozygosity in the hard filtered VCF.
+func DragenSampleMetricsFromAnalysis(analysis *Analysis) ([]DragenSampleMetrics, error) {
+	f, err := os.Open(filepath.Join(analysis.Path, "Manifest.tsv"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	manifest, err := ReadDragenManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dragen manifest: %w", err)
+	}
+
+	var runId string
+	if len(analysis.Runs) > 0 {
+		runId = analysis.Runs[0]
+	}
+
+	var sampleMetrics []DragenSampleMetrics
+	mappingFiles := manifest.FindFiles(regexp.MustCompile(`\.mapping_metrics\.csv$`))
+	for _, mappingFile := range mappingFiles {
+		sampleId := strings.TrimSuffix(filepath.Base(mappingFile), ".mapping_metrics.csv")
+		sampleDir := filepath.Dir(mappingFile)
+		metrics := NewDragenSampleMetrics(sampleId)
+		metrics.RunId = runId
+		metrics.AnalysisId = analysis.AnalysisId
+		metrics.Workflow = dragenWorkflow(mappingFile)
+
+		m, err := ReadDragenMetrics(filepath.Join(analysis.Path, mappingFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mapping metrics for %s: %w", sampleId, err)
+		}
+		metrics.AddMappingMetrics(m)
+
+		coverageRegex := regexp.MustCompile(`^` + regexp.QuoteMeta(sampleId) + `\.(.+_)?coverage_metrics\.csv$`)
+		coverageFiles := sampleFiles(manifest.FindFiles(coverageRegex), sampleDir)
+		// Prefer target region coverage over whole genome coverage
+		slices.SortStableFunc(coverageFiles, func(a, b string) int {
+			aWgs := strings.HasSuffix(a, ".wgs_coverage_metrics.csv")
+			bWgs := strings.HasSuffix(b, ".wgs_coverage_metrics.csv")
+			switch {
+			case aWgs && !bWgs:
+				return 1
+			case !aWgs && bWgs:
+				return -1
+			default:
+				return 0
+			}
+		})
+		if len(coverageFiles) > 0 {
+			m, err := ReadDragenMetrics(filepath.Join(analysis.Path, coverageFiles[0]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read coverage metrics for %s: %w", sampleId, err)
+			}
+			metrics.AddCoverageMetrics(m)
+		}
+		// Ratios for the sex chromosomes might only be reported for the whole genome
+		for _, f := range coverageFiles[min(1, len(coverageFiles)):] {
+			if !metrics.XCoverageRatio.IsNaN() {
+				break
+			}
+			m, err := ReadDragenMetrics(filepath.Join(analysis.Path, f))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read coverage metrics for %s: %w", sampleId, err)
+			}
+			metrics.AddSexChromosomeCoverage(m)
+		}
+
+		vcRegex := regexp.MustCompile(`^` + regexp.QuoteMeta(sampleId) + `\.vc_metrics\.csv$`)
+		if vcFiles := sampleFiles(manifest.FindFiles(vcRegex), sampleDir); len(vcFiles) > 0 {
+			m, err := ReadDragenMetrics(filepath.Join(analysis.Path, vcFiles[0]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read variant calling metrics for %s: %w", sampleId, err)
+			}
+			metrics.AddVariantCallingMetrics(m)
+		}
+
+		if metrics.InferredSex == SexUnknown {
+			vcfRegex := regexp.MustCompile(`^` + regexp.QuoteMeta(sampleId) + `\.hard-filtered\.vcf(\.gz)?$`)
+			if vcfFiles := sampleFiles(manifest.FindFiles(vcfRegex), sampleDir); len(vcfFiles) > 0 {
+				if err := addXHeterozygosityFromFile(&metrics, filepath.Join(analysis.Path, vcfFiles[0])); err != nil {
+					return nil, fmt.Errorf("failed to read variants for %s: %w", sampleId, err)
+				}
+			}
+		}
+
+		sampleMetrics = append(sampleMetrics, metrics)
+	}
+
+	return sampleMetrics, nil
+}
+
+func addXHeterozygosityFromFile(metrics *DragenSampleMetrics, path string) error {
+	r, err := OpenVcf(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+	return metrics.AddXHeterozygosity(r)
+}
+
+// sampleFiles returns the files that are located in dir.
+func sampleFiles(files []string, dir string) []string {
+	var res []string
+	for _, f := range files {
+		if filepath.Dir(f) == dir {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// dragenWorkflow identifies the Dragen workflow from the path of a file in a
+// Dragen analysis, e.g. `Data/DragenGermline/sample/germline_seq/sample.mapping_metrics.csv`
+// is part of the `DragenGermline` workflow. If the workflow cannot be identified,
+// an empty string is returned.
+func dragenWorkflow(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, p := range parts[:len(parts)-1] {
+		if p == "Data" && i+1 < len(parts)-1 {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package cleve
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const mockMappingMetrics = `MAPPING/ALIGNING SUMMARY,,Total input reads,1000,
+MAPPING/ALIGNING SUMMARY,,Number of duplicate marked reads,100,10.00
+MAPPING/ALIGNING SUMMARY,,Mapped reads,990,99.00
+MAPPING/ALIGNING PER RG,sample1,Total reads in RG,1000,100.00
+`
+
+const mockWgsCoverageMetrics = `COVERAGE SUMMARY,,Aligned bases,1000000,100.00
+COVERAGE SUMMARY,,Average alignment coverage over genome,31.50
+COVERAGE SUMMARY,,PCT of genome with coverage [  20x: inf),92.10
+COVERAGE SUMMARY,,XAvgCov/AutosomalAvgCov ratio over genome,1.02
+COVERAGE SUMMARY,,YAvgCov/AutosomalAvgCov ratio over genome,0.01
+`
+
+const mockTargetCoverageMetrics = `COVERAGE SUMMARY,,Aligned bases in target region,500000,50.00
+COVERAGE SUMMARY,,Average alignment coverage over target region,120.20
+COVERAGE SUMMARY,,PCT of target region with coverage [  20x: inf),98.50
+`
+
+const mockVcMetrics = `VARIANT CALLER SUMMARY,,Number of samples,1
+VARIANT CALLER PREFILTER,sample1,Ti/Tv ratio,1.90
+VARIANT CALLER POSTFILTER,sample1,Ti/Tv ratio,2.05
+`
+
+func TestParseDragenMetrics(t *testing.T) {
+	testcases := []struct {
+		name        string
+		content     string
+		metrics     int
+		shouldError bool
+	}{
+		{
+			name:    "mapping metrics",
+			content: mockMappingMetrics,
+			metrics: 4,
+		},
+		{
+			name:    "metrics without percentage",
+			content: mockVcMetrics,
+			metrics: 3,
+		},
+		{
+			name:    "empty file",
+			content: "",
+			metrics: 0,
+		},
+		{
+			name:        "too few fields",
+			content:     "MAPPING/ALIGNING SUMMARY,,Total input reads\n",
+			shouldError: true,
+		},
+		{
+			name:        "invalid percentage",
+			content:     "MAPPING/ALIGNING SUMMARY,,Mapped reads,990,many\n",
+			shouldError: true,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			metrics, err := ParseDragenMetrics(strings.NewReader(c.content))
+			if err != nil {
+				if !c.shouldError {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if c.shouldError {
+				t.Fatal("expected an error, got nil")
+			}
+			if len(metrics) != c.metrics {
+				t.Errorf("expected %d metrics, got %d", c.metrics, len(metrics))
+			}
+		})
+	}
+}
+
+func TestDragenSampleMetricsFromAnalysis(t *testing.T) {
+	analysisDir := t.TempDir()
+	files := map[string]string{
+		"Data/DragenGermline/sample1/germline_seq/sample1.mapping_metrics.csv":                    mockMappingMetrics,
+		"Data/DragenGermline/sample1/germline_seq/sample1.wgs_coverage_metrics.csv":               mockWgsCoverageMetrics,
+		"Data/DragenGermline/sample1/germline_seq/sample1.vc_metrics.csv":                         mockVcMetrics,
+		"Data/DragenEnrichment/sample2/enrichment_seq/sample2.mapping_metrics.csv":                mockMappingMetrics,
+		"Data/DragenEnrichment/sample2/enrichment_seq/sample2.wgs_coverage_metrics.csv":           mockWgsCoverageMetrics,
+		"Data/DragenEnrichment/sample2/enrichment_seq/sample2.target_bed_coverage_metrics.csv":    mockTargetCoverageMetrics,
+		"Data/DragenEnrichment/sample2/enrichment_seq/sample2.qc-coverage-region-1_coverage.json": "",
+	}
+	var manifest string
+	for name, content := range files {
+		path := filepath.Join(analysisDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := mockFile(path, content); err != nil {
+			t.Fatal(err)
+		}
+		manifest += name + "\thash\n"
+	}
+	if err := mockFile(filepath.Join(analysisDir, "Manifest.tsv"), manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis := Analysis{
+		AnalysisId: uuid.New(),
+		Path:       analysisDir,
+		Runs:       []string{"run1"},
+	}
+
+	metrics, err := DragenSampleMetricsFromAnalysis(&analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected metrics for 2 samples, got %d", len(metrics))
+	}
+
+	for _, m := range metrics {
+		if m.RunId != "run1" {
+			t.Errorf("expected run id run1, got %q", m.RunId)
+		}
+		if m.AnalysisId != analysis.AnalysisId {
+			t.Errorf("expected analysis id %s, got %s", analysis.AnalysisId, m.AnalysisId)
+		}
+		if m.TotalReads != 1000 || m.MappedReads != 990 || m.DuplicateReads != 100 {
+			t.Errorf("unexpected read counts for %s: %+v", m.SampleId, m)
+		}
+		if m.PercentMapped != 99 || m.PercentDuplicates != 10 {
+			t.Errorf("unexpected percentages for %s: %+v", m.SampleId, m)
+		}
+		if m.XCoverageRatio != 1.02 || m.YCoverageRatio != 0.01 {
+			t.Errorf("expected genome sex chromosome ratios for %s, got %f and %f", m.SampleId, m.XCoverageRatio, m.YCoverageRatio)
+		}
+		if m.InferredSex != SexFemale || m.SexMethod != SexMethodCoverage {
+			t.Errorf("expected %s to be inferred female from coverage, got %q from %q", m.SampleId, m.InferredSex, m.SexMethod)
+		}
+		switch m.SampleId {
+		case "sample1":
+			if m.Workflow != "DragenGermline" {
+				t.Errorf("expected workflow DragenGermline, got %q", m.Workflow)
+			}
+			if m.MeanCoverage != 31.5 || m.PercentTarget20x != 92.1 {
+				t.Errorf("expected genome coverage for sample1, got %f and %f", m.MeanCoverage, m.PercentTarget20x)
+			}
+			if m.TiTv != 2.05 {
+				t.Errorf("expected post-filter Ti/Tv 2.05, got %f", m.TiTv)
+			}
+		case "sample2":
+			if m.Workflow != "DragenEnrichment" {
+				t.Errorf("expected workflow DragenEnrichment, got %q", m.Workflow)
+			}
+			if m.MeanCoverage != 120.2 || m.PercentTarget20x != 98.5 {
+				t.Errorf("expected target coverage for sample2, got %f and %f", m.MeanCoverage, m.PercentTarget20x)
+			}
+			if !math.IsNaN(float64(m.TiTv)) {
+				t.Errorf("expected Ti/Tv to be NaN, got %f", m.TiTv)
+			}
+		default:
+			t.Errorf("unexpected sample %q", m.SampleId)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package cleve
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -41,6 +43,7 @@ type RunFilter struct {
 	State            string    `form:"state"`
 	From             time.Time `form:"from"`
 	To               time.Time `form:"to"`
+	Project          string    `form:"project"`
 	PaginationFilter `form:",inline"`
 }
 
@@ -70,6 +73,10 @@ func (f RunFilter) UrlParams() string {
 		p += fmt.Sprintf("%sstate=%s", sep, f.State)
 		sep = "&"
 	}
+	if f.Project != "" {
+		p += fmt.Sprintf("%sproject=%s", sep, f.Project)
+		sep = "&"
+	}
 	if f.Page != 0 {
 		p += fmt.Sprintf("%spage=%d", sep, f.Page)
 	}
@@ -127,6 +134,7 @@ func (f QcFilter) UrlParams() string {
 type AnalysisFilter struct {
 	AnalysisId       uuid.UUID `form:"-"`
 	Path             string    `form:"-"`
+	PathPrefix       string    `form:"-"` // Analyses in the directory or below it
 	RunId            string    `form:"run_id"`
 	Software         string    `form:"software"`
 	SoftwarePattern  string    `form:"software_pattern"`
@@ -148,6 +156,9 @@ func (f *AnalysisFilter) Validate() error {
 	if f.Path != "" && !filepath.IsAbs(f.Path) {
 		errs = append(errs, fmt.Errorf("path must be absolute"))
 	}
+	if f.PathPrefix != "" && !filepath.IsAbs(f.PathPrefix) {
+		errs = append(errs, fmt.Errorf("path prefix must be absolute"))
+	}
 	return errors.Join(errs...)
 }
 
@@ -158,8 +169,10 @@ type AnalysisFileFilter struct {
 	FileType   AnalysisFileType `form:"type" bson:"type,omitempty" json:"type,omitzero"`
 	Level      AnalysisLevel    `form:"level" bson:"level,omitempty" json:"level,omitzero"`
 	ParentId   string           `form:"parent_id" bson:"parent_id,omitempty" json:"parent_id,omitzero"`
-	Name       string           `form:"name" bson:"name,omitempty" json:"name,omitzero"`
-	Pattern    *regexp.Regexp   `form:"-" bson:"-" json:"-"`
+	// ParentIds matches files of any of the parents, for internal use.
+	ParentIds []string       `form:"-" bson:"-" json:"-"`
+	Name      string         `form:"name" bson:"name,omitempty" json:"name,omitzero"`
+	Pattern   *regexp.Regexp `form:"-" bson:"-" json:"-"`
 }
 
 func NewAnalysisFileFilter() AnalysisFileFilter {
@@ -168,7 +181,7 @@ func NewAnalysisFileFilter() AnalysisFileFilter {
 
 func (f *AnalysisFileFilter) Validate() error {
 	var errs []error
-	if f.FileType.IsZero() && f.ParentId == "" && f.Name == "" && f.Pattern == nil {
+	if f.FileType.IsZero() && f.ParentId == "" && len(f.ParentIds) == 0 && f.Name == "" && f.Pattern == nil {
 		// for now, pattern is only for internal use
 		errs = append(errs, fmt.Errorf("one of type, parent id or name must be defined"))
 	}
@@ -192,6 +205,9 @@ func (f *AnalysisFileFilter) Apply(file AnalysisFile) bool {
 	if f.ParentId != "" && f.ParentId != file.ParentId {
 		return false
 	}
+	if len(f.ParentIds) > 0 && !slices.Contains(f.ParentIds, file.ParentId) {
+		return false
+	}
 	if f.Name != "" && f.Name != filepath.Base(file.Path) {
 		return false
 	}
@@ -203,10 +219,17 @@ func (f *AnalysisFileFilter) Apply(file AnalysisFile) bool {
 
 // Sample filtering.
 type SampleFilter struct {
-	Name             string `form:"sample_name"`
-	Id               string `form:"sample_id"`
-	RunId            string `form:"run_id"`
-	Analysis         string `form:"analysis"`
+	Name     string `form:"sample_name"`
+	Id       string `form:"sample_id"`
+	RunId    string `form:"run_id"`
+	Analysis string `form:"analysis"`
+	Project  string `form:"project"`
+	// Metadata conditions on the form key:value, separated by commas.
+	Metadata string `form:"metadata"`
+	// Panel ID, and optionally version, that the sample or one of its analyses
+	// refers to.
+	Panel            string `form:"panel"`
+	PanelVersion     string `form:"panel_version"`
 	PaginationFilter `form:",inline"`
 }
 
@@ -234,6 +257,23 @@ func (f SampleFilter) UrlParams() string {
 	}
 	if f.Analysis != "" {
 		p += fmt.Sprintf("%sanalysis=%s", sep, f.Analysis)
+		sep = "&"
+	}
+	if f.Project != "" {
+		p += fmt.Sprintf("%sproject=%s", sep, f.Project)
+		sep = "&"
+	}
+	if f.Metadata != "" {
+		p += fmt.Sprintf("%smetadata=%s", sep, url.QueryEscape(f.Metadata))
+		sep = "&"
+	}
+	if f.Panel != "" {
+		p += fmt.Sprintf("%spanel=%s", sep, f.Panel)
+		sep = "&"
+	}
+	if f.PanelVersion != "" {
+		p += fmt.Sprintf("%spanel_version=%s", sep, f.PanelVersion)
+		sep = "&"
 	}
 	if f.Page != 0 {
 		p = fmt.Sprintf("%s%spage=%d", p, sep, f.Page)
@@ -246,6 +286,143 @@ func (f SampleFilter) UrlParams() string {
 	return p
 }
 
+// Validate checks the pagination, that a panel is given if a panel version is, and
+// that the metadata conditions of the filter can be parsed.
+func (f SampleFilter) Validate() error {
+	if err := f.PaginationFilter.Validate(); err != nil {
+		return err
+	}
+	if f.PanelVersion != "" && f.Panel == "" {
+		return errors.New("panel version requires a panel")
+	}
+	_, err := ParseMetadataFilter(f.Metadata)
+	return err
+}
+
+// Samplesheet filtering. Samplesheets match the sample ID, project and index if any
+// data section has a matching value in the corresponding column. The index matches
+// both index and index2. From and To refer to the modification time of the files the
+// samplesheet was read from.
+type SampleSheetFilter struct {
+	RunId            string    `form:"run_id"`
+	UUID             uuid.UUID `form:"-"`
+	SampleId         string    `form:"sample_id"`
+	Project          string    `form:"project"`
+	Index            string    `form:"index"`
+	From             time.Time `form:"from"`
+	To               time.Time `form:"to"`
+	PaginationFilter `form:",inline"`
+}
+
+func NewSampleSheetFilter() SampleSheetFilter {
+	return SampleSheetFilter{
+		PaginationFilter: NewPaginationFilter(),
+	}
+}
+
+func (f *SampleSheetFilter) Validate() error {
+	errs := []error{f.PaginationFilter.Validate()}
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		errs = append(errs, fmt.Errorf("to must not be before from"))
+	}
+	if f.Index != "" && !validIndex.MatchString(f.Index) {
+		errs = append(errs, fmt.Errorf("invalid index sequence %q", f.Index))
+	}
+	return errors.Join(errs...)
+}
+
+// Project filtering.
+type ProjectFilter struct {
+	NameQuery        string        `form:"name_query"`
+	RunId            string        `form:"run_id"`
+	DeliveryState    DeliveryState `form:"delivery_state"`
+	PaginationFilter `form:",inline"`
+}
+
+func NewProjectFilter() ProjectFilter {
+	return ProjectFilter{
+		PaginationFilter: NewPaginationFilter(),
+	}
+}
+
+// Convert a project filter to URL query parameters.
+func (f ProjectFilter) UrlParams() string {
+	p := "?"
+	sep := ""
+	if f.NameQuery != "" {
+		p += fmt.Sprintf("%sname_query=%s", sep, f.NameQuery)
+		sep = "&"
+	}
+	if f.RunId != "" {
+		p += fmt.Sprintf("%srun_id=%s", sep, f.RunId)
+		sep = "&"
+	}
+	if f.DeliveryState != "" {
+		p += fmt.Sprintf("%sdelivery_state=%s", sep, f.DeliveryState)
+		sep = "&"
+	}
+	if f.Page != 0 {
+		p += fmt.Sprintf("%spage=%d", sep, f.Page)
+		sep = "&"
+	}
+	if f.PageSize != 0 {
+		p += fmt.Sprintf("%spage_size=%d", sep, f.PageSize)
+	}
+	return p
+}
+
+func (f *ProjectFilter) Validate() error {
+	errs := []error{f.PaginationFilter.Validate()}
+	if f.DeliveryState != "" && !f.DeliveryState.IsValid() {
+		errs = append(errs, fmt.Errorf("invalid delivery state %q", f.DeliveryState))
+	}
+	return errors.Join(errs...)
+}
+
+// Case filtering.
+type CaseFilter struct {
+	SampleId         string     `form:"sample_id"`
+	Role             SampleRole `form:"role"`
+	PaginationFilter `form:",inline"`
+}
+
+func NewCaseFilter() CaseFilter {
+	return CaseFilter{
+		PaginationFilter: NewPaginationFilter(),
+	}
+}
+
+func (f *CaseFilter) Validate() error {
+	errs := []error{f.PaginationFilter.Validate()}
+	if f.Role != "" && !f.Role.IsValid() {
+		errs = append(errs, fmt.Errorf("invalid role %q", f.Role))
+	}
+	return errors.Join(errs...)
+}
+
+// Delivery filtering.
+type DeliveryFilter struct {
+	Project          string `form:"project"`
+	SampleId         string `form:"sample_id"`
+	PaginationFilter `form:",inline"`
+}
+
+func NewDeliveryFilter() DeliveryFilter {
+	return DeliveryFilter{
+		PaginationFilter: NewPaginationFilter(),
+	}
+}
+
+func (f *DeliveryFilter) Validate() error {
+	return f.PaginationFilter.Validate()
+}
+
+// Fingerprint filtering.
+type FingerprintFilter struct {
+	SampleId string
+	Panel    string
+}
+
 type PanelFilter struct {
 	Category  string `form:"category"`
 	Name      string `form:"name"`
@@ -2,6 +2,7 @@ package cleve
 
 import (
 	"encoding/json"
+	"reflect"
 	"regexp"
 	"testing"
 
@@ -165,7 +166,7 @@ func TestAnalysisFileFilterJSON(t *testing.T) {
 				}
 				t.Log(err)
 			}
-			if filter != c.filter {
+			if !reflect.DeepEqual(filter, c.filter) {
 				t.Error("filters are mismatching")
 				t.Log(filter)
 				t.Log(c.filter)
@@ -0,0 +1,37 @@
+package cleve
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Genotypes in a fingerprint are encoded as one character per site in the SNP panel.
+const (
+	GenotypeMissing = '.'
+	GenotypeHomRef  = '0'
+	GenotypeHet     = '1'
+	GenotypeHomAlt  = '2'
+)
+
+// Fingerprint is a compact representation of the genotypes of a sample at the sites
+// of a SNP panel, extracted from the output of an analysis. It is used to check that
+// data from different runs and analyses of a sample come from the same individual.
+type Fingerprint struct {
+	SampleId   string    `bson:"sample_id" json:"sample_id"`
+	AnalysisId uuid.UUID `bson:"analysis_id" json:"analysis_id"`
+	Runs       []string  `bson:"runs" json:"runs"`
+	// Panel is the name of the SNP panel that the genotypes refer to. Only
+	// fingerprints for the same panel can be compared.
+	Panel string `bson:"panel" json:"panel"`
+	// Genotypes has one character per site in the panel, see [GenotypeMissing],
+	// [GenotypeHomRef], [GenotypeHet] and [GenotypeHomAlt].
+	Genotypes string    `bson:"genotypes" json:"genotypes"`
+	Created   time.Time `bson:"created" json:"created"`
+}
+
+// Sites returns the number of sites in the fingerprint that have a genotype.
+func (f Fingerprint) Sites() int {
+	return len(f.Genotypes) - strings.Count(f.Genotypes, string(GenotypeMissing))
+}
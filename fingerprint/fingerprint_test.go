@@ -0,0 +1,326 @@
+package fingerprint
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/google/uuid"
+)
+
+const testPanel = `##name=test_panel
+#chrom	pos	ref	alt	id
+chr1	100	A	G	rs1
+chr1	200	C	T	rs2
+chr2	300	G	A	rs3
+chr2	400	T	C	rs4
+chrX	500	A	C	rs5
+chr3	600	C	G	rs6
+`
+
+const testVcf = `##fileformat=VCFv4.2
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	S1
+1	100	.	A	G	50	PASS	.	GT:DP	0/1:30
+1	150	.	A	T	50	PASS	.	GT:DP	1/1:30
+1	200	.	C	T	50	PASS	.	GT:DP	1|1:30
+2	250	.	G	<NON_REF>	.	.	END=350	GT:DP	0/0:25
+2	400	.	T	C	50	LowQual	.	GT:DP	0/1:5
+X	500	.	A	G,C	50	PASS	.	GT	1/2
+3	600	.	C	G	50	PASS	.	GT	./.
+`
+
+func readTestPanel(t *testing.T) *Panel {
+	t.Helper()
+	p, err := ReadPanel(strings.NewReader(testPanel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestReadPanel(t *testing.T) {
+	p := readTestPanel(t)
+	if p.Name != "test_panel" {
+		t.Errorf("expected name test_panel, got %q", p.Name)
+	}
+	if len(p.Sites) != 6 {
+		t.Fatalf("expected 6 sites, got %d", len(p.Sites))
+	}
+	if s := p.Sites[2]; s.Chrom != "chr2" || s.Pos != 300 || s.Ref != "G" || s.Alt != "A" || s.Id != "rs3" {
+		t.Errorf("unexpected site %+v", s)
+	}
+
+	invalid := []string{
+		"",
+		"chr1\t100\tA\n",
+		"chr1\tpos\tA\tG\n",
+		"chr1\t100\tAT\tG\n",
+	}
+	for _, s := range invalid {
+		if _, err := ReadPanel(strings.NewReader(s)); err == nil {
+			t.Errorf("expected an error for panel %q", s)
+		}
+	}
+}
+
+func TestReadPanelFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity_snps.tsv")
+	if err := os.WriteFile(path, []byte("chr1\t100\tA\tG\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ReadPanelFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "identity_snps" {
+		t.Errorf("expected the panel to be named after the file, got %q", p.Name)
+	}
+}
+
+func TestGenotypes(t *testing.T) {
+	p := readTestPanel(t)
+
+	genotypes, err := Genotypes(strings.NewReader(testVcf), p, "S1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rs1 het, rs2 hom alt, rs3 in a reference block, rs4 filtered,
+	// rs5 with another alt allele, rs6 no call.
+	if genotypes != "120..." {
+		t.Errorf("expected genotypes 120..., got %s", genotypes)
+	}
+
+	t.Run("multiple samples", func(t *testing.T) {
+		vcf := "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tS1\tS2\n" +
+			"1\t100\t.\tA\tG\t50\tPASS\t.\tGT\t0/1\t1/1\n"
+		genotypes, err := Genotypes(strings.NewReader(vcf), p, "S2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if genotypes != "2....." {
+			t.Errorf("expected genotypes 2....., got %s", genotypes)
+		}
+		if _, err := Genotypes(strings.NewReader(vcf), p, "S3"); err == nil {
+			t.Error("expected an error for a missing sample")
+		}
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		if _, err := Genotypes(strings.NewReader("1\t100\t.\tA\tG\t50\tPASS\t.\tGT\t0/1\n"), p, "S1"); err == nil {
+			t.Error("expected an error for a vcf without header")
+		}
+	})
+}
+
+func TestReadGenotypesGzip(t *testing.T) {
+	p := readTestPanel(t)
+	path := filepath.Join(t.TempDir(), "S1.hard-filtered.vcf.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(testVcf)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	genotypes, err := ReadGenotypes(path, p, "S1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genotypes != "120..." {
+		t.Errorf("expected genotypes 120..., got %s", genotypes)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	thresholds := Thresholds{MinSites: 4, MinConcordance: 0.75}
+
+	cases := []struct {
+		name   string
+		a      cleve.Fingerprint
+		b      cleve.Fingerprint
+		sites  int
+		status Status
+	}{
+		{
+			name:   "same sample match",
+			a:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "0121."},
+			b:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "01212"},
+			sites:  4,
+			status: StatusMatch,
+		},
+		{
+			name:   "same sample discordant",
+			a:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "01210"},
+			b:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "21012"},
+			sites:  5,
+			status: StatusDiscordant,
+		},
+		{
+			name:   "different samples match",
+			a:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "01210"},
+			b:      cleve.Fingerprint{SampleId: "S2", Panel: "p", Genotypes: "01210"},
+			sites:  5,
+			status: StatusUnexpectedMatch,
+		},
+		{
+			name:   "different samples",
+			a:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "01210"},
+			b:      cleve.Fingerprint{SampleId: "S2", Panel: "p", Genotypes: "21012"},
+			sites:  5,
+			status: StatusDistinct,
+		},
+		{
+			name:   "too few sites",
+			a:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "01..."},
+			b:      cleve.Fingerprint{SampleId: "S1", Panel: "p", Genotypes: "21012"},
+			sites:  2,
+			status: StatusInconclusive,
+		},
+		{
+			name:   "different panels",
+			a:      cleve.Fingerprint{SampleId: "S1", Panel: "p1", Genotypes: "01210"},
+			b:      cleve.Fingerprint{SampleId: "S1", Panel: "p2", Genotypes: "01210"},
+			sites:  0,
+			status: StatusInconclusive,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := Compare(c.a, c.b, thresholds)
+			if res.Sites != c.sites {
+				t.Errorf("expected %d sites, got %d", c.sites, res.Sites)
+			}
+			if res.Status != c.status {
+				t.Errorf("expected status %s, got %s", c.status, res.Status)
+			}
+		})
+	}
+}
+
+type testStore struct {
+	fingerprints []cleve.Fingerprint
+	comparisons  []Comparison
+}
+
+func (s *testStore) Fingerprints(filter cleve.FingerprintFilter) ([]cleve.Fingerprint, error) {
+	var fingerprints []cleve.Fingerprint
+	for _, f := range s.fingerprints {
+		if filter.SampleId != "" && f.SampleId != filter.SampleId {
+			continue
+		}
+		if filter.Panel != "" && f.Panel != filter.Panel {
+			continue
+		}
+		fingerprints = append(fingerprints, f)
+	}
+	return fingerprints, nil
+}
+
+func (s *testStore) FingerprintComparisons(sampleId string) ([]Comparison, error) {
+	var comparisons []Comparison
+	for _, c := range s.comparisons {
+		if c.SampleId == sampleId || c.OtherSampleId == sampleId {
+			comparisons = append(comparisons, c)
+		}
+	}
+	return comparisons, nil
+}
+
+func (s *testStore) SetFingerprint(f *cleve.Fingerprint) error {
+	s.fingerprints = slices.DeleteFunc(s.fingerprints, func(o cleve.Fingerprint) bool {
+		return o.SampleId == f.SampleId && o.AnalysisId == f.AnalysisId && o.Panel == f.Panel
+	})
+	s.fingerprints = append(s.fingerprints, *f)
+	return nil
+}
+
+func (s *testStore) SetFingerprintComparisons(f *cleve.Fingerprint, comparisons []Comparison) error {
+	s.comparisons = slices.DeleteFunc(s.comparisons, func(c Comparison) bool {
+		return c.Panel == f.Panel && (c.AnalysisId == f.AnalysisId && c.SampleId == f.SampleId ||
+			c.OtherAnalysisId == f.AnalysisId && c.OtherSampleId == f.SampleId)
+	})
+	s.comparisons = append(s.comparisons, comparisons...)
+	return nil
+}
+
+func TestCheckSample(t *testing.T) {
+	thresholds := Thresholds{MinSites: 4, MinConcordance: 0.75}
+	a1, a2, a3, a4 := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	store := &testStore{}
+	for _, f := range []cleve.Fingerprint{
+		{SampleId: "S1", AnalysisId: a1, Panel: "p", Genotypes: "01210"},
+		{SampleId: "S1", AnalysisId: a2, Panel: "p", Genotypes: "21012"},
+		{SampleId: "S2", AnalysisId: a3, Panel: "p", Genotypes: "21012"},
+		{SampleId: "S3", AnalysisId: a4, Panel: "p", Genotypes: "00000"},
+	} {
+		if _, err := Add(store, &f, thresholds); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(store.comparisons) != 2 {
+		t.Errorf("expected 2 stored comparisons, got %+v", store.comparisons)
+	}
+
+	identity, err := CheckSample(store, "S1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identity.Fingerprints) != 2 {
+		t.Errorf("expected 2 fingerprints, got %d", len(identity.Fingerprints))
+	}
+	if !identity.Flagged {
+		t.Error("expected the identity check to be flagged")
+	}
+	if len(identity.Comparisons) != 2 {
+		t.Fatalf("expected 2 comparisons, got %+v", identity.Comparisons)
+	}
+	if identity.Comparisons[0].Status != StatusDiscordant {
+		t.Errorf("expected the fingerprints of S1 to be discordant, got %s", identity.Comparisons[0].Status)
+	}
+	c := identity.Comparisons[1]
+	if c.Status != StatusUnexpectedMatch || c.SampleId != "S1" || c.OtherSampleId != "S2" || !c.Involves(a2) || c.Involves(a1) {
+		t.Errorf("expected an unexpected match between S1 and S2, got %+v", c)
+	}
+	if len(identity.FlaggedComparisons()) != 2 {
+		t.Errorf("expected 2 flagged comparisons, got %d", len(identity.FlaggedComparisons()))
+	}
+
+	identity, err = CheckSample(store, "S3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Flagged || len(identity.Comparisons) != 0 {
+		t.Errorf("expected no flagged comparisons for S3, got %+v", identity.Comparisons)
+	}
+
+	t.Run("replaced fingerprint", func(t *testing.T) {
+		f := cleve.Fingerprint{SampleId: "S1", AnalysisId: a2, Panel: "p", Genotypes: "01210"}
+		comparisons, err := Add(store, &f, thresholds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(comparisons) != 1 || comparisons[0].Status != StatusMatch {
+			t.Errorf("expected a single match with the other fingerprint of S1, got %+v", comparisons)
+		}
+		identity, err := CheckSample(store, "S1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if identity.Flagged || len(identity.Comparisons) != 1 {
+			t.Errorf("expected the identity check not to be flagged, got %+v", identity.Comparisons)
+		}
+	})
+}
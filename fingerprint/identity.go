@@ -0,0 +1,238 @@
+package fingerprint
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Thresholds decide when two fingerprints are considered to come from the same
+// individual.
+type Thresholds struct {
+	// MinSites is the minimum number of sites with a genotype in both fingerprints
+	// for a comparison to be conclusive.
+	MinSites int `mapstructure:"min_sites"`
+	// MinConcordance is the minimum fraction of matching genotypes for two
+	// fingerprints to be considered to come from the same individual.
+	MinConcordance float64 `mapstructure:"min_concordance"`
+}
+
+// DefaultThresholds returns the thresholds used when nothing else has been configured.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MinSites:       20,
+		MinConcordance: 0.9,
+	}
+}
+
+// ThresholdsFromConfig returns the thresholds defined under `fingerprint` in the
+// configuration. Thresholds that are not configured get their default values.
+func ThresholdsFromConfig() (Thresholds, error) {
+	t := DefaultThresholds()
+	if !viper.IsSet("fingerprint") {
+		return t, nil
+	}
+	if err := viper.UnmarshalKey("fingerprint", &t); err != nil {
+		return t, fmt.Errorf("invalid fingerprint thresholds: %w", err)
+	}
+	return t, nil
+}
+
+// Status is the outcome of comparing two fingerprints.
+type Status string
+
+const (
+	// StatusMatch means that fingerprints of the same sample are concordant.
+	StatusMatch Status = "match"
+	// StatusDiscordant means that fingerprints of the same sample are not concordant.
+	StatusDiscordant Status = "discordant"
+	// StatusUnexpectedMatch means that fingerprints of different samples are concordant.
+	StatusUnexpectedMatch Status = "unexpected_match"
+	// StatusDistinct means that fingerprints of different samples are not concordant.
+	StatusDistinct Status = "distinct"
+	// StatusInconclusive means that too few sites have genotypes in both fingerprints.
+	StatusInconclusive Status = "inconclusive"
+)
+
+// Flagged returns true if the status indicates a possible sample mix-up.
+func (s Status) Flagged() bool {
+	return s == StatusDiscordant || s == StatusUnexpectedMatch
+}
+
+// Comparison is the result of comparing two fingerprints.
+type Comparison struct {
+	SampleId        string    `bson:"sample_id" json:"sample_id"`
+	AnalysisId      uuid.UUID `bson:"analysis_id" json:"analysis_id"`
+	OtherSampleId   string    `bson:"other_sample_id" json:"other_sample_id"`
+	OtherAnalysisId uuid.UUID `bson:"other_analysis_id" json:"other_analysis_id"`
+	Panel           string    `bson:"panel" json:"panel"`
+	// Sites is the number of sites with a genotype in both fingerprints.
+	Sites int `bson:"sites" json:"sites"`
+	// Matches is the number of sites where the genotypes are identical.
+	Matches     int     `bson:"matches" json:"matches"`
+	Concordance float64 `bson:"concordance" json:"concordance"`
+	Status      Status  `bson:"status" json:"status"`
+}
+
+// From returns the comparison as seen from a sample, i.e. with the fingerprint of the
+// sample first if only one of the compared fingerprints is from the sample.
+func (c Comparison) From(sampleId string) Comparison {
+	if c.SampleId == sampleId || c.OtherSampleId != sampleId {
+		return c
+	}
+	c.SampleId, c.OtherSampleId = c.OtherSampleId, c.SampleId
+	c.AnalysisId, c.OtherAnalysisId = c.OtherAnalysisId, c.AnalysisId
+	return c
+}
+
+// Involves returns true if one of the compared fingerprints is from the analysis.
+func (c Comparison) Involves(analysisId uuid.UUID) bool {
+	return c.AnalysisId == analysisId || c.OtherAnalysisId == analysisId
+}
+
+func (c Comparison) String() string {
+	return fmt.Sprintf(
+		"%s (analysis %s) vs %s (analysis %s): %s, concordance %.2f over %d sites",
+		c.SampleId, c.AnalysisId, c.OtherSampleId, c.OtherAnalysisId, c.Status, c.Concordance, c.Sites,
+	)
+}
+
+// Compare compares two fingerprints for the same SNP panel.
+func Compare(a, b cleve.Fingerprint, t Thresholds) Comparison {
+	c := Comparison{
+		SampleId:        a.SampleId,
+		AnalysisId:      a.AnalysisId,
+		OtherSampleId:   b.SampleId,
+		OtherAnalysisId: b.AnalysisId,
+		Panel:           a.Panel,
+	}
+	if a.Panel == b.Panel && len(a.Genotypes) == len(b.Genotypes) {
+		for i := range len(a.Genotypes) {
+			if a.Genotypes[i] == cleve.GenotypeMissing || b.Genotypes[i] == cleve.GenotypeMissing {
+				continue
+			}
+			c.Sites++
+			if a.Genotypes[i] == b.Genotypes[i] {
+				c.Matches++
+			}
+		}
+	}
+	if c.Sites > 0 {
+		c.Concordance = float64(c.Matches) / float64(c.Sites)
+	}
+	concordant := c.Concordance >= t.MinConcordance
+	switch {
+	case c.Sites < t.MinSites || c.Sites == 0:
+		c.Status = StatusInconclusive
+	case a.SampleId == b.SampleId && concordant:
+		c.Status = StatusMatch
+	case a.SampleId == b.SampleId:
+		c.Status = StatusDiscordant
+	case concordant:
+		c.Status = StatusUnexpectedMatch
+	default:
+		c.Status = StatusDistinct
+	}
+	return c
+}
+
+// Identity is the result of an identity check of a sample. The comparisons include
+// all pairs of fingerprints of the sample, and any fingerprints of other samples that
+// unexpectedly match the sample.
+type Identity struct {
+	SampleId     string              `json:"sample_id"`
+	Fingerprints []cleve.Fingerprint `json:"fingerprints"`
+	Comparisons  []Comparison        `json:"comparisons"`
+	// Flagged is true if any comparison indicates a possible sample mix-up.
+	Flagged bool `json:"flagged"`
+}
+
+// FlaggedComparisons returns the comparisons that indicate a possible sample mix-up.
+func (i Identity) FlaggedComparisons() []Comparison {
+	var flagged []Comparison
+	for _, c := range i.Comparisons {
+		if c.Status.Flagged() {
+			flagged = append(flagged, c)
+		}
+	}
+	return flagged
+}
+
+// Comparisons compares a fingerprint to other fingerprints for the same SNP panel.
+// Comparisons to fingerprints of the same sample are always included, but comparisons
+// to fingerprints of other samples only if they unexpectedly match.
+func Comparisons(f cleve.Fingerprint, others []cleve.Fingerprint, t Thresholds) []Comparison {
+	comparisons := []Comparison{}
+	for _, o := range others {
+		if o.Panel != f.Panel || (o.SampleId == f.SampleId && o.AnalysisId == f.AnalysisId) {
+			continue
+		}
+		c := Compare(f, o, t)
+		if o.SampleId == f.SampleId || c.Status == StatusUnexpectedMatch {
+			comparisons = append(comparisons, c)
+		}
+	}
+	return comparisons
+}
+
+// Store is where fingerprints and their comparisons are read from.
+type Store interface {
+	Fingerprints(cleve.FingerprintFilter) ([]cleve.Fingerprint, error)
+	FingerprintComparisons(sampleId string) ([]Comparison, error)
+}
+
+// Setter is where fingerprints and their comparisons are stored.
+type Setter interface {
+	Store
+	SetFingerprint(*cleve.Fingerprint) error
+	// SetFingerprintComparisons replaces the comparisons that involve the fingerprint.
+	SetFingerprintComparisons(*cleve.Fingerprint, []Comparison) error
+}
+
+// Add stores a fingerprint and compares it to the other fingerprints for the same SNP
+// panel, see [Comparisons]. The comparisons are stored as well, so that identity
+// checks do not have to compare the fingerprints again, and they are returned.
+// Comparisons are not updated if the thresholds change.
+func Add(store Setter, f *cleve.Fingerprint, t Thresholds) ([]Comparison, error) {
+	if err := store.SetFingerprint(f); err != nil {
+		return nil, err
+	}
+	others, err := store.Fingerprints(cleve.FingerprintFilter{Panel: f.Panel})
+	if err != nil {
+		return nil, err
+	}
+	comparisons := Comparisons(*f, others, t)
+	if err := store.SetFingerprintComparisons(f, comparisons); err != nil {
+		return nil, err
+	}
+	return comparisons, nil
+}
+
+// CheckSample checks the identity of a sample using its fingerprints and the
+// comparisons that were stored when the fingerprints were added, see [Add].
+func CheckSample(store Store, sampleId string) (Identity, error) {
+	identity := Identity{
+		SampleId:     sampleId,
+		Fingerprints: []cleve.Fingerprint{},
+		Comparisons:  []Comparison{},
+	}
+	fingerprints, err := store.Fingerprints(cleve.FingerprintFilter{SampleId: sampleId})
+	if err != nil {
+		return identity, err
+	}
+	identity.Fingerprints = append(identity.Fingerprints, fingerprints...)
+	comparisons, err := store.FingerprintComparisons(sampleId)
+	if err != nil {
+		return identity, err
+	}
+	for _, c := range comparisons {
+		identity.Comparisons = append(identity.Comparisons, c.From(sampleId))
+	}
+	identity.Flagged = slices.ContainsFunc(identity.Comparisons, func(c Comparison) bool {
+		return c.Status.Flagged()
+	})
+	return identity, nil
+}
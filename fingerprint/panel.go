@@ -0,0 +1,156 @@
+// Package fingerprint extracts genotype fingerprints from VCF files and compares them
+// in order to check that data labelled with the same sample ID come from the same
+// individual, and that data from different samples do not.
+package fingerprint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Site is a single nucleotide polymorphism in a SNP panel.
+type Site struct {
+	Chrom string
+	Pos   int
+	Ref   string
+	Alt   string
+	Id    string
+}
+
+// Panel is a set of SNP sites that fingerprints are extracted at. The order of the
+// sites decides the order of the genotypes in a fingerprint, so a panel must not be
+// changed without also changing its name.
+type Panel struct {
+	Name  string
+	Sites []Site
+
+	// Indices of the sites for each chromosome, sorted by position.
+	chroms map[string][]int
+}
+
+// normaliseChrom strips any chr prefix so that panels and VCF files using different
+// naming conventions can be matched.
+func normaliseChrom(chrom string) string {
+	return strings.TrimPrefix(chrom, "chr")
+}
+
+// ReadPanel reads a SNP panel from a tab-separated file with the columns chrom, pos,
+// ref, alt and an optional id. Lines starting with # are ignored, except for metadata
+// lines on the form ##key=value. The only supported metadata key is name.
+func ReadPanel(r io.Reader) (*Panel, error) {
+	p := &Panel{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "##") {
+			key, value, ok := strings.Cut(line[2:], "=")
+			if ok && strings.TrimSpace(key) == "name" {
+				p.Name = strings.TrimSpace(value)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: expected at least 4 columns, got %d", lineNo, len(fields))
+		}
+		pos, err := strconv.Atoi(fields[1])
+		if err != nil || pos < 1 {
+			return nil, fmt.Errorf("line %d: invalid position %q", lineNo, fields[1])
+		}
+		site := Site{
+			Chrom: fields[0],
+			Pos:   pos,
+			Ref:   strings.ToUpper(fields[2]),
+			Alt:   strings.ToUpper(fields[3]),
+		}
+		if len(site.Ref) != 1 || len(site.Alt) != 1 {
+			return nil, fmt.Errorf("line %d: only single nucleotide variants are supported", lineNo)
+		}
+		if len(fields) > 4 {
+			site.Id = fields[4]
+		}
+		p.Sites = append(p.Sites, site)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p.Sites) == 0 {
+		return nil, fmt.Errorf("snp panel has no sites")
+	}
+	p.index()
+	return p, nil
+}
+
+// ReadPanelFile reads a SNP panel from a file. If the file does not define a name,
+// the file name without extension is used.
+func ReadPanelFile(path string) (*Panel, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	p, err := ReadPanel(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snp panel %s: %w", path, err)
+	}
+	if p.Name == "" {
+		base := filepath.Base(path)
+		p.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return p, nil
+}
+
+// PanelFromConfig reads the SNP panel defined by `fingerprint.snp_panel` in the
+// configuration. If no panel is configured, nil is returned.
+func PanelFromConfig() (*Panel, error) {
+	path := viper.GetString("fingerprint.snp_panel")
+	if path == "" {
+		return nil, nil
+	}
+	return ReadPanelFile(path)
+}
+
+func (p *Panel) index() {
+	p.chroms = make(map[string][]int)
+	for i, s := range p.Sites {
+		chrom := normaliseChrom(s.Chrom)
+		p.chroms[chrom] = append(p.chroms[chrom], i)
+	}
+	for _, indices := range p.chroms {
+		sort.SliceStable(indices, func(a, b int) bool {
+			return p.Sites[indices[a]].Pos < p.Sites[indices[b]].Pos
+		})
+	}
+}
+
+// sitesIn returns the indices of the sites on chrom with a position in [start, end].
+func (p *Panel) sitesIn(chrom string, start, end int) []int {
+	if p.chroms == nil {
+		p.index()
+	}
+	indices := p.chroms[normaliseChrom(chrom)]
+	i := sort.Search(len(indices), func(i int) bool {
+		return p.Sites[indices[i]].Pos >= start
+	})
+	j := i
+	for j < len(indices) && p.Sites[indices[j]].Pos <= end {
+		j++
+	}
+	return indices[i:j]
+}
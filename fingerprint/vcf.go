@@ -0,0 +1,198 @@
+package fingerprint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+)
+
+const maxVcfLineLength = 1024 * 1024
+
+// Genotypes reads a VCF file and returns the genotypes of a sample at the sites of the
+// panel, encoded as described for [cleve.Fingerprint]. If the VCF has a single sample
+// it is used regardless of its name, otherwise the sample column must match sampleId.
+//
+// Reference blocks in gVCF files, i.e. records with an END in the INFO column and only
+// symbolic alternative alleles, give homozygous reference genotypes for the sites they
+// cover. Sites that are not in the VCF, and records that did not pass filtering, are
+// considered missing.
+func Genotypes(r io.Reader, panel *Panel, sampleId string) (string, error) {
+	genotypes := []byte(strings.Repeat(string(cleve.GenotypeMissing), len(panel.Sites)))
+	sampleCol := -1
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxVcfLineLength)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.HasPrefix(line, "##") || line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#CHROM") {
+			col, err := cleve.VcfSampleColumn(line, sampleId)
+			if err != nil {
+				return "", err
+			}
+			sampleCol = col
+			continue
+		}
+		if sampleCol < 0 {
+			return "", fmt.Errorf("line %d: missing vcf header", lineNo)
+		}
+		fields := strings.SplitN(line, "\t", sampleCol+2)
+		if len(fields) <= sampleCol {
+			return "", fmt.Errorf("line %d: expected at least %d columns, got %d", lineNo, sampleCol+1, len(fields))
+		}
+		if filter := fields[6]; filter != "PASS" && filter != "." {
+			continue
+		}
+		pos, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return "", fmt.Errorf("line %d: invalid position %q", lineNo, fields[1])
+		}
+		end := pos
+		alts := strings.Split(fields[4], ",")
+		refBlock := isRefBlock(alts)
+		if refBlock {
+			end = infoEnd(fields[7], pos)
+		}
+		sites := panel.sitesIn(fields[0], pos, end)
+		if len(sites) == 0 {
+			continue
+		}
+		gt := cleve.VcfGenotype(fields[8], fields[sampleCol])
+		for _, i := range sites {
+			site := panel.Sites[i]
+			g := byte(cleve.GenotypeMissing)
+			if refBlock {
+				if isHomRef(gt) {
+					g = cleve.GenotypeHomRef
+				}
+			} else if strings.EqualFold(fields[3], site.Ref) {
+				g = encodeGenotype(gt, altIndex(alts, site.Alt))
+			}
+			if g != cleve.GenotypeMissing {
+				genotypes[i] = g
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if sampleCol < 0 {
+		return "", fmt.Errorf("missing vcf header")
+	}
+	return string(genotypes), nil
+}
+
+// ReadGenotypes reads the genotypes of a sample from a VCF file, which may be gzip or
+// bgzip compressed.
+func ReadGenotypes(path string, panel *Panel, sampleId string) (string, error) {
+	r, err := cleve.OpenVcf(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = r.Close() }()
+	genotypes, err := Genotypes(r, panel, sampleId)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return genotypes, nil
+}
+
+// New creates the fingerprint of a sample from a sample level VCF file in the output
+// of an analysis.
+func New(analysis *cleve.Analysis, file cleve.AnalysisFile, panel *Panel) (*cleve.Fingerprint, error) {
+	if file.FileType != cleve.FileSnvVcf || file.Level != cleve.LevelSample {
+		return nil, fmt.Errorf("fingerprints can only be created from sample level snv vcf files")
+	}
+	genotypes, err := ReadGenotypes(file.Path, panel, file.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	return &cleve.Fingerprint{
+		SampleId:   file.ParentId,
+		AnalysisId: analysis.AnalysisId,
+		Runs:       analysis.Runs,
+		Panel:      panel.Name,
+		Genotypes:  genotypes,
+		Created:    time.Now(),
+	}, nil
+}
+
+func isRefBlock(alts []string) bool {
+	for _, a := range alts {
+		if a != "<NON_REF>" && a != "<*>" && a != "." {
+			return false
+		}
+	}
+	return true
+}
+
+// infoEnd returns the END of a record as given in the INFO column, or pos if it is
+// not defined.
+func infoEnd(info string, pos int) int {
+	for _, kv := range strings.Split(info, ";") {
+		if v, ok := strings.CutPrefix(kv, "END="); ok {
+			if end, err := strconv.Atoi(v); err == nil && end >= pos {
+				return end
+			}
+		}
+	}
+	return pos
+}
+
+// altIndex returns the allele index of alt, or -1 if it is not among the alleles.
+func altIndex(alts []string, alt string) int {
+	for i, a := range alts {
+		if strings.EqualFold(a, alt) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func isHomRef(alleles []string) bool {
+	if len(alleles) == 0 {
+		return false
+	}
+	for _, a := range alleles {
+		if a != "0" {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeGenotype encodes the alleles of a genotype given the allele index of the
+// alternative allele of the site. Genotypes with missing alleles or with other
+// alternative alleles are considered missing.
+func encodeGenotype(alleles []string, alt int) byte {
+	if len(alleles) == 0 {
+		return cleve.GenotypeMissing
+	}
+	altCount := 0
+	for _, a := range alleles {
+		switch {
+		case a == "0":
+		case alt > 0 && a == strconv.Itoa(alt):
+			altCount++
+		default:
+			return cleve.GenotypeMissing
+		}
+	}
+	switch altCount {
+	case 0:
+		return cleve.GenotypeHomRef
+	case len(alleles):
+		return cleve.GenotypeHomAlt
+	default:
+		return cleve.GenotypeHet
+	}
+}
@@ -23,6 +23,7 @@ type AnalysisSetter interface {
 	SetAnalysisState(analysisId uuid.UUID, state cleve.State) error
 	SetAnalysisPath(analysisId uuid.UUID, path string) error
 	SetAnalysisFiles(analysisId uuid.UUID, files []cleve.AnalysisFile) error
+	UpdateMultiQCMetrics([]cleve.MultiQCSampleMetrics) error
 }
 
 // Interface for both getting and storing/updating analyses.
@@ -252,6 +253,14 @@ func AddAnalysisHandler(db AnalysisGetterSetter) gin.HandlerFunc {
 			})
 			return
 		}
+		multiqc, err := a.MultiQCMetrics()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid multiqc data",
+				"details": err.Error(),
+			})
+			return
+		}
 
 		if err := db.CreateAnalysis(&a); err != nil {
 			c.AbortWithStatusJSON(
@@ -261,6 +270,16 @@ func AddAnalysisHandler(db AnalysisGetterSetter) gin.HandlerFunc {
 			return
 		}
 
+		if len(multiqc) > 0 {
+			if err := db.UpdateMultiQCMetrics(multiqc); err != nil {
+				c.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": err.Error(), "when": "storing multiqc metrics"},
+				)
+				return
+			}
+		}
+
 		c.Set("webhook_message", cleve.WebhookMessageRequest{
 			Entity:      &a,
 			Message:     "new analysis added",
@@ -373,7 +392,17 @@ func UpdateAnalysisHandler(db AnalysisGetterSetter) gin.HandlerFunc {
 				})
 				return
 			}
-			err := db.SetAnalysisFiles(analysisId, updateRequest.Files)
+			updatedAnalysis := *a
+			updatedAnalysis.OutputFiles = updateRequest.Files
+			multiqc, err := updatedAnalysis.MultiQCMetrics()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":   "invalid multiqc data",
+					"details": err.Error(),
+				})
+				return
+			}
+			err = db.SetAnalysisFiles(analysisId, updateRequest.Files)
 			if err != nil {
 				if errors.Is(err, mongo.ErrNoDocuments) {
 					c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
@@ -388,6 +417,15 @@ func UpdateAnalysisHandler(db AnalysisGetterSetter) gin.HandlerFunc {
 				})
 				return
 			}
+			if len(multiqc) > 0 {
+				if err := db.UpdateMultiQCMetrics(multiqc); err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+						"error":   "failed to store multiqc metrics",
+						"details": err.Error(),
+					})
+					return
+				}
+			}
 			filesUpdated = true
 		}
 
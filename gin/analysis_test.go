@@ -21,11 +21,12 @@ func TestAddAnalysis(t *testing.T) {
 	gin.SetMode("test")
 	tmpdir := t.TempDir()
 	testcases := []struct {
-		name   string
-		exists bool
-		data   []byte
-		code   int
-		error  bool
+		name          string
+		exists        bool
+		data          []byte
+		code          int
+		error         bool
+		multiqcStored bool
 	}{
 		{
 			name:   "analysis without files",
@@ -41,6 +42,21 @@ func TestAddAnalysis(t *testing.T) {
 			code:   http.StatusOK,
 			error:  false,
 		},
+		{
+			name:          "analysis with multiqc data",
+			exists:        false,
+			data:          fmt.Appendf([]byte{}, `{"path": "%s/analysis1", "run_id": "run1", "state": "ready", "software": "software1", "software_version": "1.0.0", "output_files": [{"path": "multiqc/multiqc_data.json", "type": "multiqc", "level": "run", "parent_id": "run1"}]}`, tmpdir),
+			code:          http.StatusOK,
+			error:         false,
+			multiqcStored: true,
+		},
+		{
+			name:   "analysis with invalid multiqc data",
+			exists: false,
+			data:   fmt.Appendf([]byte{}, `{"path": "%s/analysis1", "run_id": "run1", "state": "ready", "software": "software1", "software_version": "1.0.0", "output_files": [{"path": "fastq/sample1_1.fastq.gz", "type": "multiqc", "level": "run", "parent_id": "run1"}]}`, tmpdir),
+			code:   http.StatusBadRequest,
+			error:  false,
+		},
 	}
 
 	// Create mock files
@@ -59,6 +75,13 @@ func TestAddAnalysis(t *testing.T) {
 		t.Fatal(err)
 	}
 	_ = f2.Close()
+	mqc := filepath.Join(tmpdir, "analysis1/multiqc/multiqc_data.json")
+	if err := os.MkdirAll(filepath.Dir(mqc), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mqc, []byte(`{"report_general_stats_data": [{"sample1": {"percent_duplicates": 12.5}}], "report_general_stats_headers": [{"percent_duplicates": {"namespace": "FastQC"}}]}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
 
 	for _, c := range testcases {
 		t.Run(c.name, func(t *testing.T) {
@@ -81,6 +104,12 @@ func TestAddAnalysis(t *testing.T) {
 				}
 				return cleve.AnalysisResult{PaginationMetadata: cleve.PaginationMetadata{Page: 1, Count: 0}}, nil
 			}
+			gs.UpdateMultiQCMetricsFn = func(metrics []cleve.MultiQCSampleMetrics) error {
+				if len(metrics) != 1 || metrics[0].SampleId != "sample1" {
+					t.Errorf("unexpected multiqc metrics: %+v", metrics)
+				}
+				return nil
+			}
 			w := httptest.NewRecorder()
 			ctx, _ := gin.CreateTestContext(w)
 
@@ -92,6 +121,10 @@ func TestAddAnalysis(t *testing.T) {
 			if w.Code != c.code {
 				t.Errorf("expected status code %d, got %d: %s", c.code, w.Code, w.Body)
 			}
+
+			if c.multiqcStored != gs.UpdateMultiQCMetricsInvoked {
+				t.Errorf("multiqc stored: %t, expected it: %t", gs.UpdateMultiQCMetricsInvoked, c.multiqcStored)
+			}
 		})
 	}
 }
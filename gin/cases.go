@@ -0,0 +1,172 @@
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+// Interface for reading cases from the database.
+type CaseGetter interface {
+	Case(string) (*cleve.Case, error)
+	Cases(cleve.CaseFilter) (*cleve.CaseResult, error)
+}
+
+// Interface for storing/updating cases in the database.
+type CaseSetter interface {
+	CreateCase(*cleve.Case) error
+	UpdateCase(*cleve.Case) error
+	DeleteCase(string) error
+}
+
+// Interface for reading the analysis files of a case from the database.
+type CaseFileGetter interface {
+	Case(string) (*cleve.Case, error)
+	AnalysesFiles(cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error)
+}
+
+func CasesHandler(db CaseGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := getCaseFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cases, err := db.Cases(filter)
+		if errors.As(err, &mongo.PageOutOfBoundsError{}) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if cases.Cases == nil {
+			cases.Cases = []cleve.Case{}
+		}
+		c.JSON(http.StatusOK, cases)
+	}
+}
+
+func CaseHandler(db CaseGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseId := c.Param("caseId")
+		cs, err := db.Case(caseId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("case %s not found", caseId)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, cs)
+	}
+}
+
+func AddCaseHandler(db CaseSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cs cleve.Case
+		if err := c.ShouldBindJSON(&cs); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cs.SetDefaults()
+		if err := cs.Validate(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.CreateCase(&cs); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "a case with this id already exists", "case_id": cs.Id})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "case added", "case_id": cs.Id})
+	}
+}
+
+// UpdateCaseHandler replaces the samples and the description of a case. The case ID
+// is taken from the URL.
+func UpdateCaseHandler(db CaseSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseId := c.Param("caseId")
+		var updateRequest struct {
+			Description string             `json:"description"`
+			Samples     []cleve.CaseSample `json:"samples" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&updateRequest); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cs := cleve.Case{
+			Id:          caseId,
+			Description: updateRequest.Description,
+			Samples:     updateRequest.Samples,
+		}
+		cs.SetDefaults()
+		if err := cs.Validate(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		err := db.UpdateCase(&cs)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("case %s not found", caseId)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "case updated", "case_id": caseId})
+	}
+}
+
+func DeleteCaseHandler(db CaseSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseId := c.Param("caseId")
+		err := db.DeleteCase(caseId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("case %s not found", caseId)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "case deleted", "case_id": caseId})
+	}
+}
+
+// CaseFilesHandler lists the case level analysis files of a case. The files can be
+// filtered further in the same way as for [AnalysesFileHandler].
+func CaseFilesHandler(db CaseFileGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseId := c.Param("caseId")
+		filter, err := getAnalysisFileFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := db.Case(caseId); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("case %s not found", caseId)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		files, err := db.AnalysesFiles(filter)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, files)
+	}
+}
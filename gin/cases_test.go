@@ -0,0 +1,269 @@
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+func TestCasesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name           string
+		url            string
+		code           int
+		expectedFilter cleve.CaseFilter
+	}{
+		{
+			name:           "no filter",
+			url:            "/api/cases",
+			code:           http.StatusOK,
+			expectedFilter: cleve.NewCaseFilter(),
+		},
+		{
+			name: "sample and role",
+			url:  "/api/cases?sample_id=S1&role=tumour",
+			code: http.StatusOK,
+			expectedFilter: cleve.CaseFilter{
+				SampleId:         "S1",
+				Role:             cleve.RoleTumour,
+				PaginationFilter: cleve.NewPaginationFilter(),
+			},
+		},
+		{
+			name: "invalid role",
+			url:  "/api/cases?role=sibling",
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.CaseGetter{}
+			db.CasesFn = func(filter cleve.CaseFilter) (*cleve.CaseResult, error) {
+				if filter != c.expectedFilter {
+					t.Errorf("expected filter %+v, got %+v", c.expectedFilter, filter)
+				}
+				return &cleve.CaseResult{}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, c.url, nil)
+
+			CasesHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code == http.StatusOK && !bytes.Contains(w.Body.Bytes(), []byte(`"cases":[]`)) {
+				t.Errorf("expected an empty list of cases, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAddCase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		body    string
+		err     error
+		invoked bool
+		code    int
+	}{
+		{
+			name:    "trio",
+			body:    `{"id": "case1", "samples": [{"sample_id": "S1", "role": "proband", "sex": "female", "phenotype": "affected"}, {"sample_id": "S2", "role": "mother"}, {"sample_id": "S3", "role": "father"}]}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name: "missing samples",
+			body: `{"id": "case1"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid role",
+			body: `{"id": "case1", "samples": [{"sample_id": "S1", "role": "sibling"}]}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "existing case",
+			body:    `{"id": "case1", "samples": [{"sample_id": "S1", "role": "proband"}]}`,
+			err:     mongo.GenericDuplicateKeyError,
+			invoked: true,
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "database error",
+			body:    `{"id": "case1", "samples": [{"sample_id": "S1", "role": "proband"}]}`,
+			err:     errors.New("database error"),
+			invoked: true,
+			code:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.CaseSetter{}
+			db.CreateCaseFn = func(cs *cleve.Case) error {
+				for _, s := range cs.Samples {
+					if s.Sex == "" || s.Phenotype == "" {
+						t.Errorf("expected sex and phenotype to be set for sample %s", s.SampleId)
+					}
+				}
+				return c.err
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/cases", bytes.NewBufferString(c.body))
+
+			AddCaseHandler(&db)(ctx)
+
+			if db.CreateCaseInvoked != c.invoked {
+				t.Errorf("expected CreateCase invoked to be %t", c.invoked)
+			}
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateCase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		body    string
+		err     error
+		invoked bool
+		code    int
+	}{
+		{
+			name:    "update samples",
+			body:    `{"description": "tumour/normal", "samples": [{"sample_id": "S1", "role": "tumour"}, {"sample_id": "S2", "role": "normal"}]}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name: "invalid samples",
+			body: `{"samples": [{"sample_id": "S1", "role": "normal"}, {"sample_id": "S2", "role": "normal"}]}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "missing case",
+			body:    `{"samples": [{"sample_id": "S1", "role": "tumour"}]}`,
+			err:     mongo.ErrNoDocuments,
+			invoked: true,
+			code:    http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.CaseSetter{}
+			db.UpdateCaseFn = func(cs *cleve.Case) error {
+				if cs.Id != "case1" {
+					t.Errorf("expected case id case1, got %s", cs.Id)
+				}
+				return c.err
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "caseId", Value: "case1"}}
+			ctx.Request = httptest.NewRequest(http.MethodPut, "/api/cases/case1", bytes.NewBufferString(c.body))
+
+			UpdateCaseHandler(&db)(ctx)
+
+			if db.UpdateCaseInvoked != c.invoked {
+				t.Errorf("expected UpdateCase invoked to be %t", c.invoked)
+			}
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCaseFilesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name           string
+		url            string
+		caseErr        error
+		code           int
+		expectedFilter cleve.AnalysisFileFilter
+	}{
+		{
+			name: "all files",
+			url:  "/api/cases/case1/files",
+			code: http.StatusOK,
+			expectedFilter: cleve.AnalysisFileFilter{
+				Level:    cleve.LevelCase,
+				ParentId: "case1",
+			},
+		},
+		{
+			name: "files of a type",
+			url:  "/api/cases/case1/files?type=vcf_snv",
+			code: http.StatusOK,
+			expectedFilter: cleve.AnalysisFileFilter{
+				FileType: cleve.FileSnvVcf,
+				Level:    cleve.LevelCase,
+				ParentId: "case1",
+			},
+		},
+		{
+			name:    "missing case",
+			url:     "/api/cases/case1/files",
+			caseErr: mongo.ErrNoDocuments,
+			code:    http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.CaseFileGetter{}
+			db.CaseFn = func(caseId string) (*cleve.Case, error) {
+				if c.caseErr != nil {
+					return nil, c.caseErr
+				}
+				return &cleve.Case{Id: caseId}, nil
+			}
+			db.AnalysesFilesFn = func(filter cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error) {
+				if filter.FileType != c.expectedFilter.FileType || filter.Level != c.expectedFilter.Level || filter.ParentId != c.expectedFilter.ParentId {
+					t.Errorf("expected filter %+v, got %+v", c.expectedFilter, filter)
+				}
+				return []cleve.AnalysisFile{}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "caseId", Value: "case1"}}
+			ctx.Request = httptest.NewRequest(http.MethodGet, c.url, nil)
+
+			CaseFilesHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if db.AnalysesFilesInvoked != (c.code == http.StatusOK) {
+				t.Errorf("expected AnalysesFiles invoked to be %t", c.code == http.StatusOK)
+			}
+		})
+	}
+}
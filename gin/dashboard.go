@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gmc-norr/cleve"
 	"github.com/gmc-norr/cleve/mongo"
+	"github.com/gmc-norr/cleve/report"
 )
 
 type UserMessage struct {
@@ -91,6 +92,37 @@ func DashboardPanelListHandler(db *mongo.DB) gin.HandlerFunc {
 	}
 }
 
+// panelSample is a sample together with the version of a panel that it was analysed
+// with, and the status of that version.
+type panelSample struct {
+	cleve.Sample
+	Version string
+	Status  cleve.PanelRefStatus
+}
+
+// panelSampleStatus lists the panel versions that samples were analysed with, and
+// counts the samples analysed with a version that is superseded or archived.
+func panelSampleStatus(samples []cleve.Sample, panelId string, latest cleve.GenePanel) ([]panelSample, int) {
+	var (
+		panelSamples []panelSample
+		outdated     int
+	)
+	for _, s := range samples {
+		isOutdated := false
+		for _, ref := range s.PanelRefs(panelId) {
+			status := ref.Status(latest)
+			if status != cleve.PanelCurrent {
+				isOutdated = true
+			}
+			panelSamples = append(panelSamples, panelSample{Sample: s, Version: ref.Version, Status: status})
+		}
+		if isOutdated {
+			outdated++
+		}
+	}
+	return panelSamples, outdated
+}
+
 func DashboardPanelHandler(db *mongo.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		panelId := c.Param("panelId")
@@ -147,6 +179,17 @@ func DashboardPanelHandler(db *mongo.DB) gin.HandlerFunc {
 				return
 			}
 			d["panel"] = panel
+
+			samples, err := db.Samples(&cleve.SampleFilter{Panel: panelId, PaginationFilter: cleve.PaginationFilter{Page: 1}})
+			if err != nil {
+				c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err})
+				c.Abort()
+				return
+			}
+			latest := cleve.GenePanel{GenePanelVersion: versions[0], Archived: panel.Archived}
+			panelSamples, outdated := panelSampleStatus(samples.Samples, panelId, latest)
+			d["panelSamples"] = panelSamples
+			d["outdatedSamples"] = outdated
 		}
 		c.Header("HX-Push-Url", "/panels/"+panelId+"?version="+filter.Version)
 		if c.GetHeader("HX-Request") == "true" {
@@ -191,6 +234,12 @@ func DashboardRunHandler(db *mongo.DB) gin.HandlerFunc {
 			)
 			message = &m
 			hasQc = false
+		} else if hasQc && qc.IsStale() {
+			m := NewUserMessage(
+				"The QC data for this run was computed with an older version of cleve and may be outdated.",
+				"warning",
+			)
+			message = &m
 		}
 
 		sampleSheet, err := db.SampleSheet(mongo.SampleSheetWithRunId(runId))
@@ -202,10 +251,101 @@ func DashboardRunHandler(db *mongo.DB) gin.HandlerFunc {
 			}
 		}
 
-		c.HTML(http.StatusOK, "run", gin.H{"run": run, "qc": qc, "hasQc": hasQc, "samplesheet": sampleSheet, "chart_config": GetRunChartConfig(c), "cleve_version": cleve.GetVersion(), "message": message})
+		sampleQc, err := db.RunSampleQC(runId)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+		}
+		sampleQcRows, sexMismatches, err := sampleQcWithSexChecks(db, sampleQc)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		var indexes []cleve.RunIndex
+		wrongI5Orientation := 0
+		if hasQc {
+			kits, err := db.IndexKits()
+			if err != nil {
+				c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+			indexes = cleve.AnnotateIndexes(qc.IndexSummary.Indexes, run.Platform, kits)
+			for _, i := range indexes {
+				if i.WrongI5Orientation {
+					wrongI5Orientation++
+				}
+			}
+		}
+
+		c.HTML(http.StatusOK, "run", gin.H{"run": run, "qc": qc, "hasQc": hasQc, "indexes": indexes, "wrongI5Orientation": wrongI5Orientation, "samplesheet": sampleSheet, "sampleApplications": sampleSheet.ApplicationsBySample(), "sampleQc": sampleQcRows, "sexMismatches": sexMismatches, "chart_config": GetRunChartConfig(c), "cleve_version": cleve.GetVersion(), "message": message})
+	}
+}
+
+func DashboardSampleHandler(db *mongo.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sampleId := c.Param("sampleId")
+		sample, err := db.Sample(sampleId)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.HTML(http.StatusNotFound, "error404", gin.H{"error": fmt.Sprintf("sample %q not found", sampleId)})
+				c.Abort()
+				return
+			}
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		sampleQc, err := db.SampleQC(sampleId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		sampleQcRows, sexMismatches, err := sampleQcWithSexChecks(db, sampleQc)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.HTML(http.StatusOK, "sample", gin.H{"sample": sample, "declaredSex": sample.DeclaredSex(), "sampleQc": sampleQcRows, "sexMismatches": sexMismatches, "cleve_version": cleve.GetVersion()})
 	}
 }
 
+// sampleQcRow is the QC of a sample together with the check of its declared sex.
+type sampleQcRow struct {
+	cleve.DragenSampleMetrics
+	SexCheck cleve.SexCheck
+}
+
+// sampleQcWithSexChecks pairs sample QC metrics with sex checks, and returns the
+// number of sex mismatches.
+func sampleQcWithSexChecks(db *mongo.DB, metrics []cleve.DragenSampleMetrics) ([]sampleQcRow, int, error) {
+	if len(metrics) == 0 {
+		return nil, 0, nil
+	}
+	checks, err := db.SexChecks(metrics)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := make([]sampleQcRow, len(metrics))
+	mismatches := 0
+	for i, m := range metrics {
+		rows[i] = sampleQcRow{DragenSampleMetrics: m, SexCheck: checks[i]}
+		if checks[i].Status == cleve.SexCheckMismatch {
+			mismatches++
+		}
+	}
+	return rows, mismatches, nil
+}
+
 func DashboardRunTable(db *mongo.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filter, err := getRunFilter(c)
@@ -257,7 +397,130 @@ func DashboardQCHandler(db *mongo.DB) gin.HandlerFunc {
 		}
 		platformNames := platforms.Names()
 
+		multiqcMetrics, err := db.MultiQCMetricHeaders()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
 		c.Header("Hx-Push-Url", filter.UrlParams())
-		c.HTML(http.StatusOK, "qc", gin.H{"qc": qc.InteropSummary, "metadata": qc.PaginationMetadata, "platforms": platformNames, "filter": filter, "chart_config": chartConfig, "cleve_version": cleve.GetVersion()})
+		c.HTML(http.StatusOK, "qc", gin.H{"qc": qc.InteropSummary, "metadata": qc.PaginationMetadata, "platforms": platformNames, "filter": filter, "chart_config": chartConfig, "multiqc_metrics": multiqcMetrics, "cleve_version": cleve.GetVersion()})
+	}
+}
+
+func DashboardProjectsHandler(db *mongo.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := getProjectFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		projects, err := db.Projects(filter)
+		var oobError mongo.PageOutOfBoundsError
+		if errors.As(err, &oobError) {
+			c.HTML(http.StatusNotFound, "error404", gin.H{"error": oobError.Error()})
+			return
+		}
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Hx-Push-Url", filter.UrlParams())
+		c.HTML(http.StatusOK, "projects", gin.H{"projects": projects.Projects, "metadata": projects.PaginationMetadata, "filter": filter, "deliveryStates": []cleve.DeliveryState{cleve.DeliverySequenced, cleve.DeliveryAnalysed, cleve.DeliveryDelivered}, "itemName": "projects", "cleve_version": cleve.GetVersion()})
+	}
+}
+
+// projectRun is a run of a project together with the outcome of its QC checks.
+type projectRun struct {
+	*cleve.Run
+	QcStatus report.VerdictStatus
+}
+
+func DashboardProjectHandler(db *mongo.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectId := c.Param("projectId")
+		project, err := db.Project(projectId)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.HTML(http.StatusNotFound, "error404", gin.H{"error": fmt.Sprintf("project %q not found", projectId)})
+				c.Abort()
+				return
+			}
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		samples, err := db.Samples(&cleve.SampleFilter{Project: projectId, PaginationFilter: cleve.PaginationFilter{Page: 1}})
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		schemas, err := db.MetadataSchemas()
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		runs, err := db.Runs(cleve.RunFilter{Project: projectId, PaginationFilter: cleve.PaginationFilter{Page: 1}})
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		thresholds, err := report.ThresholdsFromConfig()
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		runIds := make([]string, len(runs.Runs))
+		for i, run := range runs.Runs {
+			runIds[i] = run.RunID
+		}
+		qcs, err := db.RunQCsById(runIds...)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		sexChecks, err := db.RunsSexChecks(runIds...)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// The project fails QC if any of its runs fail, and passes if all runs pass.
+		projectRuns := make([]projectRun, 0, len(runs.Runs))
+		passed, failed := 0, 0
+		for _, run := range runs.Runs {
+			pr := projectRun{Run: run}
+			if qc, ok := qcs[run.RunID]; ok {
+				pr.QcStatus = report.New(run, qc, thresholds).WithSexChecks(sexChecks[run.RunID]).Status()
+			}
+			switch pr.QcStatus {
+			case report.VerdictPass:
+				passed++
+			case report.VerdictFail:
+				failed++
+			}
+			projectRuns = append(projectRuns, pr)
+		}
+		qcStatus := report.VerdictNotAvailable
+		if failed > 0 {
+			qcStatus = report.VerdictFail
+		} else if passed > 0 && passed == len(projectRuns) {
+			qcStatus = report.VerdictPass
+		}
+
+		c.HTML(http.StatusOK, "project", gin.H{"project": project, "samples": samples.Samples, "metadataColumns": cleve.MetadataColumns(schemas, projectId), "runs": projectRuns, "qcStatus": qcStatus, "nextDeliveryState": project.DeliveryState.Next(), "cleve_version": cleve.GetVersion()})
 	}
 }
@@ -0,0 +1,153 @@
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/delivery"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
+)
+
+// Interface for reading deliveries from the database.
+type DeliveryGetter interface {
+	Delivery(uuid.UUID) (*cleve.Delivery, error)
+	Deliveries(cleve.DeliveryFilter) (*cleve.DeliveryResult, error)
+}
+
+// Interface for resolving the files of a delivery and storing the delivery in the
+// database.
+type DeliveryCreator interface {
+	delivery.Source
+	CreateDelivery(*cleve.Delivery) error
+	UpdateDelivery(*cleve.Delivery) error
+}
+
+func DeliveriesHandler(db DeliveryGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := getDeliveryFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		deliveries, err := db.Deliveries(filter)
+		if errors.As(err, &mongo.PageOutOfBoundsError{}) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if deliveries.Deliveries == nil {
+			deliveries.Deliveries = []cleve.Delivery{}
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+func getDelivery(c *gin.Context, db DeliveryGetter) (*cleve.Delivery, bool) {
+	deliveryId, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid delivery id: %s", err)})
+		return nil, false
+	}
+	d, err := db.Delivery(deliveryId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("delivery %s not found", deliveryId)})
+		return nil, false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return d, true
+}
+
+func DeliveryHandler(db DeliveryGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d, ok := getDelivery(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+// DeliveryManifestHandler serves the manifest of a delivery as a tab separated file.
+func DeliveryManifestHandler(db DeliveryGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d, ok := getDelivery(c, db)
+		if !ok {
+			return
+		}
+		if !d.HasChecksums() {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"error":       "the checksums of the delivery are not available",
+				"delivery_id": d.DeliveryId,
+				"state":       d.State,
+				"details":     d.Error,
+			})
+			return
+		}
+		var b bytes.Buffer
+		if err := d.WriteManifest(&b); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.manifest.tsv", d.DeliveryId))
+		c.Data(http.StatusOK, "text/tab-separated-values; charset=utf-8", b.Bytes())
+	}
+}
+
+// AddDeliveryHandler resolves the files of a delivery request and stores a pending
+// delivery. The checksums of the files are computed in the background, after which
+// the delivery is ready, or failed if a checksum could not be computed. Deliveries
+// that are still pending when the server stops have their checksums computed again
+// when the server starts.
+func AddDeliveryHandler(db DeliveryCreator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req cleve.DeliveryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := req.Validate(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		d, err := delivery.Prepare(db, req)
+		if errors.Is(err, delivery.ErrNothingToDeliver) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.CreateDelivery(d); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response := gin.H{
+			"message":     "delivery added, checksums are being computed",
+			"delivery_id": d.DeliveryId,
+			"files":       len(d.Files),
+			"state":       d.State,
+		}
+		go func() {
+			if err := delivery.Checksums(d); err != nil {
+				slog.Error("failed to compute delivery checksums", "delivery_id", d.DeliveryId, "error", err)
+			}
+			if err := db.UpdateDelivery(d); err != nil {
+				slog.Error("failed to update delivery", "delivery_id", d.DeliveryId, "error", err)
+			}
+		}()
+		c.JSON(http.StatusAccepted, response)
+	}
+}
@@ -0,0 +1,267 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
+)
+
+func TestDeliveriesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name           string
+		url            string
+		params         gin.Params
+		code           int
+		expectedFilter cleve.DeliveryFilter
+	}{
+		{
+			name:           "no filter",
+			url:            "/api/deliveries",
+			code:           http.StatusOK,
+			expectedFilter: cleve.NewDeliveryFilter(),
+		},
+		{
+			name: "project",
+			url:  "/api/projects/proj1/deliveries",
+			params: gin.Params{
+				{Key: "projectId", Value: "proj1"},
+			},
+			code: http.StatusOK,
+			expectedFilter: cleve.DeliveryFilter{
+				Project:          "proj1",
+				PaginationFilter: cleve.NewPaginationFilter(),
+			},
+		},
+		{
+			name: "sample",
+			url:  "/api/samples/S1/deliveries?page_size=5",
+			params: gin.Params{
+				{Key: "sampleId", Value: "S1"},
+			},
+			code: http.StatusOK,
+			expectedFilter: cleve.DeliveryFilter{
+				SampleId:         "S1",
+				PaginationFilter: cleve.PaginationFilter{Page: 1, PageSize: 5},
+			},
+		},
+		{
+			name: "invalid page",
+			url:  "/api/deliveries?page=0",
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.DeliveryGetter{}
+			db.DeliveriesFn = func(filter cleve.DeliveryFilter) (*cleve.DeliveryResult, error) {
+				if filter != c.expectedFilter {
+					t.Errorf("expected filter %+v, got %+v", c.expectedFilter, filter)
+				}
+				return &cleve.DeliveryResult{}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = c.params
+			ctx.Request = httptest.NewRequest(http.MethodGet, c.url, nil)
+
+			DeliveriesHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code == http.StatusOK && !bytes.Contains(w.Body.Bytes(), []byte(`"deliveries":[]`)) {
+				t.Errorf("expected an empty list of deliveries, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeliveryManifestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deliveryId := uuid.New()
+
+	cases := []struct {
+		name       string
+		deliveryId string
+		state      cleve.State
+		err        error
+		code       int
+	}{
+		{
+			name:       "ready delivery",
+			deliveryId: deliveryId.String(),
+			state:      cleve.StateReady,
+			code:       http.StatusOK,
+		},
+		{
+			name:       "pending checksums",
+			deliveryId: deliveryId.String(),
+			state:      cleve.StatePending,
+			code:       http.StatusConflict,
+		},
+		{
+			name:       "without state",
+			deliveryId: deliveryId.String(),
+			code:       http.StatusConflict,
+		},
+		{
+			name:       "invalid id",
+			deliveryId: "not-a-uuid",
+			code:       http.StatusBadRequest,
+		},
+		{
+			name:       "missing delivery",
+			deliveryId: deliveryId.String(),
+			err:        mongo.ErrNoDocuments,
+			code:       http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.DeliveryGetter{}
+			db.DeliveryFn = func(id uuid.UUID) (*cleve.Delivery, error) {
+				if c.err != nil {
+					return nil, c.err
+				}
+				return &cleve.Delivery{
+					DeliveryId: id,
+					State:      c.state,
+					FileType:   cleve.FileFastq,
+					Files: []cleve.DeliveryFile{
+						{AnalysisFile: cleve.AnalysisFile{Path: "/data/S1.fastq.gz", FileType: cleve.FileFastq, Level: cleve.LevelSample, ParentId: "S1"}, Checksum: "abc", Size: 1},
+					},
+				}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "deliveryId", Value: c.deliveryId}}
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/deliveries/"+c.deliveryId+"/manifest", nil)
+
+			DeliveryManifestHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code == http.StatusOK && !strings.Contains(w.Body.String(), "abc\t1\tfastq\tsample\tS1\t/data/S1.fastq.gz\n") {
+				t.Errorf("expected the file in the manifest, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAddDelivery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	fastq := filepath.Join(dir, "S1_R1.fastq.gz")
+	if err := os.WriteFile(fastq, []byte("reads"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		body    string
+		files   []cleve.AnalysisFile
+		invoked bool
+		state   cleve.State
+		code    int
+	}{
+		{
+			name:    "project",
+			body:    `{"project": "proj1", "type": "fastq", "recipients": ["someone@example.com"], "destination": "/data/outbox"}`,
+			files:   []cleve.AnalysisFile{{Path: fastq, FileType: cleve.FileFastq, Level: cleve.LevelSample, ParentId: "S1"}},
+			invoked: true,
+			state:   cleve.StateReady,
+			code:    http.StatusAccepted,
+		},
+		{
+			name: "no files",
+			body: `{"project": "proj1", "type": "fastq", "recipients": ["someone@example.com"], "destination": "/data/outbox"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "missing recipients",
+			body: `{"project": "proj1", "type": "fastq", "destination": "/data/outbox"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "missing file on disk",
+			body:    `{"samples": ["S1"], "type": "fastq", "recipients": ["someone@example.com"], "destination": "/data/outbox"}`,
+			files:   []cleve.AnalysisFile{{Path: filepath.Join(dir, "missing.fastq.gz"), FileType: cleve.FileFastq, Level: cleve.LevelSample, ParentId: "S1"}},
+			invoked: true,
+			state:   cleve.StateError,
+			code:    http.StatusAccepted,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.DeliveryCreator{}
+			db.SamplesFn = func(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
+				if filter.Project != "proj1" {
+					t.Errorf("expected project proj1, got %q", filter.Project)
+				}
+				return &cleve.SampleResult{Samples: []cleve.Sample{{Id: "S1", Project: "proj1"}}}, nil
+			}
+			db.AnalysesFilesFn = func(filter cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error) {
+				return c.files, nil
+			}
+			db.CreateDeliveryFn = func(d *cleve.Delivery) error {
+				if len(d.Files) != len(c.files) {
+					t.Errorf("expected %d files, got %d", len(c.files), len(d.Files))
+				}
+				if d.State != cleve.StatePending {
+					t.Errorf("expected a pending delivery, got %s", d.State)
+				}
+				return nil
+			}
+			updated := make(chan *cleve.Delivery, 1)
+			db.UpdateDeliveryFn = func(d *cleve.Delivery) error {
+				updated <- d
+				return nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/deliveries", strings.NewReader(c.body))
+
+			AddDeliveryHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if db.CreateDeliveryInvoked != c.invoked {
+				t.Errorf("expected CreateDelivery invoked to be %t", c.invoked)
+			}
+			if !c.invoked {
+				return
+			}
+			select {
+			case d := <-updated:
+				if d.State != c.state {
+					t.Errorf("expected state %s after computing checksums, got %s", c.state, d.State)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the checksums")
+			}
+		})
+	}
+}
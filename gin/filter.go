@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"regexp"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gmc-norr/cleve"
@@ -55,6 +56,10 @@ func getAnalysisFileFilter(c *gin.Context) (cleve.AnalysisFileFilter, error) {
 	if p, ok := c.Params.Get("runId"); ok {
 		filter.RunId = p
 	}
+	if p, ok := c.Params.Get("caseId"); ok {
+		filter.Level = cleve.LevelCase
+		filter.ParentId = p
+	}
 	if p, ok := c.Params.Get("analysisId"); ok {
 		id, err := uuid.Parse(p)
 		if err != nil {
@@ -70,6 +75,58 @@ func getSampleFilter(c *gin.Context) (cleve.SampleFilter, error) {
 	if err := c.BindQuery(&filter); err != nil {
 		return filter, err
 	}
+	if p, ok := c.Params.Get("panelId"); ok {
+		filter.Panel = p
+		if v := c.Query("version"); v != "" {
+			filter.PanelVersion = v
+		}
+	}
+	return filter, filter.Validate()
+}
+
+func getCaseFilter(c *gin.Context) (cleve.CaseFilter, error) {
+	filter := cleve.NewCaseFilter()
+	if err := c.BindQuery(&filter); err != nil {
+		return filter, err
+	}
+	return filter, filter.Validate()
+}
+
+func getDeliveryFilter(c *gin.Context) (cleve.DeliveryFilter, error) {
+	filter := cleve.NewDeliveryFilter()
+	if err := c.BindQuery(&filter); err != nil {
+		return filter, err
+	}
+	if p, ok := c.Params.Get("projectId"); ok {
+		filter.Project = p
+	}
+	if p, ok := c.Params.Get("sampleId"); ok {
+		filter.SampleId = p
+	}
+	return filter, filter.Validate()
+}
+
+func getProjectFilter(c *gin.Context) (cleve.ProjectFilter, error) {
+	filter := cleve.NewProjectFilter()
+	if err := c.BindQuery(&filter); err != nil {
+		return filter, err
+	}
+	return filter, filter.Validate()
+}
+
+func getSampleSheetFilter(c *gin.Context) (cleve.SampleSheetFilter, error) {
+	filter := cleve.NewSampleSheetFilter()
+	if err := c.BindQuery(&filter); err != nil {
+		return filter, err
+	}
+	if p := c.Query("uuid"); p != "" {
+		id, err := uuid.Parse(p)
+		if err != nil {
+			return filter, err
+		}
+		filter.UUID = id
+	}
+	filter.Index = strings.ToUpper(filter.Index)
 	return filter, filter.Validate()
 }
 
@@ -3,6 +3,7 @@ package gin
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gmc-norr/cleve/charts"
@@ -54,6 +55,31 @@ func GlobalChartsHandler(db *mongo.DB) gin.HandlerFunc {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
+		case "index_hopping":
+			plotData := charts.RunStats[interop.OptionalFloat]{
+				Data:  make([]charts.RunStat[interop.OptionalFloat], 0),
+				Label: "% hopped reads",
+				Type:  config.ChartType,
+			}
+			for _, q := range qc.InteropSummary {
+				percentHopped := q.IndexHopping.PercentHopped
+				datapoint := charts.RunStat[interop.OptionalFloat]{
+					RunID: q.RunId,
+				}
+				if len(q.IndexHopping.Lanes) > 0 && !percentHopped.IsNaN() {
+					datapoint.Value = &percentHopped
+				}
+				plotData.Data = append(plotData.Data, datapoint)
+			}
+			p, err := plotData.Plot()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if err := p.Render(c.Writer); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
 		case "error_rate":
 			plotData := charts.RunStats[interop.OptionalFloat]{
 				Data:  make([]charts.RunStat[interop.OptionalFloat], 0),
@@ -79,6 +105,62 @@ func GlobalChartsHandler(db *mongo.DB) gin.HandlerFunc {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
+		default:
+			key, ok := strings.CutPrefix(config.ChartData, "multiqc:")
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid chart data"})
+				return
+			}
+			runIds := make([]string, len(qc.InteropSummary))
+			for i, q := range qc.InteropSummary {
+				runIds[i] = q.RunId
+			}
+			metrics, err := db.RunsMultiQC(runIds)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.String(http.StatusOK, "No data to plot")
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			// Plot the mean value of the metric over all samples in each run
+			sums := make(map[string]float64)
+			counts := make(map[string]int)
+			for _, m := range metrics {
+				v, ok := m.Metric(key)
+				if !ok {
+					continue
+				}
+				for _, runId := range m.Runs {
+					sums[runId] += v
+					counts[runId]++
+				}
+			}
+			plotData := charts.RunStats[float64]{
+				Data:  make([]charts.RunStat[float64], 0),
+				Label: "Mean " + key,
+				Type:  config.ChartType,
+			}
+			for _, runId := range runIds {
+				datapoint := charts.RunStat[float64]{
+					RunID: runId,
+				}
+				if counts[runId] > 0 {
+					mean := sums[runId] / float64(counts[runId])
+					datapoint.Value = &mean
+				}
+				plotData.Data = append(plotData.Data, datapoint)
+			}
+			p, err := plotData.Plot()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if err := p.Render(c.Writer); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
 		}
 	}
 }
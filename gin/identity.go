@@ -0,0 +1,41 @@
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/fingerprint"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+// Interface for reading samples and their fingerprints from the database.
+type SampleIdentityGetter interface {
+	Sample(string) (*cleve.Sample, error)
+	fingerprint.Store
+}
+
+// SampleIdentityHandler returns the identity check of a sample, i.e. the comparisons of
+// its fingerprints to each other and to the fingerprints of all other samples. The
+// comparisons are made when the fingerprints are added, see [fingerprint.Add].
+func SampleIdentityHandler(db SampleIdentityGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sampleId := c.Param("sampleId")
+		if _, err := db.Sample(sampleId); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("sample with ID %s not found", sampleId)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		identity, err := fingerprint.CheckSample(db, sampleId)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, identity)
+	}
+}
@@ -0,0 +1,107 @@
+package gin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/fingerprint"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
+)
+
+func TestSampleIdentityHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	genotypes := "01210012100121001210"
+	cases := []struct {
+		name         string
+		sampleErr    error
+		fingerprints []cleve.Fingerprint
+		code         int
+		flagged      bool
+	}{
+		{
+			name: "concordant",
+			fingerprints: []cleve.Fingerprint{
+				{SampleId: "S1", AnalysisId: uuid.New(), Panel: "p", Genotypes: genotypes},
+				{SampleId: "S1", AnalysisId: uuid.New(), Panel: "p", Genotypes: genotypes},
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "unexpected match",
+			fingerprints: []cleve.Fingerprint{
+				{SampleId: "S1", AnalysisId: uuid.New(), Panel: "p", Genotypes: genotypes},
+				{SampleId: "S2", AnalysisId: uuid.New(), Panel: "p", Genotypes: genotypes},
+			},
+			code:    http.StatusOK,
+			flagged: true,
+		},
+		{
+			name: "no fingerprints",
+			code: http.StatusOK,
+		},
+		{
+			name:      "missing sample",
+			sampleErr: mongo.ErrNoDocuments,
+			code:      http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.SampleIdentityGetter{}
+			db.SampleFn = func(sampleId string) (*cleve.Sample, error) {
+				if c.sampleErr != nil {
+					return nil, c.sampleErr
+				}
+				return &cleve.Sample{Id: sampleId}, nil
+			}
+			db.FingerprintsFn = func(filter cleve.FingerprintFilter) ([]cleve.Fingerprint, error) {
+				var fingerprints []cleve.Fingerprint
+				for _, f := range c.fingerprints {
+					if (filter.SampleId == "" || f.SampleId == filter.SampleId) && (filter.Panel == "" || f.Panel == filter.Panel) {
+						fingerprints = append(fingerprints, f)
+					}
+				}
+				return fingerprints, nil
+			}
+			db.FingerprintComparisonsFn = func(sampleId string) ([]fingerprint.Comparison, error) {
+				var comparisons []fingerprint.Comparison
+				for i, f := range c.fingerprints {
+					comparisons = append(comparisons, fingerprint.Comparisons(f, c.fingerprints[:i], fingerprint.DefaultThresholds())...)
+				}
+				return comparisons, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "sampleId", Value: "S1"}}
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/samples/S1/identity", nil)
+
+			SampleIdentityHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code != http.StatusOK {
+				return
+			}
+			var identity fingerprint.Identity
+			if err := json.Unmarshal(w.Body.Bytes(), &identity); err != nil {
+				t.Fatal(err)
+			}
+			if identity.Flagged != c.flagged {
+				t.Errorf("expected flagged to be %t, got %+v", c.flagged, identity)
+			}
+			if identity.Fingerprints == nil || identity.Comparisons == nil {
+				t.Errorf("expected empty lists rather than null, got %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package gin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+// Interface for reading sample metadata schemas from the database.
+type MetadataSchemaGetter interface {
+	MetadataSchema(string) (*cleve.MetadataSchema, error)
+	MetadataSchemas() ([]cleve.MetadataSchema, error)
+}
+
+// Interface for storing/updating sample metadata schemas in the database.
+type MetadataSchemaSetter interface {
+	CreateMetadataSchema(*cleve.MetadataSchema) error
+	UpdateMetadataSchema(*cleve.MetadataSchema) error
+	DeleteMetadataSchema(string) error
+}
+
+// metadataSchemaRequest is the body of requests for adding and updating metadata
+// schemas.
+type metadataSchemaRequest struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Project     string           `json:"project"`
+	SampleType  string           `json:"sample_type"`
+	Schema      cleve.JSONSchema `json:"schema"`
+}
+
+// bindMetadataSchema reads a metadata schema from the request body. Unknown fields,
+// including JSON Schema keywords that are not supported, are rejected rather than
+// silently ignored.
+func bindMetadataSchema(c *gin.Context) (cleve.MetadataSchema, error) {
+	var req metadataSchemaRequest
+	if c.Request == nil || c.Request.Body == nil {
+		return cleve.MetadataSchema{}, errors.New("invalid request")
+	}
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return cleve.MetadataSchema{}, err
+	}
+	return cleve.MetadataSchema{
+		Name:        req.Name,
+		Description: req.Description,
+		Project:     req.Project,
+		SampleType:  req.SampleType,
+		Schema:      req.Schema,
+	}, nil
+}
+
+func MetadataSchemasHandler(db MetadataSchemaGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schemas, err := db.MetadataSchemas()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, schemas)
+	}
+}
+
+func MetadataSchemaHandler(db MetadataSchemaGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("schemaName")
+		schema, err := db.MetadataSchema(name)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("metadata schema %s not found", name)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, schema)
+	}
+}
+
+func AddMetadataSchemaHandler(db MetadataSchemaSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schema, err := bindMetadataSchema(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := schema.Validate(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.CreateMetadataSchema(&schema); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "a metadata schema with this name already exists", "name": schema.Name})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "metadata schema added", "name": schema.Name})
+	}
+}
+
+// UpdateMetadataSchemaHandler replaces a metadata schema. The name of the schema is
+// taken from the URL, and metadata already stored for samples is not revalidated.
+func UpdateMetadataSchemaHandler(db MetadataSchemaSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("schemaName")
+		schema, err := bindMetadataSchema(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if schema.Name != "" && schema.Name != name {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "metadata schema cannot be renamed"})
+			return
+		}
+		schema.Name = name
+		if err := schema.Validate(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		err = db.UpdateMetadataSchema(&schema)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("metadata schema %s not found", name)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "metadata schema updated", "name": name})
+	}
+}
+
+func DeleteMetadataSchemaHandler(db MetadataSchemaSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("schemaName")
+		err := db.DeleteMetadataSchema(name)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("metadata schema %s not found", name)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "metadata schema deleted", "name": name})
+	}
+}
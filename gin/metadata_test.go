@@ -0,0 +1,156 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+func TestAddMetadataSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		body    string
+		err     error
+		invoked bool
+		code    int
+	}{
+		{
+			name:    "valid schema",
+			body:    `{"name": "germline", "project": "P1", "schema": {"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object", "required": ["tissue"], "properties": {"tissue": {"type": "string", "enum": ["blood", "saliva"]}}}}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name: "missing name",
+			body: `{"schema": {"type": "object"}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "unsupported keyword",
+			body: `{"name": "germline", "schema": {"type": "object", "properties": {"tissue": {"oneOf": [{"type": "string"}]}}}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid schema",
+			body: `{"name": "germline", "schema": {"type": "object", "properties": {"dna": {"type": "float"}}}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "existing schema",
+			body:    `{"name": "germline", "schema": {"type": "object"}}`,
+			err:     mongo.GenericDuplicateKeyError,
+			invoked: true,
+			code:    http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.MetadataSchemaSetter{}
+			db.CreateMetadataSchemaFn = func(schema *cleve.MetadataSchema) error {
+				if schema.Name != "germline" {
+					t.Errorf("expected schema germline, got %s", schema.Name)
+				}
+				return c.err
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/metadata/schemas", bytes.NewBufferString(c.body))
+
+			AddMetadataSchemaHandler(&db)(ctx)
+
+			if db.CreateMetadataSchemaInvoked != c.invoked {
+				t.Errorf("expected CreateMetadataSchema invoked to be %t", c.invoked)
+			}
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateMetadataSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		body    string
+		err     error
+		invoked bool
+		code    int
+	}{
+		{
+			name:    "update schema",
+			body:    `{"sample_type": "tissue", "schema": {"type": "object"}}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name: "rename schema",
+			body: `{"name": "somatic", "schema": {"type": "object"}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "missing schema",
+			body:    `{"schema": {"type": "object"}}`,
+			err:     mongo.ErrNoDocuments,
+			invoked: true,
+			code:    http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.MetadataSchemaSetter{}
+			db.UpdateMetadataSchemaFn = func(schema *cleve.MetadataSchema) error {
+				if schema.Name != "germline" {
+					t.Errorf("expected schema germline, got %s", schema.Name)
+				}
+				return c.err
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "schemaName", Value: "germline"}}
+			ctx.Request = httptest.NewRequest(http.MethodPut, "/api/metadata/schemas/germline", bytes.NewBufferString(c.body))
+
+			UpdateMetadataSchemaHandler(&db)(ctx)
+
+			if db.UpdateMetadataSchemaInvoked != c.invoked {
+				t.Errorf("expected UpdateMetadataSchema invoked to be %t", c.invoked)
+			}
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMetadataSchemaHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := mock.MetadataSchemaGetter{}
+	db.MetadataSchemaFn = func(name string) (*cleve.MetadataSchema, error) {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "schemaName", Value: "germline"}}
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/metadata/schemas/germline", nil)
+
+	MetadataSchemaHandler(&db)(ctx)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,208 @@
+package gin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Interface for tracking the analyses of Nextflow runs in the database.
+type NextflowAnalysisGetterSetter interface {
+	Analysis(analysisId uuid.UUID, runId ...string) (*cleve.Analysis, error)
+	CreateAnalysis(*cleve.Analysis) error
+	UpdateAnalysis(*cleve.Analysis) error
+	UpdateMultiQCMetrics([]cleve.MultiQCSampleMetrics) error
+}
+
+// NextflowOutputsFromConfig returns the output mapping defined under
+// `integrations.nextflow.outputs` in the configuration.
+func NextflowOutputsFromConfig() ([]cleve.NextflowOutput, error) {
+	var outputs []cleve.NextflowOutput
+	if !viper.IsSet("integrations.nextflow.outputs") {
+		return outputs, nil
+	}
+	if err := viper.UnmarshalKey("integrations.nextflow.outputs", &outputs); err != nil {
+		return nil, fmt.Errorf("invalid nextflow outputs: %w", err)
+	}
+	var errs []error
+	for _, o := range outputs {
+		errs = append(errs, o.Validate())
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// integrationAuthMiddleware authenticates requests from an integration with the API
+// key defined under `integrations.<name>.api_key` in the configuration. Since not all
+// integrations can set headers, the key can be passed either in the Authorization
+// header or in the `api_key` query parameter. Keys in the query are redacted from the
+// request log. If no key is defined, the integration is disabled and all requests are
+// rejected.
+func integrationAuthMiddleware(name string) gin.HandlerFunc {
+	apiKey := viper.GetString(fmt.Sprintf("integrations.%s.api_key", name))
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": fmt.Sprintf("the %s integration is not enabled", name),
+			})
+			return
+		}
+		requestKey := c.Request.Header.Get("Authorization")
+		if requestKey == "" {
+			requestKey = c.Query("api_key")
+		}
+		if requestKey == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "missing API key",
+			})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(requestKey), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "invalid API key",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// NextflowWeblogHandler receives the lifecycle events that Nextflow posts when running
+// with `-with-weblog`. A `started` event creates a pending analysis for the run, or
+// marks an existing analysis as pending again if the run is resumed. Process events
+// keep the analysis pending unless it has already failed or completed, and an `error`
+// event marks it as failed. When the run
+// completes successfully, the output files are resolved according to the outputs
+// that apply to the workflow, and the analysis is ready.
+func NextflowWeblogHandler(db NextflowAnalysisGetterSetter, outputs []cleve.NextflowOutput) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var event cleve.NextflowWeblogEvent
+		if err := c.ShouldBindJSON(&event); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid weblog event", "details": err.Error()})
+			return
+		}
+		if err := event.Validate(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid weblog event", "details": err.Error()})
+			return
+		}
+		analysisId, _ := event.AnalysisId()
+
+		analysis, err := db.Analysis(analysisId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "when": "fetching analysis"})
+			return
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if event.Event != cleve.NextflowEventStarted {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+					"error":       "analysis not found",
+					"analysis_id": analysisId,
+				})
+				return
+			}
+			a, err := cleve.NewNextflowAnalysis(event)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid weblog event", "details": err.Error()})
+				return
+			}
+			if err := db.CreateAnalysis(&a); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "when": "adding analysis"})
+				return
+			}
+			c.Set("webhook_message", cleve.WebhookMessageRequest{
+				Entity:      &a,
+				Message:     "new analysis added",
+				MessageType: cleve.MessageStateUpdate,
+			})
+			c.JSON(http.StatusOK, gin.H{
+				"message":     "analysis added",
+				"analysis_id": a.AnalysisId,
+				"state":       a.StateHistory.LastState(),
+			})
+			return
+		}
+
+		lastState := analysis.StateHistory.LastState()
+		state := lastState
+		var multiqc []cleve.MultiQCSampleMetrics
+		var outputErr error
+		switch event.Event {
+		case cleve.NextflowEventStarted:
+			// The run was resumed
+			state = cleve.StatePending
+			analysis.OutputFiles = nil
+		case cleve.NextflowEventProcessSubmitted, cleve.NextflowEventProcessStarted, cleve.NextflowEventProcessCompleted:
+			// Late or retried process events do not change the state of a run that
+			// has already failed or completed.
+			if lastState != cleve.StateError && lastState != cleve.StateReady {
+				state = cleve.StatePending
+			}
+		case cleve.NextflowEventError:
+			state = cleve.StateError
+		case cleve.NextflowEventCompleted:
+			if !event.Metadata.Workflow.Success {
+				state = cleve.StateError
+				break
+			}
+			files, err := cleve.NextflowOutputFiles(analysis, outputs, event.Metadata.Parameters)
+			if err == nil {
+				analysis.OutputFiles = files
+				multiqc, err = analysis.MultiQCMetrics()
+			}
+			if err != nil {
+				outputErr = err
+				state = cleve.StateError
+				break
+			}
+			state = cleve.StateReady
+		}
+
+		if state != lastState || event.Event == cleve.NextflowEventStarted {
+			analysis.StateHistory.Add(state)
+		}
+		if err := db.UpdateAnalysis(analysis); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "when": "updating analysis"})
+			return
+		}
+		if len(multiqc) > 0 {
+			if err := db.UpdateMultiQCMetrics(multiqc); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "when": "storing multiqc metrics"})
+				return
+			}
+		}
+		if state != lastState {
+			c.Set("webhook_message", cleve.WebhookMessageRequest{
+				Entity:      analysis,
+				Message:     "analysis state updated",
+				MessageType: cleve.MessageStateUpdate,
+			})
+		}
+		if outputErr != nil {
+			// Not aborted, so that the state update is still sent as a webhook message
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":       "failed to resolve output files",
+				"details":     outputErr.Error(),
+				"analysis_id": analysis.AnalysisId,
+				"state":       state,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "analysis updated",
+			"analysis_id": analysis.AnalysisId,
+			"state":       state,
+		})
+	}
+}
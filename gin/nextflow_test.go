@@ -0,0 +1,290 @@
+package gin
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestNextflowWeblogHandler(t *testing.T) {
+	gin.SetMode("test")
+	tmpdir := t.TempDir()
+	outdir := filepath.Join(tmpdir, "results")
+	mqc := filepath.Join(outdir, "multiqc", "multiqc_data", "multiqc_data.json")
+	if err := os.MkdirAll(filepath.Dir(mqc), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mqc, []byte(`{"report_general_stats_data": [{"sample1": {"percent_duplicates": 12.5}}], "report_general_stats_headers": [{"percent_duplicates": {"namespace": "FastQC"}}]}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	runId := uuid.New()
+	metadata := fmt.Sprintf(`{"parameters": {"outdir": "results", "run_id": "run1"}, "workflow": {"projectName": "nf-core/rnaseq", "launchDir": "%s", "success": %%t, "manifest": {"name": "nf-core/rnaseq", "version": "3.14.0"}}}`, tmpdir)
+	outputs := []cleve.NextflowOutput{
+		{Path: "multiqc/*/multiqc_data.json", Type: "multiqc", Level: "run", ParentId: "{params.run_id}"},
+	}
+
+	testcases := []struct {
+		name          string
+		existing      *cleve.Analysis
+		data          string
+		outputs       []cleve.NextflowOutput
+		code          int
+		created       bool
+		updated       bool
+		state         cleve.State
+		outputFiles   int
+		multiqcStored bool
+	}{
+		{
+			name:    "started",
+			data:    fmt.Sprintf(`{"runId": "%s", "runName": "happy_turing", "event": "started", "metadata": %s}`, runId, fmt.Sprintf(metadata, false)),
+			code:    http.StatusOK,
+			created: true,
+			state:   cleve.StatePending,
+		},
+		{
+			name:     "resumed",
+			existing: &cleve.Analysis{AnalysisId: runId, Path: outdir, StateHistory: cleve.StateHistory{{State: cleve.StateError}}},
+			data:     fmt.Sprintf(`{"runId": "%s", "runName": "happy_turing", "event": "started", "metadata": %s}`, runId, fmt.Sprintf(metadata, false)),
+			code:     http.StatusOK,
+			updated:  true,
+			state:    cleve.StatePending,
+		},
+		{
+			name:     "process completed",
+			existing: &cleve.Analysis{AnalysisId: runId, Path: outdir, StateHistory: cleve.StateHistory{{State: cleve.StatePending}}},
+			data:     fmt.Sprintf(`{"runId": "%s", "event": "process_completed", "trace": {"task_id": 1, "name": "FASTQC (sample1)", "status": "COMPLETED"}}`, runId),
+			code:     http.StatusOK,
+			updated:  true,
+			state:    cleve.StatePending,
+		},
+		{
+			name:     "process event after completion",
+			existing: &cleve.Analysis{AnalysisId: runId, Path: outdir, StateHistory: cleve.StateHistory{{State: cleve.StateReady}}},
+			data:     fmt.Sprintf(`{"runId": "%s", "event": "process_completed", "trace": {"task_id": 1, "name": "FASTQC (sample1)", "status": "COMPLETED"}}`, runId),
+			code:     http.StatusOK,
+			updated:  true,
+			state:    cleve.StateReady,
+		},
+		{
+			name: "process event for unknown run",
+			data: fmt.Sprintf(`{"runId": "%s", "event": "process_started", "trace": {"task_id": 1, "name": "FASTQC (sample1)", "status": "RUNNING"}}`, runId),
+			code: http.StatusNotFound,
+		},
+		{
+			name:     "error",
+			existing: &cleve.Analysis{AnalysisId: runId, Path: outdir, StateHistory: cleve.StateHistory{{State: cleve.StatePending}}},
+			data:     fmt.Sprintf(`{"runId": "%s", "event": "error", "trace": {"task_id": 2, "name": "MULTIQC", "status": "FAILED"}}`, runId),
+			code:     http.StatusOK,
+			updated:  true,
+			state:    cleve.StateError,
+		},
+		{
+			name:     "completed with failure",
+			existing: &cleve.Analysis{AnalysisId: runId, Path: outdir, StateHistory: cleve.StateHistory{{State: cleve.StateError}}},
+			data:     fmt.Sprintf(`{"runId": "%s", "event": "completed", "metadata": %s}`, runId, fmt.Sprintf(metadata, false)),
+			code:     http.StatusOK,
+			updated:  true,
+			state:    cleve.StateError,
+		},
+		{
+			name:          "completed",
+			existing:      &cleve.Analysis{AnalysisId: runId, Path: outdir, Software: "nf-core/rnaseq", StateHistory: cleve.StateHistory{{State: cleve.StatePending}}},
+			data:          fmt.Sprintf(`{"runId": "%s", "event": "completed", "metadata": %s}`, runId, fmt.Sprintf(metadata, true)),
+			outputs:       outputs,
+			code:          http.StatusOK,
+			updated:       true,
+			state:         cleve.StateReady,
+			outputFiles:   1,
+			multiqcStored: true,
+		},
+		{
+			name:     "completed with missing outputs",
+			existing: &cleve.Analysis{AnalysisId: runId, Path: outdir, Software: "nf-core/rnaseq", StateHistory: cleve.StateHistory{{State: cleve.StatePending}}},
+			data:     fmt.Sprintf(`{"runId": "%s", "event": "completed", "metadata": %s}`, runId, fmt.Sprintf(metadata, true)),
+			outputs: []cleve.NextflowOutput{
+				{Path: "star_salmon/{sample}/{sample}.bam", Type: "bam", Level: "sample"},
+			},
+			code:    http.StatusUnprocessableEntity,
+			updated: true,
+			state:   cleve.StateError,
+		},
+		{
+			name: "completed without metadata",
+			data: fmt.Sprintf(`{"runId": "%s", "event": "completed"}`, runId),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid event",
+			data: fmt.Sprintf(`{"runId": "%s", "event": "unknown"}`, runId),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid run id",
+			data: `{"runId": "happy_turing", "event": "process_started"}`,
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			var stored *cleve.Analysis
+			db := mock.NextflowAnalysisGetterSetter{
+				AnalysisFn: func(id uuid.UUID, _ ...string) (*cleve.Analysis, error) {
+					if c.existing == nil {
+						return nil, mongo.ErrNoDocuments
+					}
+					a := *c.existing
+					return &a, nil
+				},
+				CreateAnalysisFn: func(a *cleve.Analysis) error {
+					stored = a
+					return nil
+				},
+				UpdateAnalysisFn: func(a *cleve.Analysis) error {
+					stored = a
+					return nil
+				},
+				UpdateMultiQCMetricsFn: func(metrics []cleve.MultiQCSampleMetrics) error {
+					if len(metrics) != 1 || metrics[0].SampleId != "sample1" || metrics[0].AnalysisId != runId {
+						t.Errorf("unexpected multiqc metrics: %+v", metrics)
+					}
+					return nil
+				},
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/integrations/nextflow/weblog", bytes.NewBufferString(c.data))
+			ctx.Request.Header.Set("Content-Type", "application/json")
+
+			NextflowWeblogHandler(&db, c.outputs)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status code %d, got %d: %s", c.code, w.Code, w.Body)
+			}
+			if db.CreateAnalysisInvoked != c.created {
+				t.Errorf("analysis created: %t, expected it: %t", db.CreateAnalysisInvoked, c.created)
+			}
+			if db.UpdateAnalysisInvoked != c.updated {
+				t.Errorf("analysis updated: %t, expected it: %t", db.UpdateAnalysisInvoked, c.updated)
+			}
+			if db.UpdateMultiQCMetricsInvoked != c.multiqcStored {
+				t.Errorf("multiqc stored: %t, expected it: %t", db.UpdateMultiQCMetricsInvoked, c.multiqcStored)
+			}
+			if stored == nil {
+				return
+			}
+			if s := stored.StateHistory.LastState(); s != c.state {
+				t.Errorf("expected state %s, got %s", c.state, s)
+			}
+			if _, ok := ctx.Get("webhook_message"); ok && c.existing != nil && c.existing.StateHistory.LastState() == c.state {
+				t.Error("expected no webhook message when the state is unchanged")
+			}
+			if len(stored.OutputFiles) != c.outputFiles {
+				t.Errorf("expected %d output files, got %d", c.outputFiles, len(stored.OutputFiles))
+			}
+			if c.created && (stored.Path != outdir || stored.Software != "nf-core/rnaseq" || stored.SoftwareVersion != "3.14.0") {
+				t.Errorf("unexpected analysis: %+v", stored)
+			}
+		})
+	}
+}
+
+func TestIntegrationAuthMiddleware(t *testing.T) {
+	gin.SetMode("test")
+	t.Cleanup(viper.Reset)
+
+	testcases := []struct {
+		name   string
+		apiKey string
+		header string
+		query  string
+		code   int
+	}{
+		{
+			name:   "not enabled",
+			header: "secret",
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "missing key",
+			apiKey: "secret",
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid key",
+			apiKey: "secret",
+			header: "wrong",
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "header",
+			apiKey: "secret",
+			header: "secret",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "query parameter",
+			apiKey: "secret",
+			query:  "?api_key=secret",
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			viper.Set("integrations.nextflow.api_key", c.apiKey)
+			r := gin.New()
+			r.POST("/weblog", integrationAuthMiddleware("nextflow"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/weblog"+c.query, nil)
+			if c.header != "" {
+				req.Header.Set("Authorization", c.header)
+			}
+			r.ServeHTTP(w, req)
+			if w.Code != c.code {
+				t.Errorf("expected status code %d, got %d: %s", c.code, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestRequestLogRedactsApiKey(t *testing.T) {
+	gin.SetMode("test")
+	t.Cleanup(viper.Reset)
+	viper.Set("integrations.nextflow.api_key", "secret")
+
+	var log bytes.Buffer
+	r := gin.New()
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{Formatter: logFormatter, Output: &log}))
+	r.POST("/weblog", integrationAuthMiddleware("nextflow"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/weblog?api_key=secret&page=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if strings.Contains(log.String(), "secret") {
+		t.Errorf("expected api key to be redacted, got %q", log.String())
+	}
+	if !strings.Contains(log.String(), "/weblog?api_key=REDACTED&page=1") {
+		t.Errorf("expected redacted path in log, got %q", log.String())
+	}
+}
@@ -142,3 +142,48 @@ func ArchivePanelHandler(db *mongo.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "panel archived", "id": panelId})
 	}
 }
+
+// Interface for reading the samples that have been analysed with a gene panel.
+type PanelSampleGetter interface {
+	Panel(string, string) (cleve.GenePanel, error)
+	Samples(*cleve.SampleFilter) (*cleve.SampleResult, error)
+}
+
+// PanelSamplesHandler lists the samples that refer to a panel, either directly or
+// through one of their analyses. Use the version query parameter to only list samples
+// analysed with a specific version of the panel.
+func PanelSamplesHandler(db PanelSampleGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		panelId := c.Param("panelId")
+		filter, err := getSampleFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := db.Panel(panelId, filter.PanelVersion); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+					"error":   "panel not found",
+					"id":      panelId,
+					"version": filter.PanelVersion,
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		samples, err := db.Samples(&filter)
+		if errors.As(err, &mongo.PageOutOfBoundsError{}) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if samples.Samples == nil {
+			samples.Samples = []cleve.Sample{}
+		}
+		c.JSON(http.StatusOK, samples)
+	}
+}
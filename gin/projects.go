@@ -0,0 +1,176 @@
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+// Interface for reading projects from the database.
+type ProjectGetter interface {
+	Project(string) (*cleve.Project, error)
+	Projects(cleve.ProjectFilter) (*cleve.ProjectResult, error)
+}
+
+// Interface for reading the samples of a project from the database.
+type ProjectSampleGetter interface {
+	Project(string) (*cleve.Project, error)
+	Samples(*cleve.SampleFilter) (*cleve.SampleResult, error)
+}
+
+// Interface for reading the runs of a project from the database.
+type ProjectRunGetter interface {
+	Project(string) (*cleve.Project, error)
+	Runs(cleve.RunFilter) (cleve.RunResult, error)
+}
+
+// Interface for updating projects in the database.
+type ProjectSetter interface {
+	SetProjectDeliveryState(string, cleve.DeliveryState) (*cleve.Project, error)
+}
+
+func ProjectsHandler(db ProjectGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := getProjectFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		projects, err := db.Projects(filter)
+		if errors.As(err, &mongo.PageOutOfBoundsError{}) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if projects.Projects == nil {
+			projects.Projects = []cleve.Project{}
+		}
+		c.JSON(http.StatusOK, projects)
+	}
+}
+
+func ProjectHandler(db ProjectGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectId := c.Param("projectId")
+		project, err := db.Project(projectId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("project %s not found", projectId)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, project)
+	}
+}
+
+// ProjectSamplesHandler lists the samples of a project. The samples can be filtered
+// further in the same way as for [SamplesHandler].
+func ProjectSamplesHandler(db ProjectSampleGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectId := c.Param("projectId")
+		filter, err := getSampleFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := db.Project(projectId); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("project %s not found", projectId)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Project = projectId
+		samples, err := db.Samples(&filter)
+		if errors.As(err, &mongo.PageOutOfBoundsError{}) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if samples.Samples == nil {
+			samples.Samples = []cleve.Sample{}
+		}
+		c.JSON(http.StatusOK, samples)
+	}
+}
+
+// ProjectRunsHandler lists the runs that a project has samples on. The runs can be
+// filtered further in the same way as for [RunsHandler].
+func ProjectRunsHandler(db ProjectRunGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectId := c.Param("projectId")
+		filter, err := getRunFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := db.Project(projectId); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("project %s not found", projectId)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Project = projectId
+		runs, err := db.Runs(filter)
+		if errors.As(err, &mongo.PageOutOfBoundsError{}) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, runs)
+	}
+}
+
+// UpdateProjectDeliveryStateHandler moves a project to the next delivery state.
+func UpdateProjectDeliveryStateHandler(db ProjectSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectId := c.Param("projectId")
+
+		var updateRequest struct {
+			State cleve.DeliveryState `json:"state" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&updateRequest); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !updateRequest.State.IsValid() {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid delivery state %q", updateRequest.State)})
+			return
+		}
+
+		project, err := db.SetProjectDeliveryState(projectId, updateRequest.State)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("project %s not found", projectId)})
+			return
+		}
+		if errors.Is(err, mongo.ErrConflict) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "delivery state updated", "project": project.Name, "delivery_state": project.DeliveryState})
+	}
+}
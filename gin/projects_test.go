@@ -0,0 +1,245 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+func TestProjectsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name           string
+		url            string
+		code           int
+		dbError        error
+		expectedFilter cleve.ProjectFilter
+	}{
+		{
+			name: "no filter",
+			url:  "/api/projects",
+			code: http.StatusOK,
+			expectedFilter: cleve.ProjectFilter{
+				PaginationFilter: cleve.PaginationFilter{Page: 1, PageSize: 10},
+			},
+		},
+		{
+			name: "all filters",
+			url:  "/api/projects?name_query=proj&run_id=run1&delivery_state=analysed&page=2&page_size=5",
+			code: http.StatusOK,
+			expectedFilter: cleve.ProjectFilter{
+				NameQuery:        "proj",
+				RunId:            "run1",
+				DeliveryState:    cleve.DeliveryAnalysed,
+				PaginationFilter: cleve.PaginationFilter{Page: 2, PageSize: 5},
+			},
+		},
+		{
+			name: "invalid delivery state",
+			url:  "/api/projects?delivery_state=archived",
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "page out of bounds",
+			url:     "/api/projects?page=3",
+			code:    http.StatusNotFound,
+			dbError: mongo.PageOutOfBoundsError{},
+			expectedFilter: cleve.ProjectFilter{
+				PaginationFilter: cleve.PaginationFilter{Page: 3, PageSize: 10},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.ProjectGetter{}
+			db.ProjectsFn = func(filter cleve.ProjectFilter) (*cleve.ProjectResult, error) {
+				if filter != c.expectedFilter {
+					t.Errorf("expected filter %+v, got %+v", c.expectedFilter, filter)
+				}
+				return &cleve.ProjectResult{}, c.dbError
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, c.url, nil)
+
+			ProjectsHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code == http.StatusBadRequest && db.ProjectsInvoked {
+				t.Error("Projects should not be invoked for an invalid filter")
+			}
+			if c.code == http.StatusOK && !bytes.Contains(w.Body.Bytes(), []byte(`"projects":[]`)) {
+				t.Errorf("expected an empty list of projects, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProjectSamplesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name       string
+		projectErr error
+		code       int
+	}{
+		{
+			name: "existing project",
+			code: http.StatusOK,
+		},
+		{
+			name:       "missing project",
+			projectErr: mongo.ErrNoDocuments,
+			code:       http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.ProjectSampleGetter{}
+			db.ProjectFn = func(name string) (*cleve.Project, error) {
+				if c.projectErr != nil {
+					return nil, c.projectErr
+				}
+				p := cleve.NewProject(name)
+				return &p, nil
+			}
+			db.SamplesFn = func(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
+				if filter.Project != "P1" {
+					t.Errorf("expected project P1, got %q", filter.Project)
+				}
+				if filter.RunId != "run1" {
+					t.Errorf("expected run run1, got %q", filter.RunId)
+				}
+				return &cleve.SampleResult{}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "projectId", Value: "P1"}}
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/projects/P1/samples?run_id=run1", nil)
+
+			ProjectSamplesHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if db.SamplesInvoked != (c.code == http.StatusOK) {
+				t.Errorf("expected Samples invoked to be %t", c.code == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestProjectRunsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := mock.ProjectRunGetter{}
+	db.ProjectFn = func(name string) (*cleve.Project, error) {
+		p := cleve.NewProject(name)
+		return &p, nil
+	}
+	db.RunsFn = func(filter cleve.RunFilter) (cleve.RunResult, error) {
+		expected := cleve.RunFilter{
+			Platform:         "NovaSeq",
+			Project:          "P1",
+			PaginationFilter: cleve.PaginationFilter{Page: 1, PageSize: 10},
+		}
+		if filter != expected {
+			t.Errorf("expected filter %+v, got %+v", expected, filter)
+		}
+		return cleve.RunResult{Runs: []*cleve.Run{}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "projectId", Value: "P1"}}
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/projects/P1/runs?platform=NovaSeq&project=P2", nil)
+
+	ProjectRunsHandler(&db)(ctx)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+func TestUpdateProjectDeliveryState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		body    string
+		err     error
+		invoked bool
+		code    int
+	}{
+		{
+			name:    "next state",
+			body:    `{"state": "analysed"}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name:    "invalid transition",
+			body:    `{"state": "delivered"}`,
+			err:     mongo.ErrConflict,
+			invoked: true,
+			code:    http.StatusConflict,
+		},
+		{
+			name: "invalid state",
+			body: `{"state": "archived"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "missing state",
+			body: `{}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "missing project",
+			body:    `{"state": "analysed"}`,
+			err:     mongo.ErrNoDocuments,
+			invoked: true,
+			code:    http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.ProjectSetter{}
+			db.SetProjectDeliveryStateFn = func(name string, state cleve.DeliveryState) (*cleve.Project, error) {
+				if c.err != nil {
+					return nil, c.err
+				}
+				p := cleve.NewProject(name)
+				return &p, p.SetDeliveryState(state)
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "projectId", Value: "P1"}}
+			ctx.Request = httptest.NewRequest(http.MethodPatch, "/api/projects/P1/state", bytes.NewBufferString(c.body))
+
+			UpdateProjectDeliveryStateHandler(&db)(ctx)
+
+			if db.SetProjectDeliveryStateInvoked != c.invoked {
+				t.Errorf("expected SetProjectDeliveryState invoked to be %t", c.invoked)
+			}
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/gmc-norr/cleve/report"
+)
+
+// Interface for reading the data needed for a run QC report.
+type RunReportGetter interface {
+	Run(string) (*cleve.Run, error)
+	RunQC(string) (interop.InteropSummary, error)
+	RunSexChecks(string) ([]cleve.SexCheck, error)
+}
+
+// RunReportHandler renders a QC report for a run. The format is given by the `format`
+// query parameter, and can be either "html" (default) or "pdf".
+func RunReportHandler(db RunReportGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := report.FormatFromString(c.DefaultQuery("format", "html"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		runId := c.Param("runId")
+		run, err := db.Run(runId)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.HTML(http.StatusNotFound, "error404", gin.H{"error": fmt.Sprintf("run with id %q not found", runId)})
+				c.Abort()
+				return
+			}
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		qc, err := db.RunQC(runId)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.HTML(http.StatusNotFound, "error404", gin.H{"error": fmt.Sprintf("qc for run %q not found", runId)})
+				c.Abort()
+				return
+			}
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		thresholds, err := report.ThresholdsFromConfig()
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		sexChecks, err := db.RunSexChecks(runId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		var b bytes.Buffer
+		if err := report.New(run, qc, thresholds).WithSexChecks(sexChecks).Write(&b, format); err != nil {
+			c.HTML(http.StatusInternalServerError, "error500", gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		switch format {
+		case report.FormatPDF:
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_qc_report.pdf", runId))
+			c.Data(http.StatusOK, "application/pdf", b.Bytes())
+		default:
+			c.Data(http.StatusOK, "text/html; charset=utf-8", b.Bytes())
+		}
+	}
+}
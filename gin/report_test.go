@@ -0,0 +1,93 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+func TestRunReportHandler(t *testing.T) {
+	gin.SetMode("test")
+
+	table := []struct {
+		name        string
+		query       string
+		code        int
+		contentType string
+		disposition string
+		body        string
+	}{
+		{
+			name:        "default format",
+			code:        http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			body:        "<title>QC report: run1</title>",
+		},
+		{
+			name:        "html",
+			query:       "?format=html",
+			code:        http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			body:        "<title>QC report: run1</title>",
+		},
+		{
+			name:        "pdf",
+			query:       "?format=pdf",
+			code:        http.StatusOK,
+			contentType: "application/pdf",
+			disposition: "attachment; filename=run1_qc_report.pdf",
+			body:        "%PDF-",
+		},
+		{
+			name:  "invalid format",
+			query: "?format=docx",
+			code:  http.StatusBadRequest,
+			body:  `"error":"invalid report format \"docx\""`,
+		},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			db := mock.RunReportGetter{
+				RunFn: func(string) (*cleve.Run, error) {
+					return novaseq1, nil
+				},
+				RunQCFn: func(runId string) (interop.InteropSummary, error) {
+					return interop.InteropSummary{RunId: runId}, nil
+				},
+				RunSexChecksFn: func(string) ([]cleve.SexCheck, error) {
+					return nil, mongo.ErrNoDocuments
+				},
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/runs/run1/report"+v.query, nil)
+			c.Params = gin.Params{gin.Param{Key: "runId", Value: "run1"}}
+			RunReportHandler(&db)(c)
+
+			if w.Code != v.code {
+				t.Fatalf("expected HTTP %d, got %d: %s", v.code, w.Code, w.Body.String())
+			}
+			if v.code == http.StatusOK && !(db.RunInvoked && db.RunQCInvoked && db.RunSexChecksInvoked) {
+				t.Error("expected run, qc and sex checks to be fetched")
+			}
+			if ct := w.Header().Get("Content-Type"); v.contentType != "" && ct != v.contentType {
+				t.Errorf("expected content type %q, got %q", v.contentType, ct)
+			}
+			if cd := w.Header().Get("Content-Disposition"); cd != v.disposition {
+				t.Errorf("expected content disposition %q, got %q", v.disposition, cd)
+			}
+			if !strings.Contains(w.Body.String(), v.body) {
+				t.Errorf("expected %q in body", v.body)
+			}
+		})
+	}
+}
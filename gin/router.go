@@ -9,8 +9,10 @@ import (
 	"log/slog"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gmc-norr/cleve"
@@ -153,6 +155,12 @@ func title(s string) string {
 	return cases.Title(language.English).String(s)
 }
 
+// pathEscape escapes a value for use as a path parameter, e.g. project names that
+// contain slashes.
+func pathEscape(s string) string {
+	return url.PathEscape(s)
+}
+
 func trimSuffix(s string, suffix string) string {
 	return strings.TrimSuffix(s, suffix)
 }
@@ -176,6 +184,10 @@ func toFloat(x any) float64 {
 		return float64(v)
 	case int:
 		return float64(v)
+	case *int:
+		if v != nil {
+			return float64(*v)
+		}
 	}
 	return 0
 }
@@ -185,7 +197,49 @@ func LoadHTMLFS(e *gin.Engine, fs fs.FS, patterns ...string) {
 	e.SetHTMLTemplate(t)
 }
 
-func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client) http.Handler {
+// logFormatter formats request logs like the default gin logger, but with API keys
+// passed as query parameters redacted.
+func logFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactQuery(param.Path, "api_key"),
+		param.ErrorMessage,
+	)
+}
+
+// redactQuery replaces the values of the given query parameters in a path. If the
+// query cannot be parsed, it is removed altogether.
+func redactQuery(path string, keys ...string) string {
+	p, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return p
+	}
+	for _, k := range keys {
+		if values.Has(k) {
+			values.Set(k, "REDACTED")
+		}
+	}
+	return p + "?" + values.Encode()
+}
+
+func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client, nextflowOutputs []cleve.NextflowOutput) http.Handler {
 	gin.DisableConsoleColor()
 	if viper.GetString("logfile") != "" {
 		f, err := os.OpenFile(viper.GetString("logfile"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o666)
@@ -202,7 +256,11 @@ func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client) http.Handler {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.LoggerWithFormatter(logFormatter), gin.Recovery())
+	// Match routes on the escaped path, so that escaped slashes in path parameters,
+	// e.g. in project names, do not split the path.
+	r.UseRawPath = true
 	r.SetFuncMap(template.FuncMap{
 		"add":         add,
 		"addInt":      addInt,
@@ -214,6 +272,7 @@ func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client) http.Handler {
 		"multiplyInt": multiplyInt,
 		"title":       title,
 		"trimSuffix":  trimSuffix,
+		"pathEscape":  pathEscape,
 		"toFloat":     toFloat,
 		"isNaN":       math.IsNaN,
 		"N":           N,
@@ -238,12 +297,17 @@ func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client) http.Handler {
 	r.GET("/", DashboardHandler(db))
 	r.GET("/runs", DashboardHandler(db))
 	r.GET("/runs/:runId", DashboardRunHandler(db))
+	r.GET("/runs/:runId/report", RunReportHandler(db))
+	r.GET("/projects", DashboardProjectsHandler(db))
+	r.GET("/projects/:projectId", DashboardProjectHandler(db))
+	r.GET("/samples/:sampleId", DashboardSampleHandler(db))
 	r.GET("/panels", DashboardPanelHandler(db))
 	r.GET("/panels/:panelId", DashboardPanelHandler(db))
 	r.GET("/qc", DashboardQCHandler(db))
 	r.GET("/qc/charts/global", GlobalChartsHandler(db))
 	r.GET("/qc/charts/run/:runId", RunChartsHandler(db))
 	r.GET("/qc/charts/run/:runId/index", IndexChartHandler(db))
+	r.GET("/qc/charts/run/:runId/index-hopping", IndexHoppingChartHandler(db))
 
 	hxEndpoints := r.Group("/")
 	hxEndpoints.Use(hxMiddleware())
@@ -261,6 +325,15 @@ func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client) http.Handler {
 	r.GET("/api/analyses/:analysisId", AnalysisHandler(db))
 	r.GET("/api/analyses/:analysisId/files", AnalysisFileHandler(db))
 	r.GET("/api/analyses/:analysisId/files/prefix", AnalysisFilePrefixHandler(db))
+	r.GET("/api/analyses/:analysisId/samplesheet", AnalysisSampleSheetHandler(db))
+	r.GET("/api/cases", CasesHandler(db))
+	r.GET("/api/cases/:caseId", CaseHandler(db))
+	r.GET("/api/cases/:caseId/files", CaseFilesHandler(db))
+	r.GET("/api/deliveries", DeliveriesHandler(db))
+	r.GET("/api/deliveries/:deliveryId", DeliveryHandler(db))
+	r.GET("/api/deliveries/:deliveryId/manifest", DeliveryManifestHandler(db))
+	r.GET("/api/metadata/schemas", MetadataSchemasHandler(db))
+	r.GET("/api/metadata/schemas/:schemaName", MetadataSchemaHandler(db))
 	r.GET("/api/runs", RunsHandler(db))
 	r.GET("/api/runs/:runId", RunHandler(db))
 	r.GET("/api/runs/:runId/analyses", AnalysesHandler(db))
@@ -269,27 +342,55 @@ func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client) http.Handler {
 	r.GET("/api/runs/:runId/analyses/:analysisId", AnalysisHandler(db))
 	r.GET("/api/runs/:runId/analyses/:analysisId/files", AnalysisFileHandler(db))
 	r.GET("/api/runs/:runId/analyses/:analysisId/files/prefix", AnalysisFilePrefixHandler(db))
+	r.GET("/api/runs/:runId/analyses/:analysisId/samplesheet", AnalysisSampleSheetHandler(db))
 	r.GET("/api/runs/:runId/samplesheet", RunSampleSheetHandler(db))
+	r.GET("/api/runs/:runId/samplesheet/applications", RunSampleSheetApplicationsHandler(db))
+	r.GET("/api/runs/:runId/samplesheet/diff", RunSampleSheetDiffHandler(db))
+	r.GET("/api/runs/:runId/samplesheet/revisions", RunSampleSheetRevisionsHandler(db))
+	r.GET("/api/runs/:runId/samplesheet/revisions/:revision", RunSampleSheetRevisionHandler(db))
 	r.GET("/api/runs/:runId/qc", RunQcHandler(db))
+	r.GET("/api/runs/:runId/qc/indexes", RunIndexesHandler(db))
 	r.GET("/api/runs/:runId/qc/samples", RunSamplesQcHandler(db))
 	r.GET("/api/runs/:runId/qc/samples/:sampleId", RunSampleQcHandler(db))
 	r.GET("/api/panels", PanelsHandler(db))
 	r.GET("/api/panels/:panelId", PanelHandler(db))
+	r.GET("/api/panels/:panelId/samples", PanelSamplesHandler(db))
 	r.GET("/api/platforms", PlatformsHandler(db))
 	r.GET("/api/platforms/:platformName", GetPlatformHandler(db))
+	r.GET("/api/projects", ProjectsHandler(db))
+	r.GET("/api/projects/:projectId", ProjectHandler(db))
+	r.GET("/api/projects/:projectId/deliveries", DeliveriesHandler(db))
+	r.GET("/api/projects/:projectId/runs", ProjectRunsHandler(db))
+	r.GET("/api/projects/:projectId/samples", ProjectSamplesHandler(db))
+	r.GET("/api/qc/stale", StaleRunQcHandler(db))
 	r.GET("/api/qc/:platformName", AllRunQcHandler(db))
 	r.GET("/api/samples", SamplesHandler(db))
 	r.GET("/api/samples/:sampleId", SampleHandler(db))
 	r.GET("/api/samples/:sampleId/analyses", AnalysesHandler(db))
 	r.GET("/api/samples/:sampleId/analyses/:analysisId", AnalysisHandler(db))
+	r.GET("/api/samples/:sampleId/deliveries", DeliveriesHandler(db))
+	r.GET("/api/samples/:sampleId/identity", SampleIdentityHandler(db))
+	r.GET("/api/samples/:sampleId/qc", SampleQCHandler(db))
+	r.GET("/api/samples/:sampleId/qc/multiqc", SampleMultiQCHandler(db))
+	r.GET("/api/samplesheets", SampleSheetsHandler(db))
 	r.GET("/api/samplesheets/:uuid", SampleSheetHandler(db))
+	r.POST("/api/samplesheets/generate", GenerateSampleSheetHandler(db))
+	r.POST("/api/samplesheets/validate", ValidateSampleSheetHandler())
 
 	authEndpoints := r.Group("/")
 	authEndpoints.Use(authMiddleware(db))
 	authEndpoints.POST("/api/analyses", AddAnalysisHandler(db), webhookMiddleware(webhook))
 	authEndpoints.PATCH("/api/analyses/:analysisId", UpdateAnalysisHandler(db), webhookMiddleware(webhook))
+	authEndpoints.POST("/api/cases", AddCaseHandler(db))
+	authEndpoints.PUT("/api/cases/:caseId", UpdateCaseHandler(db))
+	authEndpoints.DELETE("/api/cases/:caseId", DeleteCaseHandler(db))
+	authEndpoints.POST("/api/deliveries", AddDeliveryHandler(db))
+	authEndpoints.POST("/api/metadata/schemas", AddMetadataSchemaHandler(db))
+	authEndpoints.PUT("/api/metadata/schemas/:schemaName", UpdateMetadataSchemaHandler(db))
+	authEndpoints.DELETE("/api/metadata/schemas/:schemaName", DeleteMetadataSchemaHandler(db))
 	authEndpoints.POST("/api/panels", AddPanelHandler(db))
 	authEndpoints.PATCH("/api/panels/:panelId/archive", ArchivePanelHandler(db))
+	authEndpoints.PATCH("/api/projects/:projectId/state", UpdateProjectDeliveryStateHandler(db))
 	authEndpoints.POST("/api/runs", AddRunHandler(db), webhookMiddleware(webhook))
 	authEndpoints.PATCH("/api/runs/:runId", UpdateRunHandler(db), webhookMiddleware(webhook))
 	authEndpoints.PATCH("/api/runs/:runId/path", UpdateRunPathHandler(db), webhookMiddleware(webhook))
@@ -297,8 +398,16 @@ func NewRouter(db *mongo.DB, debug bool, webhook *webhook.Client) http.Handler {
 	authEndpoints.POST("/api/runs/:runId/samplesheet", AddRunSampleSheetHandler(db))
 	authEndpoints.POST("/api/runs/:runId/qc", AddRunQcHandler(db))
 	authEndpoints.POST("/api/samples", AddSampleHandler(db))
+	authEndpoints.PATCH("/api/samples/:sampleId", UpdateSampleHandler(db))
 	authEndpoints.POST("/api/samplesheets", AddSampleSheetHandler(db))
 
+	r.POST(
+		"/api/integrations/nextflow/weblog",
+		integrationAuthMiddleware("nextflow"),
+		NextflowWeblogHandler(db, nextflowOutputs),
+		webhookMiddleware(webhook),
+	)
+
 	r.NoRoute(func(c *gin.Context) {
 		path := c.Request.URL.Path
 		if strings.HasPrefix(path, "/api") {
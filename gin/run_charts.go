@@ -3,6 +3,8 @@ package gin
 import (
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gmc-norr/cleve/charts"
@@ -64,6 +66,74 @@ func IndexChartHandler(db *mongo.DB) gin.HandlerFunc {
 	}
 }
 
+// IndexHoppingChartHandler renders the index hopping for a lane as an i7 × i5 matrix
+// where each cell is the number of reads for that hopped index combination. The
+// expected combinations are left out, since their read counts are orders of
+// magnitude larger and would hide the hopped ones. If no lane is given, the first
+// lane with index hopping data is used.
+func IndexHoppingChartHandler(db RunQCGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runId := c.Param("runId")
+		d, err := db.RunQC(runId)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(d.IndexHopping.Lanes) == 0 {
+			c.String(http.StatusOK, "No index hopping data for this run")
+			return
+		}
+
+		laneHopping := &d.IndexHopping.Lanes[0]
+		if l := c.Query("lane"); l != "" {
+			lane, err := strconv.Atoi(l)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid lane: %s", l)})
+				return
+			}
+			laneHopping = d.IndexHopping.Lane(lane)
+			if laneHopping == nil {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no index hopping data for lane %d", lane)})
+				return
+			}
+		}
+
+		plotData := charts.HeatmapData{
+			XLabel: "i7",
+			YLabel: "i5",
+		}
+		for _, comb := range laneHopping.Combinations {
+			if !slices.Contains(plotData.XCategories, comb.Index) {
+				plotData.XCategories = append(plotData.XCategories, comb.Index)
+			}
+			if !slices.Contains(plotData.YCategories, comb.Index2) {
+				plotData.YCategories = append(plotData.YCategories, comb.Index2)
+			}
+			if !comb.Hopped {
+				continue
+			}
+			plotData.Data = append(plotData.Data, charts.HeatmapDatum{
+				X:     comb.Index,
+				Y:     comb.Index2,
+				Value: float64(comb.ReadCount),
+			})
+		}
+
+		p, err := plotData.Plot()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		s := p.RenderSnippet()
+		c.String(http.StatusOK, s.Element+s.Script)
+	}
+}
+
 func RunChartsHandler(db RunQCGetter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		runId := c.Param("runId")
@@ -0,0 +1,115 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+func TestIndexHoppingChartHandler(t *testing.T) {
+	gin.SetMode("test")
+
+	hopping := interop.IndexHoppingSummary{
+		Lanes: []interop.LaneIndexHopping{
+			{
+				Lane: 1,
+				Combinations: []interop.IndexHoppingCombination{
+					{Index: "AAAA", Index2: "CCCC", Sample: "sample1", ReadCount: 1000},
+					{Index: "AAAA", Index2: "GGGG", ReadCount: 10, Hopped: true},
+				},
+			},
+			{
+				Lane: 2,
+				Combinations: []interop.IndexHoppingCombination{
+					{Index: "TTTT", Index2: "GGGG", Sample: "sample2", ReadCount: 1000},
+				},
+			},
+		},
+	}
+
+	table := []struct {
+		name     string
+		hopping  interop.IndexHoppingSummary
+		error    error
+		query    string
+		code     int
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "first lane",
+			hopping:  hopping,
+			code:     http.StatusOK,
+			contains: []string{"AAAA", "CCCC", "GGGG"},
+			excludes: []string{"1000"},
+		},
+		{
+			name:     "specific lane",
+			hopping:  hopping,
+			query:    "?lane=2",
+			code:     http.StatusOK,
+			contains: []string{"TTTT", "GGGG"},
+		},
+		{
+			name:    "missing lane",
+			hopping: hopping,
+			query:   "?lane=3",
+			code:    http.StatusNotFound,
+		},
+		{
+			name:    "invalid lane",
+			hopping: hopping,
+			query:   "?lane=one",
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:     "no index hopping data",
+			code:     http.StatusOK,
+			contains: []string{"No index hopping data"},
+		},
+		{
+			name:  "missing run",
+			error: mongo.ErrNoDocuments,
+			code:  http.StatusNotFound,
+		},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			db := mock.RunQCGetter{
+				RunQCFn: func(runId string) (interop.InteropSummary, error) {
+					return interop.InteropSummary{RunId: runId, IndexHopping: v.hopping}, v.error
+				},
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/runs/run1/charts/index_hopping"+v.query, nil)
+			c.Params = gin.Params{{Key: "runId", Value: "run1"}}
+			IndexHoppingChartHandler(&db)(c)
+
+			if !db.RunQCInvoked {
+				t.Fatal("RunQC not invoked")
+			}
+			if w.Code != v.code {
+				t.Fatalf("expected status code %d, got %d: %s", v.code, w.Code, w.Body)
+			}
+			for _, s := range v.contains {
+				if !strings.Contains(w.Body.String(), s) {
+					t.Errorf("expected response to contain %q: %s", s, w.Body)
+				}
+			}
+			for _, s := range v.excludes {
+				if strings.Contains(w.Body.String(), s) {
+					t.Errorf("expected response to not contain %q: %s", s, w.Body)
+				}
+			}
+		})
+	}
+}
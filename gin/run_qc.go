@@ -22,6 +22,7 @@ type RunQCGetter interface {
 type RunQCSetter interface {
 	Run(string) (*cleve.Run, error)
 	CreateRunQC(string, interop.InteropSummary) error
+	UpdateFromIndexSummary(string, interop.IndexSummary) error
 }
 
 // Interface for both getting and storing run QC data.
@@ -30,6 +31,59 @@ type RunQCGetterSetter interface {
 	RunQCSetter
 }
 
+// Interface for reading run indexes and index kits from the database.
+type RunIndexGetter interface {
+	Run(string) (*cleve.Run, error)
+	RunQC(string) (interop.InteropSummary, error)
+	IndexKits() ([]cleve.IndexKit, error)
+}
+
+// RunIndexesHandler returns the indexes of a run from the index metrics, annotated with
+// index names and wells from the registered index kits. Indexes where index2 only
+// matches a kit in the orientation not used by the platform of the run are flagged,
+// and the number of such indexes is reported.
+func RunIndexesHandler(db RunIndexGetter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		runId := ctx.Param("runId")
+		run, err := db.Run(runId)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("run %s not found", runId)})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		qc, err := db.RunQC(runId)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("qc for run %s not found", runId)})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		kits, err := db.IndexKits()
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		indexes := cleve.AnnotateIndexes(qc.IndexSummary.Indexes, run.Platform, kits)
+		wrongOrientation := 0
+		for _, i := range indexes {
+			if i.WrongI5Orientation {
+				wrongOrientation++
+			}
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"run_id":               runId,
+			"platform":             run.Platform,
+			"indexes":              indexes,
+			"wrong_i5_orientation": wrongOrientation,
+		})
+	}
+}
+
 func RunQcHandler(db RunQCGetter) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		runId := ctx.Param("runId")
@@ -184,7 +238,8 @@ func AddRunQcHandler(db RunQCGetterSetter) gin.HandlerFunc {
 			return
 		}
 
-		if err := db.CreateRunQC(runId, qc.Summarise()); err != nil {
+		summary := qc.Summarise()
+		if err := db.CreateRunQC(runId, summary); err != nil {
 			if mongo.IsDuplicateKeyError(err) {
 				ctx.AbortWithStatusJSON(
 					http.StatusConflict,
@@ -198,7 +253,35 @@ func AddRunQcHandler(db RunQCGetterSetter) gin.HandlerFunc {
 			)
 			return
 		}
+		if err := db.UpdateFromIndexSummary(runId, summary.IndexSummary); err != nil {
+			ctx.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error(), "when": "storing sample read counts"},
+			)
+			return
+		}
 
 		ctx.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("run qc data added for run %s", runId)})
 	}
 }
+
+// Interface for finding runs with stale QC data.
+type StaleRunQCGetter interface {
+	StaleRunQC() ([]cleve.StaleQc, error)
+}
+
+// StaleRunQcHandler lists the runs where the QC data was computed with an older
+// version of the QC computation than the current one.
+func StaleRunQcHandler(db StaleRunQCGetter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		stale, err := db.StaleRunQC()
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"computation_version": interop.SummaryComputationVersion,
+			"runs":                stale,
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package gin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+func TestStaleRunQcHandler(t *testing.T) {
+	gin.SetMode("test")
+
+	table := []struct {
+		name  string
+		stale []cleve.StaleQc
+		error error
+		code  int
+	}{
+		{
+			name:  "no stale qc",
+			stale: []cleve.StaleQc{},
+			code:  http.StatusOK,
+		},
+		{
+			name: "stale qc",
+			stale: []cleve.StaleQc{
+				{RunId: "run1"},
+				{RunId: "run2", ComputationVersion: interop.SummaryComputationVersion - 1, ComputedAt: time.Now()},
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:  "database error",
+			error: errors.New("database error"),
+			code:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			db := mock.StaleRunQCGetter{
+				StaleRunQCFn: func() ([]cleve.StaleQc, error) {
+					return v.stale, v.error
+				},
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			StaleRunQcHandler(&db)(c)
+
+			if !db.StaleRunQCInvoked {
+				t.Fatal("StaleRunQC not invoked")
+			}
+			if w.Code != v.code {
+				t.Fatalf("expected HTTP %d, got %d", v.code, w.Code)
+			}
+			if v.code != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				ComputationVersion int             `json:"computation_version"`
+				Runs               []cleve.StaleQc `json:"runs"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.ComputationVersion != interop.SummaryComputationVersion {
+				t.Errorf("expected computation version %d, got %d", interop.SummaryComputationVersion, body.ComputationVersion)
+			}
+			if len(body.Runs) != len(v.stale) {
+				t.Errorf("expected %d stale runs, got %d", len(v.stale), len(body.Runs))
+			}
+		})
+	}
+}
+
+func TestRunIndexesHandler(t *testing.T) {
+	gin.SetMode("test")
+
+	kits := []cleve.IndexKit{
+		{
+			Name: "udi",
+			Indexes: []cleve.IndexKitIndex{
+				{Id: "UDP0001", Index: "GAACTGAGCG", Index2: "TCGTGGAGCG", Well: "A01"},
+				{Id: "UDP0002", Index: "AGGTCAGATA", Index2: "CTACAAGATA", Well: "B01"},
+			},
+		},
+	}
+
+	table := []struct {
+		name             string
+		platform         string
+		runErr           error
+		indexes          []interop.IndexSummaryRecord
+		code             int
+		names            []string
+		wrongOrientation int
+	}{
+		{
+			name:     "forward platform",
+			platform: "NovaSeq X Plus",
+			indexes: []interop.IndexSummaryRecord{
+				{Sample: "sample1", Index: "GAACTGAGCG-TCGTGGAGCG"},
+				{Sample: "sample2", Index: "AGGTCAGATA-CTACAAGATA"},
+				{Sample: "sample3", Index: "ACGTACGTAC-ACGTACGTAC"},
+			},
+			code:  http.StatusOK,
+			names: []string{"UDP0001", "UDP0002", ""},
+		},
+		{
+			name:     "wrong orientation on reverse complement platform",
+			platform: "NextSeq 5x0",
+			indexes: []interop.IndexSummaryRecord{
+				{Sample: "sample1", Index: "GAACTGAGCG-TCGTGGAGCG"},
+				{Sample: "sample2", Index: "AGGTCAGATA-TATCTTGTAG"},
+			},
+			code:             http.StatusOK,
+			names:            []string{"UDP0001", "UDP0002"},
+			wrongOrientation: 1,
+		},
+		{
+			name:   "missing run",
+			runErr: mongo.ErrNoDocuments,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, c := range table {
+		t.Run(c.name, func(t *testing.T) {
+			db := &mock.RunIndexGetter{}
+			db.RunFn = func(runId string) (*cleve.Run, error) {
+				if c.runErr != nil {
+					return nil, c.runErr
+				}
+				return &cleve.Run{RunID: runId, Platform: c.platform}, nil
+			}
+			db.RunQCFn = func(runId string) (interop.InteropSummary, error) {
+				return interop.InteropSummary{IndexSummary: interop.IndexSummary{Indexes: c.indexes}}, nil
+			}
+			db.IndexKitsFn = func() ([]cleve.IndexKit, error) {
+				return kits, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/runs/run1/qc/indexes", nil)
+			ctx.Params = []gin.Param{{Key: "runId", Value: "run1"}}
+			RunIndexesHandler(db)(ctx)
+
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				Indexes          []cleve.RunIndex `json:"indexes"`
+				WrongOrientation int              `json:"wrong_i5_orientation"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Indexes) != len(c.names) {
+				t.Fatalf("expected %d indexes, got %d", len(c.names), len(res.Indexes))
+			}
+			for i, name := range c.names {
+				if res.Indexes[i].Name != name {
+					t.Errorf("expected index %d to be %q, got %q", i, name, res.Indexes[i].Name)
+				}
+				if res.Indexes[i].Matched != (name != "") {
+					t.Errorf("expected index %d matched to be %t", i, name != "")
+				}
+			}
+			if res.WrongOrientation != c.wrongOrientation {
+				t.Errorf("expected %d indexes with wrong orientation, got %d", c.wrongOrientation, res.WrongOrientation)
+			}
+		})
+	}
+}
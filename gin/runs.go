@@ -26,6 +26,7 @@ type RunSetter interface {
 	SetRunState(string, cleve.State) error
 	SetRunPath(string, string) error
 	UpdateRunQC(interop.InteropSummary) error
+	UpdateFromIndexSummary(string, interop.IndexSummary) error
 }
 
 func RunsHandler(db RunGetter) gin.HandlerFunc {
@@ -129,10 +130,15 @@ func AddRunHandler(db RunSetter) gin.HandlerFunc {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to read run qc", "run_id": run.RunID, "error": err})
 				return
 			}
-			if err := db.UpdateRunQC(qc.Summarise()); err != nil {
+			summary := qc.Summarise()
+			if err := db.UpdateRunQC(summary); err != nil {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to add qc to run", "run_id": run.RunID, "error": err})
 				return
 			}
+			if err := db.UpdateFromIndexSummary(run.RunID, summary.IndexSummary); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to add sample read counts to run", "run_id": run.RunID, "error": err})
+				return
+			}
 		}
 
 		c.Set("webhook_message", cleve.WebhookMessageRequest{
@@ -260,10 +266,15 @@ func UpdateRunHandler(db *mongo.DB) gin.HandlerFunc {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to read qc data", "error": err})
 				return
 			}
-			if err := db.UpdateRunQC(qc.Summarise()); err != nil {
+			summary := qc.Summarise()
+			if err := db.UpdateRunQC(summary); err != nil {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to update qc data", "run": run.RunID, "error": err})
 				return
 			}
+			if err := db.UpdateFromIndexSummary(run.RunID, summary.IndexSummary); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to update sample read counts", "run": run.RunID, "error": err})
+				return
+			}
 			updated["qc"] = true
 		}
 
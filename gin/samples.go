@@ -20,6 +20,54 @@ type SampleGetter interface {
 type SampleSetter interface {
 	CreateSample(*cleve.Sample) error
 	CreateSamples([]*cleve.Sample) error
+	UpdateSample(string, cleve.SampleUpdate) error
+}
+
+// Interface for storing/updating samples where the metadata of the samples is
+// validated against the metadata schemas in the database, and where panel references
+// must refer to existing panel versions.
+type SampleEditor interface {
+	SampleSetter
+	Sample(string) (*cleve.Sample, error)
+	MetadataSchemas() ([]cleve.MetadataSchema, error)
+	Panel(string, string) (cleve.GenePanel, error)
+}
+
+var errInvalidPanelRef = errors.New("invalid panel reference")
+
+// checkPanelRefs checks that the referenced panel versions exist. If a panel version
+// does not exist, the returned error is wrapped in errInvalidPanelRef.
+func checkPanelRefs(db SampleEditor, refs []cleve.PanelRef) error {
+	for _, r := range refs {
+		_, err := db.Panel(r.Id, r.Version)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: panel %s version %s not found", errInvalidPanelRef, r.Id, r.Version)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// abortPanelRefError aborts with a bad request if the panel reference is invalid,
+// and with an internal server error otherwise.
+func abortPanelRefError(c *gin.Context, err error) {
+	if errors.Is(err, errInvalidPanelRef) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// Interface for reading sample QC data from the database.
+type SampleQCGetter interface {
+	SampleQC(string) ([]cleve.DragenSampleMetrics, error)
+}
+
+// Interface for reading MultiQC metrics of samples from the database.
+type SampleMultiQCGetter interface {
+	SampleMultiQC(string) ([]cleve.MultiQCSampleMetrics, error)
 }
 
 func SampleHandler(db SampleGetter) gin.HandlerFunc {
@@ -38,6 +86,38 @@ func SampleHandler(db SampleGetter) gin.HandlerFunc {
 	}
 }
 
+func SampleQCHandler(db SampleQCGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sampleId := c.Param("sampleId")
+		qc, err := db.SampleQC(sampleId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("qc for sample %s not found", sampleId)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, qc)
+	}
+}
+
+func SampleMultiQCHandler(db SampleMultiQCGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sampleId := c.Param("sampleId")
+		qc, err := db.SampleMultiQC(sampleId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("multiqc metrics for sample %s not found", sampleId)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, qc)
+	}
+}
+
 func SamplesHandler(db SampleGetter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filter, err := getSampleFilter(c)
@@ -62,13 +142,20 @@ func SamplesHandler(db SampleGetter) gin.HandlerFunc {
 	}
 }
 
-func AddSampleHandler(db SampleSetter) gin.HandlerFunc {
+// AddSampleHandler adds a sample. The metadata of the sample is validated against the
+// metadata schemas that apply to the project and type of the sample.
+func AddSampleHandler(db SampleEditor) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var addSampleRequest struct {
-			Id       string                  `json:"id" binding:"required"`
-			Name     string                  `json:"name" binding:"required"`
-			Fastq    []string                `json:"fastq"`
-			Analyses []*cleve.SampleAnalysis `json:"analyses"`
+			Id          string                  `json:"id" binding:"required"`
+			Name        string                  `json:"name" binding:"required"`
+			Fastq       []string                `json:"fastq"`
+			Analyses    []*cleve.SampleAnalysis `json:"analyses"`
+			TargetReads int                     `json:"target_reads" binding:"min=0"`
+			Project     string                  `json:"project"`
+			Type        string                  `json:"type"`
+			Metadata    map[string]any          `json:"metadata"`
+			Panels      []cleve.PanelRef        `json:"panels" binding:"omitempty,dive"`
 		}
 
 		if err := c.BindJSON(&addSampleRequest); err != nil {
@@ -77,10 +164,36 @@ func AddSampleHandler(db SampleSetter) gin.HandlerFunc {
 		}
 
 		sample := cleve.Sample{
-			Id:       addSampleRequest.Id,
-			Name:     addSampleRequest.Name,
-			Fastq:    addSampleRequest.Fastq,
-			Analyses: addSampleRequest.Analyses,
+			Id:          addSampleRequest.Id,
+			Name:        addSampleRequest.Name,
+			Fastq:       addSampleRequest.Fastq,
+			Analyses:    addSampleRequest.Analyses,
+			TargetReads: addSampleRequest.TargetReads,
+			Project:     addSampleRequest.Project,
+			Type:        addSampleRequest.Type,
+			Metadata:    addSampleRequest.Metadata,
+			Panels:      addSampleRequest.Panels,
+		}
+
+		schemas, err := db.MetadataSchemas()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := sample.ValidateMetadata(schemas); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		panels := sample.Panels
+		for _, a := range sample.Analyses {
+			if a != nil {
+				panels = append(panels, a.Panels...)
+			}
+		}
+		if err := checkPanelRefs(db, panels); err != nil {
+			abortPanelRefError(c, err)
+			return
 		}
 
 		if err := db.CreateSample(&sample); err != nil {
@@ -91,3 +204,86 @@ func AddSampleHandler(db SampleSetter) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "sample added", "sample_id": sample.Id})
 	}
 }
+
+// UpdateSampleHandler updates the target number of reads, the metadata and/or the
+// gene panels of a sample. The sample needs to be sequenced further if the reads
+// across all runs are below the target. Metadata replaces any existing metadata of
+// the sample, and is validated against the metadata schemas that apply to the sample.
+// Panels replace the panels of the sample, and must refer to existing panel versions.
+func UpdateSampleHandler(db SampleEditor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sampleId := c.Param("sampleId")
+
+		var updateRequest struct {
+			TargetReads *int             `json:"target_reads" binding:"omitempty,min=0"`
+			Metadata    map[string]any   `json:"metadata"`
+			Panels      []cleve.PanelRef `json:"panels" binding:"omitempty,dive"`
+		}
+
+		if err := c.ShouldBindJSON(&updateRequest); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if updateRequest.TargetReads == nil && updateRequest.Metadata == nil && updateRequest.Panels == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "target_reads, metadata or panels is required"})
+			return
+		}
+		if err := checkPanelRefs(db, updateRequest.Panels); err != nil {
+			abortPanelRefError(c, err)
+			return
+		}
+
+		notFound := func() {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("sample with ID %s not found", sampleId)})
+		}
+
+		response := gin.H{"message": "sample updated", "sample_id": sampleId}
+
+		if updateRequest.Metadata != nil {
+			sample, err := db.Sample(sampleId)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				notFound()
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			schemas, err := db.MetadataSchemas()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			sample.Metadata = updateRequest.Metadata
+			if err := sample.ValidateMetadata(schemas); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		err := db.UpdateSample(sampleId, cleve.SampleUpdate{
+			TargetReads: updateRequest.TargetReads,
+			Metadata:    updateRequest.Metadata,
+			Panels:      updateRequest.Panels,
+		})
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			notFound()
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if updateRequest.TargetReads != nil {
+			response["target_reads"] = *updateRequest.TargetReads
+		}
+		if updateRequest.Metadata != nil {
+			response["metadata"] = updateRequest.Metadata
+		}
+		if updateRequest.Panels != nil {
+			response["panels"] = updateRequest.Panels
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
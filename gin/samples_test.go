@@ -122,6 +122,31 @@ func TestSamples(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "metadata filtering",
+			code: http.StatusOK,
+			url:  "/samples?metadata=tissue:blood,capture_kit:twist",
+			expectedFilter: cleve.SampleFilter{
+				Metadata: "tissue:blood,capture_kit:twist",
+				PaginationFilter: cleve.PaginationFilter{
+					Page:     1,
+					PageSize: 10,
+				},
+			},
+		},
+		{
+			name:        "invalid metadata filter",
+			code:        http.StatusBadRequest,
+			url:         "/samples?metadata=tissue",
+			filterError: fmt.Errorf(`invalid metadata filter "tissue", expected key:value`),
+			expectedFilter: cleve.SampleFilter{
+				Metadata: "tissue",
+				PaginationFilter: cleve.PaginationFilter{
+					Page:     1,
+					PageSize: 10,
+				},
+			},
+		},
 		{
 			name:        "illegal page number -1",
 			code:        http.StatusBadRequest,
@@ -200,7 +225,10 @@ func TestSamples(t *testing.T) {
 func TestCreateSample(t *testing.T) {
 	t.Run("add sample", func(t *testing.T) {
 		sampleCollection := make([]*cleve.Sample, 0)
-		ss := mock.SampleSetter{}
+		ss := mock.SampleEditor{}
+		ss.MetadataSchemasFn = func() ([]cleve.MetadataSchema, error) {
+			return []cleve.MetadataSchema{}, nil
+		}
 		ss.CreateSampleFn = func(sample *cleve.Sample) error {
 			sampleCollection = append(sampleCollection, sample)
 			return nil
@@ -253,7 +281,7 @@ func TestCreateSample(t *testing.T) {
 	})
 
 	t.Run("missing name", func(t *testing.T) {
-		ss := mock.SampleSetter{}
+		ss := mock.SampleEditor{}
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -281,3 +309,467 @@ func TestCreateSample(t *testing.T) {
 		}
 	})
 }
+
+func TestSampleQC(t *testing.T) {
+	testcases := []struct {
+		name    string
+		metrics []cleve.DragenSampleMetrics
+		err     error
+		code    int
+	}{
+		{
+			name: "sample with qc",
+			metrics: []cleve.DragenSampleMetrics{
+				cleve.NewDragenSampleMetrics("sample1"),
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "sample without qc",
+			err:  mongo.ErrNoDocuments,
+			code: http.StatusNotFound,
+		},
+		{
+			name: "database error",
+			err:  fmt.Errorf("database error"),
+			code: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			sg := mock.SampleQCGetter{}
+			sg.SampleQCFn = func(sampleId string) ([]cleve.DragenSampleMetrics, error) {
+				return tc.metrics, tc.err
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{gin.Param{Key: "sampleId", Value: "sample1"}}
+
+			SampleQCHandler(&sg)(c)
+
+			if !sg.SampleQCInvoked {
+				t.Error("SampleQC was not invoked")
+			}
+			if w.Code != tc.code {
+				t.Fatalf("expected status %d, got %d", tc.code, w.Code)
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+			var metrics []map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &metrics); err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != len(tc.metrics) {
+				t.Errorf("expected %d entries, got %d", len(tc.metrics), len(metrics))
+			}
+			if metrics[0]["mean_coverage"] != nil {
+				t.Errorf("expected missing coverage to be null, got %v", metrics[0]["mean_coverage"])
+			}
+		})
+	}
+}
+
+func TestSampleMultiQC(t *testing.T) {
+	testcases := []struct {
+		name    string
+		metrics []cleve.MultiQCSampleMetrics
+		err     error
+		code    int
+	}{
+		{
+			name: "sample with multiqc metrics",
+			metrics: []cleve.MultiQCSampleMetrics{
+				{SampleId: "sample1", Source: "multiqc/multiqc_data.json"},
+				{SampleId: "sample1", Source: "qc/multiqc_data.json"},
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "sample without multiqc metrics",
+			err:  mongo.ErrNoDocuments,
+			code: http.StatusNotFound,
+		},
+		{
+			name: "database error",
+			err:  fmt.Errorf("database error"),
+			code: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			sg := mock.SampleQCGetter{}
+			sg.SampleMultiQCFn = func(sampleId string) ([]cleve.MultiQCSampleMetrics, error) {
+				return tc.metrics, tc.err
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{gin.Param{Key: "sampleId", Value: "sample1"}}
+
+			SampleMultiQCHandler(&sg)(c)
+
+			if !sg.SampleMultiQCInvoked {
+				t.Error("SampleMultiQC was not invoked")
+			}
+			if w.Code != tc.code {
+				t.Fatalf("expected status %d, got %d", tc.code, w.Code)
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+			var metrics []map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &metrics); err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != len(tc.metrics) {
+				t.Errorf("expected %d entries, got %d", len(tc.metrics), len(metrics))
+			}
+			if metrics[0]["source"] != tc.metrics[0].Source {
+				t.Errorf("expected source %q, got %v", tc.metrics[0].Source, metrics[0]["source"])
+			}
+		})
+	}
+}
+
+func TestUpdateSample(t *testing.T) {
+	testcases := []struct {
+		name    string
+		body    string
+		err     error
+		invoked bool
+		code    int
+	}{
+		{
+			name:    "set target",
+			body:    `{"target_reads": 20000000}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name:    "remove target",
+			body:    `{"target_reads": 0}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name: "negative target",
+			body: `{"target_reads": -1}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "missing target",
+			body: `{}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "missing sample",
+			body:    `{"target_reads": 100}`,
+			err:     mongo.ErrNoDocuments,
+			invoked: true,
+			code:    http.StatusNotFound,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			ss := mock.SampleEditor{}
+			ss.UpdateSampleFn = func(sampleId string, update cleve.SampleUpdate) error {
+				if sampleId != "S1" {
+					t.Errorf("expected sample S1, got %s", sampleId)
+				}
+				if update.TargetReads == nil || update.Metadata != nil || update.Panels != nil {
+					t.Errorf("expected only the target reads to be updated, got %+v", update)
+				}
+				return c.err
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "sampleId", Value: "S1"}}
+			ctx.Request, _ = http.NewRequest("PATCH", "/api/samples/S1", bytes.NewBufferString(c.body))
+
+			UpdateSampleHandler(&ss)(ctx)
+
+			if ss.UpdateSampleInvoked != c.invoked {
+				t.Errorf("expected UpdateSample invoked to be %t", c.invoked)
+			}
+			if w.Code != c.code {
+				t.Errorf("expected %d, got %d", c.code, w.Code)
+			}
+		})
+	}
+}
+
+func TestSampleMetadata(t *testing.T) {
+	schemas := []cleve.MetadataSchema{
+		{
+			Name:    "germline",
+			Project: "P1",
+			Schema: cleve.JSONSchema{
+				Type:     "object",
+				Required: []string{"tissue"},
+				Properties: map[string]*cleve.JSONSchema{
+					"tissue": {Type: "string", Enum: []any{"blood", "saliva"}},
+					"dna":    {Type: "number", Minimum: new(float64)},
+				},
+			},
+		},
+	}
+
+	t.Run("add", func(t *testing.T) {
+		testcases := []struct {
+			name    string
+			body    string
+			invoked bool
+			code    int
+		}{
+			{
+				name:    "valid metadata",
+				body:    `{"id": "S1", "name": "S1", "project": "P1", "metadata": {"tissue": "blood", "dna": 12.5}}`,
+				invoked: true,
+				code:    http.StatusOK,
+			},
+			{
+				name: "missing required metadata",
+				body: `{"id": "S1", "name": "S1", "project": "P1"}`,
+				code: http.StatusBadRequest,
+			},
+			{
+				name: "invalid metadata value",
+				body: `{"id": "S1", "name": "S1", "project": "P1", "metadata": {"tissue": "blood", "dna": -1}}`,
+				code: http.StatusBadRequest,
+			},
+			{
+				name:    "schema for other project",
+				body:    `{"id": "S1", "name": "S1", "project": "P2", "metadata": {"tissue": "skin"}}`,
+				invoked: true,
+				code:    http.StatusOK,
+			},
+		}
+
+		for _, c := range testcases {
+			t.Run(c.name, func(t *testing.T) {
+				ss := mock.SampleEditor{}
+				ss.MetadataSchemasFn = func() ([]cleve.MetadataSchema, error) {
+					return schemas, nil
+				}
+				ss.CreateSampleFn = func(*cleve.Sample) error {
+					return nil
+				}
+
+				w := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(w)
+				ctx.Request, _ = http.NewRequest("POST", "/api/samples", bytes.NewBufferString(c.body))
+
+				AddSampleHandler(&ss)(ctx)
+
+				if ss.CreateSampleInvoked != c.invoked {
+					t.Errorf("expected CreateSample invoked to be %t", c.invoked)
+				}
+				if w.Code != c.code {
+					t.Errorf("expected %d, got %d: %s", c.code, w.Code, w.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		testcases := []struct {
+			name      string
+			body      string
+			sampleErr error
+			invoked   bool
+			code      int
+		}{
+			{
+				name:    "valid metadata",
+				body:    `{"metadata": {"tissue": "saliva"}}`,
+				invoked: true,
+				code:    http.StatusOK,
+			},
+			{
+				name: "invalid metadata",
+				body: `{"metadata": {"tissue": "skin"}}`,
+				code: http.StatusBadRequest,
+			},
+			{
+				name:      "missing sample",
+				body:      `{"metadata": {"tissue": "saliva"}}`,
+				sampleErr: mongo.ErrNoDocuments,
+				code:      http.StatusNotFound,
+			},
+		}
+
+		for _, c := range testcases {
+			t.Run(c.name, func(t *testing.T) {
+				ss := mock.SampleEditor{}
+				ss.SampleFn = func(sampleId string) (*cleve.Sample, error) {
+					if c.sampleErr != nil {
+						return nil, c.sampleErr
+					}
+					return &cleve.Sample{Id: sampleId, Project: "P1"}, nil
+				}
+				ss.MetadataSchemasFn = func() ([]cleve.MetadataSchema, error) {
+					return schemas, nil
+				}
+				ss.UpdateSampleFn = func(sampleId string, update cleve.SampleUpdate) error {
+					if update.Metadata["tissue"] != "saliva" {
+						t.Errorf("expected tissue saliva, got %v", update.Metadata["tissue"])
+					}
+					if update.TargetReads != nil {
+						t.Error("expected target reads not to be updated")
+					}
+					return nil
+				}
+
+				w := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(w)
+				ctx.Params = gin.Params{{Key: "sampleId", Value: "S1"}}
+				ctx.Request, _ = http.NewRequest("PATCH", "/api/samples/S1", bytes.NewBufferString(c.body))
+
+				UpdateSampleHandler(&ss)(ctx)
+
+				if ss.UpdateSampleInvoked != c.invoked {
+					t.Errorf("expected UpdateSample invoked to be %t", c.invoked)
+				}
+				if w.Code != c.code {
+					t.Errorf("expected %d, got %d: %s", c.code, w.Code, w.Body.String())
+				}
+			})
+		}
+	})
+}
+
+func TestSamplePanels(t *testing.T) {
+	testcases := []struct {
+		name    string
+		body    string
+		invoked bool
+		code    int
+	}{
+		{
+			name:    "existing panel version",
+			body:    `{"panels": [{"id": "cardio", "version": "1.0"}]}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name:    "remove panels",
+			body:    `{"panels": []}`,
+			invoked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name: "missing panel version",
+			body: `{"panels": [{"id": "cardio", "version": "2.0"}]}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "incomplete panel reference",
+			body: `{"panels": [{"id": "cardio"}]}`,
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			ss := mock.SampleEditor{}
+			ss.PanelFn = func(panelId string, version string) (cleve.GenePanel, error) {
+				if panelId == "cardio" && version == "1.0" {
+					return cleve.GenePanel{Id: panelId}, nil
+				}
+				return cleve.GenePanel{}, mongo.ErrNoDocuments
+			}
+			ss.UpdateSampleFn = func(sampleId string, update cleve.SampleUpdate) error {
+				if update.Panels == nil {
+					t.Error("expected panels to be updated")
+				}
+				return nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "sampleId", Value: "S1"}}
+			ctx.Request, _ = http.NewRequest("PATCH", "/api/samples/S1", bytes.NewBufferString(c.body))
+
+			UpdateSampleHandler(&ss)(ctx)
+
+			if ss.UpdateSampleInvoked != c.invoked {
+				t.Errorf("expected UpdateSample invoked to be %t", c.invoked)
+			}
+			if w.Code != c.code {
+				t.Errorf("expected %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPanelSamples(t *testing.T) {
+	testcases := []struct {
+		name           string
+		url            string
+		panelErr       error
+		code           int
+		expectedFilter cleve.SampleFilter
+	}{
+		{
+			name: "all versions",
+			url:  "/api/panels/cardio/samples",
+			code: http.StatusOK,
+			expectedFilter: cleve.SampleFilter{
+				Panel:            "cardio",
+				PaginationFilter: cleve.NewPaginationFilter(),
+			},
+		},
+		{
+			name: "specific version",
+			url:  "/api/panels/cardio/samples?version=1.0&project=P1",
+			code: http.StatusOK,
+			expectedFilter: cleve.SampleFilter{
+				Panel:            "cardio",
+				PanelVersion:     "1.0",
+				Project:          "P1",
+				PaginationFilter: cleve.NewPaginationFilter(),
+			},
+		},
+		{
+			name:     "missing panel",
+			url:      "/api/panels/cardio/samples",
+			panelErr: mongo.ErrNoDocuments,
+			code:     http.StatusNotFound,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.PanelSampleGetter{}
+			db.PanelFn = func(panelId string, version string) (cleve.GenePanel, error) {
+				return cleve.GenePanel{Id: panelId}, c.panelErr
+			}
+			db.SamplesFn = func(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
+				if *filter != c.expectedFilter {
+					t.Errorf("expected filter %+v, got %+v", c.expectedFilter, *filter)
+				}
+				return &cleve.SampleResult{}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "panelId", Value: "cardio"}}
+			ctx.Request = httptest.NewRequest("GET", c.url, nil)
+
+			PanelSamplesHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if db.SamplesInvoked != (c.code == http.StatusOK) {
+				t.Errorf("expected Samples invoked to be %t", c.code == http.StatusOK)
+			}
+		})
+	}
+}
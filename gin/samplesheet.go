@@ -1,13 +1,19 @@
 package gin
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
 	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
 )
 
 // Interface for reading samplesheets from the database.
@@ -193,3 +199,350 @@ func SampleSheetHandler(db SampleSheetGetter) func(*gin.Context) {
 		c.AbortWithStatus(http.StatusNotImplemented)
 	}
 }
+
+// RunSampleSheetApplicationsHandler lists the secondary analysis applications that each
+// sample is configured for in the samplesheet of a run.
+func RunSampleSheetApplicationsHandler(db SampleSheetGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runId := c.Param("runId")
+		sampleSheet, err := db.SampleSheet(mongo.SampleSheetWithRunId(runId))
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no samplesheet found for run %q", runId)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		samples := sampleSheet.ApplicationsBySample()
+		if samples == nil {
+			samples = []cleve.SampleSheetSampleApplications{}
+		}
+		c.JSON(http.StatusOK, gin.H{"run_id": runId, "samples": samples})
+	}
+}
+
+// Interface for listing samplesheets in the database.
+type SampleSheetsGetter interface {
+	SampleSheets(cleve.SampleSheetFilter) (*cleve.SampleSheetResult, error)
+}
+
+// SampleSheetsHandler lists samplesheets, optionally filtered by run, UUID, sample,
+// project, index sequence and modification time.
+func SampleSheetsHandler(db SampleSheetsGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := getSampleSheetFilter(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sampleSheets, err := db.SampleSheets(filter)
+		if errors.As(err, &mongo.PageOutOfBoundsError{}) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if sampleSheets.SampleSheets == nil {
+			sampleSheets.SampleSheets = []cleve.SampleSheet{}
+		}
+		c.JSON(http.StatusOK, sampleSheets)
+	}
+}
+
+// Interface for reading the samplesheet of an analysis from the database.
+type AnalysisSampleSheetGetter interface {
+	Analysis(analysisId uuid.UUID, runId ...string) (*cleve.Analysis, error)
+	SampleSheetGetter
+}
+
+// AnalysisSampleSheetHandler returns the samplesheet that was used by an analysis.
+// If the analysis does not have a samplesheet of its own, 404 is returned.
+func AnalysisSampleSheetHandler(db AnalysisSampleSheetGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		analysisId, err := uuid.Parse(c.Param("analysisId"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "invalid analysis ID", "details": err})
+			return
+		}
+		runId := c.Param("runId")
+		analysis, err := db.Analysis(analysisId, runId)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "analysis not found", "analysis_id": analysisId})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if analysis.SampleSheetId == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no samplesheet found for analysis", "analysis_id": analysisId})
+			return
+		}
+		sampleSheet, err := db.SampleSheet(mongo.SampleSheetWithUuid(analysis.SampleSheetId.String()))
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no samplesheet found for analysis", "analysis_id": analysisId})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, sampleSheet)
+	}
+}
+
+// ValidateSampleSheetHandler validates an uploaded samplesheet. The samplesheet is
+// uploaded as a multipart form file with the key `samplesheet`. Samplesheets that
+// cannot be parsed are reported as invalid with a single parse error finding.
+func ValidateSampleSheetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fh, err := c.FormFile("samplesheet")
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("samplesheet file required: %s", err.Error())})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer func() { _ = f.Close() }()
+
+		var runInfo *interop.RunInfo
+		if rh, err := c.FormFile("runinfo"); err == nil {
+			rf, err := rh.Open()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			ri, err := interop.ParseRunInfo(rf)
+			_ = rf.Close()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid run info: %s", err.Error())})
+				return
+			}
+			runInfo = &ri
+		}
+
+		var findings cleve.ValidationFindings
+		sampleSheet, err := cleve.ParseSampleSheet(bufio.NewReader(f))
+		if err != nil {
+			findings = cleve.ValidationFindings{{
+				Severity: cleve.SeverityError,
+				Code:     cleve.FindingParseError,
+				Message:  err.Error(),
+			}}
+		} else if runInfo != nil {
+			findings = sampleSheet.ValidateRunInfo(*runInfo)
+		} else {
+			findings = sampleSheet.Validate()
+		}
+		if findings == nil {
+			findings = cleve.ValidationFindings{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"filename": fh.Filename,
+			"valid":    findings.Valid(),
+			"errors":   findings.Errors(),
+			"warnings": findings.Warnings(),
+			"findings": findings,
+		})
+	}
+}
+
+// Interface for reading index kits from the database.
+type IndexKitGetter interface {
+	IndexKit(string) (cleve.IndexKit, error)
+}
+
+// GenerateSampleSheetHandler generates a v2 samplesheet from a JSON run plan. Indexes
+// referenced by ID are looked up in the registered index kits. The generated
+// samplesheet is validated, and if it has errors the findings are returned with status
+// 422. By default the samplesheet is returned as a JSON object together with the
+// validation findings, but with the query parameter `format=csv` the samplesheet itself
+// is returned.
+func GenerateSampleSheetHandler(db IndexKitGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "csv" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid format %q, must be json or csv", format)})
+			return
+		}
+
+		var plan cleve.SampleSheetPlan
+		if err := c.ShouldBindJSON(&plan); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid run plan: %s", err.Error())})
+			return
+		}
+
+		sampleSheet, err := plan.SampleSheet(func(name string) (cleve.IndexKit, error) {
+			kit, err := db.IndexKit(name)
+			if err == mongo.ErrNoDocuments {
+				return kit, fmt.Errorf("index kit %q is not registered", name)
+			}
+			return kit, err
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var b strings.Builder
+		if err := sampleSheet.WriteCSV(&b); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		findings := cleve.ValidateSampleSheetCSV(strings.NewReader(b.String()))
+		if findings == nil {
+			findings = cleve.ValidationFindings{}
+		}
+		if !findings.Valid() {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    "generated samplesheet is invalid",
+				"valid":    false,
+				"findings": findings,
+			})
+			return
+		}
+
+		if format == "csv" {
+			c.Header("Content-Disposition", "attachment; filename=SampleSheet.csv")
+			c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(b.String()))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"valid":       true,
+			"findings":    findings,
+			"samplesheet": b.String(),
+		})
+	}
+}
+
+// Interface for reading samplesheet revisions from the database.
+type SampleSheetRevisionGetter interface {
+	SampleSheetRevisions(string) ([]cleve.SampleSheetRevision, error)
+	SampleSheetRevision(string, int) (cleve.SampleSheetRevision, error)
+}
+
+// RunSampleSheetRevisionsHandler lists all revisions of the samplesheet for a run,
+// without the samplesheet content.
+func RunSampleSheetRevisionsHandler(db SampleSheetRevisionGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runId := c.Param("runId")
+		revisions, err := db.SampleSheetRevisions(runId)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no samplesheet revisions found for run %q", runId)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"run_id": runId, "revisions": revisions})
+	}
+}
+
+// RunSampleSheetRevisionHandler returns a single revision of the samplesheet for a run.
+func RunSampleSheetRevisionHandler(db SampleSheetRevisionGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runId := c.Param("runId")
+		revision, err := strconv.Atoi(c.Param("revision"))
+		if err != nil || revision < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid revision %q", c.Param("revision"))})
+			return
+		}
+		r, err := db.SampleSheetRevision(runId, revision)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("revision %d of the samplesheet for run %q not found", revision, runId)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	}
+}
+
+// RunSampleSheetDiffHandler compares two revisions of the samplesheet for a run. The
+// revisions are given by the query parameters `from` and `to`. By default the latest
+// revision is compared to the one before it.
+func RunSampleSheetDiffHandler(db SampleSheetRevisionGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runId := c.Param("runId")
+
+		revisionParam := func(name string) (int, error) {
+			v := c.Query(name)
+			if v == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid revision %q for %s", v, name)
+			}
+			return n, nil
+		}
+		from, err := revisionParam("from")
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		to, err := revisionParam("to")
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if to == 0 || from == 0 {
+			revisions, err := db.SampleSheetRevisions(runId)
+			if err != nil {
+				if err == mongo.ErrNoDocuments {
+					c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no samplesheet revisions found for run %q", runId)})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if to == 0 {
+				to = revisions[len(revisions)-1].Revision
+			}
+			if from == 0 {
+				from = to - 1
+			}
+		}
+		if from < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("there is no revision before revision %d to compare with", to)})
+			return
+		}
+
+		var sheets [2]cleve.SampleSheet
+		for i, revision := range []int{from, to} {
+			r, err := db.SampleSheetRevision(runId, revision)
+			if err != nil {
+				if err == mongo.ErrNoDocuments {
+					c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("revision %d of the samplesheet for run %q not found", revision, runId)})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			sheets[i] = r.SampleSheet()
+		}
+
+		diff := cleve.DiffSampleSheets(sheets[0], sheets[1])
+		c.JSON(http.StatusOK, gin.H{
+			"run_id":   runId,
+			"from":     from,
+			"to":       to,
+			"equal":    diff.Equal(),
+			"critical": diff.Critical,
+			"sections": diff.Sections,
+		})
+	}
+}
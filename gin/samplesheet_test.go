@@ -1,15 +1,23 @@
 package gin
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gmc-norr/cleve"
 	"github.com/gmc-norr/cleve/mock"
 	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -184,3 +192,673 @@ func TestAddSampleSheet(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSampleSheet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name     string
+		content  string
+		runInfo  string
+		noFile   bool
+		code     int
+		valid    bool
+		errors   int
+		warnings int
+	}{
+		{
+			name: "valid samplesheet",
+			content: `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+[BCLConvert_Data]
+Sample_ID,Index
+sample1,ACGTACGT
+sample2,TGCATGCA
+`,
+			code:  http.StatusOK,
+			valid: true,
+		},
+		{
+			name: "index collision",
+			content: `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+[BCLConvert_Data]
+Sample_ID,Index
+sample1,ACGTACGT
+sample2,ACGTACGA
+sample 3,TGCATGC
+`,
+			code:     http.StatusOK,
+			errors:   2,
+			warnings: 1,
+		},
+		{
+			name: "override cycles against run info",
+			content: `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+OverrideCycles,Y151;I8
+[BCLConvert_Data]
+Sample_ID,Index
+sample1,ACGTACGT
+`,
+			runInfo: `<?xml version="1.0"?>
+<RunInfo Version="6">
+	<Run Id="run1" Number="1">
+		<Flowcell>HXXXXXXXX</Flowcell>
+		<Instrument>A00001</Instrument>
+		<Date>2024-01-01T00:00:00Z</Date>
+		<Reads>
+			<Read Number="1" NumCycles="151" IsIndexedRead="N" IsReverseComplemented="N"/>
+			<Read Number="2" NumCycles="10" IsIndexedRead="Y" IsReverseComplemented="N"/>
+		</Reads>
+	</Run>
+</RunInfo>`,
+			code:   http.StatusOK,
+			errors: 1,
+		},
+		{
+			name:    "invalid run info",
+			content: "[Header]\nFileFormatVersion,2\n[Reads]\nRead1Cycles,151\n",
+			runInfo: "not xml",
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "unparsable samplesheet",
+			content: "[Header]\nFileFormatVersion,2\n",
+			code:    http.StatusOK,
+			errors:  1,
+		},
+		{
+			name:   "no file",
+			noFile: true,
+			code:   http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			if !c.noFile {
+				fw, err := mw.CreateFormFile("samplesheet", "SampleSheet.csv")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := fw.Write([]byte(c.content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if c.runInfo != "" {
+				fw, err := mw.CreateFormFile("runinfo", "RunInfo.xml")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := fw.Write([]byte(c.runInfo)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/samplesheets/validate", &body)
+			ctx.Request.Header.Set("Content-Type", mw.FormDataContentType())
+			ValidateSampleSheetHandler()(ctx)
+
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				Valid    bool                     `json:"valid"`
+				Errors   int                      `json:"errors"`
+				Warnings int                      `json:"warnings"`
+				Findings []map[string]interface{} `json:"findings"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Valid != c.valid {
+				t.Errorf("expected valid to be %t, got %t", c.valid, res.Valid)
+			}
+			if res.Errors != c.errors || res.Warnings != c.warnings {
+				t.Errorf("expected %d errors and %d warnings, got %d and %d: %v", c.errors, c.warnings, res.Errors, res.Warnings, res.Findings)
+			}
+			if len(res.Findings) != c.errors+c.warnings {
+				t.Errorf("expected %d findings, got %d", c.errors+c.warnings, len(res.Findings))
+			}
+		})
+	}
+}
+
+func TestGenerateSampleSheet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	kit := cleve.IndexKit{Name: "udi", Indexes: []cleve.IndexKitIndex{
+		{Id: "UDP0001", Index: "GAACTGAGCG", Index2: "TCGTGGAGCG"},
+		{Id: "UDP0002", Index: "AGGTCAGATA", Index2: "CTACAAGATA"},
+	}}
+
+	cases := []struct {
+		name        string
+		plan        string
+		format      string
+		code        int
+		contentType string
+	}{
+		{
+			name: "valid plan",
+			plan: `{
+				"reads": {"read1_cycles": 151, "index1_cycles": 10, "index2_cycles": 10},
+				"index_kit": "udi",
+				"bclconvert": {"SoftwareVersion": "4.2.7"},
+				"samples": [
+					{"sample_id": "sample1", "index_id": "UDP0001"},
+					{"sample_id": "sample2", "index_id": "UDP0002"}
+				]
+			}`,
+			code:        http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+		},
+		{
+			name: "valid plan as csv",
+			plan: `{
+				"reads": {"read1_cycles": 151, "index1_cycles": 10, "index2_cycles": 10},
+				"index_kit": "udi",
+				"bclconvert": {"SoftwareVersion": "4.2.7"},
+				"samples": [{"sample_id": "sample1", "index_id": "UDP0001"}]
+			}`,
+			format:      "csv",
+			code:        http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+		},
+		{
+			name: "invalid samplesheet",
+			plan: `{
+				"reads": {"read1_cycles": 151, "index1_cycles": 10, "index2_cycles": 10},
+				"index_kit": "udi",
+				"samples": [
+					{"sample_id": "sample1", "index_id": "UDP0001"},
+					{"sample_id": "sample2", "index_id": "UDP0001"}
+				]
+			}`,
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "unregistered index kit",
+			plan: `{
+				"reads": {"read1_cycles": 151},
+				"index_kit": "missing",
+				"samples": [{"sample_id": "sample1", "index_id": "UDP0001"}]
+			}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid json",
+			plan: `{"reads": `,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid format",
+			plan:   `{}`,
+			format: "xml",
+			code:   http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			getter := &mock.IndexKitGetter{}
+			getter.IndexKitFn = func(name string) (cleve.IndexKit, error) {
+				if name == kit.Name {
+					return kit, nil
+				}
+				return cleve.IndexKit{}, mongo.ErrNoDocuments
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			url := "/api/samplesheets/generate"
+			if c.format != "" {
+				url += "?format=" + c.format
+			}
+			ctx.Request = httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(c.plan))
+			ctx.Request.Header.Set("Content-Type", "application/json")
+			GenerateSampleSheetHandler(getter)(ctx)
+
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != c.contentType {
+				t.Errorf("expected content type %q, got %q", c.contentType, ct)
+			}
+
+			samplesheet := w.Body.String()
+			if c.format != "csv" {
+				var res struct {
+					Valid       bool   `json:"valid"`
+					SampleSheet string `json:"samplesheet"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+					t.Fatal(err)
+				}
+				if !res.Valid {
+					t.Error("expected samplesheet to be valid")
+				}
+				samplesheet = res.SampleSheet
+			}
+			sheet, err := cleve.ParseSampleSheet(bufio.NewReader(strings.NewReader(samplesheet)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sheet.Section("BCLConvert_Data") == nil {
+				t.Error("expected a BCLConvert_Data section")
+			}
+		})
+	}
+}
+
+func TestRunSampleSheetDiff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	revisionSheets := map[int]string{
+		1: "[Header]\nFileFormatVersion,2\n[Reads]\nRead1Cycles,151\n[BCLConvert_Data]\nSample_ID,Index\nsample1,ACGTACGT\nsample2,TGCATGCA\n",
+		2: "[Header]\nFileFormatVersion,2\n[Reads]\nRead1Cycles,151\n[BCLConvert_Data]\nSample_ID,Index\nsample1,ACGTACGT\nsample2,TGCATGCC\n",
+		3: "[Header]\nFileFormatVersion,2\nRunName,run1\n[Reads]\nRead1Cycles,151\n[BCLConvert_Data]\nSample_ID,Index\nsample1,ACGTACGT\nsample2,TGCATGCC\n",
+	}
+
+	cases := []struct {
+		name     string
+		query    string
+		code     int
+		from     int
+		to       int
+		critical int
+	}{
+		{
+			name: "latest against previous",
+			code: http.StatusOK,
+			from: 2,
+			to:   3,
+		},
+		{
+			name:     "explicit revisions",
+			query:    "?from=1&to=2",
+			code:     http.StatusOK,
+			from:     1,
+			to:       2,
+			critical: 1,
+		},
+		{
+			name:     "only to",
+			query:    "?to=2",
+			code:     http.StatusOK,
+			from:     1,
+			to:       2,
+			critical: 1,
+		},
+		{
+			name:  "nothing before first revision",
+			query: "?to=1",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "missing revision",
+			query: "?from=1&to=4",
+			code:  http.StatusNotFound,
+		},
+		{
+			name:  "invalid revision",
+			query: "?from=a",
+			code:  http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			getter := &mock.SampleSheetRevisionGetter{}
+			getter.SampleSheetRevisionsFn = func(runId string) ([]cleve.SampleSheetRevision, error) {
+				return []cleve.SampleSheetRevision{{Revision: 1}, {Revision: 2}, {Revision: 3}}, nil
+			}
+			getter.SampleSheetRevisionFn = func(runId string, revision int) (cleve.SampleSheetRevision, error) {
+				data, ok := revisionSheets[revision]
+				if !ok {
+					return cleve.SampleSheetRevision{}, mongo.ErrNoDocuments
+				}
+				sheet, err := cleve.ParseSampleSheet(bufio.NewReader(strings.NewReader(data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return cleve.SampleSheetRevision{Revision: revision, Sections: sheet.Sections}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/runs/run1/samplesheet/diff"+c.query, nil)
+			ctx.Params = []gin.Param{{Key: "runId", Value: "run1"}}
+			RunSampleSheetDiffHandler(getter)(ctx)
+
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				From     int                 `json:"from"`
+				To       int                 `json:"to"`
+				Equal    bool                `json:"equal"`
+				Critical int                 `json:"critical"`
+				Sections []cleve.SectionDiff `json:"sections"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.From != c.from || res.To != c.to {
+				t.Errorf("expected diff from %d to %d, got %d to %d", c.from, c.to, res.From, res.To)
+			}
+			if res.Equal || len(res.Sections) != 1 {
+				t.Errorf("expected one changed section, got %+v", res.Sections)
+			}
+			if res.Critical != c.critical {
+				t.Errorf("expected %d critical changes, got %d", c.critical, res.Critical)
+			}
+		})
+	}
+}
+
+func TestAnalysisSampleSheet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	analysisId := uuid.New()
+	sampleSheetId := uuid.New()
+
+	cases := []struct {
+		name          string
+		analysisId    string
+		sampleSheetId *uuid.UUID
+		stored        bool
+		code          int
+	}{
+		{
+			name:          "analysis with samplesheet",
+			analysisId:    analysisId.String(),
+			sampleSheetId: &sampleSheetId,
+			stored:        true,
+			code:          http.StatusOK,
+		},
+		{
+			name:       "analysis without samplesheet",
+			analysisId: analysisId.String(),
+			code:       http.StatusNotFound,
+		},
+		{
+			name:          "samplesheet not stored",
+			analysisId:    analysisId.String(),
+			sampleSheetId: &sampleSheetId,
+			code:          http.StatusNotFound,
+		},
+		{
+			name:       "missing analysis",
+			analysisId: uuid.NewString(),
+			code:       http.StatusNotFound,
+		},
+		{
+			name:       "invalid analysis id",
+			analysisId: "analysis1",
+			code:       http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			getter := &mock.AnalysisSampleSheetGetter{}
+			getter.AnalysisFn = func(id uuid.UUID, runId ...string) (*cleve.Analysis, error) {
+				if id != analysisId {
+					return nil, mongo.ErrNoDocuments
+				}
+				return &cleve.Analysis{AnalysisId: analysisId, SampleSheetId: c.sampleSheetId}, nil
+			}
+			getter.SampleSheetFn = func(opts ...mongo.SampleSheetOption) (cleve.SampleSheet, error) {
+				if !c.stored {
+					return cleve.SampleSheet{}, mongo.ErrNoDocuments
+				}
+				return cleve.SampleSheet{UUID: &sampleSheetId, AnalysisId: &analysisId}, nil
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/analyses/"+c.analysisId+"/samplesheet", nil)
+			ctx.Params = []gin.Param{{Key: "analysisId", Value: c.analysisId}}
+			AnalysisSampleSheetHandler(getter)(ctx)
+
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code != http.StatusOK {
+				return
+			}
+			var res cleve.SampleSheet
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.UUID == nil || *res.UUID != sampleSheetId {
+				t.Errorf("expected samplesheet %s, got %v", sampleSheetId, res.UUID)
+			}
+			if res.AnalysisId == nil || *res.AnalysisId != analysisId {
+				t.Errorf("expected analysis %s, got %v", analysisId, res.AnalysisId)
+			}
+		})
+	}
+}
+
+func TestSampleSheetsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ssId := uuid.MustParse("92f356e3-4d4b-4c8a-bbe2-a886bfd0f63c")
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name           string
+		url            string
+		code           int
+		dbError        error
+		expectedFilter cleve.SampleSheetFilter
+	}{
+		{
+			name: "no filter",
+			url:  "/api/samplesheets",
+			code: http.StatusOK,
+			expectedFilter: cleve.SampleSheetFilter{
+				PaginationFilter: cleve.PaginationFilter{Page: 1, PageSize: 10},
+			},
+		},
+		{
+			name: "all filters",
+			url:  "/api/samplesheets?run_id=run1&uuid=" + ssId.String() + "&sample_id=sample1&project=proj1&index=acgtacgt&from=2026-10-12T00:00:00Z&page=2&page_size=5",
+			code: http.StatusOK,
+			expectedFilter: cleve.SampleSheetFilter{
+				RunId:            "run1",
+				UUID:             ssId,
+				SampleId:         "sample1",
+				Project:          "proj1",
+				Index:            "ACGTACGT",
+				From:             from,
+				PaginationFilter: cleve.PaginationFilter{Page: 2, PageSize: 5},
+			},
+		},
+		{
+			name: "invalid uuid",
+			url:  "/api/samplesheets?uuid=92f356e3",
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid index",
+			url:  "/api/samplesheets?index=ACGTX",
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "to before from",
+			url:  "/api/samplesheets?from=2026-10-12T00:00:00Z&to=2026-10-11T00:00:00Z",
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "page out of bounds",
+			url:     "/api/samplesheets?page=3",
+			code:    http.StatusNotFound,
+			dbError: mongo.PageOutOfBoundsError{},
+			expectedFilter: cleve.SampleSheetFilter{
+				PaginationFilter: cleve.PaginationFilter{Page: 3, PageSize: 10},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := mock.SampleSheetsGetter{}
+			db.SampleSheetsFn = func(filter cleve.SampleSheetFilter) (*cleve.SampleSheetResult, error) {
+				if filter != c.expectedFilter {
+					t.Errorf("expected filter %+v, got %+v", c.expectedFilter, filter)
+				}
+				return &cleve.SampleSheetResult{}, c.dbError
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, c.url, nil)
+
+			SampleSheetsHandler(&db)(ctx)
+
+			if w.Code != c.code {
+				t.Errorf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code == http.StatusBadRequest && db.SampleSheetsInvoked {
+				t.Error("SampleSheets should not be invoked for an invalid filter")
+			}
+			if c.code == http.StatusOK {
+				var res cleve.SampleSheetResult
+				if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+					t.Fatal(err)
+				}
+				if res.SampleSheets == nil {
+					t.Error("expected an empty list of samplesheets, got null")
+				}
+			}
+		})
+	}
+}
+
+func TestRunSampleSheetApplications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	data := `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+[BCLConvert_Data]
+Sample_ID,Index
+sample1,ACGTACGT
+sample2,TGCATGCA
+[DragenGermline_Settings]
+SoftwareVersion,4.2.7
+[DragenGermline_Data]
+Sample_ID,ReferenceGenomeDir
+sample1,hg38
+[Cloud_Data]
+Sample_ID,ProjectName
+sample1,project1
+sample2,project1
+`
+
+	cases := []struct {
+		name    string
+		stored  bool
+		code    int
+		samples []cleve.SampleSheetSampleApplications
+	}{
+		{
+			name:   "samplesheet with applications",
+			stored: true,
+			code:   http.StatusOK,
+			samples: []cleve.SampleSheetSampleApplications{
+				{
+					SampleId: "sample1",
+					Applications: []cleve.SampleApplication{
+						{
+							Application: "DragenGermline",
+							Settings:    map[string]string{"SoftwareVersion": "4.2.7", "ReferenceGenomeDir": "hg38"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "missing samplesheet",
+			code: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			getter := &mock.SampleSheetGetter{}
+			getter.SampleSheetFn = func(opts ...mongo.SampleSheetOption) (cleve.SampleSheet, error) {
+				if !c.stored {
+					return cleve.SampleSheet{}, mongo.ErrNoDocuments
+				}
+				return cleve.ParseSampleSheet(bufio.NewReader(strings.NewReader(data)))
+			}
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/runs/run1/samplesheet/applications", nil)
+			ctx.Params = []gin.Param{{Key: "runId", Value: "run1"}}
+			RunSampleSheetApplicationsHandler(getter)(ctx)
+
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d: %s", c.code, w.Code, w.Body.String())
+			}
+			if c.code != http.StatusOK {
+				return
+			}
+			var res struct {
+				RunId   string                                `json:"run_id"`
+				Samples []cleve.SampleSheetSampleApplications `json:"samples"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.RunId != "run1" {
+				t.Errorf("expected run id run1, got %s", res.RunId)
+			}
+			if !reflect.DeepEqual(res.Samples, c.samples) {
+				t.Errorf("expected samples\n%+v\ngot\n%+v", c.samples, res.Samples)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ go 1.26.1
 require (
 	github.com/gin-gonic/gin v1.10.1
 	github.com/go-echarts/go-echarts/v2 v2.5.4
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-yaml/yaml v2.1.0+incompatible
 	github.com/google/uuid v1.4.0
 	github.com/spf13/cobra v1.8.0
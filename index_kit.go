@@ -0,0 +1,336 @@
+package cleve
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gmc-norr/cleve/interop"
+)
+
+// I5Orientation is the orientation of the i5 index, index2, as it should be written
+// in the samplesheet for a particular platform.
+type I5Orientation string
+
+const (
+	I5Forward           I5Orientation = "forward"
+	I5ReverseComplement I5Orientation = "reverse_complement"
+)
+
+// IsValid returns true if the orientation is a known orientation.
+func (o I5Orientation) IsValid() bool {
+	return o == I5Forward || o == I5ReverseComplement
+}
+
+// Opposite returns the other orientation.
+func (o I5Orientation) Opposite() I5Orientation {
+	if o == I5ReverseComplement {
+		return I5Forward
+	}
+	return I5ReverseComplement
+}
+
+// defaultI5Orientations is the orientation of index2 in samplesheets for the supported
+// platforms. Platforms that are not listed use the forward orientation.
+var defaultI5Orientations = map[string]I5Orientation{
+	"NovaSeq X Plus": I5Forward,
+	"NextSeq 5x0":    I5ReverseComplement,
+	"MiSeq":          I5Forward,
+	"MiSeq i100":     I5Forward,
+}
+
+// IndexKit is a named collection of index sequences, e.g. a commercial library
+// preparation kit. Index sequences are given in the forward strand orientation, and
+// index2 is reverse complemented for platforms that expect this in the samplesheet,
+// see [IndexKit.Orientation].
+type IndexKit struct {
+	Name    string          `bson:"name" json:"name"`
+	Indexes []IndexKitIndex `bson:"indexes" json:"indexes"`
+	// I5Orientations overrides the default orientation of index2 in samplesheets for
+	// specific platforms.
+	I5Orientations map[string]I5Orientation `bson:"i5_orientations,omitempty" json:"i5_orientations,omitempty"`
+}
+
+// IndexKitIndex is a single index, or index pair, in an index kit. For combinatorial
+// kits, such as Nextera XT, i7 and i5 indexes are listed separately, and either Index
+// or Index2 is empty.
+type IndexKitIndex struct {
+	Id     string `bson:"id" json:"id"`
+	Index  string `bson:"index,omitempty" json:"index,omitempty"`
+	Index2 string `bson:"index2,omitempty" json:"index2,omitempty"`
+	// Well is the position of the index in the index plate, e.g. A01.
+	Well string `bson:"well,omitempty" json:"well,omitempty"`
+}
+
+// Orientation returns the orientation that index2 should have in samplesheets for the
+// given platform.
+func (k IndexKit) Orientation(platform string) I5Orientation {
+	if o, ok := k.I5Orientations[platform]; ok {
+		return o
+	}
+	if o, ok := defaultI5Orientations[platform]; ok {
+		return o
+	}
+	return I5Forward
+}
+
+// ReverseComplement returns the reverse complement of a DNA sequence.
+func ReverseComplement(seq string) string {
+	complement := map[byte]byte{'A': 'T', 'C': 'G', 'G': 'C', 'T': 'A', 'N': 'N'}
+	rc := make([]byte, len(seq))
+	for i := range len(seq) {
+		b := seq[len(seq)-1-i]
+		if c, ok := complement[b]; ok {
+			b = c
+		}
+		rc[i] = b
+	}
+	return string(rc)
+}
+
+// index2 returns index2 in the given orientation.
+func (i IndexKitIndex) index2(orientation I5Orientation) string {
+	if orientation == I5ReverseComplement {
+		return ReverseComplement(i.Index2)
+	}
+	return i.Index2
+}
+
+// IndexMatch is an index, or index pair, from a samplesheet or from index metrics that
+// has been matched against index kits.
+type IndexMatch struct {
+	Kit string `bson:"kit" json:"kit"`
+	// Name is the ID of the matching index pair, or the IDs of the matching i7 and i5
+	// indexes joined by a dash for combinatorial kits.
+	Name string `bson:"name" json:"name"`
+	Well string `bson:"well,omitempty" json:"well,omitempty"`
+	// WrongI5Orientation is true if index2 only matches the kit in the orientation that
+	// is not used on the platform, i.e. it has likely been entered in the wrong
+	// orientation in the samplesheet.
+	WrongI5Orientation bool `bson:"wrong_i5_orientation" json:"wrong_i5_orientation"`
+}
+
+// sequenceMatch returns true if seq matches the kit sequence. Indexes are often
+// sequenced with fewer cycles than the length of the kit index, so it is enough for
+// seq to be a prefix of the kit sequence.
+func sequenceMatch(kitSeq, seq string) bool {
+	return seq != "" && strings.HasPrefix(kitSeq, seq)
+}
+
+// Match finds the index in the kit that matches the given index sequences on the
+// platform. Index pairs are matched first, then i7 and i5 indexes separately. If
+// index2 only matches in the orientation not used by the platform, the match is
+// flagged with WrongI5Orientation. If index2 is empty, e.g. for a single index run
+// with a dual index kit, index pairs are matched on the i7 index alone, as long as
+// only one pair has a matching i7 index.
+func (k IndexKit) Match(platform, index, index2 string) (IndexMatch, bool) {
+	index, index2 = strings.ToUpper(index), strings.ToUpper(index2)
+	orientation := k.Orientation(platform)
+	for _, o := range []I5Orientation{orientation, orientation.Opposite()} {
+		for _, i := range k.Indexes {
+			if i.Index == "" || !sequenceMatch(i.Index, index) {
+				continue
+			}
+			if index2 == "" && i.Index2 == "" || index2 != "" && sequenceMatch(i.index2(o), index2) {
+				return IndexMatch{Kit: k.Name, Name: i.Id, Well: i.Well, WrongI5Orientation: o != orientation}, true
+			}
+		}
+	}
+
+	if index2 == "" {
+		var pair *IndexKitIndex
+		for n, i := range k.Indexes {
+			if i.Index2 == "" || !sequenceMatch(i.Index, index) {
+				continue
+			}
+			if pair != nil {
+				// Ambiguous without index2
+				pair = nil
+				break
+			}
+			pair = &k.Indexes[n]
+		}
+		if pair != nil {
+			return IndexMatch{Kit: k.Name, Name: pair.Id, Well: pair.Well}, true
+		}
+	}
+
+	// Combinatorial kits
+	i7 := slices.IndexFunc(k.Indexes, func(i IndexKitIndex) bool {
+		return i.Index2 == "" && sequenceMatch(i.Index, index)
+	})
+	if i7 < 0 {
+		return IndexMatch{}, false
+	}
+	if index2 == "" {
+		return IndexMatch{Kit: k.Name, Name: k.Indexes[i7].Id}, true
+	}
+	for _, o := range []I5Orientation{orientation, orientation.Opposite()} {
+		i5 := slices.IndexFunc(k.Indexes, func(i IndexKitIndex) bool {
+			return i.Index == "" && sequenceMatch(i.index2(o), index2)
+		})
+		if i5 >= 0 {
+			return IndexMatch{
+				Kit:                k.Name,
+				Name:               k.Indexes[i7].Id + "-" + k.Indexes[i5].Id,
+				WrongI5Orientation: o != orientation,
+			}, true
+		}
+	}
+	return IndexMatch{}, false
+}
+
+// MatchIndexKits matches index sequences against several index kits, and returns the
+// first match. Matches with index2 in the correct orientation are preferred.
+func MatchIndexKits(kits []IndexKit, platform, index, index2 string) (IndexMatch, bool) {
+	var fallback *IndexMatch
+	for _, k := range kits {
+		m, ok := k.Match(platform, index, index2)
+		if !ok {
+			continue
+		}
+		if !m.WrongI5Orientation {
+			return m, true
+		}
+		if fallback == nil {
+			fallback = &m
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return IndexMatch{}, false
+}
+
+// Lookup finds an index in the kit by its ID.
+func (k IndexKit) Lookup(id string) (IndexKitIndex, bool) {
+	i := slices.IndexFunc(k.Indexes, func(idx IndexKitIndex) bool {
+		return idx.Id == id
+	})
+	if i < 0 {
+		return IndexKitIndex{}, false
+	}
+	return k.Indexes[i], true
+}
+
+// RunIndex is an index from the index summary of a run, annotated with the matching
+// index kit, if any.
+type RunIndex struct {
+	Sample       string  `bson:"sample" json:"sample"`
+	Index        string  `bson:"index" json:"index"`
+	Index2       string  `bson:"index2,omitempty" json:"index2,omitempty"`
+	ReadCount    int     `bson:"read_count" json:"read_count"`
+	PercentReads float64 `bson:"percent_reads" json:"percent_reads"`
+	Matched      bool    `bson:"matched" json:"matched"`
+	IndexMatch   `bson:",inline"`
+}
+
+// AnnotateIndexes matches the indexes in an index summary against index kits. The
+// index of each record is split into index and index2, and matched for the given
+// platform.
+func AnnotateIndexes(records []interop.IndexSummaryRecord, platform string, kits []IndexKit) []RunIndex {
+	indexes := make([]RunIndex, 0, len(records))
+	for _, r := range records {
+		index, index2, _ := strings.Cut(r.Index, "-")
+		ri := RunIndex{
+			Sample:       r.Sample,
+			Index:        index,
+			Index2:       index2,
+			ReadCount:    r.ReadCount,
+			PercentReads: r.PercentReads,
+		}
+		ri.IndexMatch, ri.Matched = MatchIndexKits(kits, platform, index, index2)
+		indexes = append(indexes, ri)
+	}
+	return indexes
+}
+
+// ParseIndexKit reads an index kit definition from CSV. The CSV must have a header with
+// the column "id", and at least one of the columns "index" and "index2", which can also
+// be called "i7" and "i5". The plate position of the index can be given in the column
+// "well". Column names are case insensitive, and any other columns are ignored. Index2
+// should be given in the forward strand orientation. Each index must have at least one
+// of index and index2.
+func ParseIndexKit(name string, r io.Reader) (IndexKit, error) {
+	kit := IndexKit{Name: name}
+	if name == "" {
+		return kit, errors.New("index kit name cannot be empty")
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return kit, err
+	}
+	if len(records) == 0 {
+		return kit, errors.New("index kit file is empty")
+	}
+
+	aliases := map[string]string{"i7": "index", "i5": "index2"}
+	columns := map[string]int{}
+	for i, c := range records[0] {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if alias, ok := aliases[c]; ok {
+			c = alias
+		}
+		columns[c] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return kit, fmt.Errorf("index kit is missing the %q column", "id")
+	}
+	_, hasIndex := columns["index"]
+	_, hasIndex2 := columns["index2"]
+	if !hasIndex && !hasIndex2 {
+		return kit, fmt.Errorf("index kit is missing the %q column", "index")
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	value := func(record []string, column string) string {
+		return strings.ToUpper(field(record, column))
+	}
+
+	seen := map[string]bool{}
+	for i, record := range records[1:] {
+		id := field(record, "id")
+		if id == "" {
+			return kit, fmt.Errorf("empty index id on line %d", i+2)
+		}
+		if seen[id] {
+			return kit, fmt.Errorf("duplicate index id %q on line %d", id, i+2)
+		}
+		seen[id] = true
+		idx := IndexKitIndex{
+			Id:     id,
+			Index:  value(record, "index"),
+			Index2: value(record, "index2"),
+			Well:   value(record, "well"),
+		}
+		if idx.Index == "" && idx.Index2 == "" {
+			return kit, fmt.Errorf("no index for %q on line %d", id, i+2)
+		}
+		if idx.Index != "" && !validIndex.MatchString(idx.Index) {
+			return kit, fmt.Errorf("invalid index %q for %q on line %d", idx.Index, id, i+2)
+		}
+		if idx.Index2 != "" && !validIndex.MatchString(idx.Index2) {
+			return kit, fmt.Errorf("invalid index2 %q for %q on line %d", idx.Index2, id, i+2)
+		}
+		kit.Indexes = append(kit.Indexes, idx)
+	}
+
+	if len(kit.Indexes) == 0 {
+		return kit, errors.New("index kit has no indexes")
+	}
+
+	return kit, nil
+}
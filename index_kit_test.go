@@ -0,0 +1,245 @@
+package cleve
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseIndexKit(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		indexes int
+		err     bool
+	}{
+		{
+			name:    "dual indexes",
+			data:    "id,index,index2\nUDP0001,gaactgagcg,TCGTGGAGCG\nUDP0002,AGGTCAGATA,CTACAAGATA\n",
+			indexes: 2,
+		},
+		{
+			name:    "single index with extra columns",
+			data:    "Well,ID,Index\nA01,D701,ATTACTCG\n",
+			indexes: 1,
+		},
+		{
+			name:    "wells and i7/i5 column names",
+			data:    "Well,ID,i7,i5\nA01,UDP0001,GAACTGAGCG,TCGTGGAGCG\nB01,UDP0002,AGGTCAGATA,CTACAAGATA\n",
+			indexes: 2,
+		},
+		{
+			name:    "combinatorial",
+			data:    "id,index,index2\nN701,TAAGGCGA,\nS502,,CTCTCTAT\n",
+			indexes: 2,
+		},
+		{
+			name: "no sequences for index",
+			data: "id,index,index2\nN701,,\n",
+			err:  true,
+		},
+		{
+			name: "missing index column",
+			data: "id,sequence\nD701,ATTACTCG\n",
+			err:  true,
+		},
+		{
+			name: "row shorter than the id column",
+			data: "well,index,id\nA01,ACGT,x1\nA02\n",
+			err:  true,
+		},
+		{
+			name: "duplicate id",
+			data: "id,index\nD701,ATTACTCG\nD701,TCCGGAGA\n",
+			err:  true,
+		},
+		{
+			name: "invalid index",
+			data: "id,index\nD701,ATTACTXG\n",
+			err:  true,
+		},
+		{
+			name: "no indexes",
+			data: "id,index\n",
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kit, err := ParseIndexKit("kit", strings.NewReader(c.data))
+			if (err != nil) != c.err {
+				t.Fatalf("expected error to be %t, got %v", c.err, err)
+			}
+			if c.err {
+				return
+			}
+			if len(kit.Indexes) != c.indexes {
+				t.Errorf("expected %d indexes, got %d", c.indexes, len(kit.Indexes))
+			}
+		})
+	}
+
+	kit, err := ParseIndexKit("kit", strings.NewReader("id,index,index2\nUDP0001,gaactgagcg,TCGTGGAGCG\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, ok := kit.Lookup("UDP0001")
+	if !ok {
+		t.Fatal("expected to find UDP0001")
+	}
+	if idx.Index != "GAACTGAGCG" || idx.Index2 != "TCGTGGAGCG" {
+		t.Errorf("unexpected indexes %q and %q", idx.Index, idx.Index2)
+	}
+	if _, ok := kit.Lookup("UDP0002"); ok {
+		t.Error("did not expect to find UDP0002")
+	}
+}
+
+func TestReverseComplement(t *testing.T) {
+	cases := map[string]string{
+		"":         "",
+		"A":        "T",
+		"ACGTN":    "NACGT",
+		"TCGTGGAG": "CTCCACGA",
+	}
+	for seq, expected := range cases {
+		if rc := ReverseComplement(seq); rc != expected {
+			t.Errorf("expected reverse complement of %q to be %q, got %q", seq, expected, rc)
+		}
+	}
+}
+
+func TestIndexKitMatch(t *testing.T) {
+	udi := IndexKit{
+		Name: "udi",
+		Indexes: []IndexKitIndex{
+			{Id: "UDP0001", Index: "GAACTGAGCG", Index2: "TCGTGGAGCG", Well: "A01"},
+			{Id: "UDP0002", Index: "AGGTCAGATA", Index2: "CTACAAGATA", Well: "B01"},
+		},
+	}
+	nextera := IndexKit{
+		Name: "nextera",
+		Indexes: []IndexKitIndex{
+			{Id: "N701", Index: "TAAGGCGA"},
+			{Id: "S502", Index2: "CTCTCTAT"},
+		},
+		I5Orientations: map[string]I5Orientation{"MiSeq": I5ReverseComplement},
+	}
+
+	cases := []struct {
+		name     string
+		kit      IndexKit
+		platform string
+		index    string
+		index2   string
+		match    bool
+		expected IndexMatch
+	}{
+		{
+			name:     "forward platform",
+			kit:      udi,
+			platform: "NovaSeq X Plus",
+			index:    "GAACTGAGCG",
+			index2:   "TCGTGGAGCG",
+			match:    true,
+			expected: IndexMatch{Kit: "udi", Name: "UDP0001", Well: "A01"},
+		},
+		{
+			name:     "reverse complement platform",
+			kit:      udi,
+			platform: "NextSeq 5x0",
+			index:    "AGGTCAGATA",
+			index2:   "TATCTTGTAG",
+			match:    true,
+			expected: IndexMatch{Kit: "udi", Name: "UDP0002", Well: "B01"},
+		},
+		{
+			name:     "truncated indexes",
+			kit:      udi,
+			platform: "NextSeq 5x0",
+			index:    "AGGTCAGA",
+			index2:   "TATCTTGT",
+			match:    true,
+			expected: IndexMatch{Kit: "udi", Name: "UDP0002", Well: "B01"},
+		},
+		{
+			name:     "wrong orientation",
+			kit:      udi,
+			platform: "NextSeq 5x0",
+			index:    "GAACTGAGCG",
+			index2:   "TCGTGGAGCG",
+			match:    true,
+			expected: IndexMatch{Kit: "udi", Name: "UDP0001", Well: "A01", WrongI5Orientation: true},
+		},
+		{
+			name:     "no match",
+			kit:      udi,
+			platform: "NovaSeq X Plus",
+			index:    "GAACTGAGCG",
+			index2:   "CTACAAGATA",
+		},
+		{
+			name:     "single index with dual index kit",
+			kit:      udi,
+			platform: "NovaSeq X Plus",
+			index:    "AGGTCAGA",
+			match:    true,
+			expected: IndexMatch{Kit: "udi", Name: "UDP0002", Well: "B01"},
+		},
+		{
+			name: "ambiguous single index with dual index kit",
+			kit: IndexKit{
+				Name: "cdi",
+				Indexes: []IndexKitIndex{
+					{Id: "A01", Index: "GAACTGAGCG", Index2: "TCGTGGAGCG"},
+					{Id: "B01", Index: "GAACTGAGCG", Index2: "CTACAAGATA"},
+				},
+			},
+			platform: "NovaSeq X Plus",
+			index:    "GAACTGAGCG",
+		},
+		{
+			name:     "combinatorial kit with orientation override",
+			kit:      nextera,
+			platform: "MiSeq",
+			index:    "TAAGGCGA",
+			index2:   "ATAGAGAG",
+			match:    true,
+			expected: IndexMatch{Kit: "nextera", Name: "N701-S502"},
+		},
+		{
+			name:     "combinatorial kit wrong orientation",
+			kit:      nextera,
+			platform: "MiSeq",
+			index:    "TAAGGCGA",
+			index2:   "CTCTCTAT",
+			match:    true,
+			expected: IndexMatch{Kit: "nextera", Name: "N701-S502", WrongI5Orientation: true},
+		},
+		{
+			name:     "single index",
+			kit:      nextera,
+			platform: "MiSeq",
+			index:    "TAAGGCGA",
+			match:    true,
+			expected: IndexMatch{Kit: "nextera", Name: "N701"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, ok := c.kit.Match(c.platform, c.index, c.index2)
+			if ok != c.match {
+				t.Fatalf("expected match to be %t, got %t", c.match, ok)
+			}
+			if m != c.expected {
+				t.Errorf("expected %+v, got %+v", c.expected, m)
+			}
+		})
+	}
+
+	m, ok := MatchIndexKits([]IndexKit{nextera, udi}, "NextSeq 5x0", "GAACTGAGCG", "CGCTCCACGA")
+	if !ok || m.Name != "UDP0001" || m.WrongI5Orientation {
+		t.Errorf("expected UDP0001 in correct orientation, got %+v", m)
+	}
+}
@@ -0,0 +1,200 @@
+package interop
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IndexHoppingCounts represents the contents of an `Index_Hopping_Counts.csv` file
+// produced by BCLConvert for dual-indexed runs.
+type IndexHoppingCounts struct {
+	Records []IndexHoppingRecord
+}
+
+// IndexHoppingRecord is a single row of an `Index_Hopping_Counts.csv` file. Records
+// with a sample ID represent expected index combinations, and records without a
+// sample ID represent combinations where the indexes have hopped.
+type IndexHoppingRecord struct {
+	Lane      int
+	SampleId  string
+	Index     string
+	Index2    string
+	ReadCount int
+}
+
+// IsHopped returns true if the record represents an unexpected index combination.
+func (r IndexHoppingRecord) IsHopped() bool {
+	return r.SampleId == ""
+}
+
+// ParseIndexHoppingCounts parses the contents of an `Index_Hopping_Counts.csv` file.
+func ParseIndexHoppingCounts(r io.Reader) (IndexHoppingCounts, error) {
+	var counts IndexHoppingCounts
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	lines, err := csvReader.ReadAll()
+	if err != nil {
+		return counts, err
+	}
+	if len(lines) == 0 {
+		return counts, fmt.Errorf("empty index hopping file")
+	}
+
+	columns := make(map[string]int)
+	for i, col := range lines[0] {
+		columns[strings.TrimSpace(col)] = i
+	}
+	for _, col := range []string{"Lane", "SampleID", "index", "index2", "# Reads"} {
+		if _, ok := columns[col]; !ok {
+			return counts, fmt.Errorf("missing column %q in index hopping file", col)
+		}
+	}
+
+	for i, line := range lines[1:] {
+		if len(line) != len(lines[0]) {
+			return counts, fmt.Errorf("expected %d fields on line %d, got %d", len(lines[0]), i+2, len(line))
+		}
+		lane, err := strconv.Atoi(line[columns["Lane"]])
+		if err != nil {
+			return counts, fmt.Errorf("invalid lane on line %d: %w", i+2, err)
+		}
+		readCount, err := strconv.Atoi(line[columns["# Reads"]])
+		if err != nil {
+			return counts, fmt.Errorf("invalid read count on line %d: %w", i+2, err)
+		}
+		counts.Records = append(counts.Records, IndexHoppingRecord{
+			Lane:      lane,
+			SampleId:  line[columns["SampleID"]],
+			Index:     line[columns["index"]],
+			Index2:    line[columns["index2"]],
+			ReadCount: readCount,
+		})
+	}
+
+	return counts, nil
+}
+
+// ReadIndexHoppingCounts reads and parses an `Index_Hopping_Counts.csv` file.
+func ReadIndexHoppingCounts(path string) (IndexHoppingCounts, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return IndexHoppingCounts{}, err
+	}
+	defer func() { _ = f.Close() }()
+	return ParseIndexHoppingCounts(f)
+}
+
+// IndexHoppingSummary summarises index hopping for a whole run and per lane.
+type IndexHoppingSummary struct {
+	ExpectedReads int                `bson:"expected_reads" json:"expected_reads"`
+	HoppedReads   int                `bson:"hopped_reads" json:"hopped_reads"`
+	PercentHopped OptionalFloat      `bson:"percent_hopped" json:"percent_hopped"`
+	Lanes         []LaneIndexHopping `bson:"lanes" json:"lanes"`
+}
+
+// LaneIndexHopping summarises the index hopping in a single lane.
+type LaneIndexHopping struct {
+	Lane          int                       `bson:"lane" json:"lane"`
+	ExpectedReads int                       `bson:"expected_reads" json:"expected_reads"`
+	HoppedReads   int                       `bson:"hopped_reads" json:"hopped_reads"`
+	PercentHopped OptionalFloat             `bson:"percent_hopped" json:"percent_hopped"`
+	Combinations  []IndexHoppingCombination `bson:"combinations" json:"combinations"`
+}
+
+// IndexHoppingCombination is an i7/i5 combination observed in a lane. For hopped
+// combinations, Sample is empty and SampleIndex and SampleIndex2 are the samples
+// that the i7 and i5 index, respectively, belong to.
+type IndexHoppingCombination struct {
+	Index        string `bson:"index" json:"index"`
+	Index2       string `bson:"index2" json:"index2"`
+	Sample       string `bson:"sample,omitempty" json:"sample,omitempty"`
+	SampleIndex  string `bson:"sample_index,omitempty" json:"sample_index,omitempty"`
+	SampleIndex2 string `bson:"sample_index2,omitempty" json:"sample_index2,omitempty"`
+	ReadCount    int    `bson:"read_count" json:"read_count"`
+	Hopped       bool   `bson:"hopped" json:"hopped"`
+}
+
+// Lane returns the index hopping summary for a specific lane. If the lane is not
+// found, nil is returned.
+func (s IndexHoppingSummary) Lane(lane int) *LaneIndexHopping {
+	for i := range s.Lanes {
+		if s.Lanes[i].Lane == lane {
+			return &s.Lanes[i]
+		}
+	}
+	return nil
+}
+
+// Summarise calculates the number of hopped reads and hopping rates per lane as
+// well as for the whole run. The hopping rate is the fraction of hopped reads out
+// of all reads with a recognised i7 and i5 index. If there are no records, the zero
+// value is returned.
+func (c IndexHoppingCounts) Summarise() IndexHoppingSummary {
+	var summary IndexHoppingSummary
+	if len(c.Records) == 0 {
+		return summary
+	}
+	lanes := make(map[int]*LaneIndexHopping)
+	laneOrder := make([]int, 0)
+
+	// Identify which sample each index belongs to in each lane
+	i7Samples := make(map[int]map[string]string)
+	i5Samples := make(map[int]map[string]string)
+	for _, r := range c.Records {
+		if r.IsHopped() {
+			continue
+		}
+		if _, ok := i7Samples[r.Lane]; !ok {
+			i7Samples[r.Lane] = make(map[string]string)
+			i5Samples[r.Lane] = make(map[string]string)
+		}
+		i7Samples[r.Lane][r.Index] = r.SampleId
+		i5Samples[r.Lane][r.Index2] = r.SampleId
+	}
+
+	for _, r := range c.Records {
+		lane, ok := lanes[r.Lane]
+		if !ok {
+			lane = &LaneIndexHopping{Lane: r.Lane}
+			lanes[r.Lane] = lane
+			laneOrder = append(laneOrder, r.Lane)
+		}
+		combination := IndexHoppingCombination{
+			Index:     r.Index,
+			Index2:    r.Index2,
+			Sample:    r.SampleId,
+			ReadCount: r.ReadCount,
+			Hopped:    r.IsHopped(),
+		}
+		if r.IsHopped() {
+			combination.SampleIndex = i7Samples[r.Lane][r.Index]
+			combination.SampleIndex2 = i5Samples[r.Lane][r.Index2]
+			lane.HoppedReads += r.ReadCount
+		} else {
+			lane.ExpectedReads += r.ReadCount
+		}
+		lane.Combinations = append(lane.Combinations, combination)
+	}
+
+	slices.Sort(laneOrder)
+	summary.Lanes = make([]LaneIndexHopping, 0, len(laneOrder))
+	for _, l := range laneOrder {
+		lane := lanes[l]
+		lane.PercentHopped = percentHopped(lane.HoppedReads, lane.ExpectedReads)
+		summary.ExpectedReads += lane.ExpectedReads
+		summary.HoppedReads += lane.HoppedReads
+		summary.Lanes = append(summary.Lanes, *lane)
+	}
+	summary.PercentHopped = percentHopped(summary.HoppedReads, summary.ExpectedReads)
+
+	return summary
+}
+
+func percentHopped(hopped, expected int) OptionalFloat {
+	return OptionalFloat(100 * float64(hopped) / float64(hopped+expected))
+}
@@ -0,0 +1,124 @@
+package interop
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseIndexHoppingCounts(t *testing.T) {
+	testcases := []struct {
+		name        string
+		content     string
+		records     int
+		shouldError bool
+	}{
+		{
+			name: "valid file",
+			content: `Lane,SampleID,index,index2,# Reads,% of Hopped Reads,% of All Reads
+1,sample1,AAAAAAAA,CCCCCCCC,1000,0,0.5
+1,sample2,GGGGGGGG,TTTTTTTT,1000,0,0.5
+1,,AAAAAAAA,TTTTTTTT,10,50,0.005
+1,,GGGGGGGG,CCCCCCCC,10,50,0.005
+`,
+			records: 4,
+		},
+		{
+			name:    "header only",
+			content: "Lane,SampleID,index,index2,# Reads,% of Hopped Reads,% of All Reads\n",
+			records: 0,
+		},
+		{
+			name:        "empty file",
+			content:     "",
+			shouldError: true,
+		},
+		{
+			name:        "missing column",
+			content:     "Lane,SampleID,index,# Reads\n1,sample1,AAAAAAAA,1000\n",
+			shouldError: true,
+		},
+		{
+			name:        "invalid read count",
+			content:     "Lane,SampleID,index,index2,# Reads\n1,sample1,AAAAAAAA,CCCCCCCC,many\n",
+			shouldError: true,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			counts, err := ParseIndexHoppingCounts(strings.NewReader(c.content))
+			if err != nil {
+				if !c.shouldError {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if c.shouldError {
+				t.Fatal("expected an error, got nil")
+			}
+			if len(counts.Records) != c.records {
+				t.Errorf("expected %d records, got %d", c.records, len(counts.Records))
+			}
+		})
+	}
+}
+
+func TestIndexHoppingSummary(t *testing.T) {
+	counts := IndexHoppingCounts{
+		Records: []IndexHoppingRecord{
+			{Lane: 1, SampleId: "sample1", Index: "AAAA", Index2: "CCCC", ReadCount: 900},
+			{Lane: 1, SampleId: "sample2", Index: "GGGG", Index2: "TTTT", ReadCount: 1000},
+			{Lane: 1, Index: "AAAA", Index2: "TTTT", ReadCount: 60},
+			{Lane: 1, Index: "GGGG", Index2: "CCCC", ReadCount: 40},
+			{Lane: 2, SampleId: "sample1", Index: "AAAA", Index2: "CCCC", ReadCount: 1000},
+		},
+	}
+
+	summary := counts.Summarise()
+
+	if summary.HoppedReads != 100 {
+		t.Errorf("expected 100 hopped reads, got %d", summary.HoppedReads)
+	}
+	if summary.ExpectedReads != 2900 {
+		t.Errorf("expected 2900 expected reads, got %d", summary.ExpectedReads)
+	}
+	if math.Abs(float64(summary.PercentHopped)-100.0/30.0) > 1e-9 {
+		t.Errorf("expected %f percent hopped, got %f", 100.0/30.0, summary.PercentHopped)
+	}
+	if len(summary.Lanes) != 2 {
+		t.Fatalf("expected 2 lanes, got %d", len(summary.Lanes))
+	}
+
+	lane1 := summary.Lane(1)
+	if lane1 == nil {
+		t.Fatal("lane 1 not found")
+	}
+	if lane1.PercentHopped != 5 {
+		t.Errorf("expected 5 percent hopped in lane 1, got %f", lane1.PercentHopped)
+	}
+	hopped := lane1.Combinations[2]
+	if !hopped.Hopped {
+		t.Error("expected combination to be hopped")
+	}
+	if hopped.SampleIndex != "sample1" || hopped.SampleIndex2 != "sample2" {
+		t.Errorf("expected hopping between sample1 and sample2, got %q and %q", hopped.SampleIndex, hopped.SampleIndex2)
+	}
+
+	lane2 := summary.Lane(2)
+	if lane2 == nil {
+		t.Fatal("lane 2 not found")
+	}
+	if lane2.PercentHopped != 0 {
+		t.Errorf("expected 0 percent hopped in lane 2, got %f", lane2.PercentHopped)
+	}
+
+	if summary.Lane(3) != nil {
+		t.Error("expected nil for missing lane")
+	}
+
+	empty := IndexHoppingCounts{}.Summarise()
+	if empty.Lanes != nil {
+		t.Error("expected zero value summary for no records")
+	}
+}
@@ -49,6 +49,9 @@ type Interop struct {
 
 	indexMetricsFile string
 	IndexMetrics     IndexMetrics
+
+	indexHoppingFile string
+	IndexHopping     IndexHoppingCounts
 }
 
 // Returns the first file that exists, have read permission set,
@@ -120,6 +123,7 @@ func InteropFromDir(rundir string) (Interop, error) {
 	i.extendedTileMetricsFile, _ = alternativeFile(interopdir, "ExtendedTileMetricsOut.bin", "ExtendedTileMetrics.bin")
 	i.errorMetricsFile, _ = alternativeFile(interopdir, "ErrorMetricsOut.bin", "ErrorMetrics.bin")
 	i.indexMetricsFile, _ = alternativeFile(interopdir, "IndexMetricsOut.bin", "IndexMetrics.bin", "../Analysis/*/Data/Demux/IndexMetricsOut.bin")
+	i.indexHoppingFile, _ = alternativeFile(i.dir, "Analysis/*/Data/Demux/Index_Hopping_Counts.csv")
 
 	i.RunInfo, err = ReadRunInfo(i.runinfoFile)
 	if err != nil {
@@ -174,6 +178,13 @@ func InteropFromDir(rundir string) (Interop, error) {
 		}
 	}
 
+	if i.indexHoppingFile != "" {
+		i.IndexHopping, err = ReadIndexHoppingCounts(i.indexHoppingFile)
+		if err != nil {
+			return i, fmt.Errorf("error reading index hopping counts: %w", err)
+		}
+	}
+
 	return i, nil
 }
 
@@ -332,6 +343,8 @@ type IndexSummary struct {
 	PercentId           OptionalFloat        `bson:"percent_id" json:"percent_id"`
 	PercentUndetermined OptionalFloat        `bson:"percent_undetermined" json:"percent_undetermined"`
 	Indexes             []IndexSummaryRecord `bson:"indexes" json:"indexes"`
+	// Lanes are the read counts and yields of each sample in each lane.
+	Lanes []IndexSummaryLaneRecord `bson:"lanes,omitempty" json:"lanes,omitempty"`
 }
 
 type IndexSummaryRecord struct {
@@ -341,6 +354,16 @@ type IndexSummaryRecord struct {
 	PercentReads float64 `bson:"percent_reads" json:"percent_reads"`
 }
 
+// IndexSummaryLaneRecord is the number of reads and the yield, in bases, of a sample
+// in a single lane.
+type IndexSummaryLaneRecord struct {
+	Lane      int    `bson:"lane" json:"lane"`
+	Sample    string `bson:"sample" json:"sample"`
+	Project   string `bson:"project,omitempty" json:"project,omitempty"`
+	ReadCount int    `bson:"read_count" json:"read_count"`
+	Yield     int    `bson:"yield" json:"yield"`
+}
+
 func (i Interop) IndexSummary() IndexSummary {
 	summary := IndexSummary{
 		TotalReads: i.RunInfo.NonIndexReadCount() * i.TileMetrics.Clusters(),
@@ -374,9 +397,50 @@ func (i Interop) IndexSummary() IndexSummary {
 	for i, k := range keyOrder {
 		summary.Indexes[i] = records[k]
 	}
+	summary.Lanes = i.indexSummaryLanes()
 	return summary
 }
 
+// indexSummaryLanes sums the cluster counts of the index metrics by lane and sample.
+// The project of a sample is taken from the index metrics.
+// The yield is the number of clusters times the number of cycles of the non-index reads.
+func (i Interop) indexSummaryLanes() []IndexSummaryLaneRecord {
+	cycles := 0
+	for _, r := range i.RunInfo.Reads {
+		if !r.IsIndex {
+			cycles += r.Cycles
+		}
+	}
+	type laneSample struct {
+		lane    int
+		sample  string
+		project string
+	}
+	counts := make(map[laneSample]int)
+	var keyOrder []laneSample
+	for _, record := range i.IndexMetrics.Records {
+		key := laneSample{record.Lane, record.SampleName, record.ProjectName}
+		if _, ok := counts[key]; !ok {
+			keyOrder = append(keyOrder, key)
+		}
+		counts[key] += record.ClusterCount
+	}
+	slices.SortStableFunc(keyOrder, func(a, b laneSample) int {
+		return a.lane - b.lane
+	})
+	lanes := make([]IndexSummaryLaneRecord, len(keyOrder))
+	for j, k := range keyOrder {
+		lanes[j] = IndexSummaryLaneRecord{
+			Lane:      k.lane,
+			Sample:    k.sample,
+			Project:   k.project,
+			ReadCount: counts[k],
+			Yield:     counts[k] * cycles,
+		}
+	}
+	return lanes
+}
+
 type LaneSummary struct {
 	Lane      int           `bson:"lane" json:"lane"`
 	Yield     int           `bson:"yield" json:"yield"`
@@ -511,19 +575,40 @@ type InteropSummary struct {
 	LaneSummary  []LaneSummary       `bson:"lane_summary" json:"lane_summary"`
 	IndexSummary IndexSummary        `bson:"index_summary" json:"index_summary"`
 	ReadSummary  []ReadSummary       `bson:"read_summary" json:"read_summary"`
+	IndexHopping IndexHoppingSummary `bson:"index_hopping,omitzero" json:"index_hopping,omitzero"`
+	// ComputedAt is the time when the summary was computed.
+	ComputedAt time.Time `bson:"computed_at" json:"computed_at"`
+	// ComputationVersion is the version of the QC computation that produced the summary.
+	ComputationVersion int `bson:"computation_version" json:"computation_version"`
+}
+
+// SummaryComputationVersion is the current version of the QC computation. It should be
+// incremented whenever a change is made that affects the values in InteropSummary, so
+// that summaries computed with an older version can be identified and recomputed.
+//
+// Version 2 added the read counts of each sample in each lane to the index summary.
+const SummaryComputationVersion = 2
+
+// IsStale returns true if the summary was computed with an older version of the QC
+// computation than the current one.
+func (s InteropSummary) IsStale() bool {
+	return s.ComputationVersion < SummaryComputationVersion
 }
 
 func (i Interop) Summarise() InteropSummary {
 	return InteropSummary{
-		RunId:        i.RunInfo.RunId,
-		Platform:     i.RunInfo.Platform,
-		Flowcell:     i.RunInfo.FlowcellName,
-		Date:         i.RunInfo.Date,
-		RunSummary:   i.RunSummary(),
-		LaneSummary:  i.LaneSummary(),
-		TileSummary:  i.TileSummary(),
-		IndexSummary: i.IndexSummary(),
-		ReadSummary:  i.ReadSummary(),
+		RunId:              i.RunInfo.RunId,
+		Platform:           i.RunInfo.Platform,
+		Flowcell:           i.RunInfo.FlowcellName,
+		Date:               i.RunInfo.Date,
+		RunSummary:         i.RunSummary(),
+		LaneSummary:        i.LaneSummary(),
+		TileSummary:        i.TileSummary(),
+		IndexSummary:       i.IndexSummary(),
+		ReadSummary:        i.ReadSummary(),
+		IndexHopping:       i.IndexHopping.Summarise(),
+		ComputedAt:         time.Now(),
+		ComputationVersion: SummaryComputationVersion,
 	}
 }
 
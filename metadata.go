@@ -0,0 +1,306 @@
+package cleve
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// JSONSchema is the subset of JSON Schema that is supported for sample metadata. The
+// supported keywords are type, enum, required, properties, additionalProperties,
+// items, minimum, maximum, minLength, maxLength and pattern.
+type JSONSchema struct {
+	Dialect              string                 `bson:"dialect,omitempty" json:"$schema,omitempty"`
+	Title                string                 `bson:"title,omitempty" json:"title,omitempty"`
+	Description          string                 `bson:"description,omitempty" json:"description,omitempty"`
+	Type                 string                 `bson:"type,omitempty" json:"type,omitempty"`
+	Enum                 []any                  `bson:"enum,omitempty" json:"enum,omitempty"`
+	Required             []string               `bson:"required,omitempty" json:"required,omitempty"`
+	Properties           map[string]*JSONSchema `bson:"properties,omitempty" json:"properties,omitempty"`
+	AdditionalProperties *bool                  `bson:"additional_properties,omitempty" json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `bson:"items,omitempty" json:"items,omitempty"`
+	Minimum              *float64               `bson:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum              *float64               `bson:"maximum,omitempty" json:"maximum,omitempty"`
+	MinLength            *int                   `bson:"min_length,omitempty" json:"minLength,omitempty"`
+	MaxLength            *int                   `bson:"max_length,omitempty" json:"maxLength,omitempty"`
+	Pattern              string                 `bson:"pattern,omitempty" json:"pattern,omitempty"`
+}
+
+var jsonSchemaTypes = []string{"string", "number", "integer", "boolean", "array", "object", "null"}
+
+// check checks that the schema itself is valid, i.e. that types are known and that
+// patterns compile.
+func (s *JSONSchema) check(path string) []error {
+	var errs []error
+	if s.Type != "" && !slices.Contains(jsonSchemaTypes, s.Type) {
+		errs = append(errs, fmt.Errorf("%s: unknown type %q", path, s.Type))
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid pattern: %w", path, err))
+		}
+	}
+	for _, r := range s.Required {
+		if _, ok := s.Properties[r]; !ok && s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			errs = append(errs, fmt.Errorf("%s: required property %q is not defined", path, r))
+		}
+	}
+	for name, p := range s.Properties {
+		if p == nil {
+			errs = append(errs, fmt.Errorf("%s.%s: missing schema", path, name))
+			continue
+		}
+		errs = append(errs, p.check(path+"."+name)...)
+	}
+	if s.Items != nil {
+		errs = append(errs, s.Items.check(path+"[]")...)
+	}
+	return errs
+}
+
+// validate validates a value, as decoded from JSON, against the schema.
+func (s *JSONSchema) validate(path string, v any) []error {
+	if s.Type != "" && !hasJSONType(v, s.Type) {
+		return []error{fmt.Errorf("%s: expected %s, got %s", path, s.Type, jsonType(v))}
+	}
+	var errs []error
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, v) }) {
+		errs = append(errs, fmt.Errorf("%s: value must be one of %v", path, s.Enum))
+	}
+	switch x := v.(type) {
+	case string:
+		n := len([]rune(x))
+		if s.MinLength != nil && n < *s.MinLength {
+			errs = append(errs, fmt.Errorf("%s: must be at least %d characters", path, *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			errs = append(errs, fmt.Errorf("%s: must be at most %d characters", path, *s.MaxLength))
+		}
+		if s.Pattern != "" {
+			if ok, _ := regexp.MatchString(s.Pattern, x); !ok {
+				errs = append(errs, fmt.Errorf("%s: must match pattern %q", path, s.Pattern))
+			}
+		}
+	case map[string]any:
+		for _, r := range s.Required {
+			if _, ok := x[r]; !ok {
+				errs = append(errs, fmt.Errorf("%s.%s: required", path, r))
+			}
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs = append(errs, fmt.Errorf("%s.%s: unknown property", path, k))
+				}
+				continue
+			}
+			errs = append(errs, p.validate(path+"."+k, x[k])...)
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range x {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	default:
+		if f, ok := jsonNumber(v); ok {
+			if s.Minimum != nil && f < *s.Minimum {
+				errs = append(errs, fmt.Errorf("%s: must be at least %v", path, *s.Minimum))
+			}
+			if s.Maximum != nil && f > *s.Maximum {
+				errs = append(errs, fmt.Errorf("%s: must be at most %v", path, *s.Maximum))
+			}
+		}
+	}
+	return errs
+}
+
+func jsonNumber(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	if _, ok := jsonNumber(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func hasJSONType(v any, t string) bool {
+	if t == "integer" {
+		f, ok := jsonNumber(v)
+		return ok && f == math.Trunc(f)
+	}
+	return jsonType(v) == t
+}
+
+func jsonEqual(a, b any) bool {
+	fa, okA := jsonNumber(a)
+	fb, okB := jsonNumber(b)
+	if okA || okB {
+		return okA && okB && fa == fb
+	}
+	switch a.(type) {
+	case string, bool, nil:
+		return a == b
+	}
+	return false
+}
+
+// MetadataSchema is an admin defined JSON Schema that the metadata of samples is
+// validated against. A schema applies to samples in a project, samples of a sample
+// type, or both. A schema without a project or sample type applies to all samples.
+type MetadataSchema struct {
+	Name        string     `bson:"name" json:"name" binding:"required"`
+	Description string     `bson:"description,omitempty" json:"description,omitempty"`
+	Project     string     `bson:"project,omitempty" json:"project,omitempty"`
+	SampleType  string     `bson:"sample_type,omitempty" json:"sample_type,omitempty"`
+	Schema      JSONSchema `bson:"schema" json:"schema"`
+	Created     time.Time  `bson:"created" json:"created"`
+	Updated     time.Time  `bson:"updated" json:"updated"`
+}
+
+// Validate checks that the metadata schema has a name and a valid JSON Schema. The
+// schema has to describe an object where the properties are scalar values, or arrays
+// of scalar values, so that they can be filtered on and shown in tables.
+func (m MetadataSchema) Validate() error {
+	var errs []error
+	if m.Name == "" {
+		errs = append(errs, fmt.Errorf("metadata schema name must not be empty"))
+	}
+	if m.Schema.Type != "object" {
+		errs = append(errs, fmt.Errorf("metadata schema must be of type object"))
+	}
+	for name, p := range m.Schema.Properties {
+		if !metadataKeyPattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid property name %q", name))
+		}
+		if p == nil {
+			continue
+		}
+		t := p.Type
+		if t == "array" && p.Items != nil {
+			t = p.Items.Type
+		}
+		if t == "object" || t == "array" {
+			errs = append(errs, fmt.Errorf("property %q cannot be a nested %s", name, t))
+		}
+	}
+	errs = append(errs, m.Schema.check("metadata")...)
+	return errors.Join(errs...)
+}
+
+// Applies returns true if the schema applies to samples in the project with the
+// given sample type.
+func (m MetadataSchema) Applies(project, sampleType string) bool {
+	if m.Project != "" && m.Project != project {
+		return false
+	}
+	if m.SampleType != "" && m.SampleType != sampleType {
+		return false
+	}
+	return true
+}
+
+// ValidateMetadata validates sample metadata against the schema.
+func (m MetadataSchema) ValidateMetadata(metadata map[string]any) error {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	errs := m.Schema.validate("metadata", metadata)
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("metadata schema %s: %w", m.Name, errors.Join(errs...))
+}
+
+// ValidateMetadata validates the metadata of the sample against all the schemas
+// that apply to the sample.
+func (s Sample) ValidateMetadata(schemas []MetadataSchema) error {
+	var errs []error
+	for _, m := range schemas {
+		if !m.Applies(s.Project, s.Type) {
+			continue
+		}
+		if err := m.ValidateMetadata(s.Metadata); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MetadataColumns returns the names of the metadata properties defined by the schemas
+// that apply to samples in a project, of any sample type. The names of each schema
+// are sorted, and names are only listed once.
+func MetadataColumns(schemas []MetadataSchema, project string) []string {
+	var columns []string
+	for _, m := range schemas {
+		if m.Project != "" && m.Project != project {
+			continue
+		}
+		names := make([]string, 0, len(m.Schema.Properties))
+		for name := range m.Schema.Properties {
+			if !slices.Contains(columns, name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		columns = append(columns, names...)
+	}
+	return columns
+}
+
+// ParseMetadataFilter parses a metadata filter on the form key:value, with multiple
+// conditions separated by commas, into a map from keys to values.
+func ParseMetadataFilter(filter string) (map[string]string, error) {
+	if filter == "" {
+		return nil, nil
+	}
+	conditions := make(map[string]string)
+	for _, c := range strings.Split(filter, ",") {
+		key, value, ok := strings.Cut(c, ":")
+		key = strings.TrimSpace(key)
+		if !ok || !metadataKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid metadata filter %q, expected key:value", c)
+		}
+		conditions[key] = strings.TrimSpace(value)
+	}
+	return conditions, nil
+}
@@ -0,0 +1,213 @@
+package cleve
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMetadataSchemaValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		schema string
+		error  bool
+	}{
+		{
+			name:   "flat object",
+			schema: `{"type": "object", "required": ["tissue"], "properties": {"tissue": {"type": "string", "enum": ["blood", "saliva"]}, "dna": {"type": "number", "minimum": 0}, "kits": {"type": "array", "items": {"type": "string"}}}}`,
+		},
+		{
+			name:   "not an object",
+			schema: `{"type": "string"}`,
+			error:  true,
+		},
+		{
+			name:   "unknown type",
+			schema: `{"type": "object", "properties": {"dna": {"type": "float"}}}`,
+			error:  true,
+		},
+		{
+			name:   "invalid pattern",
+			schema: `{"type": "object", "properties": {"referral": {"type": "string", "pattern": "[a-z"}}}`,
+			error:  true,
+		},
+		{
+			name:   "nested object",
+			schema: `{"type": "object", "properties": {"referral": {"type": "object"}}}`,
+			error:  true,
+		},
+		{
+			name:   "invalid property name",
+			schema: `{"type": "object", "properties": {"capture.kit": {"type": "string"}}}`,
+			error:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := MetadataSchema{Name: "test"}
+			if err := json.Unmarshal([]byte(c.schema), &m.Schema); err != nil {
+				t.Fatal(err)
+			}
+			err := m.Validate()
+			if (err != nil) != c.error {
+				t.Errorf("expected error to be %t, got %v", c.error, err)
+			}
+		})
+	}
+}
+
+func TestMetadataSchemaValidateMetadata(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["tissue"],
+		"additionalProperties": false,
+		"properties": {
+			"tissue": {"type": "string", "enum": ["blood", "saliva"]},
+			"dna": {"type": "number", "minimum": 0, "maximum": 1000},
+			"replicates": {"type": "integer"},
+			"referral": {"type": "string", "pattern": "^R[0-9]+$", "maxLength": 6},
+			"kits": {"type": "array", "items": {"type": "string"}}
+		}
+	}`
+	m := MetadataSchema{Name: "test"}
+	if err := json.Unmarshal([]byte(schema), &m.Schema); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		metadata string
+		error    bool
+	}{
+		{name: "all properties", metadata: `{"tissue": "blood", "dna": 12.5, "replicates": 2, "referral": "R123", "kits": ["twist"]}`},
+		{name: "required only", metadata: `{"tissue": "saliva"}`},
+		{name: "missing required", metadata: `{"dna": 12.5}`, error: true},
+		{name: "missing metadata", metadata: `null`, error: true},
+		{name: "value not in enum", metadata: `{"tissue": "skin"}`, error: true},
+		{name: "wrong type", metadata: `{"tissue": "blood", "dna": "12.5"}`, error: true},
+		{name: "below minimum", metadata: `{"tissue": "blood", "dna": -1}`, error: true},
+		{name: "above maximum", metadata: `{"tissue": "blood", "dna": 1001}`, error: true},
+		{name: "not an integer", metadata: `{"tissue": "blood", "replicates": 1.5}`, error: true},
+		{name: "pattern mismatch", metadata: `{"tissue": "blood", "referral": "X123"}`, error: true},
+		{name: "too long", metadata: `{"tissue": "blood", "referral": "R123456"}`, error: true},
+		{name: "wrong item type", metadata: `{"tissue": "blood", "kits": [1]}`, error: true},
+		{name: "unknown property", metadata: `{"tissue": "blood", "colour": "red"}`, error: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var metadata map[string]any
+			if err := json.Unmarshal([]byte(c.metadata), &metadata); err != nil {
+				t.Fatal(err)
+			}
+			err := m.ValidateMetadata(metadata)
+			if (err != nil) != c.error {
+				t.Errorf("expected error to be %t, got %v", c.error, err)
+			}
+		})
+	}
+}
+
+func TestSampleValidateMetadata(t *testing.T) {
+	schemas := []MetadataSchema{
+		{
+			Name:    "project",
+			Project: "P1",
+			Schema:  JSONSchema{Type: "object", Required: []string{"capture_kit"}},
+		},
+		{
+			Name:       "tissue",
+			SampleType: "tissue",
+			Schema:     JSONSchema{Type: "object", Required: []string{"tumour_content"}},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		sample Sample
+		error  bool
+	}{
+		{
+			name:   "no applicable schemas",
+			sample: Sample{Project: "P2", Type: "blood"},
+		},
+		{
+			name:   "project schema",
+			sample: Sample{Project: "P1", Metadata: map[string]any{"capture_kit": "twist"}},
+		},
+		{
+			name:   "project schema not fulfilled",
+			sample: Sample{Project: "P1", Metadata: map[string]any{"tumour_content": 0.5}},
+			error:  true,
+		},
+		{
+			name:   "project and sample type schemas",
+			sample: Sample{Project: "P1", Type: "tissue", Metadata: map[string]any{"capture_kit": "twist", "tumour_content": 0.5}},
+		},
+		{
+			name:   "sample type schema not fulfilled",
+			sample: Sample{Project: "P1", Type: "tissue", Metadata: map[string]any{"capture_kit": "twist"}},
+			error:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.sample.ValidateMetadata(schemas)
+			if (err != nil) != c.error {
+				t.Errorf("expected error to be %t, got %v", c.error, err)
+			}
+		})
+	}
+}
+
+func TestMetadataColumns(t *testing.T) {
+	schemas := []MetadataSchema{
+		{Name: "all", Schema: JSONSchema{Properties: map[string]*JSONSchema{"tissue": {}, "dna": {}}}},
+		{Name: "p1", Project: "P1", Schema: JSONSchema{Properties: map[string]*JSONSchema{"capture_kit": {}, "tissue": {}}}},
+		{Name: "p2", Project: "P2", Schema: JSONSchema{Properties: map[string]*JSONSchema{"referral": {}}}},
+	}
+	columns := MetadataColumns(schemas, "P1")
+	expected := []string{"dna", "tissue", "capture_kit"}
+	if len(columns) != len(expected) {
+		t.Fatalf("expected columns %v, got %v", expected, columns)
+	}
+	for i := range expected {
+		if columns[i] != expected[i] {
+			t.Errorf("expected columns %v, got %v", expected, columns)
+		}
+	}
+}
+
+func TestParseMetadataFilter(t *testing.T) {
+	cases := []struct {
+		name     string
+		filter   string
+		expected map[string]string
+		error    bool
+	}{
+		{name: "empty", filter: ""},
+		{name: "single condition", filter: "tissue:blood", expected: map[string]string{"tissue": "blood"}},
+		{name: "multiple conditions", filter: "tissue:blood, dna:12", expected: map[string]string{"tissue": "blood", "dna": "12"}},
+		{name: "value with colon", filter: "referral:R1:2", expected: map[string]string{"referral": "R1:2"}},
+		{name: "missing value", filter: "tissue", error: true},
+		{name: "invalid key", filter: "$where:1", error: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conditions, err := ParseMetadataFilter(c.filter)
+			if (err != nil) != c.error {
+				t.Fatalf("expected error to be %t, got %v", c.error, err)
+			}
+			if len(conditions) != len(c.expected) {
+				t.Fatalf("expected %v, got %v", c.expected, conditions)
+			}
+			for k, v := range c.expected {
+				if conditions[k] != v {
+					t.Errorf("expected %s to be %q, got %q", k, v, conditions[k])
+				}
+			}
+		})
+	}
+}
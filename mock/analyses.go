@@ -11,20 +11,22 @@ import (
 // the corresponding *Invoked fields register whether the function has been
 // called. The interface implementation then just wraps the *Fn functions.
 type AnalysisGetterSetter struct {
-	AnalysesFn              func(cleve.AnalysisFilter) (cleve.AnalysisResult, error)
-	AnalysesInvoked         bool
-	AnalysesFilesFn         func(cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error)
-	AnalysesFilesInvoked    bool
-	AnalysisFn              func(uuid.UUID, ...string) (*cleve.Analysis, error)
-	AnalysisInvoked         bool
-	CreateAnalysisFn        func(*cleve.Analysis) error
-	CreateAnalysisInvoked   bool
-	SetAnalysisStateFn      func(uuid.UUID, cleve.State) error
-	SetAnalysisStateInvoked bool
-	SetAnalysisPathFn       func(uuid.UUID, string) error
-	SetAnalysisPathInvoked  bool
-	SetAnalysisFilesFn      func(uuid.UUID, []cleve.AnalysisFile) error
-	SetAnalysisFilesInvoked bool
+	AnalysesFn                  func(cleve.AnalysisFilter) (cleve.AnalysisResult, error)
+	AnalysesInvoked             bool
+	AnalysesFilesFn             func(cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error)
+	AnalysesFilesInvoked        bool
+	AnalysisFn                  func(uuid.UUID, ...string) (*cleve.Analysis, error)
+	AnalysisInvoked             bool
+	CreateAnalysisFn            func(*cleve.Analysis) error
+	CreateAnalysisInvoked       bool
+	SetAnalysisStateFn          func(uuid.UUID, cleve.State) error
+	SetAnalysisStateInvoked     bool
+	SetAnalysisPathFn           func(uuid.UUID, string) error
+	SetAnalysisPathInvoked      bool
+	SetAnalysisFilesFn          func(uuid.UUID, []cleve.AnalysisFile) error
+	SetAnalysisFilesInvoked     bool
+	UpdateMultiQCMetricsFn      func([]cleve.MultiQCSampleMetrics) error
+	UpdateMultiQCMetricsInvoked bool
 }
 
 func (gs *AnalysisGetterSetter) Analyses(filter cleve.AnalysisFilter) (cleve.AnalysisResult, error) {
@@ -61,3 +63,8 @@ func (gs *AnalysisGetterSetter) SetAnalysisFiles(analysisId uuid.UUID, files []c
 	gs.SetAnalysisFilesInvoked = true
 	return gs.SetAnalysisFilesFn(analysisId, files)
 }
+
+func (gs *AnalysisGetterSetter) UpdateMultiQCMetrics(metrics []cleve.MultiQCSampleMetrics) error {
+	gs.UpdateMultiQCMetricsInvoked = true
+	return gs.UpdateMultiQCMetricsFn(metrics)
+}
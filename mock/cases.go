@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"github.com/gmc-norr/cleve"
+)
+
+// Mock implementing the gin.CaseGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type CaseGetter struct {
+	CaseFn       func(string) (*cleve.Case, error)
+	CaseInvoked  bool
+	CasesFn      func(cleve.CaseFilter) (*cleve.CaseResult, error)
+	CasesInvoked bool
+}
+
+func (g *CaseGetter) Case(caseId string) (*cleve.Case, error) {
+	g.CaseInvoked = true
+	return g.CaseFn(caseId)
+}
+
+func (g *CaseGetter) Cases(filter cleve.CaseFilter) (*cleve.CaseResult, error) {
+	g.CasesInvoked = true
+	return g.CasesFn(filter)
+}
+
+// Mock implementing the gin.CaseSetter interface.
+//
+// See [mock.RunGetter] for more information.
+type CaseSetter struct {
+	CreateCaseFn      func(*cleve.Case) error
+	CreateCaseInvoked bool
+	UpdateCaseFn      func(*cleve.Case) error
+	UpdateCaseInvoked bool
+	DeleteCaseFn      func(string) error
+	DeleteCaseInvoked bool
+}
+
+func (s *CaseSetter) CreateCase(c *cleve.Case) error {
+	s.CreateCaseInvoked = true
+	return s.CreateCaseFn(c)
+}
+
+func (s *CaseSetter) UpdateCase(c *cleve.Case) error {
+	s.UpdateCaseInvoked = true
+	return s.UpdateCaseFn(c)
+}
+
+func (s *CaseSetter) DeleteCase(caseId string) error {
+	s.DeleteCaseInvoked = true
+	return s.DeleteCaseFn(caseId)
+}
+
+// Mock implementing the gin.CaseFileGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type CaseFileGetter struct {
+	CaseFn               func(string) (*cleve.Case, error)
+	CaseInvoked          bool
+	AnalysesFilesFn      func(cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error)
+	AnalysesFilesInvoked bool
+}
+
+func (g *CaseFileGetter) Case(caseId string) (*cleve.Case, error) {
+	g.CaseInvoked = true
+	return g.CaseFn(caseId)
+}
+
+func (g *CaseFileGetter) AnalysesFiles(filter cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error) {
+	g.AnalysesFilesInvoked = true
+	return g.AnalysesFilesFn(filter)
+}
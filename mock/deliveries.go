@@ -0,0 +1,60 @@
+package mock
+
+import (
+	"github.com/gmc-norr/cleve"
+	"github.com/google/uuid"
+)
+
+// Mock implementing the gin.DeliveryGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type DeliveryGetter struct {
+	DeliveryFn        func(uuid.UUID) (*cleve.Delivery, error)
+	DeliveryInvoked   bool
+	DeliveriesFn      func(cleve.DeliveryFilter) (*cleve.DeliveryResult, error)
+	DeliveriesInvoked bool
+}
+
+func (g *DeliveryGetter) Delivery(deliveryId uuid.UUID) (*cleve.Delivery, error) {
+	g.DeliveryInvoked = true
+	return g.DeliveryFn(deliveryId)
+}
+
+func (g *DeliveryGetter) Deliveries(filter cleve.DeliveryFilter) (*cleve.DeliveryResult, error) {
+	g.DeliveriesInvoked = true
+	return g.DeliveriesFn(filter)
+}
+
+// Mock implementing the gin.DeliveryCreator interface.
+//
+// See [mock.RunGetter] for more information.
+type DeliveryCreator struct {
+	SamplesFn             func(*cleve.SampleFilter) (*cleve.SampleResult, error)
+	SamplesInvoked        bool
+	AnalysesFilesFn       func(cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error)
+	AnalysesFilesInvoked  bool
+	CreateDeliveryFn      func(*cleve.Delivery) error
+	CreateDeliveryInvoked bool
+	UpdateDeliveryFn      func(*cleve.Delivery) error
+	UpdateDeliveryInvoked bool
+}
+
+func (c *DeliveryCreator) Samples(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
+	c.SamplesInvoked = true
+	return c.SamplesFn(filter)
+}
+
+func (c *DeliveryCreator) AnalysesFiles(filter cleve.AnalysisFileFilter) ([]cleve.AnalysisFile, error) {
+	c.AnalysesFilesInvoked = true
+	return c.AnalysesFilesFn(filter)
+}
+
+func (c *DeliveryCreator) CreateDelivery(d *cleve.Delivery) error {
+	c.CreateDeliveryInvoked = true
+	return c.CreateDeliveryFn(d)
+}
+
+func (c *DeliveryCreator) UpdateDelivery(d *cleve.Delivery) error {
+	c.UpdateDeliveryInvoked = true
+	return c.UpdateDeliveryFn(d)
+}
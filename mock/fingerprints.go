@@ -0,0 +1,33 @@
+package mock
+
+import (
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/fingerprint"
+)
+
+// Mock implementing the gin.SampleIdentityGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type SampleIdentityGetter struct {
+	SampleFn                      func(string) (*cleve.Sample, error)
+	SampleInvoked                 bool
+	FingerprintsFn                func(cleve.FingerprintFilter) ([]cleve.Fingerprint, error)
+	FingerprintsInvoked           bool
+	FingerprintComparisonsFn      func(string) ([]fingerprint.Comparison, error)
+	FingerprintComparisonsInvoked bool
+}
+
+func (g *SampleIdentityGetter) Sample(sampleId string) (*cleve.Sample, error) {
+	g.SampleInvoked = true
+	return g.SampleFn(sampleId)
+}
+
+func (g *SampleIdentityGetter) Fingerprints(filter cleve.FingerprintFilter) ([]cleve.Fingerprint, error) {
+	g.FingerprintsInvoked = true
+	return g.FingerprintsFn(filter)
+}
+
+func (g *SampleIdentityGetter) FingerprintComparisons(sampleId string) ([]fingerprint.Comparison, error) {
+	g.FingerprintComparisonsInvoked = true
+	return g.FingerprintComparisonsFn(sampleId)
+}
@@ -0,0 +1,52 @@
+package mock
+
+import (
+	"github.com/gmc-norr/cleve"
+)
+
+// Mock implementing the gin.MetadataSchemaGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type MetadataSchemaGetter struct {
+	MetadataSchemaFn       func(string) (*cleve.MetadataSchema, error)
+	MetadataSchemaInvoked  bool
+	MetadataSchemasFn      func() ([]cleve.MetadataSchema, error)
+	MetadataSchemasInvoked bool
+}
+
+func (g *MetadataSchemaGetter) MetadataSchema(name string) (*cleve.MetadataSchema, error) {
+	g.MetadataSchemaInvoked = true
+	return g.MetadataSchemaFn(name)
+}
+
+func (g *MetadataSchemaGetter) MetadataSchemas() ([]cleve.MetadataSchema, error) {
+	g.MetadataSchemasInvoked = true
+	return g.MetadataSchemasFn()
+}
+
+// Mock implementing the gin.MetadataSchemaSetter interface.
+//
+// See [mock.RunGetter] for more information.
+type MetadataSchemaSetter struct {
+	CreateMetadataSchemaFn      func(*cleve.MetadataSchema) error
+	CreateMetadataSchemaInvoked bool
+	UpdateMetadataSchemaFn      func(*cleve.MetadataSchema) error
+	UpdateMetadataSchemaInvoked bool
+	DeleteMetadataSchemaFn      func(string) error
+	DeleteMetadataSchemaInvoked bool
+}
+
+func (s *MetadataSchemaSetter) CreateMetadataSchema(schema *cleve.MetadataSchema) error {
+	s.CreateMetadataSchemaInvoked = true
+	return s.CreateMetadataSchemaFn(schema)
+}
+
+func (s *MetadataSchemaSetter) UpdateMetadataSchema(schema *cleve.MetadataSchema) error {
+	s.UpdateMetadataSchemaInvoked = true
+	return s.UpdateMetadataSchemaFn(schema)
+}
+
+func (s *MetadataSchemaSetter) DeleteMetadataSchema(name string) error {
+	s.DeleteMetadataSchemaInvoked = true
+	return s.DeleteMetadataSchemaFn(name)
+}
@@ -0,0 +1,40 @@
+package mock
+
+import (
+	"github.com/gmc-norr/cleve"
+	"github.com/google/uuid"
+)
+
+// Mock implementing the gin.NextflowAnalysisGetterSetter interface.
+//
+// See [mock.RunGetter] for more information.
+type NextflowAnalysisGetterSetter struct {
+	AnalysisFn                  func(uuid.UUID, ...string) (*cleve.Analysis, error)
+	AnalysisInvoked             bool
+	CreateAnalysisFn            func(*cleve.Analysis) error
+	CreateAnalysisInvoked       bool
+	UpdateAnalysisFn            func(*cleve.Analysis) error
+	UpdateAnalysisInvoked       bool
+	UpdateMultiQCMetricsFn      func([]cleve.MultiQCSampleMetrics) error
+	UpdateMultiQCMetricsInvoked bool
+}
+
+func (gs *NextflowAnalysisGetterSetter) Analysis(analysisId uuid.UUID, runId ...string) (*cleve.Analysis, error) {
+	gs.AnalysisInvoked = true
+	return gs.AnalysisFn(analysisId, runId...)
+}
+
+func (gs *NextflowAnalysisGetterSetter) CreateAnalysis(analysis *cleve.Analysis) error {
+	gs.CreateAnalysisInvoked = true
+	return gs.CreateAnalysisFn(analysis)
+}
+
+func (gs *NextflowAnalysisGetterSetter) UpdateAnalysis(analysis *cleve.Analysis) error {
+	gs.UpdateAnalysisInvoked = true
+	return gs.UpdateAnalysisFn(analysis)
+}
+
+func (gs *NextflowAnalysisGetterSetter) UpdateMultiQCMetrics(metrics []cleve.MultiQCSampleMetrics) error {
+	gs.UpdateMultiQCMetricsInvoked = true
+	return gs.UpdateMultiQCMetricsFn(metrics)
+}
@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"github.com/gmc-norr/cleve"
+)
+
+// Mock implementing the gin.PanelSampleGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type PanelSampleGetter struct {
+	PanelFn        func(string, string) (cleve.GenePanel, error)
+	PanelInvoked   bool
+	SamplesFn      func(*cleve.SampleFilter) (*cleve.SampleResult, error)
+	SamplesInvoked bool
+}
+
+func (g *PanelSampleGetter) Panel(panelId string, version string) (cleve.GenePanel, error) {
+	g.PanelInvoked = true
+	return g.PanelFn(panelId, version)
+}
+
+func (g *PanelSampleGetter) Samples(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
+	g.SamplesInvoked = true
+	return g.SamplesFn(filter)
+}
@@ -0,0 +1,78 @@
+package mock
+
+import (
+	"github.com/gmc-norr/cleve"
+)
+
+// Mock implementing the gin.ProjectGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type ProjectGetter struct {
+	ProjectFn       func(string) (*cleve.Project, error)
+	ProjectInvoked  bool
+	ProjectsFn      func(cleve.ProjectFilter) (*cleve.ProjectResult, error)
+	ProjectsInvoked bool
+}
+
+func (g *ProjectGetter) Project(name string) (*cleve.Project, error) {
+	g.ProjectInvoked = true
+	return g.ProjectFn(name)
+}
+
+func (g *ProjectGetter) Projects(filter cleve.ProjectFilter) (*cleve.ProjectResult, error) {
+	g.ProjectsInvoked = true
+	return g.ProjectsFn(filter)
+}
+
+// Mock implementing the gin.ProjectSampleGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type ProjectSampleGetter struct {
+	ProjectFn      func(string) (*cleve.Project, error)
+	ProjectInvoked bool
+	SamplesFn      func(*cleve.SampleFilter) (*cleve.SampleResult, error)
+	SamplesInvoked bool
+}
+
+func (g *ProjectSampleGetter) Project(name string) (*cleve.Project, error) {
+	g.ProjectInvoked = true
+	return g.ProjectFn(name)
+}
+
+func (g *ProjectSampleGetter) Samples(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
+	g.SamplesInvoked = true
+	return g.SamplesFn(filter)
+}
+
+// Mock implementing the gin.ProjectRunGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type ProjectRunGetter struct {
+	ProjectFn      func(string) (*cleve.Project, error)
+	ProjectInvoked bool
+	RunsFn         func(cleve.RunFilter) (cleve.RunResult, error)
+	RunsInvoked    bool
+}
+
+func (g *ProjectRunGetter) Project(name string) (*cleve.Project, error) {
+	g.ProjectInvoked = true
+	return g.ProjectFn(name)
+}
+
+func (g *ProjectRunGetter) Runs(filter cleve.RunFilter) (cleve.RunResult, error) {
+	g.RunsInvoked = true
+	return g.RunsFn(filter)
+}
+
+// Mock implementing the gin.ProjectSetter interface.
+//
+// See [mock.RunGetter] for more information.
+type ProjectSetter struct {
+	SetProjectDeliveryStateFn      func(string, cleve.DeliveryState) (*cleve.Project, error)
+	SetProjectDeliveryStateInvoked bool
+}
+
+func (s *ProjectSetter) SetProjectDeliveryState(name string, state cleve.DeliveryState) (*cleve.Project, error) {
+	s.SetProjectDeliveryStateInvoked = true
+	return s.SetProjectDeliveryStateFn(name, state)
+}
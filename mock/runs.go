@@ -32,16 +32,18 @@ func (g *RunGetter) Runs(filter cleve.RunFilter) (cleve.RunResult, error) {
 //
 // See [mock.RunGetter] for more information.
 type RunSetter struct {
-	CreateRunFn              func(*cleve.Run) error
-	CreateRunInvoked         bool
-	CreateSampleSheetFn      func(cleve.SampleSheet, ...mongo.SampleSheetOption) (*cleve.UpdateResult, error)
-	CreateSampleSheetInvoked bool
-	SetRunStateFn            func(string, cleve.State) error
-	SetRunStateInvoked       bool
-	SetRunPathFn             func(string, string) error
-	SetRunPathInvoked        bool
-	UpdateRunQCFn            func(interop.InteropSummary) error
-	UpdateRunQCInvoked       bool
+	CreateRunFn                   func(*cleve.Run) error
+	CreateRunInvoked              bool
+	CreateSampleSheetFn           func(cleve.SampleSheet, ...mongo.SampleSheetOption) (*cleve.UpdateResult, error)
+	CreateSampleSheetInvoked      bool
+	SetRunStateFn                 func(string, cleve.State) error
+	SetRunStateInvoked            bool
+	SetRunPathFn                  func(string, string) error
+	SetRunPathInvoked             bool
+	UpdateRunQCFn                 func(interop.InteropSummary) error
+	UpdateRunQCInvoked            bool
+	UpdateFromIndexSummaryFn      func(string, interop.IndexSummary) error
+	UpdateFromIndexSummaryInvoked bool
 }
 
 func (s *RunSetter) CreateRun(run *cleve.Run) error {
@@ -69,6 +71,11 @@ func (s *RunSetter) UpdateRunQC(qc interop.InteropSummary) error {
 	return s.UpdateRunQCFn(qc)
 }
 
+func (s *RunSetter) UpdateFromIndexSummary(runId string, summary interop.IndexSummary) error {
+	s.UpdateFromIndexSummaryInvoked = true
+	return s.UpdateFromIndexSummaryFn(runId, summary)
+}
+
 // Mock implementing the runHandler for RunWatcher
 type RunHandler struct {
 	RunsFn             func(cleve.RunFilter) (cleve.RunResult, error)
@@ -97,3 +104,97 @@ func (h *AnalysesHandler) Analyses(filter cleve.AnalysisFilter) (cleve.AnalysisR
 	h.AnalysesInvoked = true
 	return h.AnalysesFn(filter)
 }
+
+// Mock implementing the gin.RunReportGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type RunReportGetter struct {
+	RunFn               func(string) (*cleve.Run, error)
+	RunInvoked          bool
+	RunQCFn             func(string) (interop.InteropSummary, error)
+	RunQCInvoked        bool
+	RunSexChecksFn      func(string) ([]cleve.SexCheck, error)
+	RunSexChecksInvoked bool
+}
+
+func (g *RunReportGetter) Run(id string) (*cleve.Run, error) {
+	g.RunInvoked = true
+	return g.RunFn(id)
+}
+
+func (g *RunReportGetter) RunQC(id string) (interop.InteropSummary, error) {
+	g.RunQCInvoked = true
+	return g.RunQCFn(id)
+}
+
+func (g *RunReportGetter) RunSexChecks(id string) ([]cleve.SexCheck, error) {
+	g.RunSexChecksInvoked = true
+	return g.RunSexChecksFn(id)
+}
+
+// Mock implementing the gin.RunIndexGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type RunIndexGetter struct {
+	RunReportGetter
+	IndexKitsFn      func() ([]cleve.IndexKit, error)
+	IndexKitsInvoked bool
+}
+
+func (g *RunIndexGetter) IndexKits() ([]cleve.IndexKit, error) {
+	g.IndexKitsInvoked = true
+	return g.IndexKitsFn()
+}
+
+// Mock implementing the gin.RunQCGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type RunQCGetter struct {
+	RunsFn        func(cleve.RunFilter) (cleve.RunResult, error)
+	RunsInvoked   bool
+	RunQCFn       func(string) (interop.InteropSummary, error)
+	RunQCInvoked  bool
+	RunQCsFn      func(cleve.QcFilter) (cleve.QcResult, error)
+	RunQCsInvoked bool
+}
+
+func (g *RunQCGetter) Runs(filter cleve.RunFilter) (cleve.RunResult, error) {
+	g.RunsInvoked = true
+	return g.RunsFn(filter)
+}
+
+func (g *RunQCGetter) RunQC(id string) (interop.InteropSummary, error) {
+	g.RunQCInvoked = true
+	return g.RunQCFn(id)
+}
+
+func (g *RunQCGetter) RunQCs(filter cleve.QcFilter) (cleve.QcResult, error) {
+	g.RunQCsInvoked = true
+	return g.RunQCsFn(filter)
+}
+
+// Mock implementing the gin.StaleRunQCGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type StaleRunQCGetter struct {
+	StaleRunQCFn      func() ([]cleve.StaleQc, error)
+	StaleRunQCInvoked bool
+}
+
+func (g *StaleRunQCGetter) StaleRunQC() ([]cleve.StaleQc, error) {
+	g.StaleRunQCInvoked = true
+	return g.StaleRunQCFn()
+}
+
+// Mock implementing the sampleSheetHandler for SampleSheetWatcher
+type SampleSheetHandler struct {
+	RunHandler
+	AnalysesHandler
+	SampleSheetFn      func(...mongo.SampleSheetOption) (cleve.SampleSheet, error)
+	SampleSheetInvoked bool
+}
+
+func (h *SampleSheetHandler) SampleSheet(opts ...mongo.SampleSheetOption) (cleve.SampleSheet, error) {
+	h.SampleSheetInvoked = true
+	return h.SampleSheetFn(opts...)
+}
@@ -32,6 +32,8 @@ type SampleSetter struct {
 	CreateSampleInvoked  bool
 	CreateSamplesFn      func([]*cleve.Sample) error
 	CreateSamplesInvoked bool
+	UpdateSampleFn       func(string, cleve.SampleUpdate) error
+	UpdateSampleInvoked  bool
 }
 
 func (s *SampleSetter) CreateSample(sample *cleve.Sample) error {
@@ -43,3 +45,56 @@ func (s *SampleSetter) CreateSamples(samples []*cleve.Sample) error {
 	s.CreateSamplesInvoked = true
 	return s.CreateSamplesFn(samples)
 }
+
+func (s *SampleSetter) UpdateSample(sampleId string, update cleve.SampleUpdate) error {
+	s.UpdateSampleInvoked = true
+	return s.UpdateSampleFn(sampleId, update)
+}
+
+// Mock implementing the gin.SampleEditor interface.
+//
+// See [mock.RunGetter] for more information.
+type SampleEditor struct {
+	SampleSetter
+	SampleFn               func(string) (*cleve.Sample, error)
+	SampleInvoked          bool
+	MetadataSchemasFn      func() ([]cleve.MetadataSchema, error)
+	MetadataSchemasInvoked bool
+	PanelFn                func(string, string) (cleve.GenePanel, error)
+	PanelInvoked           bool
+}
+
+func (e *SampleEditor) Sample(sampleId string) (*cleve.Sample, error) {
+	e.SampleInvoked = true
+	return e.SampleFn(sampleId)
+}
+
+func (e *SampleEditor) MetadataSchemas() ([]cleve.MetadataSchema, error) {
+	e.MetadataSchemasInvoked = true
+	return e.MetadataSchemasFn()
+}
+
+func (e *SampleEditor) Panel(panelId string, version string) (cleve.GenePanel, error) {
+	e.PanelInvoked = true
+	return e.PanelFn(panelId, version)
+}
+
+// Mock implementing the gin.SampleQCGetter and gin.SampleMultiQCGetter interfaces.
+//
+// See [mock.RunGetter] for more information.
+type SampleQCGetter struct {
+	SampleQCFn           func(string) ([]cleve.DragenSampleMetrics, error)
+	SampleQCInvoked      bool
+	SampleMultiQCFn      func(string) ([]cleve.MultiQCSampleMetrics, error)
+	SampleMultiQCInvoked bool
+}
+
+func (g *SampleQCGetter) SampleQC(sampleId string) ([]cleve.DragenSampleMetrics, error) {
+	g.SampleQCInvoked = true
+	return g.SampleQCFn(sampleId)
+}
+
+func (g *SampleQCGetter) SampleMultiQC(sampleId string) ([]cleve.MultiQCSampleMetrics, error) {
+	g.SampleMultiQCInvoked = true
+	return g.SampleMultiQCFn(sampleId)
+}
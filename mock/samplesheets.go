@@ -3,14 +3,18 @@ package mock
 import (
 	"github.com/gmc-norr/cleve"
 	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
 )
 
+// Mock implementing the gin.SampleSheetGetter interface.
+//
+// See [mock.RunGetter] for more information.
 type SampleSheetGetter struct {
-	SampleSheetFn      func(...mongo.SampleSheetOption) (*cleve.SampleSheet, error)
+	SampleSheetFn      func(...mongo.SampleSheetOption) (cleve.SampleSheet, error)
 	SampleSheetInvoked bool
 }
 
-func (g *SampleSheetGetter) SampleSheet(opts ...mongo.SampleSheetOption) (*cleve.SampleSheet, error) {
+func (g *SampleSheetGetter) SampleSheet(opts ...mongo.SampleSheetOption) (cleve.SampleSheet, error) {
 	g.SampleSheetInvoked = true
 	return g.SampleSheetFn(opts...)
 }
@@ -24,3 +28,69 @@ func (s *SampleSheetSetter) CreateSampleSheet(samplesheet cleve.SampleSheet, opt
 	s.CreateSampleSheetInvoked = true
 	return s.CreateSampleSheetFn(samplesheet, opts...)
 }
+
+// Mock implementing the gin.IndexKitGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type IndexKitGetter struct {
+	IndexKitFn      func(string) (cleve.IndexKit, error)
+	IndexKitInvoked bool
+}
+
+func (g *IndexKitGetter) IndexKit(name string) (cleve.IndexKit, error) {
+	g.IndexKitInvoked = true
+	return g.IndexKitFn(name)
+}
+
+// Mock implementing the gin.SampleSheetRevisionGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type SampleSheetRevisionGetter struct {
+	SampleSheetRevisionsFn      func(string) ([]cleve.SampleSheetRevision, error)
+	SampleSheetRevisionsInvoked bool
+	SampleSheetRevisionFn       func(string, int) (cleve.SampleSheetRevision, error)
+	SampleSheetRevisionInvoked  bool
+}
+
+func (g *SampleSheetRevisionGetter) SampleSheetRevisions(runId string) ([]cleve.SampleSheetRevision, error) {
+	g.SampleSheetRevisionsInvoked = true
+	return g.SampleSheetRevisionsFn(runId)
+}
+
+func (g *SampleSheetRevisionGetter) SampleSheetRevision(runId string, revision int) (cleve.SampleSheetRevision, error) {
+	g.SampleSheetRevisionInvoked = true
+	return g.SampleSheetRevisionFn(runId, revision)
+}
+
+// Mock implementing the gin.AnalysisSampleSheetGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type AnalysisSampleSheetGetter struct {
+	AnalysisFn         func(uuid.UUID, ...string) (*cleve.Analysis, error)
+	AnalysisInvoked    bool
+	SampleSheetFn      func(...mongo.SampleSheetOption) (cleve.SampleSheet, error)
+	SampleSheetInvoked bool
+}
+
+func (g *AnalysisSampleSheetGetter) Analysis(analysisId uuid.UUID, runId ...string) (*cleve.Analysis, error) {
+	g.AnalysisInvoked = true
+	return g.AnalysisFn(analysisId, runId...)
+}
+
+func (g *AnalysisSampleSheetGetter) SampleSheet(opts ...mongo.SampleSheetOption) (cleve.SampleSheet, error) {
+	g.SampleSheetInvoked = true
+	return g.SampleSheetFn(opts...)
+}
+
+// Mock implementing the gin.SampleSheetsGetter interface.
+//
+// See [mock.RunGetter] for more information.
+type SampleSheetsGetter struct {
+	SampleSheetsFn      func(cleve.SampleSheetFilter) (*cleve.SampleSheetResult, error)
+	SampleSheetsInvoked bool
+}
+
+func (g *SampleSheetsGetter) SampleSheets(filter cleve.SampleSheetFilter) (*cleve.SampleSheetResult, error) {
+	g.SampleSheetsInvoked = true
+	return g.SampleSheetsFn(filter)
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gmc-norr/cleve"
@@ -42,6 +44,17 @@ func (db DB) Analyses(filter cleve.AnalysisFilter) (cleve.AnalysisResult, error)
 		})
 	}
 
+	if filter.PathPrefix != "" {
+		prefix := strings.TrimSuffix(filter.PathPrefix, "/")
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.D{
+				{Key: "path", Value: bson.D{
+					{Key: "$regex", Value: primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix) + "(/|$)"}},
+				}},
+			}},
+		})
+	}
+
 	if filter.RunId != "" {
 		pipeline = append(pipeline, bson.D{
 			{Key: "$match", Value: bson.D{
@@ -218,6 +231,14 @@ func (db DB) AnalysesFiles(filter cleve.AnalysisFileFilter) ([]cleve.AnalysisFil
 		})
 	}
 
+	if len(filter.ParentIds) > 0 {
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.D{
+				{Key: "output_files.parent_id", Value: bson.D{{Key: "$in", Value: filter.ParentIds}}},
+			}},
+		})
+	}
+
 	cursor, err := db.AnalysesCollection().Aggregate(context.TODO(), pipeline)
 	if err != nil {
 		return nil, err
@@ -286,6 +307,7 @@ func (db DB) UpdateAnalysis(analysis *cleve.Analysis) error {
 			{Key: "$set", Value: bson.D{
 				{Key: "state_history", Value: analysis.StateHistory},
 				{Key: "output_files", Value: analysis.OutputFiles},
+				{Key: "samplesheet_id", Value: analysis.SampleSheetId},
 			}},
 			{Key: "$currentDate", Value: bson.D{{Key: "updated", Value: true}}},
 		},
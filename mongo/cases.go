@@ -0,0 +1,235 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Case retrieves a single case from the database.
+func (db DB) Case(caseId string) (*cleve.Case, error) {
+	var c cleve.Case
+	if err := db.CaseCollection().FindOne(context.TODO(), bson.M{"id": caseId}).Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Cases retrieves cases from the database, most recently updated first. If both a
+// sample ID and a role are given, the sample must have that role in the case.
+func (db DB) Cases(filter cleve.CaseFilter) (*cleve.CaseResult, error) {
+	var caseResult cleve.CaseResult
+
+	var pipeline mongo.Pipeline
+
+	sampleMatch := bson.M{}
+	if filter.SampleId != "" {
+		sampleMatch["sample_id"] = filter.SampleId
+	}
+	if filter.Role != "" {
+		sampleMatch["role"] = filter.Role
+	}
+	if len(sampleMatch) > 0 {
+		pipeline = append(pipeline, bson.D{{
+			Key:   "$match",
+			Value: bson.M{"samples": bson.M{"$elemMatch": sampleMatch}},
+		}})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{
+		{Key: "updated", Value: -1},
+		{Key: "id", Value: 1},
+	}}})
+
+	// Facetting pipeline
+	facetPipeline := mongo.Pipeline{}
+
+	if filter.Page > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$skip",
+			Value: filter.PageSize * (filter.Page - 1),
+		}})
+	}
+
+	if filter.PageSize > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$limit",
+			Value: filter.PageSize,
+		}})
+	}
+
+	// Facetting
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$facet",
+			Value: bson.M{
+				"metadata": bson.A{
+					bson.M{
+						"$count": "total_count",
+					},
+				},
+				"cases": facetPipeline,
+			},
+		},
+	})
+
+	// Projection
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$project",
+			Value: bson.M{
+				"cases": 1,
+				"metadata": bson.M{
+					"$arrayElemAt": bson.A{"$metadata", 0},
+				},
+			},
+		},
+	})
+
+	// Add more pagination metadata
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"metadata.count": bson.M{
+					"$size": "$cases",
+				},
+				"metadata.page":      filter.Page,
+				"metadata.page_size": filter.PageSize,
+				"metadata.total_pages": bson.M{
+					"$cond": bson.M{
+						"if": bson.M{
+							"$gt": bson.A{
+								filter.PageSize,
+								0,
+							},
+						},
+						"then": bson.M{
+							"$ceil": bson.M{
+								"$divide": bson.A{
+									"$metadata.total_count",
+									filter.PageSize,
+								},
+							},
+						},
+						"else": 1,
+					},
+				},
+			},
+		},
+	})
+
+	cursor, err := db.CaseCollection().Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return &caseResult, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	if ok := cursor.Next(context.TODO()); ok {
+		err := cursor.Decode(&caseResult)
+		if err != nil {
+			return &caseResult, err
+		}
+		if caseResult.TotalCount == 0 {
+			// No results found. Represent this as a single page
+			// with an empty slice of cases.
+			caseResult.TotalPages = 1
+		}
+		if caseResult.Page > caseResult.TotalPages {
+			return &caseResult, PageOutOfBoundsError{
+				page:       caseResult.Page,
+				totalPages: caseResult.TotalPages,
+			}
+		}
+	}
+	return &caseResult, cursor.Err()
+}
+
+// CreateCase stores a new case in the database.
+func (db DB) CreateCase(c *cleve.Case) error {
+	c.Created = time.Now()
+	c.Updated = c.Created
+	_, err := db.CaseCollection().InsertOne(context.TODO(), c)
+	return err
+}
+
+// UpdateCase replaces the samples and description of an existing case.
+func (db DB) UpdateCase(c *cleve.Case) error {
+	c.Updated = time.Now()
+	res, err := db.CaseCollection().UpdateOne(
+		context.TODO(),
+		bson.M{"id": c.Id},
+		bson.M{"$set": bson.M{
+			"description": c.Description,
+			"samples":     c.Samples,
+			"updated":     c.Updated,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteCase removes a case from the database. Analysis files referring to the case
+// are left untouched.
+func (db DB) DeleteCase(caseId string) error {
+	res, err := db.CaseCollection().DeleteOne(context.TODO(), bson.M{"id": caseId})
+	if err == nil && res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return err
+}
+
+func (db DB) CaseIndex() ([]map[string]string, error) {
+	cursor, err := db.CaseCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetCaseIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "samples.sample_id", Value: 1}},
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.CaseCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.CaseCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
@@ -94,14 +94,58 @@ func (db DB) RunQCCollection() *mongo.Collection {
 	return db.Collection("run_qc")
 }
 
+func (db DB) SampleQCCollection() *mongo.Collection {
+	return db.Collection("sample_qc")
+}
+
+func (db DB) MultiQCCollection() *mongo.Collection {
+	return db.Collection("multiqc")
+}
+
 func (db DB) SampleCollection() *mongo.Collection {
 	return db.Collection("samples")
 }
 
+func (db DB) CaseCollection() *mongo.Collection {
+	return db.Collection("cases")
+}
+
+func (db DB) DeliveryCollection() *mongo.Collection {
+	return db.Collection("deliveries")
+}
+
+func (db DB) FingerprintCollection() *mongo.Collection {
+	return db.Collection("fingerprints")
+}
+
+func (db DB) FingerprintComparisonCollection() *mongo.Collection {
+	return db.Collection("fingerprint_comparisons")
+}
+
+func (db DB) MetadataSchemaCollection() *mongo.Collection {
+	return db.Collection("metadata_schemas")
+}
+
+func (db DB) ProjectCollection() *mongo.Collection {
+	return db.Collection("projects")
+}
+
+func (db DB) SampleReadsCollection() *mongo.Collection {
+	return db.Collection("sample_reads")
+}
+
 func (db DB) SampleSheetCollection() *mongo.Collection {
 	return db.Collection("samplesheets")
 }
 
+func (db DB) SampleSheetRevisionCollection() *mongo.Collection {
+	return db.Collection("samplesheet_revisions")
+}
+
+func (db DB) IndexKitCollection() *mongo.Collection {
+	return db.Collection("index_kits")
+}
+
 func (db *DB) SetIndexes() error {
 	name, err := db.SetRunIndex()
 	if err != nil {
@@ -127,6 +171,18 @@ func (db *DB) SetIndexes() error {
 	}
 	slog.Info("set index", "collection", "run_qc", "name", name)
 
+	name, err = db.SetSampleQCIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on sample qc, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "sample_qc", "name", name)
+
+	name, err = db.SetMultiQCIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on multiqc, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "multiqc", "name", name)
+
 	name, err = db.SetSampleSheetIndex()
 	if err != nil {
 		return fmt.Errorf("failed to set index on samplesheets, does the collection exist? %w", err)
@@ -139,6 +195,66 @@ func (db *DB) SetIndexes() error {
 	}
 	slog.Info("set index", "collection", "panels", "name", name)
 
+	name, err = db.SetSampleSheetRevisionIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on samplesheet revisions, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "samplesheet_revisions", "name", name)
+
+	name, err = db.SetIndexKitIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on index kits, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "index_kits", "name", name)
+
+	name, err = db.SetSampleIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on samples, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "samples", "name", name)
+
+	name, err = db.SetSampleReadsIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on sample reads, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "sample_reads", "name", name)
+
+	name, err = db.SetProjectIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on projects, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "projects", "name", name)
+
+	name, err = db.SetCaseIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on cases, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "cases", "name", name)
+
+	name, err = db.SetMetadataSchemaIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on metadata schemas, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "metadata_schemas", "name", name)
+
+	name, err = db.SetDeliveryIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on deliveries, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "deliveries", "name", name)
+
+	name, err = db.SetFingerprintIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on fingerprints, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "fingerprints", "name", name)
+
+	name, err = db.SetFingerprintComparisonIndex()
+	if err != nil {
+		return fmt.Errorf("failed to set index on fingerprint comparisons, does the collection exist? %w", err)
+	}
+	slog.Info("set index", "collection", "fingerprint_comparisons", "name", name)
+
 	return nil
 }
 
@@ -182,9 +298,66 @@ func (db *DB) Init(ctx context.Context) error {
 	if _, err := db.SetRunQCIndex(); err != nil {
 		return err
 	}
+	if err := createCollection("sample_qc"); err != nil {
+		return err
+	}
+	if _, err := db.SetSampleQCIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("multiqc"); err != nil {
+		return err
+	}
+	if _, err := db.SetMultiQCIndex(); err != nil {
+		return err
+	}
 	if err := createCollection("samples"); err != nil {
 		return err
 	}
+	if _, err := db.SetSampleIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("sample_reads"); err != nil {
+		return err
+	}
+	if _, err := db.SetSampleReadsIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("projects"); err != nil {
+		return err
+	}
+	if _, err := db.SetProjectIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("cases"); err != nil {
+		return err
+	}
+	if _, err := db.SetCaseIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("metadata_schemas"); err != nil {
+		return err
+	}
+	if _, err := db.SetMetadataSchemaIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("deliveries"); err != nil {
+		return err
+	}
+	if _, err := db.SetDeliveryIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("fingerprints"); err != nil {
+		return err
+	}
+	if _, err := db.SetFingerprintIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("fingerprint_comparisons"); err != nil {
+		return err
+	}
+	if _, err := db.SetFingerprintComparisonIndex(); err != nil {
+		return err
+	}
 	if err := createCollection("panels"); err != nil {
 		return err
 	}
@@ -197,6 +370,18 @@ func (db *DB) Init(ctx context.Context) error {
 	if _, err := db.SetSampleSheetIndex(); err != nil {
 		return err
 	}
+	if err := createCollection("samplesheet_revisions"); err != nil {
+		return err
+	}
+	if _, err := db.SetSampleSheetRevisionIndex(); err != nil {
+		return err
+	}
+	if err := createCollection("index_kits"); err != nil {
+		return err
+	}
+	if _, err := db.SetIndexKitIndex(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -221,6 +406,16 @@ func (db *DB) GetIndexes() (map[string][]map[string]string, error) {
 		return nil, err
 	}
 
+	sampleQcIndex, err := db.SampleQCIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	multiQcIndex, err := db.MultiQCIndex()
+	if err != nil {
+		return nil, err
+	}
+
 	sampleSheetIndex, err := db.SampleSheetIndex()
 	if err != nil {
 		return nil, err
@@ -231,13 +426,75 @@ func (db *DB) GetIndexes() (map[string][]map[string]string, error) {
 		return nil, err
 	}
 
+	sampleSheetRevisionIndex, err := db.SampleSheetRevisionIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	indexKitIndex, err := db.IndexKitIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	sampleIndex, err := db.SampleIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	sampleReadsIndex, err := db.SampleReadsIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	projectIndex, err := db.ProjectIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	caseIndex, err := db.CaseIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	metadataSchemaIndex, err := db.MetadataSchemaIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryIndex, err := db.DeliveryIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprintIndex, err := db.FingerprintIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprintComparisonIndex, err := db.FingerprintComparisonIndex()
+	if err != nil {
+		return nil, err
+	}
+
 	indexes := make(map[string][]map[string]string)
 	indexes["runs"] = runIndex
 	indexes["keys"] = keyIndex
 	indexes["analyses"] = analysesIndex
 	indexes["run_qc"] = runQcIndex
+	indexes["sample_qc"] = sampleQcIndex
+	indexes["multiqc"] = multiQcIndex
 	indexes["samplesheets"] = sampleSheetIndex
 	indexes["panels"] = panelIndex
+	indexes["samplesheet_revisions"] = sampleSheetRevisionIndex
+	indexes["index_kits"] = indexKitIndex
+	indexes["samples"] = sampleIndex
+	indexes["sample_reads"] = sampleReadsIndex
+	indexes["projects"] = projectIndex
+	indexes["cases"] = caseIndex
+	indexes["metadata_schemas"] = metadataSchemaIndex
+	indexes["deliveries"] = deliveryIndex
+	indexes["fingerprints"] = fingerprintIndex
+	indexes["fingerprint_comparisons"] = fingerprintComparisonIndex
 
 	return indexes, nil
 }
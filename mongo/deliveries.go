@@ -0,0 +1,240 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Delivery retrieves a single delivery from the database.
+func (db DB) Delivery(deliveryId uuid.UUID) (*cleve.Delivery, error) {
+	var d cleve.Delivery
+	if err := db.DeliveryCollection().FindOne(context.TODO(), bson.M{"delivery_id": deliveryId}).Decode(&d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Deliveries retrieves deliveries from the database, most recent first.
+func (db DB) Deliveries(filter cleve.DeliveryFilter) (*cleve.DeliveryResult, error) {
+	var deliveryResult cleve.DeliveryResult
+
+	var pipeline mongo.Pipeline
+
+	match := bson.M{}
+	if filter.Project != "" {
+		match["project"] = filter.Project
+	}
+	if filter.SampleId != "" {
+		match["samples"] = filter.SampleId
+	}
+	if len(match) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{
+		{Key: "created", Value: -1},
+		{Key: "delivery_id", Value: 1},
+	}}})
+
+	// Facetting pipeline
+	facetPipeline := mongo.Pipeline{}
+
+	if filter.Page > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$skip",
+			Value: filter.PageSize * (filter.Page - 1),
+		}})
+	}
+
+	if filter.PageSize > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$limit",
+			Value: filter.PageSize,
+		}})
+	}
+
+	// Facetting
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$facet",
+			Value: bson.M{
+				"metadata": bson.A{
+					bson.M{
+						"$count": "total_count",
+					},
+				},
+				"deliveries": facetPipeline,
+			},
+		},
+	})
+
+	// Projection
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$project",
+			Value: bson.M{
+				"deliveries": 1,
+				"metadata": bson.M{
+					"$arrayElemAt": bson.A{"$metadata", 0},
+				},
+			},
+		},
+	})
+
+	// Add more pagination metadata
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"metadata.count": bson.M{
+					"$size": "$deliveries",
+				},
+				"metadata.page":      filter.Page,
+				"metadata.page_size": filter.PageSize,
+				"metadata.total_pages": bson.M{
+					"$cond": bson.M{
+						"if": bson.M{
+							"$gt": bson.A{
+								filter.PageSize,
+								0,
+							},
+						},
+						"then": bson.M{
+							"$ceil": bson.M{
+								"$divide": bson.A{
+									"$metadata.total_count",
+									filter.PageSize,
+								},
+							},
+						},
+						"else": 1,
+					},
+				},
+			},
+		},
+	})
+
+	cursor, err := db.DeliveryCollection().Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return &deliveryResult, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	if ok := cursor.Next(context.TODO()); ok {
+		err := cursor.Decode(&deliveryResult)
+		if err != nil {
+			return &deliveryResult, err
+		}
+		if deliveryResult.TotalCount == 0 {
+			// No results found. Represent this as a single page
+			// with an empty slice of deliveries.
+			deliveryResult.TotalPages = 1
+		}
+		if deliveryResult.Page > deliveryResult.TotalPages {
+			return &deliveryResult, PageOutOfBoundsError{
+				page:       deliveryResult.Page,
+				totalPages: deliveryResult.TotalPages,
+			}
+		}
+	}
+	return &deliveryResult, cursor.Err()
+}
+
+// CreateDelivery stores a new delivery in the database.
+func (db DB) CreateDelivery(d *cleve.Delivery) error {
+	_, err := db.DeliveryCollection().InsertOne(context.TODO(), d)
+	return err
+}
+
+// PendingDeliveries retrieves the deliveries whose checksums have not been computed,
+// oldest first.
+func (db DB) PendingDeliveries() ([]*cleve.Delivery, error) {
+	cursor, err := db.DeliveryCollection().Find(
+		context.TODO(),
+		bson.M{"state": cleve.StatePending},
+		options.Find().SetSort(bson.D{{Key: "created", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*cleve.Delivery, 0)
+	if err := cursor.All(context.TODO(), &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery replaces the files and the state of a delivery, e.g. when the
+// checksums of the files have been computed.
+func (db DB) UpdateDelivery(d *cleve.Delivery) error {
+	res, err := db.DeliveryCollection().UpdateOne(
+		context.TODO(),
+		bson.M{"delivery_id": d.DeliveryId},
+		bson.M{"$set": bson.M{
+			"files": d.Files,
+			"state": d.State,
+			"error": d.Error,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (db DB) DeliveryIndex() ([]map[string]string, error) {
+	cursor, err := db.DeliveryCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetDeliveryIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "delivery_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "project", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "samples", Value: 1}},
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.DeliveryCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.DeliveryCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
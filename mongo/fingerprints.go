@@ -0,0 +1,193 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/fingerprint"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fingerprints retrieves fingerprints from the database.
+func (db DB) Fingerprints(filter cleve.FingerprintFilter) ([]cleve.Fingerprint, error) {
+	query := bson.M{}
+	if filter.SampleId != "" {
+		query["sample_id"] = filter.SampleId
+	}
+	if filter.Panel != "" {
+		query["panel"] = filter.Panel
+	}
+	opts := options.Find().SetSort(bson.D{
+		{Key: "sample_id", Value: 1},
+		{Key: "created", Value: 1},
+	})
+	cursor, err := db.FingerprintCollection().Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	fingerprints := make([]cleve.Fingerprint, 0)
+	if err := cursor.All(context.TODO(), &fingerprints); err != nil {
+		return nil, err
+	}
+	return fingerprints, nil
+}
+
+// SetFingerprint stores the fingerprint of a sample in an analysis, replacing any
+// existing fingerprint of the sample in the analysis for the same SNP panel.
+func (db DB) SetFingerprint(f *cleve.Fingerprint) error {
+	_, err := db.FingerprintCollection().ReplaceOne(
+		context.TODO(),
+		bson.M{
+			"sample_id":   f.SampleId,
+			"analysis_id": f.AnalysisId,
+			"panel":       f.Panel,
+		},
+		f,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+// FingerprintComparisons retrieves the stored comparisons that involve the fingerprints
+// of a sample, in the order they were stored.
+func (db DB) FingerprintComparisons(sampleId string) ([]fingerprint.Comparison, error) {
+	query := bson.M{"$or": bson.A{
+		bson.M{"sample_id": sampleId},
+		bson.M{"other_sample_id": sampleId},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := db.FingerprintComparisonCollection().Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	comparisons := make([]fingerprint.Comparison, 0)
+	if err := cursor.All(context.TODO(), &comparisons); err != nil {
+		return nil, err
+	}
+	return comparisons, nil
+}
+
+// SetFingerprintComparisons replaces the stored comparisons that involve a fingerprint.
+func (db DB) SetFingerprintComparisons(f *cleve.Fingerprint, comparisons []fingerprint.Comparison) error {
+	models := make([]mongo.WriteModel, 0, len(comparisons)+1)
+	models = append(models, mongo.NewDeleteManyModel().SetFilter(bson.M{
+		"panel": f.Panel,
+		"$or": bson.A{
+			bson.M{"sample_id": f.SampleId, "analysis_id": f.AnalysisId},
+			bson.M{"other_sample_id": f.SampleId, "other_analysis_id": f.AnalysisId},
+		},
+	}))
+	for _, c := range comparisons {
+		models = append(models, mongo.NewInsertOneModel().SetDocument(c))
+	}
+	_, err := db.FingerprintComparisonCollection().BulkWrite(context.TODO(), models)
+	return err
+}
+
+func (db DB) FingerprintIndex() ([]map[string]string, error) {
+	cursor, err := db.FingerprintCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetFingerprintIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "sample_id", Value: 1},
+				{Key: "analysis_id", Value: 1},
+				{Key: "panel", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "panel", Value: 1}},
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.FingerprintCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.FingerprintCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
+
+func (db DB) FingerprintComparisonIndex() ([]map[string]string, error) {
+	cursor, err := db.FingerprintComparisonCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetFingerprintComparisonIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "sample_id", Value: 1},
+				{Key: "analysis_id", Value: 1},
+				{Key: "panel", Value: 1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "other_sample_id", Value: 1},
+				{Key: "other_analysis_id", Value: 1},
+				{Key: "panel", Value: 1},
+			},
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.FingerprintComparisonCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.FingerprintComparisonCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
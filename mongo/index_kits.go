@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateIndexKit stores an index kit in the database. If a kit with the same name
+// already exists, it is replaced.
+func (db DB) CreateIndexKit(kit cleve.IndexKit) error {
+	_, err := db.IndexKitCollection().ReplaceOne(
+		context.TODO(),
+		bson.D{{Key: "name", Value: kit.Name}},
+		kit,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+// IndexKit retrieves an index kit by name. If no kit with the name exists,
+// ErrNoDocuments is returned.
+func (db DB) IndexKit(name string) (cleve.IndexKit, error) {
+	var kit cleve.IndexKit
+	err := db.IndexKitCollection().FindOne(context.TODO(), bson.D{{Key: "name", Value: name}}).Decode(&kit)
+	return kit, err
+}
+
+// IndexKits retrieves all index kits, sorted by name.
+func (db DB) IndexKits() ([]cleve.IndexKit, error) {
+	kits := []cleve.IndexKit{}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := db.IndexKitCollection().Find(context.TODO(), bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	if err := cursor.All(context.TODO(), &kits); err != nil {
+		return nil, err
+	}
+	return kits, nil
+}
+
+// DeleteIndexKit deletes an index kit by name. If no kit with the name exists,
+// ErrNoDocuments is returned.
+func (db DB) DeleteIndexKit(name string) error {
+	res, err := db.IndexKitCollection().DeleteOne(context.TODO(), bson.D{{Key: "name", Value: name}})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (db DB) IndexKitIndex() ([]map[string]string, error) {
+	cursor, err := db.IndexKitCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetIndexKitIndex() (string, error) {
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.IndexKitCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.IndexKitCollection().Indexes().CreateOne(context.TODO(), indexModel)
+	return name, err
+}
@@ -0,0 +1,144 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MetadataSchema retrieves a single sample metadata schema from the database.
+func (db DB) MetadataSchema(name string) (*cleve.MetadataSchema, error) {
+	var schema cleve.MetadataSchema
+	if err := db.MetadataSchemaCollection().FindOne(context.TODO(), bson.M{"name": name}).Decode(&schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// MetadataSchemas retrieves all sample metadata schemas from the database, sorted by
+// name.
+func (db DB) MetadataSchemas() ([]cleve.MetadataSchema, error) {
+	cursor, err := db.MetadataSchemaCollection().Find(
+		context.TODO(),
+		bson.D{},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	schemas := []cleve.MetadataSchema{}
+	if err := cursor.All(context.TODO(), &schemas); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// CreateMetadataSchema stores a new sample metadata schema in the database.
+func (db DB) CreateMetadataSchema(schema *cleve.MetadataSchema) error {
+	schema.Created = time.Now()
+	schema.Updated = schema.Created
+	_, err := db.MetadataSchemaCollection().InsertOne(context.TODO(), schema)
+	return err
+}
+
+// UpdateMetadataSchema replaces the definition of an existing sample metadata schema.
+// Metadata already stored for samples is not revalidated.
+func (db DB) UpdateMetadataSchema(schema *cleve.MetadataSchema) error {
+	schema.Updated = time.Now()
+	res, err := db.MetadataSchemaCollection().UpdateOne(
+		context.TODO(),
+		bson.M{"name": schema.Name},
+		bson.M{"$set": bson.M{
+			"description": schema.Description,
+			"project":     schema.Project,
+			"sample_type": schema.SampleType,
+			"schema":      schema.Schema,
+			"updated":     schema.Updated,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteMetadataSchema removes a sample metadata schema from the database. Metadata
+// stored for samples is left untouched.
+func (db DB) DeleteMetadataSchema(name string) error {
+	res, err := db.MetadataSchemaCollection().DeleteOne(context.TODO(), bson.M{"name": name})
+	if err == nil && res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return err
+}
+
+// metadataMatch returns a match expression for metadata conditions. Values are
+// matched as strings, and also as numbers or booleans if they can be parsed as such.
+func metadataMatch(conditions map[string]string) bson.M {
+	match := bson.A{}
+	for key, value := range conditions {
+		field := "metadata." + key
+		values := bson.A{value}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			values = append(values, f)
+		}
+		if b, err := strconv.ParseBool(value); err == nil {
+			values = append(values, b)
+		}
+		match = append(match, bson.M{field: bson.M{"$in": values}})
+	}
+	return bson.M{"$and": match}
+}
+
+func (db DB) MetadataSchemaIndex() ([]map[string]string, error) {
+	cursor, err := db.MetadataSchemaCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetMetadataSchemaIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.MetadataSchemaCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.MetadataSchemaCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateMultiQCMetrics stores MultiQC metrics in the database. Existing metrics for
+// the same sample, analysis and source file are replaced. Samples that are not
+// already in the samples collection are added to it.
+func (db DB) UpdateMultiQCMetrics(metrics []cleve.MultiQCSampleMetrics) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(metrics))
+	sampleModels := make([]mongo.WriteModel, len(metrics))
+	for i, m := range metrics {
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{
+				{Key: "sample_id", Value: m.SampleId},
+				{Key: "analysis_id", Value: m.AnalysisId},
+				{Key: "source", Value: m.Source},
+			}).
+			SetReplacement(m).
+			SetUpsert(true)
+		sampleModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "id", Value: m.SampleId}}).
+			SetUpdate(bson.D{{Key: "$setOnInsert", Value: cleve.Sample{
+				Id:       m.SampleId,
+				Name:     m.SampleId,
+				Fastq:    []string{},
+				Analyses: []*cleve.SampleAnalysis{},
+			}}}).
+			SetUpsert(true)
+	}
+	if _, err := db.MultiQCCollection().BulkWrite(context.TODO(), models); err != nil {
+		return err
+	}
+	_, err := db.SampleCollection().BulkWrite(context.TODO(), sampleModels)
+	return err
+}
+
+// SampleMultiQC retrieves all MultiQC metrics for a sample. If there are no metrics
+// for the sample, ErrNoDocuments is returned.
+func (db DB) SampleMultiQC(sampleId string) ([]cleve.MultiQCSampleMetrics, error) {
+	return db.multiQC(bson.D{{Key: "sample_id", Value: sampleId}})
+}
+
+// RunsMultiQC retrieves the MultiQC metrics for all samples in any of the given
+// runs. If there are no metrics for the runs, ErrNoDocuments is returned.
+func (db DB) RunsMultiQC(runIds []string) ([]cleve.MultiQCSampleMetrics, error) {
+	return db.multiQC(bson.D{{Key: "runs", Value: bson.D{{Key: "$in", Value: runIds}}}})
+}
+
+func (db DB) multiQC(filter bson.D) ([]cleve.MultiQCSampleMetrics, error) {
+	var metrics []cleve.MultiQCSampleMetrics
+	opts := options.Find().SetSort(bson.D{{Key: "sample_id", Value: 1}})
+	cursor, err := db.MultiQCCollection().Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	if err := cursor.All(context.TODO(), &metrics); err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return metrics, nil
+}
+
+// MultiQCMetricHeaders returns all distinct MultiQC metrics that are stored in the
+// database, sorted by module and name.
+func (db DB) MultiQCMetricHeaders() ([]cleve.MultiQCMetricHeader, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$unwind", Value: "$metrics"}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "module", Value: "$metrics.module"},
+				{Key: "name", Value: "$metrics.name"},
+			}},
+			{Key: "title", Value: bson.D{{Key: "$first", Value: "$metrics.title"}}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "module", Value: "$_id.module"},
+			{Key: "name", Value: "$_id.name"},
+			{Key: "title", Value: 1},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "module", Value: 1},
+			{Key: "name", Value: 1},
+		}}},
+	}
+	cursor, err := db.MultiQCCollection().Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	var headers []cleve.MultiQCMetricHeader
+	if err := cursor.All(context.TODO(), &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+func (db DB) MultiQCIndex() ([]map[string]string, error) {
+	cursor, err := db.MultiQCCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetMultiQCIndex() (string, error) {
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "sample_id", Value: 1},
+			{Key: "analysis_id", Value: 1},
+			{Key: "source", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.MultiQCCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.MultiQCCollection().Indexes().CreateOne(context.TODO(), indexModel)
+	return name, err
+}
@@ -0,0 +1,305 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateRunProjects links projects to a run, and unlinks the run from projects that
+// are no longer listed for it. Projects that do not exist are created in the
+// sequenced delivery state.
+func (db DB) UpdateRunProjects(runId string, projects []string) error {
+	_, err := db.ProjectCollection().BulkWrite(context.TODO(), runProjectsModels(runId, projects), options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// runProjectsModels returns the write models that set the projects of a run.
+func runProjectsModels(runId string, projects []string) []mongo.WriteModel {
+	if projects == nil {
+		projects = []string{}
+	}
+	models := make([]mongo.WriteModel, 0, len(projects)+1)
+	models = append(models, mongo.NewUpdateManyModel().
+		SetFilter(bson.M{
+			"runs": runId,
+			"name": bson.M{"$nin": projects},
+		}).
+		SetUpdate(bson.M{"$pull": bson.M{"runs": runId}}),
+	)
+	for _, name := range projects {
+		p := cleve.NewProject(name)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"name": name}).
+			SetUpdate(bson.M{
+				"$addToSet": bson.M{"runs": runId},
+				"$setOnInsert": bson.M{
+					"delivery_state":   p.DeliveryState,
+					"delivery_history": p.DeliveryHistory,
+				},
+			}).
+			SetUpsert(true),
+		)
+	}
+	return models
+}
+
+// Projects retrieves projects from the database, sorted by name. The number of samples
+// is set for each project, but not the reads and yield, see [DB.Project].
+func (db DB) Projects(filter cleve.ProjectFilter) (*cleve.ProjectResult, error) {
+	var projectResult cleve.ProjectResult
+
+	var pipeline mongo.Pipeline
+
+	if filter.NameQuery != "" {
+		pipeline = append(pipeline, bson.D{{
+			Key: "$match",
+			Value: bson.M{"$expr": bson.M{"$regexMatch": bson.M{
+				"input":   "$name",
+				"regex":   filter.NameQuery,
+				"options": "i",
+			}}},
+		}})
+	}
+
+	if filter.RunId != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"runs": filter.RunId}}})
+	}
+
+	if filter.DeliveryState != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"delivery_state": filter.DeliveryState}}})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}}}})
+
+	// Facetting pipeline
+	facetPipeline := mongo.Pipeline{}
+
+	if filter.Page > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$skip",
+			Value: filter.PageSize * (filter.Page - 1),
+		}})
+	}
+
+	if filter.PageSize > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$limit",
+			Value: filter.PageSize,
+		}})
+	}
+
+	// Sample counts are only needed for the current page
+	facetPipeline = append(facetPipeline,
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "samples",
+			"localField":   "name",
+			"foreignField": "project",
+			"pipeline":     bson.A{bson.M{"$project": bson.M{"_id": 1}}},
+			"as":           "samples",
+		}}},
+		bson.D{{Key: "$set", Value: bson.M{"sample_count": bson.M{"$size": "$samples"}}}},
+		bson.D{{Key: "$unset", Value: "samples"}},
+	)
+
+	// Facetting
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$facet",
+			Value: bson.M{
+				"metadata": bson.A{
+					bson.M{
+						"$count": "total_count",
+					},
+				},
+				"projects": facetPipeline,
+			},
+		},
+	})
+
+	// Projection
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$project",
+			Value: bson.M{
+				"projects": 1,
+				"metadata": bson.M{
+					"$arrayElemAt": bson.A{"$metadata", 0},
+				},
+			},
+		},
+	})
+
+	// Add more pagination metadata
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"metadata.count": bson.M{
+					"$size": "$projects",
+				},
+				"metadata.page":      filter.Page,
+				"metadata.page_size": filter.PageSize,
+				"metadata.total_pages": bson.M{
+					"$cond": bson.M{
+						"if": bson.M{
+							"$gt": bson.A{
+								filter.PageSize,
+								0,
+							},
+						},
+						"then": bson.M{
+							"$ceil": bson.M{
+								"$divide": bson.A{
+									"$metadata.total_count",
+									filter.PageSize,
+								},
+							},
+						},
+						"else": 1,
+					},
+				},
+			},
+		},
+	})
+
+	cursor, err := db.ProjectCollection().Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return &projectResult, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	if ok := cursor.Next(context.TODO()); ok {
+		err := cursor.Decode(&projectResult)
+		if err != nil {
+			return &projectResult, err
+		}
+		if projectResult.TotalCount == 0 {
+			// No results found. Represent this as a single page
+			// with an empty slice of projects.
+			projectResult.TotalPages = 1
+		}
+		if projectResult.Page > projectResult.TotalPages {
+			return &projectResult, PageOutOfBoundsError{
+				page:       projectResult.Page,
+				totalPages: projectResult.TotalPages,
+			}
+		}
+	}
+	return &projectResult, cursor.Err()
+}
+
+// Project retrieves a single project from the database, together with the number of
+// samples and the reads and yield of all the samples across all runs.
+func (db DB) Project(name string) (*cleve.Project, error) {
+	var project cleve.Project
+	if err := db.ProjectCollection().FindOne(context.TODO(), bson.M{"name": name}).Decode(&project); err != nil {
+		return nil, err
+	}
+
+	cursor, err := db.SampleCollection().Find(context.TODO(), bson.M{"project": name})
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	var samples []cleve.Sample
+	if err := cursor.All(context.TODO(), &samples); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(samples))
+	for _, s := range samples {
+		ids = append(ids, s.Id)
+	}
+	reads, err := db.SampleReads(ids...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range samples {
+		samples[i].SetReads(reads)
+	}
+	project.SampleCount = len(samples)
+	project.SetReads(samples)
+	return &project, nil
+}
+
+// SetProjectDeliveryState moves a project to the next delivery state, see
+// [cleve.Project.SetDeliveryState]. An [ErrConflict] is returned if the project
+// cannot move to the state.
+func (db DB) SetProjectDeliveryState(name string, state cleve.DeliveryState) (*cleve.Project, error) {
+	project, err := db.Project(name)
+	if err != nil {
+		return nil, err
+	}
+	previous := project.DeliveryState
+	if err := project.SetDeliveryState(state); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	entry := project.DeliveryHistory[len(project.DeliveryHistory)-1]
+	res, err := db.ProjectCollection().UpdateOne(
+		context.TODO(),
+		bson.M{"name": name, "delivery_state": previous},
+		bson.M{
+			"$set":  bson.M{"delivery_state": state},
+			"$push": bson.M{"delivery_history": entry},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, fmt.Errorf("%w: delivery state of project %s was changed concurrently", ErrConflict, name)
+	}
+	return project, nil
+}
+
+func (db DB) ProjectIndex() ([]map[string]string, error) {
+	cursor, err := db.ProjectCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetProjectIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "runs", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "delivery_state", Value: 1}},
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.ProjectCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.ProjectCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
@@ -0,0 +1,57 @@
+package mongo
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRunProjectsModels(t *testing.T) {
+	cases := []struct {
+		name     string
+		projects []string
+		kept     []string
+	}{
+		{
+			name:     "renamed project",
+			projects: []string{"project2"},
+			kept:     []string{"project2"},
+		},
+		{
+			name: "no projects",
+			kept: []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			models := runProjectsModels("run1", c.projects)
+			if len(models) != len(c.projects)+1 {
+				t.Fatalf("expected %d models, got %d", len(c.projects)+1, len(models))
+			}
+			unlink, ok := models[0].(*mongo.UpdateManyModel)
+			if !ok {
+				t.Fatalf("expected the first model to unlink projects, got %T", models[0])
+			}
+			filter := bson.M{"runs": "run1", "name": bson.M{"$nin": c.kept}}
+			if !reflect.DeepEqual(unlink.Filter, filter) {
+				t.Errorf("expected filter %v, got %v", filter, unlink.Filter)
+			}
+			update := bson.M{"$pull": bson.M{"runs": "run1"}}
+			if !reflect.DeepEqual(unlink.Update, update) {
+				t.Errorf("expected update %v, got %v", update, unlink.Update)
+			}
+			for i, name := range c.projects {
+				link, ok := models[i+1].(*mongo.UpdateOneModel)
+				if !ok {
+					t.Fatalf("expected model %d to link a project, got %T", i+1, models[i+1])
+				}
+				if !reflect.DeepEqual(link.Filter, bson.M{"name": name}) {
+					t.Errorf("expected project %s to be linked, got %v", name, link.Filter)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/gmc-norr/cleve"
@@ -25,6 +26,31 @@ func (db DB) CreateRunQC(runId string, qc interop.InteropSummary) error {
 	return err
 }
 
+// UpdateFromIndexSummary stores the read counts of the samples from the index summary
+// of the run QC, and sets the projects in the index metrics as the projects of the
+// run, unless the index metrics have no projects. This is done separately from
+// storing the run QC. Nothing is done for summaries computed before the read counts
+// were part of the index summary.
+func (db DB) UpdateFromIndexSummary(runId string, summary interop.IndexSummary) error {
+	if len(summary.Lanes) == 0 {
+		return nil
+	}
+	reads := cleve.SampleReadsFromIndexSummary(runId, summary)
+	if err := db.UpdateSampleReads(runId, cleve.ReadsFromIndexMetrics, reads); err != nil {
+		return err
+	}
+	var projects []string
+	for _, l := range summary.Lanes {
+		if l.Project != "" && !slices.Contains(projects, l.Project) {
+			projects = append(projects, l.Project)
+		}
+	}
+	if len(projects) == 0 {
+		return nil
+	}
+	return db.UpdateRunProjects(runId, projects)
+}
+
 func (db DB) DeleteRunQC(runId string) error {
 	_, err := db.RunQCCollection().DeleteOne(context.TODO(), bson.D{
 		{Key: "run_id", Value: runId},
@@ -217,6 +243,34 @@ func (db DB) RunQC(runId string) (interop.InteropSummary, error) {
 	return is, nil
 }
 
+// RunQCsById retrieves the QC data of several runs in a single query, keyed by run ID.
+// Runs without QC data are not part of the result.
+func (db DB) RunQCsById(runIds ...string) (map[string]interop.InteropSummary, error) {
+	qcs := make(map[string]interop.InteropSummary, len(runIds))
+	if len(runIds) == 0 {
+		return qcs, nil
+	}
+	cursor, err := db.RunQCCollection().Find(context.TODO(), bson.D{{Key: "run_id", Value: bson.D{{Key: "$in", Value: runIds}}}})
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	for cursor.Next(context.TODO()) {
+		var auxQc struct {
+			Version int                    `bson:"schema_version"`
+			Qc      interop.InteropSummary `bson:",inline"`
+		}
+		if err := cursor.Decode(&auxQc); err != nil {
+			return nil, err
+		}
+		if auxQc.Version < 2 {
+			auxQc.Qc.Date = time.Time{}
+		}
+		qcs[auxQc.Qc.RunId] = auxQc.Qc
+	}
+	return qcs, cursor.Err()
+}
+
 func (db DB) RunQCIndex() ([]map[string]string, error) {
 	cursor, err := db.RunQCCollection().Indexes().List(context.TODO())
 	if err != nil {
@@ -259,3 +313,34 @@ func (db DB) SetRunQCIndex() (string, error) {
 	name, err := db.RunQCCollection().Indexes().CreateOne(context.TODO(), indexModel)
 	return name, err
 }
+
+// StaleRunQC returns the runs with QC data that was computed with an older version of
+// the QC computation than [interop.SummaryComputationVersion]. QC data without a
+// computation version is considered stale.
+func (db DB) StaleRunQC() ([]cleve.StaleQc, error) {
+	cursor, err := db.RunQCCollection().Find(
+		context.TODO(),
+		bson.D{
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "computation_version", Value: bson.D{{Key: "$exists", Value: false}}}},
+				bson.D{{Key: "computation_version", Value: bson.D{{Key: "$lt", Value: interop.SummaryComputationVersion}}}},
+			}},
+		},
+		options.Find().
+			SetProjection(bson.D{
+				{Key: "run_id", Value: 1},
+				{Key: "computation_version", Value: 1},
+				{Key: "computed_at", Value: 1},
+			}).
+			SetSort(bson.D{{Key: "run_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	stale := make([]cleve.StaleQc, 0)
+	if err := cursor.All(context.TODO(), &stale); err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
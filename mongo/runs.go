@@ -54,6 +54,22 @@ func (db DB) Runs(filter cleve.RunFilter) (cleve.RunResult, error) {
 		}},
 	})
 
+	// Filter on sequencing date
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		dateFilter := bson.D{}
+		if !filter.From.IsZero() {
+			dateFilter = append(dateFilter, bson.E{Key: "$gte", Value: filter.From})
+		}
+		if !filter.To.IsZero() {
+			dateFilter = append(dateFilter, bson.E{Key: "$lte", Value: filter.To})
+		}
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.D{
+				{Key: "sequencing_date", Value: dateFilter},
+			}},
+		})
+	}
+
 	// Strict match on run id
 	if filter.RunID != "" {
 		pipeline = append(pipeline, bson.D{
@@ -66,6 +82,21 @@ func (db DB) Runs(filter cleve.RunFilter) (cleve.RunResult, error) {
 		})
 	}
 
+	// Filter on runs that a project has samples on
+	if filter.Project != "" {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from":         "projects",
+				"localField":   "run_id",
+				"foreignField": "runs",
+				"pipeline":     bson.A{bson.M{"$match": bson.M{"name": filter.Project}}},
+				"as":           "projects",
+			}}},
+			bson.D{{Key: "$match", Value: bson.M{"projects": bson.M{"$ne": bson.A{}}}}},
+			bson.D{{Key: "$unset", Value: "projects"}},
+		)
+	}
+
 	// Regex match on run id
 	if filter.RunIdQuery != "" {
 		pipeline = append(pipeline, bson.D{
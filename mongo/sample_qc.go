@@ -0,0 +1,178 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateSampleQC stores sample QC metrics in the database. Existing metrics for the
+// same sample and analysis are replaced.
+func (db DB) UpdateSampleQC(metrics []cleve.DragenSampleMetrics) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(metrics))
+	for i, m := range metrics {
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{
+				{Key: "sample_id", Value: m.SampleId},
+				{Key: "analysis_id", Value: m.AnalysisId},
+			}).
+			SetReplacement(m).
+			SetUpsert(true)
+	}
+	_, err := db.SampleQCCollection().BulkWrite(context.TODO(), models)
+	return err
+}
+
+// SampleQC retrieves all QC metrics for a sample. If there are no metrics for the
+// sample, ErrNoDocuments is returned.
+func (db DB) SampleQC(sampleId string) ([]cleve.DragenSampleMetrics, error) {
+	return db.sampleQC(bson.D{{Key: "sample_id", Value: sampleId}})
+}
+
+// RunSampleQC retrieves the QC metrics for all samples in a run. If there are no
+// metrics for the run, ErrNoDocuments is returned.
+func (db DB) RunSampleQC(runId string) ([]cleve.DragenSampleMetrics, error) {
+	return db.sampleQC(bson.D{{Key: "run_id", Value: runId}})
+}
+
+func (db DB) sampleQC(filter bson.D) ([]cleve.DragenSampleMetrics, error) {
+	var metrics []cleve.DragenSampleMetrics
+	opts := options.Find().SetSort(bson.D{
+		{Key: "run_id", Value: 1},
+		{Key: "sample_id", Value: 1},
+	})
+	cursor, err := db.SampleQCCollection().Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	if err := cursor.All(context.TODO(), &metrics); err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return metrics, nil
+}
+
+func (db DB) SampleQCIndex() ([]map[string]string, error) {
+	cursor, err := db.SampleQCCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetSampleQCIndex() (string, error) {
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "sample_id", Value: 1},
+			{Key: "analysis_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.SampleQCCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.SampleQCCollection().Indexes().CreateOne(context.TODO(), indexModel)
+	return name, err
+}
+
+// SampleSexChecks compares the inferred sex in the QC metrics of a sample with the
+// sex declared in the sample metadata. If there are no metrics for the sample,
+// ErrNoDocuments is returned.
+func (db DB) SampleSexChecks(sampleId string) ([]cleve.SexCheck, error) {
+	metrics, err := db.SampleQC(sampleId)
+	if err != nil {
+		return nil, err
+	}
+	return db.SexChecks(metrics)
+}
+
+// RunSexChecks compares the inferred sex in the QC metrics of the samples in a run
+// with the sex declared in the sample metadata. If there are no metrics for the run,
+// ErrNoDocuments is returned.
+func (db DB) RunSexChecks(runId string) ([]cleve.SexCheck, error) {
+	metrics, err := db.RunSampleQC(runId)
+	if err != nil {
+		return nil, err
+	}
+	return db.SexChecks(metrics)
+}
+
+// RunsSexChecks compares the inferred sex in the QC metrics of the samples in several
+// runs with the sex declared in the sample metadata, keyed by run ID. Runs without
+// metrics are not part of the result.
+func (db DB) RunsSexChecks(runIds ...string) (map[string][]cleve.SexCheck, error) {
+	checks := make(map[string][]cleve.SexCheck, len(runIds))
+	if len(runIds) == 0 {
+		return checks, nil
+	}
+	metrics, err := db.sampleQC(bson.D{{Key: "run_id", Value: bson.D{{Key: "$in", Value: runIds}}}})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return checks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sexChecks, err := db.SexChecks(metrics)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range metrics {
+		checks[m.RunId] = append(checks[m.RunId], sexChecks[i])
+	}
+	return checks, nil
+}
+
+// SexChecks compares the inferred sex in sample QC metrics with the sex declared in
+// the sample metadata. There is one check per metrics entry, in the same order.
+func (db DB) SexChecks(metrics []cleve.DragenSampleMetrics) ([]cleve.SexCheck, error) {
+	sampleIds := make([]string, len(metrics))
+	for i, m := range metrics {
+		sampleIds[i] = m.SampleId
+	}
+	opts := options.Find().SetProjection(bson.D{
+		{Key: "id", Value: 1},
+		{Key: "metadata", Value: 1},
+	})
+	cursor, err := db.SampleCollection().Find(context.TODO(), bson.M{"id": bson.M{"$in": sampleIds}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	var samples []cleve.Sample
+	if err := cursor.All(context.TODO(), &samples); err != nil {
+		return nil, err
+	}
+	return cleve.SexChecks(metrics, samples), nil
+}
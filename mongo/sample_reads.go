@@ -0,0 +1,109 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateSampleReads replaces the read counts from a specific source of the samples in a
+// run. Only the samples that are part of the read counts are replaced, so that the read
+// counts of other samples in the run are kept, e.g. when only some of the samples are
+// demultiplexed again.
+func (db DB) UpdateSampleReads(runId string, source cleve.ReadsSource, reads []cleve.SampleLaneReads) error {
+	if len(reads) == 0 {
+		return nil
+	}
+	var sampleIds []string
+	for _, r := range reads {
+		if r.RunId != runId || r.Source != source {
+			return fmt.Errorf("read count for %s from %s, expected %s from %s", r.RunId, r.Source, runId, source)
+		}
+		if !slices.Contains(sampleIds, r.SampleId) {
+			sampleIds = append(sampleIds, r.SampleId)
+		}
+	}
+	models := make([]mongo.WriteModel, 0, len(reads)+1)
+	models = append(models, mongo.NewDeleteManyModel().SetFilter(bson.D{
+		{Key: "run_id", Value: runId},
+		{Key: "source", Value: source},
+		{Key: "sample_id", Value: bson.D{{Key: "$in", Value: sampleIds}}},
+	}))
+	for _, r := range reads {
+		models = append(models, mongo.NewInsertOneModel().SetDocument(r))
+	}
+	_, err := db.SampleReadsCollection().BulkWrite(context.TODO(), models, options.BulkWrite().SetOrdered(true))
+	return err
+}
+
+// SampleReads retrieves the read counts of the given samples across all runs.
+func (db DB) SampleReads(sampleIds ...string) ([]cleve.SampleLaneReads, error) {
+	cursor, err := db.SampleReadsCollection().Find(
+		context.TODO(),
+		bson.M{"sample_id": bson.M{"$in": sampleIds}},
+		options.Find().SetSort(bson.D{{Key: "run_id", Value: 1}, {Key: "lane", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	var reads []cleve.SampleLaneReads
+	err = cursor.All(context.TODO(), &reads)
+	return reads, err
+}
+
+func (db DB) SampleReadsIndex() ([]map[string]string, error) {
+	cursor, err := db.SampleReadsCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetSampleReadsIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "run_id", Value: 1},
+				{Key: "lane", Value: 1},
+				{Key: "sample_id", Value: 1},
+				{Key: "source", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "sample_id", Value: 1}},
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.SampleReadsCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.SampleReadsCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
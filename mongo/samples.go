@@ -2,19 +2,26 @@ package mongo
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gmc-norr/cleve"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Retrieves a single sample from the database.
 func (db DB) Sample(sampleId string) (*cleve.Sample, error) {
-	var sample *cleve.Sample
-	if err := db.SampleCollection().FindOne(context.TODO(), bson.M{"id": sampleId}).Decode(sample); err != nil {
-		return sample, err
+	var sample cleve.Sample
+	if err := db.SampleCollection().FindOne(context.TODO(), bson.M{"id": sampleId}).Decode(&sample); err != nil {
+		return nil, err
 	}
-	return sample, nil
+	reads, err := db.SampleReads(sample.Id)
+	if err != nil {
+		return nil, err
+	}
+	sample.SetReads(reads)
+	return &sample, nil
 }
 
 // Retrieves samples from the database.
@@ -41,6 +48,54 @@ func (db DB) Samples(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
 		})
 	}
 
+	if filter.Id != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"id": filter.Id}}})
+	}
+
+	if filter.RunId != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"runs.run_id": filter.RunId}}})
+	}
+
+	if filter.Project != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"project": filter.Project}}})
+	}
+
+	if filter.Metadata != "" {
+		conditions, err := cleve.ParseMetadataFilter(filter.Metadata)
+		if err != nil {
+			return &sampleResult, err
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: metadataMatch(conditions)}})
+	}
+
+	// The panel can either be referred to by the sample itself, or by one of its
+	// analyses.
+	if filter.Panel != "" {
+		panelMatch := bson.M{"id": filter.Panel}
+		if filter.PanelVersion != "" {
+			panelMatch["version"] = filter.PanelVersion
+		}
+		pipeline = append(pipeline, bson.D{{
+			Key: "$match",
+			Value: bson.M{"$or": bson.A{
+				bson.M{"panels": bson.M{"$elemMatch": panelMatch}},
+				bson.M{"analyses.panels": bson.M{"$elemMatch": panelMatch}},
+			}},
+		}})
+	}
+
+	// The analysis can either be a pipeline that the sample has been analysed with, or
+	// an application that the sample is listed with in a samplesheet.
+	if filter.Analysis != "" {
+		pipeline = append(pipeline, bson.D{{
+			Key: "$match",
+			Value: bson.M{"$or": bson.A{
+				bson.M{"analyses.pipeline.name": filter.Analysis},
+				bson.M{"runs.applications": filter.Analysis},
+			}},
+		}})
+	}
+
 	// Facetting pipeline
 	facetPipeline := mongo.Pipeline{}
 
@@ -141,7 +196,23 @@ func (db DB) Samples(filter *cleve.SampleFilter) (*cleve.SampleResult, error) {
 			}
 		}
 	}
-	return &sampleResult, cursor.Err()
+	if err := cursor.Err(); err != nil {
+		return &sampleResult, err
+	}
+	if len(sampleResult.Samples) > 0 {
+		ids := make([]string, 0, len(sampleResult.Samples))
+		for _, s := range sampleResult.Samples {
+			ids = append(ids, s.Id)
+		}
+		reads, err := db.SampleReads(ids...)
+		if err != nil {
+			return &sampleResult, err
+		}
+		for i := range sampleResult.Samples {
+			sampleResult.Samples[i].SetReads(reads)
+		}
+	}
+	return &sampleResult, nil
 }
 
 // CreateSample stores a sample in the database.
@@ -155,3 +226,160 @@ func (db DB) CreateSamples(samples []*cleve.Sample) error {
 	_, err := db.SampleCollection().InsertMany(context.TODO(), []any{samples})
 	return err
 }
+
+// UpdateSample updates the target reads, the metadata and/or the gene panels of a
+// sample in a single update. The metadata should be validated before it is stored, see
+// [cleve.Sample.ValidateMetadata].
+func (db DB) UpdateSample(sampleId string, update cleve.SampleUpdate) error {
+	set := bson.M{}
+	unset := bson.M{}
+	if update.TargetReads != nil {
+		if *update.TargetReads == 0 {
+			unset["target_reads"] = ""
+		} else {
+			set["target_reads"] = *update.TargetReads
+		}
+	}
+	if update.Metadata != nil {
+		if len(update.Metadata) == 0 {
+			unset["metadata"] = ""
+		} else {
+			set["metadata"] = update.Metadata
+		}
+	}
+	if update.Panels != nil {
+		if len(update.Panels) == 0 {
+			unset["panels"] = ""
+		} else {
+			set["panels"] = update.Panels
+		}
+	}
+	doc := bson.M{}
+	if len(set) > 0 {
+		doc["$set"] = set
+	}
+	if len(unset) > 0 {
+		doc["$unset"] = unset
+	}
+	if len(doc) == 0 {
+		return fmt.Errorf("nothing to update")
+	}
+	res, err := db.SampleCollection().UpdateOne(context.TODO(), bson.M{"id": sampleId}, doc)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateRunSamples updates the sample registry with the samples listed in the
+// samplesheet of a run. Samples are created if they do not exist, and the name,
+// project and the run entries for the run are replaced for samples that do exist.
+// Samples that are no longer listed in the samplesheet are unlinked from the run.
+// Calling this repeatedly with the same samples leaves the registry unchanged.
+func (db DB) UpdateRunSamples(runId string, samples []cleve.Sample) error {
+	ids := make([]string, 0, len(samples))
+	models := make([]mongo.WriteModel, 0, len(samples)+1)
+	for _, s := range samples {
+		ids = append(ids, s.Id)
+	}
+
+	models = append(models, mongo.NewUpdateManyModel().
+		SetFilter(bson.D{
+			{Key: "runs.run_id", Value: runId},
+			{Key: "id", Value: bson.M{"$nin": ids}},
+		}).
+		SetUpdate(bson.M{"$pull": bson.M{"runs": bson.M{"run_id": runId}}}),
+	)
+
+	for _, s := range samples {
+		runs := make([]cleve.SampleRun, 0, len(s.Runs))
+		for _, r := range s.Runs {
+			if r.RunId != runId {
+				return fmt.Errorf("sample %s has run entry for %s, expected %s", s.Id, r.RunId, runId)
+			}
+			runs = append(runs, r)
+		}
+		set := bson.M{
+			"name":     s.Name,
+			"fastq":    bson.M{"$ifNull": bson.A{"$fastq", bson.A{}}},
+			"analyses": bson.M{"$ifNull": bson.A{"$analyses", bson.A{}}},
+			"runs": bson.M{
+				"$concatArrays": bson.A{
+					bson.M{"$filter": bson.M{
+						"input": bson.M{"$ifNull": bson.A{"$runs", bson.A{}}},
+						"cond":  bson.M{"$ne": bson.A{"$$this.run_id", runId}},
+					}},
+					bson.M{"$literal": runs},
+				},
+			},
+		}
+		if s.Project != "" {
+			set["project"] = s.Project
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"id": s.Id}).
+			SetUpdate(mongo.Pipeline{{{Key: "$set", Value: set}}}).
+			SetUpsert(true),
+		)
+	}
+
+	_, err := db.SampleCollection().BulkWrite(context.TODO(), models, options.BulkWrite().SetOrdered(true))
+	return err
+}
+
+func (db DB) SampleIndex() ([]map[string]string, error) {
+	cursor, err := db.SampleCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetSampleIndex() (string, error) {
+	indexModels := []mongo.IndexModel{
+		{
+			// Samples are upserted by ID, so concurrent ingests of the same run
+			// must not be able to add the same sample twice.
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "runs.run_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "project", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "panels.id", Value: 1}},
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.SampleCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.SampleCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
@@ -4,17 +4,81 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 
 	"github.com/gmc-norr/cleve"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// sampleSheetDocument is a samplesheet as it is stored in the database. The values of
+// the data section columns that samplesheets can be searched on are stored in a
+// separate field, so that searches can use indexes.
+type sampleSheetDocument struct {
+	cleve.SampleSheet `bson:",inline"`
+	Search            sampleSheetSearch `bson:"search"`
+}
+
+// sampleSheetSearch are the searchable values of a samplesheet. Indexes are stored in
+// upper case.
+type sampleSheetSearch struct {
+	SampleIds []string `bson:"sample_ids"`
+	Projects  []string `bson:"projects"`
+	Indexes   []string `bson:"indexes"`
+}
+
+func newSampleSheetDocument(sampleSheet cleve.SampleSheet) sampleSheetDocument {
+	return sampleSheetDocument{
+		SampleSheet: sampleSheet,
+		Search: sampleSheetSearch{
+			SampleIds: sampleSheetColumnValues(sampleSheet, []string{"Sample_ID"}),
+			Projects:  sampleSheetColumnValues(sampleSheet, []string{"Sample_Project", "ProjectName"}),
+			Indexes:   sampleSheetColumnValues(sampleSheet, []string{"index", "index2"}, strings.ToUpper),
+		},
+	}
+}
+
+// sampleSheetColumnValues returns the unique non-empty values of the columns in all data
+// sections of a samplesheet, in the order they appear. Column names are matched case
+// insensitively, and values can be normalised by an optional function.
+func sampleSheetColumnValues(sampleSheet cleve.SampleSheet, columns []string, normalise ...func(string) string) []string {
+	values := []string{}
+	for _, section := range sampleSheet.Sections {
+		if section.Type != cleve.DataSection || len(section.Rows) == 0 {
+			continue
+		}
+		var indices []int
+		for i, c := range section.Rows[0] {
+			if slices.ContainsFunc(columns, func(name string) bool { return strings.EqualFold(name, c) }) {
+				indices = append(indices, i)
+			}
+		}
+		for _, row := range section.Rows[1:] {
+			for _, i := range indices {
+				if i >= len(row) || row[i] == "" {
+					continue
+				}
+				v := row[i]
+				for _, f := range normalise {
+					v = f(v)
+				}
+				if !slices.Contains(values, v) {
+					values = append(values, v)
+				}
+			}
+		}
+	}
+	return values
+}
+
 type sampleSheetOptions struct {
-	runId *string
-	uuid  *uuid.UUID
+	runId      *string
+	uuid       *uuid.UUID
+	analysisId *uuid.UUID
 }
 
 type SampleSheetOption func(*sampleSheetOptions) error
@@ -45,11 +109,27 @@ func SampleSheetWithUuid(id string) SampleSheetOption {
 	}
 }
 
+// SampleSheetWithAnalysisId associates the sample sheet with an analysis.
+func SampleSheetWithAnalysisId(id string) SampleSheetOption {
+	return func(o *sampleSheetOptions) error {
+		analysisId, err := uuid.Parse(id)
+		if err != nil {
+			return err
+		}
+		o.analysisId = &analysisId
+		return nil
+	}
+}
+
 // Add a sample sheet to the database. If the same sample sheet already
 // exists, it will be updated, but only if the modification time is newer than
 // the existing sample sheet. The UUID is the main identifier for the sample
 // sheet, but if that is missing, the run ID from the options is then used.
 // If neither a UUID nor a run ID can be found, an error is returned.
+//
+// If the sample sheet is associated with a run, the samples in it are added to
+// the sample registry, see [DB.UpdateRunSamples], and the projects of the samples
+// become the projects of the run, see [DB.UpdateRunProjects].
 func (db DB) CreateSampleSheet(sampleSheet cleve.SampleSheet, opts ...SampleSheetOption) (*cleve.UpdateResult, error) {
 	var ssOptions sampleSheetOptions
 	for _, opt := range opts {
@@ -72,6 +152,9 @@ func (db DB) CreateSampleSheet(sampleSheet cleve.SampleSheet, opts ...SampleShee
 	if ssOptions.runId != nil {
 		sampleSheet.RunID = ssOptions.runId
 	}
+	if ssOptions.analysisId != nil {
+		sampleSheet.AnalysisId = ssOptions.analysisId
+	}
 
 	updatedSampleSheet := &sampleSheet
 	var existingSampleSheet cleve.SampleSheet
@@ -96,7 +179,35 @@ func (db DB) CreateSampleSheet(sampleSheet cleve.SampleSheet, opts ...SampleShee
 		}
 	}
 
-	return db.SampleSheetCollection().ReplaceOne(context.TODO(), updateKey, updatedSampleSheet, options.Replace().SetUpsert(true))
+	res, err := db.SampleSheetCollection().ReplaceOne(context.TODO(), updateKey, newSampleSheetDocument(*updatedSampleSheet), options.Replace().SetUpsert(true))
+	if err != nil {
+		return res, err
+	}
+
+	if _, err := db.addSampleSheetRevision(updateKey, sampleSheet); err != nil {
+		return res, fmt.Errorf("failed to store samplesheet revision: %w", err)
+	}
+
+	if updatedSampleSheet.RunID != nil && updatedSampleSheet.SampleSection() != nil {
+		samples, err := updatedSampleSheet.SampleRecords()
+		if err != nil {
+			return res, fmt.Errorf("failed to extract samples from samplesheet: %w", err)
+		}
+		if err := db.UpdateRunSamples(*updatedSampleSheet.RunID, samples); err != nil {
+			return res, fmt.Errorf("failed to update samples: %w", err)
+		}
+		var projects []string
+		for _, s := range samples {
+			if s.Project != "" && !slices.Contains(projects, s.Project) {
+				projects = append(projects, s.Project)
+			}
+		}
+		if err := db.UpdateRunProjects(*updatedSampleSheet.RunID, projects); err != nil {
+			return res, fmt.Errorf("failed to update projects: %w", err)
+		}
+	}
+
+	return res, nil
 }
 
 func (db DB) DeleteSampleSheet(runID string) error {
@@ -107,19 +218,172 @@ func (db DB) DeleteSampleSheet(runID string) error {
 	return err
 }
 
-func (db DB) SampleSheets() ([]cleve.SampleSheet, error) {
-	var sampleSheets []cleve.SampleSheet
-	cursor, err := db.SampleSheetCollection().Find(context.TODO(), bson.D{})
+// SampleSheets retrieves samplesheets from the database, most recently modified first.
+func (db DB) SampleSheets(filter cleve.SampleSheetFilter) (*cleve.SampleSheetResult, error) {
+	var sampleSheetResult cleve.SampleSheetResult
+
+	var pipeline mongo.Pipeline
+
+	// Strict match on run id and uuid
+	if filter.RunId != "" {
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.M{"run_id": filter.RunId}},
+		})
+	}
+	if filter.UUID != uuid.Nil {
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.M{"uuid": filter.UUID}},
+		})
+	}
+
+	// Filter on modification time of any of the samplesheet files
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		dateFilter := bson.D{}
+		if !filter.From.IsZero() {
+			dateFilter = append(dateFilter, bson.E{Key: "$gte", Value: filter.From})
+		}
+		if !filter.To.IsZero() {
+			dateFilter = append(dateFilter, bson.E{Key: "$lte", Value: filter.To})
+		}
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.M{
+				"files": bson.M{"$elemMatch": bson.M{"modification_time": dateFilter}},
+			}},
+		})
+	}
+
+	// Filter on values in data sections
+	columnFilters := []struct {
+		field string
+		value string
+	}{
+		{"search.sample_ids", filter.SampleId},
+		{"search.projects", filter.Project},
+		{"search.indexes", strings.ToUpper(filter.Index)},
+	}
+	for _, f := range columnFilters {
+		if f.value == "" {
+			continue
+		}
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.M{f.field: f.value}},
+		})
+	}
+
+	// Sort by modification time, most recent first
+	pipeline = append(pipeline, bson.D{
+		{Key: "$sort", Value: bson.D{
+			{Key: "files.modification_time", Value: -1},
+			{Key: "_id", Value: 1},
+		}},
+	})
+
+	// Facetting pipeline
+	facetPipeline := mongo.Pipeline{}
+
+	if filter.Page > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$skip",
+			Value: filter.PageSize * (filter.Page - 1),
+		}})
+	}
+
+	if filter.PageSize > 0 {
+		facetPipeline = append(facetPipeline, bson.D{{
+			Key:   "$limit",
+			Value: filter.PageSize,
+		}})
+	}
+
+	// Facetting
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$facet",
+			Value: bson.M{
+				"metadata": bson.A{
+					bson.M{
+						"$count": "total_count",
+					},
+				},
+				"samplesheets": facetPipeline,
+			},
+		},
+	})
+
+	// Projection
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$project",
+			Value: bson.M{
+				"samplesheets": 1,
+				"metadata": bson.M{
+					"$arrayElemAt": bson.A{"$metadata", 0},
+				},
+			},
+		},
+	})
+
+	// Add more pagination metadata
+	pipeline = append(pipeline, bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"metadata.count": bson.M{
+					"$size": "$samplesheets",
+				},
+				"metadata.page":      filter.Page,
+				"metadata.page_size": filter.PageSize,
+				"metadata.total_pages": bson.M{
+					"$cond": bson.M{
+						"if": bson.M{
+							"$gt": bson.A{
+								filter.PageSize,
+								0,
+							},
+						},
+						"then": bson.M{
+							"$ceil": bson.M{
+								"$divide": bson.A{
+									"$metadata.total_count",
+									filter.PageSize,
+								},
+							},
+						},
+						"else": 1,
+					},
+				},
+			},
+		},
+	})
+
+	cursor, err := db.SampleSheetCollection().Aggregate(context.TODO(), pipeline)
 	if err != nil {
-		return nil, err
+		return &sampleSheetResult, err
 	}
-	if err = cursor.All(context.TODO(), &sampleSheets); err != nil {
-		return nil, err
+	defer closeCursor(cursor, context.TODO())
+	if ok := cursor.Next(context.TODO()); ok {
+		err := cursor.Decode(&sampleSheetResult)
+		if err != nil {
+			return &sampleSheetResult, err
+		}
+		if sampleSheetResult.TotalCount == 0 {
+			// No results found. Represent this as a single page
+			// with an empty slice of samplesheets.
+			sampleSheetResult.TotalPages = 1
+		}
+		if sampleSheetResult.Page > sampleSheetResult.TotalPages {
+			return &sampleSheetResult, PageOutOfBoundsError{
+				page:       sampleSheetResult.Page,
+				totalPages: sampleSheetResult.TotalPages,
+			}
+		}
 	}
-	return sampleSheets, nil
+	return &sampleSheetResult, cursor.Err()
 }
 
-// Get a samplesheet either by run ID or UUID, passed by options.
+// Get a samplesheet either by run ID, UUID or analysis ID, passed by options.
+// If more than one is given, UUID takes precedence over analysis ID, which takes
+// precedence over run ID.
 func (db DB) SampleSheet(opts ...SampleSheetOption) (cleve.SampleSheet, error) {
 	var sampleSheet cleve.SampleSheet
 
@@ -137,6 +401,8 @@ func (db DB) SampleSheet(opts ...SampleSheetOption) (cleve.SampleSheet, error) {
 	var key bson.D
 	if ssOptions.uuid != nil {
 		key = bson.D{{Key: "uuid", Value: ssOptions.uuid}}
+	} else if ssOptions.analysisId != nil {
+		key = bson.D{{Key: "analysis_id", Value: ssOptions.analysisId}}
 	} else if ssOptions.runId != nil {
 		key = bson.D{{Key: "run_id", Value: ssOptions.runId}}
 	}
@@ -204,6 +470,32 @@ func (db DB) SetSampleSheetIndex() (string, error) {
 				},
 			),
 		},
+		{
+			Keys: bson.D{
+				{Key: "analysis_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+				bson.D{
+					{
+						Key: "analysis_id",
+						Value: bson.D{{
+							Key:   "$type",
+							Value: "binData",
+						}},
+					},
+				},
+			),
+		},
+	}
+
+	for _, field := range []string{"search.sample_ids", "search.projects", "search.indexes"} {
+		indexModels = append(indexModels, mongo.IndexModel{
+			Keys: bson.D{{Key: field, Value: 1}},
+		})
+	}
+
+	if err := db.updateSampleSheetSearch(); err != nil {
+		return "", fmt.Errorf("failed to update searchable samplesheet values: %w", err)
 	}
 
 	// TODO: do this as a transaction and roll back if anything fails
@@ -217,3 +509,28 @@ func (db DB) SetSampleSheetIndex() (string, error) {
 	name, err := db.SampleSheetCollection().Indexes().CreateMany(context.TODO(), indexModels)
 	return fmt.Sprintf("%v", name), err
 }
+
+// updateSampleSheetSearch stores the searchable values of samplesheets that were added
+// before these were stored separately.
+func (db DB) updateSampleSheetSearch() error {
+	cursor, err := db.SampleSheetCollection().Find(context.TODO(), bson.D{{Key: "search", Value: bson.D{{Key: "$exists", Value: false}}}})
+	if err != nil {
+		return err
+	}
+	defer closeCursor(cursor, context.TODO())
+	for cursor.Next(context.TODO()) {
+		var doc struct {
+			Id                primitive.ObjectID `bson:"_id"`
+			cleve.SampleSheet `bson:",inline"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		search := newSampleSheetDocument(doc.SampleSheet).Search
+		_, err := db.SampleSheetCollection().UpdateByID(context.TODO(), doc.Id, bson.D{{Key: "$set", Value: bson.D{{Key: "search", Value: search}}}})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,161 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gmc-norr/cleve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Number of attempts at adding a samplesheet revision. Revisions are numbered by
+// reading the latest revision, and if two revisions are added at the same time, the
+// unique index on the revision number makes one of them fail and try again.
+const sampleSheetRevisionAttempts = 5
+
+// addSampleSheetRevision stores a samplesheet as a new revision, identified by the same
+// key as the samplesheet itself. If the content is the same as the latest revision, no
+// revision is added and false is returned.
+func (db DB) addSampleSheetRevision(key bson.D, sampleSheet cleve.SampleSheet) (bool, error) {
+	revision, err := cleve.NewSampleSheetRevision(sampleSheet)
+	if err != nil {
+		return false, err
+	}
+
+	for range sampleSheetRevisionAttempts {
+		var latest cleve.SampleSheetRevision
+		opts := options.FindOne().
+			SetSort(bson.D{{Key: "revision", Value: -1}}).
+			SetProjection(bson.D{{Key: "revision", Value: 1}, {Key: "checksum", Value: 1}})
+		err := db.SampleSheetRevisionCollection().FindOne(context.TODO(), key, opts).Decode(&latest)
+		switch {
+		case err == mongo.ErrNoDocuments:
+		case err != nil:
+			return false, err
+		case latest.Checksum == revision.Checksum:
+			return false, nil
+		}
+
+		revision.Revision = latest.Revision + 1
+		_, err = db.SampleSheetRevisionCollection().InsertOne(context.TODO(), revision)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		return err == nil, err
+	}
+	return false, fmt.Errorf("failed to add samplesheet revision after %d attempts", sampleSheetRevisionAttempts)
+}
+
+// sampleSheetRevisionKey returns the key identifying the revisions of the samplesheet
+// for a run. Revisions are identified by the UUID of the samplesheet if it has one,
+// otherwise by the run ID.
+func (db DB) sampleSheetRevisionKey(runId string) (bson.D, error) {
+	sampleSheet, err := db.SampleSheet(SampleSheetWithRunId(runId))
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == nil && sampleSheet.UUID != nil {
+		return bson.D{{Key: "uuid", Value: sampleSheet.UUID}}, nil
+	}
+	return bson.D{{Key: "run_id", Value: runId}}, nil
+}
+
+// SampleSheetRevisions retrieves all revisions of the samplesheet for a run, oldest
+// first. The sections of the samplesheets are not included. If there are no revisions,
+// ErrNoDocuments is returned.
+func (db DB) SampleSheetRevisions(runId string) ([]cleve.SampleSheetRevision, error) {
+	key, err := db.sampleSheetRevisionKey(runId)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "revision", Value: 1}}).
+		SetProjection(bson.D{{Key: "sections", Value: 0}})
+	cursor, err := db.SampleSheetRevisionCollection().Find(context.TODO(), key, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCursor(cursor, context.TODO())
+	var revisions []cleve.SampleSheetRevision
+	if err := cursor.All(context.TODO(), &revisions); err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return revisions, nil
+}
+
+// SampleSheetRevision retrieves a specific revision of the samplesheet for a run. If
+// the revision does not exist, ErrNoDocuments is returned.
+func (db DB) SampleSheetRevision(runId string, revision int) (cleve.SampleSheetRevision, error) {
+	var r cleve.SampleSheetRevision
+	key, err := db.sampleSheetRevisionKey(runId)
+	if err != nil {
+		return r, err
+	}
+	key = append(key, bson.E{Key: "revision", Value: revision})
+	err = db.SampleSheetRevisionCollection().FindOne(context.TODO(), key).Decode(&r)
+	return r, err
+}
+
+func (db DB) SampleSheetRevisionIndex() ([]map[string]string, error) {
+	cursor, err := db.SampleSheetRevisionCollection().Indexes().List(context.TODO())
+	if err != nil {
+		return []map[string]string{}, err
+	}
+	defer closeCursor(cursor, context.TODO())
+
+	var indexes []map[string]string
+
+	var result []bson.M
+	if err = cursor.All(context.TODO(), &result); err != nil {
+		return []map[string]string{}, err
+	}
+
+	for _, v := range result {
+		i := map[string]string{}
+		for k, val := range v {
+			i[k] = fmt.Sprintf("%v", val)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+func (db DB) SetSampleSheetRevisionIndex() (string, error) {
+	// Revisions keyed by run ID have no UUID, so the UUID is part of the run ID index
+	// to keep it unique for revisions keyed by UUID. The UUID index only covers
+	// revisions that have a UUID.
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "run_id", Value: 1},
+				{Key: "uuid", Value: 1},
+				{Key: "revision", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "uuid", Value: 1},
+				{Key: "revision", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "uuid", Value: bson.D{{Key: "$type", Value: "binData"}}}}),
+		},
+	}
+
+	// TODO: do this as a transaction and roll back if anything fails
+	_, err := db.SampleSheetRevisionCollection().Indexes().DropAll(context.TODO())
+	if err != nil {
+		return "", err
+	}
+
+	name, err := db.SampleSheetRevisionCollection().Indexes().CreateMany(context.TODO(), indexModels)
+	return fmt.Sprintf("%v", name), err
+}
@@ -1,7 +1,10 @@
 package mongo
 
 import (
+	"slices"
 	"testing"
+
+	"github.com/gmc-norr/cleve"
 )
 
 func TestSamplesSheetOptions(t *testing.T) {
@@ -72,3 +75,44 @@ func TestSamplesSheetOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestSampleSheetSearch(t *testing.T) {
+	sampleSheet := cleve.SampleSheet{
+		Sections: []cleve.Section{
+			{
+				Name: "Header",
+				Type: cleve.SettingsSection,
+				Rows: [][]string{{"FileFormatVersion", "2"}},
+			},
+			{
+				Name: "BCLConvert_Data",
+				Type: cleve.DataSection,
+				Rows: [][]string{
+					{"Lane", "Sample_ID", "Index", "Index2", "Sample_Project"},
+					{"1", "sample1", "acgtacgt", "TTGGCCAA", "proj1"},
+					{"2", "sample1", "acgtacgt", "TTGGCCAA", "proj1"},
+					{"1", "sample2", "TGCATGCA", "", ""},
+				},
+			},
+			{
+				Name: "Cloud_Data",
+				Type: cleve.DataSection,
+				Rows: [][]string{
+					{"Sample_ID", "ProjectName"},
+					{"sample1", "proj2"},
+				},
+			},
+		},
+	}
+
+	search := newSampleSheetDocument(sampleSheet).Search
+	if !slices.Equal(search.SampleIds, []string{"sample1", "sample2"}) {
+		t.Errorf("unexpected sample ids: %v", search.SampleIds)
+	}
+	if !slices.Equal(search.Projects, []string{"proj1", "proj2"}) {
+		t.Errorf("unexpected projects: %v", search.Projects)
+	}
+	if !slices.Equal(search.Indexes, []string{"ACGTACGT", "TTGGCCAA", "TGCATGCA"}) {
+		t.Errorf("unexpected indexes: %v", search.Indexes)
+	}
+}
@@ -0,0 +1,153 @@
+package cleve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MultiQCMetricHeader describes a metric in the MultiQC general statistics table.
+type MultiQCMetricHeader struct {
+	// Module is the MultiQC module, or namespace, that the metric comes from.
+	Module string `bson:"module" json:"module"`
+	// Name is the key of the metric within the module.
+	Name string `bson:"name" json:"name"`
+	// Title is the human readable name of the metric.
+	Title string `bson:"title" json:"title"`
+}
+
+// Key returns a key that uniquely identifies the metric, on the form `module.name`.
+func (h MultiQCMetricHeader) Key() string {
+	return h.Module + "." + h.Name
+}
+
+// MultiQCMetric is a single value from the MultiQC general statistics table.
+type MultiQCMetric struct {
+	MultiQCMetricHeader `bson:",inline"`
+	Value               float64 `bson:"value" json:"value"`
+}
+
+// MultiQCSampleMetrics represents the MultiQC general statistics for a single sample.
+type MultiQCSampleMetrics struct {
+	SampleId   string    `bson:"sample_id" json:"sample_id"`
+	Runs       []string  `bson:"runs" json:"runs"`
+	AnalysisId uuid.UUID `bson:"analysis_id" json:"analysis_id"`
+	// Source is the path of the MultiQC data file within the analysis directory, or
+	// an absolute path if the file is not part of the analysis. An analysis can have
+	// several MultiQC reports, and a sample can be part of more than one of them.
+	Source  string          `bson:"source" json:"source"`
+	Metrics []MultiQCMetric `bson:"metrics" json:"metrics"`
+}
+
+// Metric returns the value of the metric identified by key. The second return
+// value is false if the sample does not have the metric.
+func (m MultiQCSampleMetrics) Metric(key string) (float64, bool) {
+	for _, metric := range m.Metrics {
+		if metric.Key() == key {
+			return metric.Value, true
+		}
+	}
+	return 0, false
+}
+
+// ParseMultiQCGeneralStats parses the general statistics table from a MultiQC
+// `multiqc_data.json` file. Non-numeric values are ignored. The samples are
+// sorted by sample ID, and the metrics for each sample are sorted by key.
+func ParseMultiQCGeneralStats(r io.Reader) ([]MultiQCSampleMetrics, error) {
+	var data struct {
+		Data    []map[string]map[string]any `json:"report_general_stats_data"`
+		Headers []map[string]struct {
+			Namespace string `json:"namespace"`
+			Title     string `json:"title"`
+		} `json:"report_general_stats_headers"`
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse multiqc data: %w", err)
+	}
+	if data.Data == nil {
+		return nil, fmt.Errorf("no general statistics found in multiqc data")
+	}
+
+	samples := make(map[string]*MultiQCSampleMetrics)
+	for i, moduleData := range data.Data {
+		for sampleId, values := range moduleData {
+			sample, ok := samples[sampleId]
+			if !ok {
+				sample = &MultiQCSampleMetrics{SampleId: sampleId}
+				samples[sampleId] = sample
+			}
+			for name, value := range values {
+				v, ok := value.(float64)
+				if !ok {
+					continue
+				}
+				header := MultiQCMetricHeader{Name: name, Title: name}
+				if i < len(data.Headers) {
+					if h, ok := data.Headers[i][name]; ok {
+						header.Module = h.Namespace
+						if h.Title != "" {
+							header.Title = h.Title
+						}
+					}
+				}
+				sample.Metrics = append(sample.Metrics, MultiQCMetric{
+					MultiQCMetricHeader: header,
+					Value:               v,
+				})
+			}
+		}
+	}
+
+	metrics := make([]MultiQCSampleMetrics, 0, len(samples))
+	for _, s := range samples {
+		slices.SortFunc(s.Metrics, func(a, b MultiQCMetric) int {
+			return strings.Compare(a.Key(), b.Key())
+		})
+		metrics = append(metrics, *s)
+	}
+	slices.SortFunc(metrics, func(a, b MultiQCSampleMetrics) int {
+		return strings.Compare(a.SampleId, b.SampleId)
+	})
+	return metrics, nil
+}
+
+// MultiQCMetrics reads the general statistics from all MultiQC output files of
+// the analysis. If the analysis has no MultiQC output files, nil is returned.
+func (a *Analysis) MultiQCMetrics() ([]MultiQCSampleMetrics, error) {
+	var metrics []MultiQCSampleMetrics
+	for _, f := range a.OutputFiles {
+		if f.FileType != FileMultiQC {
+			continue
+		}
+		path := f.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(a.Path, path)
+		}
+		fh, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		m, err := ParseMultiQCGeneralStats(fh)
+		_ = fh.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Path, err)
+		}
+		for i := range m {
+			m[i].Runs = a.Runs
+			m[i].AnalysisId = a.AnalysisId
+			m[i].Source = f.Path
+		}
+		metrics = append(metrics, m...)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,171 @@
+package cleve
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const mockMultiQCData = `{
+	"config_version": "1.25",
+	"report_general_stats_data": [
+		{
+			"sample1": {"percent_duplicates": 12.5, "total_sequences": 1000000.0},
+			"sample2": {"percent_duplicates": 8.0, "total_sequences": 2000000.0}
+		},
+		{
+			"sample1": {"percent_duplicates": 10.1, "status": "pass"}
+		}
+	],
+	"report_general_stats_headers": [
+		{
+			"percent_duplicates": {"namespace": "FastQC", "title": "% Dups"},
+			"total_sequences": {"namespace": "FastQC", "title": "Seqs"}
+		},
+		{
+			"percent_duplicates": {"namespace": "Picard", "title": "% Dups"}
+		}
+	]
+}`
+
+func TestParseMultiQCGeneralStats(t *testing.T) {
+	testcases := []struct {
+		name        string
+		content     string
+		samples     []string
+		metrics     []int
+		shouldError bool
+	}{
+		{
+			name:    "general stats",
+			content: mockMultiQCData,
+			samples: []string{"sample1", "sample2"},
+			metrics: []int{3, 2},
+		},
+		{
+			name:    "empty general stats",
+			content: `{"report_general_stats_data": [], "report_general_stats_headers": []}`,
+			samples: []string{},
+			metrics: []int{},
+		},
+		{
+			name:        "missing general stats",
+			content:     `{"config_version": "1.25"}`,
+			shouldError: true,
+		},
+		{
+			name:        "invalid json",
+			content:     `{"report_general_stats_data": [`,
+			shouldError: true,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			metrics, err := ParseMultiQCGeneralStats(strings.NewReader(c.content))
+			if err != nil {
+				if !c.shouldError {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if c.shouldError {
+				t.Fatal("expected an error, got nil")
+			}
+			if len(metrics) != len(c.samples) {
+				t.Fatalf("expected %d samples, got %d", len(c.samples), len(metrics))
+			}
+			for i, m := range metrics {
+				if m.SampleId != c.samples[i] {
+					t.Errorf("expected sample %q, got %q", c.samples[i], m.SampleId)
+				}
+				if len(m.Metrics) != c.metrics[i] {
+					t.Errorf("expected %d metrics for %s, got %d", c.metrics[i], m.SampleId, len(m.Metrics))
+				}
+			}
+		})
+	}
+}
+
+func TestMultiQCSampleMetric(t *testing.T) {
+	metrics, err := ParseMultiQCGeneralStats(strings.NewReader(mockMultiQCData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample1 := metrics[0]
+
+	testcases := []struct {
+		key   string
+		value float64
+		found bool
+	}{
+		{key: "FastQC.percent_duplicates", value: 12.5, found: true},
+		{key: "Picard.percent_duplicates", value: 10.1, found: true},
+		{key: "FastQC.total_sequences", value: 1000000, found: true},
+		{key: "Picard.status", found: false},
+		{key: "percent_duplicates", found: false},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.key, func(t *testing.T) {
+			v, ok := sample1.Metric(c.key)
+			if ok != c.found {
+				t.Fatalf("expected found = %t, got %t", c.found, ok)
+			}
+			if v != c.value {
+				t.Errorf("expected %f, got %f", c.value, v)
+			}
+		})
+	}
+}
+
+func TestAnalysisMultiQCMetrics(t *testing.T) {
+	analysisDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(analysisDir, "multiqc", "multiqc_data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockFile(filepath.Join(analysisDir, "multiqc", "multiqc_data", "multiqc_data.json"), mockMultiQCData); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis := Analysis{
+		AnalysisId: uuid.New(),
+		Path:       analysisDir,
+		Runs:       []string{"run1"},
+		OutputFiles: AnalysisFiles{
+			{Path: "multiqc/multiqc_data/multiqc_data.json", FileType: FileMultiQC, Level: LevelRun, ParentId: "run1"},
+			{Path: "multiqc/multiqc_report.html", FileType: FileHtml, Level: LevelRun, ParentId: "run1"},
+		},
+	}
+
+	metrics, err := analysis.MultiQCMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected metrics for 2 samples, got %d", len(metrics))
+	}
+	for _, m := range metrics {
+		if m.AnalysisId != analysis.AnalysisId {
+			t.Errorf("expected analysis id %s, got %s", analysis.AnalysisId, m.AnalysisId)
+		}
+		if len(m.Runs) != 1 || m.Runs[0] != "run1" {
+			t.Errorf("expected runs [run1], got %v", m.Runs)
+		}
+		if m.Source != "multiqc/multiqc_data/multiqc_data.json" {
+			t.Errorf("expected source multiqc/multiqc_data/multiqc_data.json, got %q", m.Source)
+		}
+	}
+
+	analysis.OutputFiles = analysis.OutputFiles[1:]
+	metrics, err = analysis.MultiQCMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics != nil {
+		t.Errorf("expected no metrics, got %d", len(metrics))
+	}
+}
@@ -0,0 +1,303 @@
+package cleve
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+	"github.com/google/uuid"
+)
+
+// NextflowDetector detects the output directories of Nextflow pipelines, i.e.
+// directories with a `pipeline_info` directory containing an execution trace. This is
+// what nf-core pipelines, among others, publish.
+//
+// The workflow name and version are read from the software versions file that nf-core
+// pipelines write to `pipeline_info`. An analysis is pending until the execution report
+// of the latest execution has been written, and if any task failed in that execution
+// the analysis is in an error state.
+type NextflowDetector struct {
+	// Outputs maps the files published by the pipelines to output files of the
+	// analyses, see [NextflowOutput].
+	Outputs []NextflowOutput
+}
+
+func (NextflowDetector) Name() string {
+	return "nextflow"
+}
+
+func (NextflowDetector) Match(path string) bool {
+	return nextflowTraceFile(path) != ""
+}
+
+func (d NextflowDetector) New(path string, run *Run) (Analysis, error) {
+	analysis := Analysis{
+		AnalysisId: uuid.New(),
+		Runs:       []string{},
+		Software:   "Nextflow",
+		Path:       path,
+	}
+
+	if !filepath.IsAbs(path) {
+		return analysis, fmt.Errorf("path must be absolute")
+	}
+	if run != nil {
+		analysis.Runs = []string{run.RunID}
+	}
+
+	if versionsFile := nextflowVersionsFile(path); versionsFile != "" {
+		name, version, err := readNextflowVersions(versionsFile)
+		if err != nil {
+			return analysis, fmt.Errorf("failed to read software versions: %w", err)
+		}
+		if name != "" {
+			analysis.Software = name
+		}
+		analysis.SoftwareVersion = version
+	}
+
+	state := d.State(&analysis)
+	analysis.StateHistory.Add(state)
+	if state == StateReady {
+		files, err := d.OutputFiles(&analysis)
+		if err != nil {
+			return analysis, err
+		}
+		analysis.OutputFiles = files
+	}
+	return analysis, nil
+}
+
+func (NextflowDetector) State(a *Analysis) State {
+	if _, err := os.Stat(a.Path); os.IsNotExist(err) {
+		return StateMoved
+	}
+	trace := nextflowTraceFile(a.Path)
+	if trace == "" {
+		return StatePending
+	}
+	suffix := strings.TrimPrefix(filepath.Base(trace), "execution_trace")
+	suffix = strings.TrimSuffix(suffix, ".txt")
+	report := filepath.Join(filepath.Dir(trace), "execution_report"+suffix+".html")
+	if _, err := os.Stat(report); err != nil {
+		return StatePending
+	}
+	f, err := os.Open(trace)
+	if err != nil {
+		return StateUnknown
+	}
+	defer func() { _ = f.Close() }()
+	failed, err := nextflowFailedTasks(f)
+	if err != nil {
+		return StateUnknown
+	}
+	if len(failed) > 0 {
+		return StateError
+	}
+	return StateReady
+}
+
+// OutputFiles returns the MultiQC data and the execution report of the pipeline if
+// the analysis is associated with a run, since these are run level files. Files
+// matching the outputs of the detector are returned whether or not there is a run,
+// with the parameters of the latest execution used in parent IDs.
+func (d NextflowDetector) OutputFiles(a *Analysis) (AnalysisFiles, error) {
+	var files AnalysisFiles
+	if len(a.Runs) > 0 {
+		runFiles, err := nextflowRunFiles(a)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, runFiles...)
+	}
+	if len(d.Outputs) == 0 {
+		return files, nil
+	}
+	params, err := readNextflowParams(a.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parameters: %w", err)
+	}
+	outputFiles, err := NextflowOutputFiles(a, d.Outputs, params)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, outputFiles...), nil
+}
+
+// nextflowRunFiles returns the MultiQC data and the execution report of a pipeline as
+// files belonging to the first run of the analysis.
+func nextflowRunFiles(a *Analysis) (AnalysisFiles, error) {
+	var files AnalysisFiles
+	err := filepath.WalkDir(a.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Intermediate files are not outputs
+			if d.Name() == "work" && path != a.Path {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "multiqc_data.json" {
+			return nil
+		}
+		rel, err := filepath.Rel(a.Path, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, AnalysisFile{
+			partOfAnalysis: true,
+			Path:           rel,
+			FileType:       FileMultiQC,
+			Level:          LevelRun,
+			ParentId:       a.Runs[0],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if trace := nextflowTraceFile(a.Path); trace != "" {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(trace), "execution_trace"), ".txt")
+		report := filepath.Join("pipeline_info", "execution_report"+suffix+".html")
+		if _, err := os.Stat(filepath.Join(a.Path, report)); err == nil {
+			files = append(files, AnalysisFile{
+				partOfAnalysis: true,
+				Path:           report,
+				FileType:       FileHtml,
+				Level:          LevelRun,
+				ParentId:       a.Runs[0],
+			})
+		}
+	}
+	return files, nil
+}
+
+// readNextflowParams returns the parameters of the latest execution of a pipeline,
+// which nf-core pipelines write to `pipeline_info`. If there is no parameter file, an
+// empty map is returned.
+func readNextflowParams(path string) (map[string]any, error) {
+	params := make(map[string]any)
+	files, _ := filepath.Glob(filepath.Join(path, "pipeline_info", "params_*.json"))
+	if len(files) == 0 {
+		return params, nil
+	}
+	slices.Sort(files)
+	data, err := os.ReadFile(files[len(files)-1])
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// nextflowTraceFile returns the execution trace of the latest execution of a pipeline
+// in the directory, or an empty string if there is none. Trace files are named after
+// the time of the execution, so the latest execution is last in lexical order.
+func nextflowTraceFile(path string) string {
+	traces, _ := filepath.Glob(filepath.Join(path, "pipeline_info", "execution_trace*.txt"))
+	if len(traces) == 0 {
+		return ""
+	}
+	slices.Sort(traces)
+	return traces[len(traces)-1]
+}
+
+// nextflowVersionsFile returns the software versions file of an nf-core pipeline, or
+// an empty string if there is none.
+func nextflowVersionsFile(path string) string {
+	for _, pattern := range []string{"*software*versions*.yml", "*software*versions*.yaml"} {
+		files, _ := filepath.Glob(filepath.Join(path, "pipeline_info", pattern))
+		if len(files) > 0 {
+			slices.Sort(files)
+			return files[0]
+		}
+	}
+	return ""
+}
+
+// readNextflowVersions returns the name and version of the workflow from the
+// `Workflow` section of an nf-core software versions file. The section lists the
+// workflow together with Nextflow itself.
+func readNextflowVersions(path string) (string, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	var versions map[string]map[string]any
+	if err := yaml.Unmarshal(data, &versions); err != nil {
+		return "", "", err
+	}
+	workflow := versions["Workflow"]
+	var nextflowVersion string
+	names := make([]string, 0, len(workflow))
+	for k, v := range workflow {
+		if strings.EqualFold(k, "nextflow") {
+			nextflowVersion = fmt.Sprint(v)
+			continue
+		}
+		names = append(names, k)
+	}
+	if len(names) == 0 {
+		return "", nextflowVersion, nil
+	}
+	slices.Sort(names)
+	return names[0], fmt.Sprint(workflow[names[0]]), nil
+}
+
+// nextflowFailedTasks returns the names of the tasks in an execution trace whose
+// last attempt failed or was aborted. Tasks that failed and then succeeded when
+// retried are not included.
+func nextflowFailedTasks(r io.Reader) ([]string, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.Comma = '\t'
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	nameCol, statusCol := slices.Index(header, "name"), slices.Index(header, "status")
+	if nameCol < 0 || statusCol < 0 {
+		return nil, fmt.Errorf("execution trace is missing name or status column")
+	}
+	var names []string
+	status := make(map[string]string)
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) <= max(nameCol, statusCol) {
+			continue
+		}
+		name := record[nameCol]
+		if _, ok := status[name]; !ok {
+			names = append(names, name)
+		}
+		status[name] = record[statusCol]
+	}
+	var failed []string
+	for _, name := range names {
+		if s := status[name]; s == "FAILED" || s == "ABORTED" {
+			failed = append(failed, name)
+		}
+	}
+	return failed, nil
+}
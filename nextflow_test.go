@@ -0,0 +1,205 @@
+package cleve
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const mockNextflowVersions = `BWAMEM2_MEM:
+  bwa-mem2: 2.2.1
+Workflow:
+  Nextflow: 24.04.2
+  nf-core/sarek: v3.4.4
+`
+
+const mockTraceHeader = "task_id\thash\tnative_id\tname\tstatus\texit\n"
+
+func TestNextflowFailedTasks(t *testing.T) {
+	testcases := []struct {
+		name   string
+		trace  string
+		failed []string
+	}{
+		{
+			name:  "empty",
+			trace: "",
+		},
+		{
+			name:  "completed",
+			trace: mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tCOMPLETED\t0\n2\tab/cdef13\t2\tMULTIQC\tCACHED\t0\n",
+		},
+		{
+			name:   "failed",
+			trace:  mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tCOMPLETED\t0\n2\tab/cdef13\t2\tMULTIQC\tFAILED\t1\n",
+			failed: []string{"MULTIQC"},
+		},
+		{
+			name:  "retried",
+			trace: mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tFAILED\t137\n2\tab/cdef13\t2\tFASTQC (sample1)\tCOMPLETED\t0\n",
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			failed, err := nextflowFailedTasks(strings.NewReader(c.trace))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(failed, c.failed) {
+				t.Errorf("expected failed tasks %v, got %v", c.failed, failed)
+			}
+		})
+	}
+
+	if _, err := nextflowFailedTasks(strings.NewReader("task_id\thash\n1\tab/cdef12\n")); err == nil {
+		t.Error("expected an error for a trace without name and status")
+	}
+}
+
+func TestNextflowDetector(t *testing.T) {
+	testcases := []struct {
+		name        string
+		trace       string
+		report      bool
+		run         *Run
+		outputs     []NextflowOutput
+		state       State
+		outputFiles int
+	}{
+		{
+			name:  "running",
+			trace: mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tCOMPLETED\t0\n",
+			state: StatePending,
+		},
+		{
+			name:   "completed without run",
+			trace:  mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tCOMPLETED\t0\n",
+			report: true,
+			state:  StateReady,
+		},
+		{
+			name:        "completed with run",
+			trace:       mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tCOMPLETED\t0\n",
+			report:      true,
+			run:         &Run{RunID: "run1"},
+			state:       StateReady,
+			outputFiles: 2,
+		},
+		{
+			name:   "completed without run with outputs",
+			trace:  mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tCOMPLETED\t0\n",
+			report: true,
+			outputs: []NextflowOutput{
+				{Path: "multiqc/*/multiqc_data.json", Type: "multiqc", Level: "run", ParentId: "{params.run_id}"},
+				{Workflow: "nf-core/sarek", Path: "preprocessing/markduplicates/{sample}/{sample}.md.cram", Type: "bam", Level: "sample"},
+				{Workflow: "nf-core/rnaseq", Path: "star_salmon/{sample}/{sample}.bam", Type: "bam", Level: "sample"},
+			},
+			state:       StateReady,
+			outputFiles: 2,
+		},
+		{
+			name:   "completed with run with outputs",
+			trace:  mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tCOMPLETED\t0\n",
+			report: true,
+			run:    &Run{RunID: "run1"},
+			outputs: []NextflowOutput{
+				{Path: "preprocessing/markduplicates/{sample}/{sample}.md.cram", Type: "bam", Level: "sample"},
+			},
+			state:       StateReady,
+			outputFiles: 3,
+		},
+		{
+			name:   "failed",
+			trace:  mockTraceHeader + "1\tab/cdef12\t1\tFASTQC (sample1)\tFAILED\t1\n",
+			report: true,
+			state:  StateError,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			d := NextflowDetector{Outputs: c.outputs}
+			outdir := t.TempDir()
+			if d.Match(outdir) {
+				t.Error("expected directory without pipeline_info to not match")
+			}
+			files := map[string]string{
+				"pipeline_info/execution_trace_2024-01-01_10-00-00.txt":    mockTraceHeader,
+				"pipeline_info/execution_report_2024-01-01_10-00-00.html":  "",
+				"pipeline_info/execution_trace_2025-01-01_10-00-00.txt":    c.trace,
+				"pipeline_info/nf_core_pipeline_software_mqc_versions.yml": mockNextflowVersions,
+				"pipeline_info/params_2024-01-01_10-00-00.json":            `{"run_id": "run0"}`,
+				"pipeline_info/params_2025-01-01_10-00-00.json":            `{"run_id": "run1"}`,
+				"multiqc/multiqc_data/multiqc_data.json":                   "{}",
+				"work/ab/cdef12/multiqc_data/multiqc_data.json":            "{}",
+				"preprocessing/markduplicates/sample1/sample1.md.cram":     "",
+			}
+			if c.report {
+				files["pipeline_info/execution_report_2025-01-01_10-00-00.html"] = ""
+			}
+			for name, content := range files {
+				path := filepath.Join(outdir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := mockFile(path, content); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !d.Match(outdir) {
+				t.Fatal("expected directory with execution trace to match")
+			}
+
+			a, err := d.New(outdir, c.run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Software != "nf-core/sarek" || a.SoftwareVersion != "v3.4.4" {
+				t.Errorf("expected nf-core/sarek v3.4.4, got %s %s", a.Software, a.SoftwareVersion)
+			}
+			if state := a.StateHistory.LastState(); state != c.state {
+				t.Errorf("expected state %s, got %s", c.state, state)
+			}
+			if len(a.OutputFiles) != c.outputFiles {
+				t.Fatalf("expected %d output files, got %d: %+v", c.outputFiles, len(a.OutputFiles), a.OutputFiles)
+			}
+			for _, f := range a.OutputFiles {
+				if err := f.Validate(); err != nil {
+					t.Errorf("invalid output file %s: %s", f.Path, err)
+				}
+				if strings.HasPrefix(f.Path, "work") {
+					t.Errorf("expected intermediate files to be excluded, got %s", f.Path)
+				}
+				if f.Level == LevelRun && f.ParentId != "run1" {
+					t.Errorf("expected run level file %s to belong to run1, got %s", f.Path, f.ParentId)
+				}
+			}
+		})
+	}
+}
+
+func TestNextflowDetectorWithoutVersions(t *testing.T) {
+	outdir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outdir, "pipeline_info"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockFile(filepath.Join(outdir, "pipeline_info", "execution_trace.txt"), mockTraceHeader); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NextflowDetector{}.New(outdir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Software != "Nextflow" || a.SoftwareVersion != "" {
+		t.Errorf("expected software Nextflow without version, got %q %q", a.Software, a.SoftwareVersion)
+	}
+
+	if err := os.RemoveAll(outdir); err != nil {
+		t.Fatal(err)
+	}
+	if state := (NextflowDetector{}).State(&a); state != StateMoved {
+		t.Errorf("expected state moved, got %s", state)
+	}
+}
@@ -0,0 +1,336 @@
+package cleve
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Events sent by Nextflow when running with `-with-weblog`.
+const (
+	NextflowEventStarted          = "started"
+	NextflowEventProcessSubmitted = "process_submitted"
+	NextflowEventProcessStarted   = "process_started"
+	NextflowEventProcessCompleted = "process_completed"
+	NextflowEventError            = "error"
+	NextflowEventCompleted        = "completed"
+)
+
+// NextflowWeblogEvent is a lifecycle event of a Nextflow run, as posted by Nextflow
+// when running with `-with-weblog`. Workflow events carry the metadata of the run,
+// and process events carry the trace of a task.
+type NextflowWeblogEvent struct {
+	RunName  string            `json:"runName"`
+	RunId    string            `json:"runId"`
+	Event    string            `json:"event"`
+	UtcTime  string            `json:"utcTime"`
+	Trace    *NextflowTrace    `json:"trace,omitempty"`
+	Metadata *NextflowMetadata `json:"metadata,omitempty"`
+}
+
+// NextflowTrace is the trace of a task in a Nextflow run.
+type NextflowTrace struct {
+	TaskId  int    `json:"task_id"`
+	Name    string `json:"name"`
+	Process string `json:"process"`
+	Status  string `json:"status"`
+}
+
+// NextflowMetadata is the metadata of a Nextflow run.
+type NextflowMetadata struct {
+	Parameters map[string]any           `json:"parameters"`
+	Workflow   NextflowWorkflowMetadata `json:"workflow"`
+}
+
+// NextflowWorkflowMetadata describes the workflow of a Nextflow run.
+type NextflowWorkflowMetadata struct {
+	ProjectName  string `json:"projectName"`
+	LaunchDir    string `json:"launchDir"`
+	OutputDir    string `json:"outputDir"`
+	Success      bool   `json:"success"`
+	ErrorMessage string `json:"errorMessage"`
+	Manifest     struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"manifest"`
+}
+
+func (e NextflowWeblogEvent) Validate() error {
+	var errs []error
+	switch e.Event {
+	case NextflowEventStarted, NextflowEventCompleted:
+		if e.Metadata == nil {
+			errs = append(errs, fmt.Errorf("missing metadata for %s event", e.Event))
+		}
+	case NextflowEventError, NextflowEventProcessSubmitted, NextflowEventProcessStarted, NextflowEventProcessCompleted:
+	case "":
+		errs = append(errs, fmt.Errorf("missing event"))
+	default:
+		errs = append(errs, fmt.Errorf("invalid event %q", e.Event))
+	}
+	if _, err := e.AnalysisId(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// AnalysisId returns the ID of the analysis that tracks the Nextflow run. Nextflow
+// identifies runs by a UUID, and it is used as is. A resumed run keeps its ID, and
+// thus maps to the same analysis.
+func (e NextflowWeblogEvent) AnalysisId() (uuid.UUID, error) {
+	id, err := uuid.Parse(e.RunId)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid run id %q: %w", e.RunId, err)
+	}
+	return id, nil
+}
+
+// WorkflowName returns the name of the workflow from its manifest, falling back to
+// the name of the project.
+func (m NextflowMetadata) WorkflowName() string {
+	if m.Workflow.Manifest.Name != "" {
+		return m.Workflow.Manifest.Name
+	}
+	return m.Workflow.ProjectName
+}
+
+// OutputDir returns the directory where the workflow publishes its results. This is
+// the `outdir` parameter that most pipelines use, resolved against the launch
+// directory if it is relative. If there is no such parameter, the output directory
+// of the workflow is used, and lastly the launch directory.
+func (m NextflowMetadata) OutputDir() string {
+	outdir, _ := m.Parameters["outdir"].(string)
+	if outdir == "" {
+		outdir = m.Workflow.OutputDir
+	}
+	if outdir == "" {
+		return m.Workflow.LaunchDir
+	}
+	if filepath.IsAbs(outdir) {
+		return filepath.Clean(outdir)
+	}
+	return filepath.Join(m.Workflow.LaunchDir, outdir)
+}
+
+// NewNextflowAnalysis creates a pending analysis from the `started` event of a
+// Nextflow run.
+func NewNextflowAnalysis(e NextflowWeblogEvent) (Analysis, error) {
+	var analysis Analysis
+	if e.Event != NextflowEventStarted {
+		return analysis, fmt.Errorf("analyses can only be created from %s events", NextflowEventStarted)
+	}
+	if err := e.Validate(); err != nil {
+		return analysis, err
+	}
+	analysis.AnalysisId, _ = e.AnalysisId()
+	analysis.Runs = []string{}
+	analysis.Software = e.Metadata.WorkflowName()
+	analysis.SoftwareVersion = e.Metadata.Workflow.Manifest.Version
+	analysis.Path = e.Metadata.OutputDir()
+	if analysis.Software == "" {
+		return analysis, fmt.Errorf("missing workflow name")
+	}
+	if !filepath.IsAbs(analysis.Path) {
+		return analysis, fmt.Errorf("path must be absolute")
+	}
+	analysis.StateHistory.Add(StatePending)
+	return analysis, nil
+}
+
+// NextflowOutput maps files published by a Nextflow workflow to output files of the
+// analysis. The path is relative to the output directory of the workflow and may
+// contain glob patterns. For sample level files, the path can contain a `{sample}`
+// placeholder that matches a single path element, and the sample ID is then taken
+// from the path. The parent ID can refer to the sample with `{sample}`, and to
+// pipeline parameters with `{params.<name>}`.
+type NextflowOutput struct {
+	// Workflow is the name of the workflow that the output applies to. If empty,
+	// it applies to all workflows.
+	Workflow string `mapstructure:"workflow"`
+	Path     string `mapstructure:"path"`
+	Type     string `mapstructure:"type"`
+	Level    string `mapstructure:"level"`
+	ParentId string `mapstructure:"parent_id"`
+	// Optional outputs are skipped if no files match, otherwise that is an error.
+	Optional bool `mapstructure:"optional"`
+}
+
+var nextflowParamRegex = regexp.MustCompile(`\{params\.([^}]+)\}`)
+
+func (o NextflowOutput) Validate() error {
+	var errs []error
+	if o.Path == "" {
+		errs = append(errs, fmt.Errorf("missing path"))
+	} else if filepath.IsAbs(o.Path) {
+		errs = append(errs, fmt.Errorf("path must be relative to the output directory"))
+	}
+	if !AnalysisFileTypeFromString(o.Type).IsValid() {
+		errs = append(errs, fmt.Errorf("invalid file type %q", o.Type))
+	}
+	if level, err := AnalysisLevelFromString(o.Level); err != nil {
+		errs = append(errs, err)
+	} else if !level.IsValid() {
+		errs = append(errs, fmt.Errorf("missing level"))
+	}
+	if o.ParentId == "" && !strings.Contains(o.Path, "{sample}") {
+		errs = append(errs, fmt.Errorf("missing parent id"))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("nextflow output %q: %w", o.Path, errors.Join(errs...))
+}
+
+// AppliesTo returns true if the output applies to the workflow.
+func (o NextflowOutput) AppliesTo(workflow string) bool {
+	return o.Workflow == "" || strings.EqualFold(o.Workflow, workflow)
+}
+
+// files returns the unresolved output files for the analysis, one per sample if the
+// path has a sample placeholder.
+func (o NextflowOutput) files(a *Analysis, params map[string]any) (AnalysisFiles, error) {
+	level, err := AnalysisLevelFromString(o.Level)
+	if err != nil {
+		return nil, err
+	}
+	parentId := o.ParentId
+	if parentId == "" {
+		parentId = "{sample}"
+	}
+	var paramErr error
+	parentId = nextflowParamRegex.ReplaceAllStringFunc(parentId, func(s string) string {
+		name := nextflowParamRegex.FindStringSubmatch(s)[1]
+		v, ok := params[name]
+		if !ok || v == nil {
+			paramErr = fmt.Errorf("parameter %q is not set", name)
+			return ""
+		}
+		return fmt.Sprint(v)
+	})
+	if paramErr != nil {
+		return nil, paramErr
+	}
+
+	newFile := func(path, parentId string) AnalysisFile {
+		f := AnalysisFile{
+			Path:     path,
+			FileType: AnalysisFileTypeFromString(o.Type),
+			Level:    level,
+			ParentId: parentId,
+		}
+		f.IsPartOfAnalysis()
+		return f
+	}
+
+	if !strings.Contains(o.Path, "{sample}") {
+		return AnalysisFiles{newFile(o.Path, parentId)}, nil
+	}
+	samples, err := nextflowOutputSamples(a.Path, o.Path)
+	if err != nil {
+		return nil, err
+	}
+	files := make(AnalysisFiles, 0, len(samples))
+	for _, s := range samples {
+		files = append(files, newFile(
+			strings.ReplaceAll(o.Path, "{sample}", s),
+			strings.ReplaceAll(parentId, "{sample}", s),
+		))
+	}
+	return files, nil
+}
+
+// nextflowOutputSamples returns the sample IDs matched by the `{sample}` placeholder
+// of a path pattern in the output directory, in lexical order.
+func nextflowOutputSamples(dir, pattern string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, strings.ReplaceAll(pattern, "{sample}", "*")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob files: %w", err)
+	}
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i, part := range strings.Split(filepath.ToSlash(pattern), "{sample}") {
+		if i > 0 {
+			expr.WriteString("([^/]+)")
+		}
+		for _, r := range part {
+			switch r {
+			case '*':
+				expr.WriteString("[^/]*")
+			case '?':
+				expr.WriteString("[^/]")
+			default:
+				expr.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+	}
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	var samples []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			continue
+		}
+		m := re.FindStringSubmatch(filepath.ToSlash(rel))
+		if m == nil {
+			continue
+		}
+		for _, s := range m[1:] {
+			if s != m[1] {
+				// The placeholder must match the same sample everywhere
+				m = nil
+				break
+			}
+		}
+		if m != nil && !slices.Contains(samples, m[1]) {
+			samples = append(samples, m[1])
+		}
+	}
+	slices.Sort(samples)
+	return samples, nil
+}
+
+// NextflowOutputFiles resolves the files published by a Nextflow workflow according
+// to the outputs that apply to the workflow of the analysis. Parameters of the run
+// are used in parent IDs. An error is returned if an output that is not optional
+// does not match any files.
+func NextflowOutputFiles(a *Analysis, outputs []NextflowOutput, params map[string]any) (AnalysisFiles, error) {
+	var files AnalysisFiles
+	for _, o := range outputs {
+		if !o.AppliesTo(a.Software) {
+			continue
+		}
+		if err := o.Validate(); err != nil {
+			return nil, err
+		}
+		outputFiles, err := o.files(a, params)
+		if err != nil {
+			return nil, fmt.Errorf("nextflow output %q: %w", o.Path, err)
+		}
+		if len(outputFiles) == 0 {
+			if o.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("nextflow output %q: no samples found", o.Path)
+		}
+		if err := outputFiles.Validate(); err != nil {
+			return nil, fmt.Errorf("nextflow output %q: %w", o.Path, err)
+		}
+		if err := outputFiles.ResolvePaths(a.Path); err != nil {
+			if o.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("nextflow output %q: %w", o.Path, err)
+		}
+		files = append(files, outputFiles...)
+	}
+	return files, nil
+}
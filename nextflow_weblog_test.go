@@ -0,0 +1,204 @@
+package cleve
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNextflowMetadataOutputDir(t *testing.T) {
+	testcases := []struct {
+		name     string
+		metadata NextflowMetadata
+		outdir   string
+	}{
+		{
+			name: "relative outdir",
+			metadata: NextflowMetadata{
+				Parameters: map[string]any{"outdir": "results"},
+				Workflow:   NextflowWorkflowMetadata{LaunchDir: "/data/launch", OutputDir: "/data/launch/other"},
+			},
+			outdir: "/data/launch/results",
+		},
+		{
+			name: "absolute outdir",
+			metadata: NextflowMetadata{
+				Parameters: map[string]any{"outdir": "/data/results/"},
+				Workflow:   NextflowWorkflowMetadata{LaunchDir: "/data/launch"},
+			},
+			outdir: "/data/results",
+		},
+		{
+			name: "workflow output dir",
+			metadata: NextflowMetadata{
+				Workflow: NextflowWorkflowMetadata{LaunchDir: "/data/launch", OutputDir: "/data/launch/output"},
+			},
+			outdir: "/data/launch/output",
+		},
+		{
+			name: "launch dir",
+			metadata: NextflowMetadata{
+				Workflow: NextflowWorkflowMetadata{LaunchDir: "/data/launch"},
+			},
+			outdir: "/data/launch",
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			if outdir := c.metadata.OutputDir(); outdir != c.outdir {
+				t.Errorf("expected output dir %q, got %q", c.outdir, outdir)
+			}
+		})
+	}
+}
+
+func TestNewNextflowAnalysis(t *testing.T) {
+	event := NextflowWeblogEvent{
+		RunId: "5d7b6a4e-5f4a-4e35-8a43-0f8a2b6c4d1e",
+		Event: NextflowEventStarted,
+		Metadata: &NextflowMetadata{
+			Parameters: map[string]any{"outdir": "results"},
+			Workflow: NextflowWorkflowMetadata{
+				ProjectName: "nf-core/rnaseq",
+				LaunchDir:   "/data/launch",
+			},
+		},
+	}
+	event.Metadata.Workflow.Manifest.Name = "nf-core/rnaseq"
+	event.Metadata.Workflow.Manifest.Version = "3.14.0"
+
+	a, err := NewNextflowAnalysis(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.AnalysisId.String() != event.RunId {
+		t.Errorf("expected analysis id %s, got %s", event.RunId, a.AnalysisId)
+	}
+	if a.Software != "nf-core/rnaseq" || a.SoftwareVersion != "3.14.0" {
+		t.Errorf("expected nf-core/rnaseq 3.14.0, got %s %s", a.Software, a.SoftwareVersion)
+	}
+	if a.Path != "/data/launch/results" {
+		t.Errorf("expected path /data/launch/results, got %s", a.Path)
+	}
+	if a.StateHistory.LastState() != StatePending {
+		t.Errorf("expected state pending, got %s", a.StateHistory.LastState())
+	}
+
+	event.Event = NextflowEventCompleted
+	if _, err := NewNextflowAnalysis(event); err == nil {
+		t.Error("expected an error when creating an analysis from a completed event")
+	}
+
+	event.Event = NextflowEventStarted
+	event.RunId = "not-a-uuid"
+	if _, err := NewNextflowAnalysis(event); err == nil {
+		t.Error("expected an error for an invalid run id")
+	}
+}
+
+func TestNextflowOutputFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"multiqc/multiqc_data/multiqc_data.json",
+		"star_salmon/sample1/sample1.markdup.sorted.bam",
+		"star_salmon/sample2/sample2.markdup.sorted.bam",
+		"star_salmon/sample2/other.markdup.sorted.bam",
+	} {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	analysis := &Analysis{Path: dir, Software: "nf-core/rnaseq"}
+	params := map[string]any{"run_id": "run1"}
+
+	testcases := []struct {
+		name    string
+		outputs []NextflowOutput
+		files   []string
+		parents []string
+		error   bool
+	}{
+		{
+			name: "run level with parameter",
+			outputs: []NextflowOutput{
+				{Path: "multiqc/*/multiqc_data.json", Type: "multiqc", Level: "run", ParentId: "{params.run_id}"},
+			},
+			files:   []string{"multiqc/multiqc_data/multiqc_data.json"},
+			parents: []string{"run1"},
+		},
+		{
+			name: "sample placeholder",
+			outputs: []NextflowOutput{
+				{Path: "star_salmon/{sample}/{sample}.markdup.sorted.bam", Type: "bam", Level: "sample"},
+			},
+			files: []string{
+				"star_salmon/sample1/sample1.markdup.sorted.bam",
+				"star_salmon/sample2/sample2.markdup.sorted.bam",
+			},
+			parents: []string{"sample1", "sample2"},
+		},
+		{
+			name: "other workflow",
+			outputs: []NextflowOutput{
+				{Workflow: "nf-core/sarek", Path: "missing.bam", Type: "bam", Level: "run", ParentId: "run1"},
+			},
+		},
+		{
+			name: "optional missing",
+			outputs: []NextflowOutput{
+				{Path: "missing.bam", Type: "bam", Level: "run", ParentId: "run1", Optional: true},
+			},
+		},
+		{
+			name: "missing",
+			outputs: []NextflowOutput{
+				{Path: "missing.bam", Type: "bam", Level: "run", ParentId: "run1"},
+			},
+			error: true,
+		},
+		{
+			name: "missing parameter",
+			outputs: []NextflowOutput{
+				{Path: "multiqc/*/multiqc_data.json", Type: "multiqc", Level: "run", ParentId: "{params.missing}"},
+			},
+			error: true,
+		},
+		{
+			name: "invalid type",
+			outputs: []NextflowOutput{
+				{Path: "multiqc/*/multiqc_data.json", Type: "invalid", Level: "run", ParentId: "run1"},
+			},
+			error: true,
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			files, err := NextflowOutputFiles(analysis, c.outputs, params)
+			if c.error {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths, parents []string
+			for _, f := range files {
+				paths = append(paths, f.Path)
+				parents = append(parents, f.ParentId)
+			}
+			if !slices.Equal(paths, c.files) {
+				t.Errorf("expected files %v, got %v", c.files, paths)
+			}
+			if !slices.Equal(parents, c.parents) {
+				t.Errorf("expected parent ids %v, got %v", c.parents, parents)
+			}
+		})
+	}
+}
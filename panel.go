@@ -203,3 +203,33 @@ func GenePanelFromYaml(r io.Reader) (GenePanel, error) {
 func (p *GenePanel) Add(gene Gene) {
 	p.Genes = append(p.Genes, gene)
 }
+
+// PanelRef refers to a specific version of a gene panel, e.g. the panel that was used
+// to interpret a sample.
+type PanelRef struct {
+	Id      string `bson:"id" json:"id" binding:"required"`
+	Version string `bson:"version" json:"version" binding:"required"`
+}
+
+// PanelRefStatus tells whether the panel version that a reference points to is still
+// the current version of the panel.
+type PanelRefStatus string
+
+const (
+	PanelCurrent    PanelRefStatus = "current"
+	PanelSuperseded PanelRefStatus = "superseded"
+	PanelArchived   PanelRefStatus = "archived"
+)
+
+// Status returns the status of the referenced panel version given the most recent
+// version of the panel. Archived panels take precedence over superseded versions.
+func (r PanelRef) Status(latest GenePanel) PanelRefStatus {
+	if latest.Archived {
+		return PanelArchived
+	}
+	v, err := ParseVersion(r.Version)
+	if err != nil || !v.Equal(latest.Version) {
+		return PanelSuperseded
+	}
+	return PanelCurrent
+}
@@ -0,0 +1,55 @@
+package cleve
+
+import "testing"
+
+func TestPanelRefStatus(t *testing.T) {
+	latest := GenePanel{GenePanelVersion: GenePanelVersion{Version: NewMinorVersion(2, 1)}}
+	archived := latest
+	archived.Archived = true
+
+	cases := []struct {
+		name     string
+		ref      PanelRef
+		latest   GenePanel
+		expected PanelRefStatus
+	}{
+		{name: "current", ref: PanelRef{Id: "p", Version: "2.1"}, latest: latest, expected: PanelCurrent},
+		{name: "superseded", ref: PanelRef{Id: "p", Version: "2.0"}, latest: latest, expected: PanelSuperseded},
+		{name: "invalid version", ref: PanelRef{Id: "p", Version: "two"}, latest: latest, expected: PanelSuperseded},
+		{name: "archived", ref: PanelRef{Id: "p", Version: "2.1"}, latest: archived, expected: PanelArchived},
+		{name: "archived and superseded", ref: PanelRef{Id: "p", Version: "1.0"}, latest: archived, expected: PanelArchived},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if s := c.ref.Status(c.latest); s != c.expected {
+				t.Errorf("expected %s, got %s", c.expected, s)
+			}
+		})
+	}
+}
+
+func TestSamplePanelRefs(t *testing.T) {
+	s := Sample{
+		Id:     "S1",
+		Panels: []PanelRef{{Id: "p1", Version: "1.0"}, {Id: "p2", Version: "1.0"}},
+		Analyses: []*SampleAnalysis{
+			{Panels: []PanelRef{{Id: "p1", Version: "1.0"}}},
+			{Panels: []PanelRef{{Id: "p1", Version: "1.1"}}},
+			nil,
+		},
+	}
+	refs := s.PanelRefs("p1")
+	expected := []PanelRef{{Id: "p1", Version: "1.0"}, {Id: "p1", Version: "1.1"}}
+	if len(refs) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, refs)
+	}
+	for i := range expected {
+		if refs[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, refs)
+		}
+	}
+	if refs := s.PanelRefs("p3"); len(refs) != 0 {
+		t.Errorf("expected no references, got %v", refs)
+	}
+}
@@ -0,0 +1,99 @@
+package cleve
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DeliveryState is the state of a project in the delivery process. A project starts
+// out as sequenced, and moves on to analysed and delivered in that order.
+type DeliveryState string
+
+const (
+	DeliverySequenced DeliveryState = "sequenced"
+	DeliveryAnalysed  DeliveryState = "analysed"
+	DeliveryDelivered DeliveryState = "delivered"
+)
+
+var deliveryStates = []DeliveryState{DeliverySequenced, DeliveryAnalysed, DeliveryDelivered}
+
+// IsValid returns true if the state is one of the known delivery states.
+func (s DeliveryState) IsValid() bool {
+	return slices.Contains(deliveryStates, s)
+}
+
+// Next returns the state that follows s, or an empty state if s is the last state.
+func (s DeliveryState) Next() DeliveryState {
+	i := slices.Index(deliveryStates, s)
+	if i < 0 || i == len(deliveryStates)-1 {
+		return ""
+	}
+	return deliveryStates[i+1]
+}
+
+// TimedDeliveryState is a delivery state together with the time it was entered.
+type TimedDeliveryState struct {
+	State DeliveryState `bson:"state" json:"state"`
+	Time  time.Time     `bson:"time" json:"time"`
+}
+
+// Project represents a sequencing project as given by `Sample_Project` in the
+// samplesheet, or `ProjectName` in the index metrics of a run.
+type Project struct {
+	Name string `bson:"name" json:"name"`
+	// Runs that the project has samples on.
+	Runs []string `bson:"runs" json:"runs"`
+	// DeliveryState is the current delivery state, and DeliveryHistory all the
+	// delivery states the project has been in.
+	DeliveryState   DeliveryState        `bson:"delivery_state" json:"delivery_state"`
+	DeliveryHistory []TimedDeliveryState `bson:"delivery_history" json:"delivery_history"`
+	// Number of samples in the project, and the reads and yield summed over all
+	// samples and runs.
+	SampleCount int `bson:"sample_count,omitempty" json:"sample_count"`
+	Reads       int `bson:"-" json:"reads"`
+	Yield       int `bson:"-" json:"yield"`
+}
+
+// NewProject returns a project in the sequenced state.
+func NewProject(name string) Project {
+	return Project{
+		Name:          name,
+		Runs:          []string{},
+		DeliveryState: DeliverySequenced,
+		DeliveryHistory: []TimedDeliveryState{
+			{State: DeliverySequenced, Time: time.Now()},
+		},
+	}
+}
+
+// SetDeliveryState moves the project to a new delivery state. A project can only move
+// to the state directly following the current one.
+func (p *Project) SetDeliveryState(state DeliveryState) error {
+	if !state.IsValid() {
+		return fmt.Errorf("invalid delivery state %q", state)
+	}
+	if next := p.DeliveryState.Next(); state != next {
+		return fmt.Errorf("project %s cannot move from %s to %s", p.Name, p.DeliveryState, state)
+	}
+	p.DeliveryState = state
+	p.DeliveryHistory = append(p.DeliveryHistory, TimedDeliveryState{State: state, Time: time.Now()})
+	return nil
+}
+
+// SetReads sets the total number of reads and yield of the project from the read
+// counts of its samples, see [Sample.SetReads].
+func (p *Project) SetReads(samples []Sample) {
+	p.Reads = 0
+	p.Yield = 0
+	for _, s := range samples {
+		p.Reads += s.Reads
+		p.Yield += s.Yield
+	}
+}
+
+// ProjectResult is a paginated list of projects.
+type ProjectResult struct {
+	PaginationMetadata `bson:"metadata" json:"metadata"`
+	Projects           []Project `bson:"projects" json:"projects"`
+}
@@ -0,0 +1,41 @@
+package cleve
+
+import "testing"
+
+func TestProjectSetDeliveryState(t *testing.T) {
+	cases := []struct {
+		name    string
+		from    DeliveryState
+		to      DeliveryState
+		error   bool
+		history int
+	}{
+		{name: "sequenced to analysed", from: DeliverySequenced, to: DeliveryAnalysed, history: 2},
+		{name: "analysed to delivered", from: DeliveryAnalysed, to: DeliveryDelivered, history: 2},
+		{name: "sequenced to delivered", from: DeliverySequenced, to: DeliveryDelivered, error: true, history: 1},
+		{name: "delivered to analysed", from: DeliveryDelivered, to: DeliveryAnalysed, error: true, history: 1},
+		{name: "same state", from: DeliveryAnalysed, to: DeliveryAnalysed, error: true, history: 1},
+		{name: "invalid state", from: DeliverySequenced, to: "archived", error: true, history: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := NewProject("project1")
+			p.DeliveryState = c.from
+			err := p.SetDeliveryState(c.to)
+			if (err != nil) != c.error {
+				t.Fatalf("expected error to be %t, got %v", c.error, err)
+			}
+			if len(p.DeliveryHistory) != c.history {
+				t.Errorf("expected %d history entries, got %d", c.history, len(p.DeliveryHistory))
+			}
+			expected := c.to
+			if c.error {
+				expected = c.from
+			}
+			if p.DeliveryState != expected {
+				t.Errorf("expected state %s, got %s", expected, p.DeliveryState)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package cleve
 
 import (
+	"time"
+
 	"github.com/gmc-norr/cleve/interop"
 )
 
@@ -13,3 +15,11 @@ type SampleQc struct {
 	SampleId  string `json:"sample_id"`
 	ReadCount int    `json:"read_count"`
 }
+
+// StaleQc identifies a run whose stored QC was computed with an outdated version of
+// the QC computation.
+type StaleQc struct {
+	RunId              string    `bson:"run_id" json:"run_id"`
+	ComputationVersion int       `bson:"computation_version" json:"computation_version"`
+	ComputedAt         time.Time `bson:"computed_at" json:"computed_at"`
+}
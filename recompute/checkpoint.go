@@ -0,0 +1,122 @@
+package recompute
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/gmc-norr/cleve/interop"
+)
+
+// Checkpoint keeps track of the runs that have been recomputed so that an interrupted
+// recomputation can be resumed. The checkpoint is written to disk every time a run is
+// marked as done.
+//
+// A nil Checkpoint is valid, and considers no runs to be done.
+type Checkpoint struct {
+	path string
+
+	mu        sync.Mutex
+	version   int
+	completed map[string]bool
+}
+
+type checkpointFile struct {
+	ComputationVersion int      `json:"computation_version"`
+	Completed          []string `json:"completed"`
+}
+
+// OpenCheckpoint reads the checkpoint in path. If the file does not exist, an empty
+// checkpoint is returned. A checkpoint that was written for another version of the QC
+// computation is discarded, since the runs in it have to be recomputed anyway.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{
+		path:      path,
+		version:   interop.SummaryComputationVersion,
+		completed: make(map[string]bool),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f checkpointFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	if f.ComputationVersion != c.version {
+		return c, nil
+	}
+	for _, runId := range f.Completed {
+		c.completed[runId] = true
+	}
+	return c, nil
+}
+
+// IsDone returns true if the run has been recomputed.
+func (c *Checkpoint) IsDone(runId string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.completed[runId]
+}
+
+// Len returns the number of runs that have been recomputed.
+func (c *Checkpoint) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.completed)
+}
+
+// MarkDone marks a run as recomputed and saves the checkpoint.
+func (c *Checkpoint) MarkDone(runId string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed[runId] = true
+	return c.save()
+}
+
+// save writes the checkpoint to a temporary file and then moves it into place so that
+// an interruption never leaves a partially written checkpoint behind.
+func (c *Checkpoint) save() error {
+	f := checkpointFile{
+		ComputationVersion: c.version,
+		Completed:          make([]string, 0, len(c.completed)),
+	}
+	for runId := range c.completed {
+		f.Completed = append(f.Completed, runId)
+	}
+	slices.Sort(f.Completed)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
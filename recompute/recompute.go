@@ -0,0 +1,174 @@
+// Package recompute recomputes run QC from InterOp data for many runs concurrently.
+package recompute
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+)
+
+// Store is where recomputed QC data is saved, together with the sample read counts
+// from the index summary.
+type Store interface {
+	UpdateRunQC(interop.InteropSummary) error
+	UpdateFromIndexSummary(string, interop.IndexSummary) error
+}
+
+// Reader reads the InterOp data for the run in a directory and summarises it.
+type Reader func(path string) (interop.InteropSummary, error)
+
+// ReadInterop is the default [Reader] that reads InterOp data from disk.
+func ReadInterop(path string) (interop.InteropSummary, error) {
+	i, err := interop.InteropFromDir(path)
+	if err != nil {
+		return interop.InteropSummary{}, err
+	}
+	return i.Summarise(), nil
+}
+
+// Result is the outcome of recomputing QC for a single run.
+type Result struct {
+	RunId string
+	// Skipped is true if the run had already been recomputed according to the checkpoint.
+	Skipped bool
+	Err     error
+}
+
+// Progress describes how far a recomputation has come.
+type Progress struct {
+	Total     int
+	Completed int
+	Failed    int
+	Skipped   int
+	// Result is the result that triggered the progress update.
+	Result Result
+}
+
+// Recomputer recomputes QC for runs using a bounded number of workers.
+type Recomputer struct {
+	// Workers is the maximum number of runs that are processed concurrently.
+	Workers int
+	// Checkpoint keeps track of runs that are done. Runs in the checkpoint are
+	// skipped. If nil, all runs are recomputed.
+	Checkpoint *Checkpoint
+	// Progress, if not nil, is called after each run has been processed. It is
+	// never called concurrently.
+	Progress func(Progress)
+
+	store Store
+	read  Reader
+}
+
+// New creates a new Recomputer that stores QC data in store and reads InterOp data
+// from disk.
+func New(store Store, workers int) *Recomputer {
+	return &Recomputer{
+		Workers: workers,
+		store:   store,
+		read:    ReadInterop,
+	}
+}
+
+// WithReader sets the function used for reading InterOp data.
+func (r *Recomputer) WithReader(read Reader) *Recomputer {
+	r.read = read
+	return r
+}
+
+// Run recomputes the QC for the runs and returns the results in the order the runs
+// were given. If the context is cancelled, runs that have not yet been started are
+// reported with the context error.
+func (r *Recomputer) Run(ctx context.Context, runs []*cleve.Run) []Result {
+	workers := max(r.Workers, 1)
+	results := make([]Result, len(runs))
+	progress := Progress{Total: len(runs)}
+
+	type job struct {
+		index int
+		run   *cleve.Run
+	}
+	type jobResult struct {
+		index  int
+		result Result
+	}
+
+	jobs := make(chan job)
+	done := make(chan jobResult)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				done <- jobResult{index: j.index, result: r.recompute(j.run)}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i, run := range runs {
+			if r.Checkpoint.IsDone(run.RunID) {
+				done <- jobResult{index: i, result: Result{RunId: run.RunID, Skipped: true}}
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				done <- jobResult{index: i, result: Result{RunId: run.RunID, Err: err}}
+				continue
+			}
+			select {
+			case jobs <- job{index: i, run: run}:
+			case <-ctx.Done():
+				done <- jobResult{index: i, result: Result{RunId: run.RunID, Err: ctx.Err()}}
+			}
+		}
+	}()
+
+	go func() {
+		// Wait for the dispatcher to close the job channel and for the workers to finish.
+		wg.Wait()
+		close(done)
+	}()
+
+	for jr := range done {
+		results[jr.index] = jr.result
+		switch {
+		case jr.result.Skipped:
+			progress.Skipped++
+		case jr.result.Err != nil:
+			progress.Failed++
+		default:
+			progress.Completed++
+			if err := r.Checkpoint.MarkDone(jr.result.RunId); err != nil {
+				results[jr.index].Err = fmt.Errorf("qc updated but checkpoint failed: %w", err)
+			}
+		}
+		if r.Progress != nil {
+			progress.Result = results[jr.index]
+			r.Progress(progress)
+		}
+	}
+
+	return results
+}
+
+func (r *Recomputer) recompute(run *cleve.Run) Result {
+	res := Result{RunId: run.RunID}
+	qc, err := r.read(run.Path)
+	if err != nil {
+		res.Err = fmt.Errorf("failed to read qc data: %w", err)
+		return res
+	}
+	if err := r.store.UpdateRunQC(qc); err != nil {
+		res.Err = fmt.Errorf("failed to update qc data: %w", err)
+		return res
+	}
+	if err := r.store.UpdateFromIndexSummary(run.RunID, qc.IndexSummary); err != nil {
+		res.Err = fmt.Errorf("failed to update sample read counts: %w", err)
+	}
+	return res
+}
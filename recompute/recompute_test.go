@@ -0,0 +1,151 @@
+package recompute
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+)
+
+type testStore struct {
+	mu      sync.Mutex
+	updated []string
+}
+
+func (s *testStore) UpdateRunQC(qc interop.InteropSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updated = append(s.updated, qc.RunId)
+	return nil
+}
+
+func (s *testStore) UpdateFromIndexSummary(string, interop.IndexSummary) error {
+	return nil
+}
+
+func testRuns(ids ...string) []*cleve.Run {
+	runs := make([]*cleve.Run, len(ids))
+	for i, id := range ids {
+		runs[i] = &cleve.Run{RunID: id, Path: "/path/to/" + id}
+	}
+	return runs
+}
+
+func TestRecompute(t *testing.T) {
+	store := &testStore{}
+	var running, maxRunning atomic.Int32
+	r := New(store, 2).WithReader(func(path string) (interop.InteropSummary, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		runId := filepath.Base(path)
+		if runId == "run3" {
+			return interop.InteropSummary{}, errors.New("missing interop data")
+		}
+		return interop.InteropSummary{RunId: runId}, nil
+	})
+	var progress []Progress
+	r.Progress = func(p Progress) {
+		progress = append(progress, p)
+	}
+
+	results := r.Run(context.Background(), testRuns("run1", "run2", "run3", "run4", "run5"))
+
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	for i, id := range []string{"run1", "run2", "run3", "run4", "run5"} {
+		if results[i].RunId != id {
+			t.Errorf("expected result %d to be for %s, got %s", i, id, results[i].RunId)
+		}
+		if (results[i].Err != nil) != (id == "run3") {
+			t.Errorf("unexpected error for %s: %v", id, results[i].Err)
+		}
+	}
+	if len(store.updated) != 4 {
+		t.Errorf("expected 4 runs to be updated, got %d", len(store.updated))
+	}
+	if m := maxRunning.Load(); m > 2 {
+		t.Errorf("expected at most 2 concurrent workers, got %d", m)
+	}
+	if len(progress) != 5 {
+		t.Fatalf("expected 5 progress updates, got %d", len(progress))
+	}
+	last := progress[len(progress)-1]
+	if last.Total != 5 || last.Completed != 4 || last.Failed != 1 || last.Skipped != 0 {
+		t.Errorf("unexpected final progress: %+v", last)
+	}
+}
+
+func TestRecomputeCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	read := func(path string) (interop.InteropSummary, error) {
+		return interop.InteropSummary{RunId: filepath.Base(path)}, nil
+	}
+
+	checkpoint, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Len() != 0 {
+		t.Fatalf("expected empty checkpoint, got %d runs", checkpoint.Len())
+	}
+
+	store := &testStore{}
+	r := New(store, 1).WithReader(read)
+	r.Checkpoint = checkpoint
+	r.Run(context.Background(), testRuns("run1", "run2"))
+
+	// Resume from the saved checkpoint
+	checkpoint, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkpoint.IsDone("run1") || !checkpoint.IsDone("run2") || checkpoint.IsDone("run3") {
+		t.Fatalf("unexpected checkpoint state")
+	}
+
+	store = &testStore{}
+	r = New(store, 1).WithReader(read)
+	r.Checkpoint = checkpoint
+	results := r.Run(context.Background(), testRuns("run1", "run2", "run3"))
+
+	if len(store.updated) != 1 || store.updated[0] != "run3" {
+		t.Errorf("expected only run3 to be updated, got %v", store.updated)
+	}
+	if !results[0].Skipped || !results[1].Skipped || results[2].Skipped {
+		t.Errorf("expected run1 and run2 to be skipped, got %+v", results)
+	}
+}
+
+func TestRecomputeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	store := &testStore{}
+	r := New(store, 2).WithReader(func(path string) (interop.InteropSummary, error) {
+		return interop.InteropSummary{RunId: filepath.Base(path)}, nil
+	})
+	results := r.Run(ctx, testRuns("run1", "run2", "run3"))
+
+	for _, res := range results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("expected %s to be cancelled, got %v", res.RunId, res.Err)
+		}
+	}
+	if len(store.updated) != 0 {
+		t.Errorf("expected no updates, got %v", store.updated)
+	}
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+)
+
+var funcMap = template.FuncMap{
+	"float": func(v interop.OptionalFloat, precision int) string {
+		return formatFloat(float64(v), precision)
+	},
+	"millions": func(v int) string {
+		return formatFloat(float64(v)*1e-6, 2)
+	},
+	"gbp": func(v int) string {
+		return formatFloat(float64(v)*1e-9, 2)
+	},
+	"time":  formatTime,
+	"chart": chartSVG,
+}
+
+// WriteHTML writes the report as a self-contained HTML document. Styles and
+// charts are inlined so that the document can be archived as a single file.
+func (r Report) WriteHTML(w io.Writer) error {
+	fs, err := cleve.GetTemplateFS()
+	if err != nil {
+		return err
+	}
+	t, err := template.New("run.html").Funcs(funcMap).ParseFS(fs, "report/run.html")
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return t.Execute(w, struct {
+		Report
+		Charts []barChart
+	}{
+		Report: r,
+		Charts: r.charts(),
+	})
+}
+
+// chartSVG renders a horizontal bar chart as an inline SVG.
+func chartSVG(c barChart) template.HTML {
+	const (
+		labelWidth = 160
+		barWidth   = 440
+		valueWidth = 80
+		barHeight  = 14
+		barGap     = 4
+		titleSpace = 24
+	)
+	width := labelWidth + barWidth + valueWidth
+	height := titleSpace + len(c.Values)*(barHeight+barGap)
+	maxValue := c.max()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`, width, height, width, height)
+	fmt.Fprintf(&b, `<text x="0" y="14" font-size="13" font-weight="bold">%s (%s)</text>`, template.HTMLEscapeString(c.Title), template.HTMLEscapeString(c.Unit))
+	for i, v := range c.Values {
+		y := titleSpace + i*(barHeight+barGap)
+		w := 0.0
+		if maxValue > 0 && !math.IsNaN(v) {
+			w = barWidth * v / maxValue
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, labelWidth-6, y+barHeight-3, template.HTMLEscapeString(c.Labels[i]))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="#2b6cb0"/>`, labelWidth, y, w, barHeight)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%s</text>`, float64(labelWidth)+w+4, y+barHeight-3, formatFloat(v, 2))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,274 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfLineHeight = 5.0
+	pdfFontSize   = 9.0
+)
+
+// pdfWriter wraps a PDF document with helpers for the elements used in the report.
+type pdfWriter struct {
+	*fpdf.Fpdf
+	tr func(string) string
+}
+
+func newPdfWriter() *pdfWriter {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	return &pdfWriter{
+		Fpdf: pdf,
+		tr:   pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+}
+
+// contentWidth returns the width of the page between the margins.
+func (p *pdfWriter) contentWidth() float64 {
+	w, _ := p.GetPageSize()
+	l, _, r, _ := p.GetMargins()
+	return w - l - r
+}
+
+// ensureSpace starts a new page if there is less than h mm left on the current page.
+func (p *pdfWriter) ensureSpace(h float64) {
+	_, pageHeight := p.GetPageSize()
+	_, _, _, bottom := p.GetMargins()
+	if p.GetY()+h > pageHeight-bottom {
+		p.AddPage()
+	}
+}
+
+func (p *pdfWriter) heading(s string) {
+	p.ensureSpace(20)
+	p.Ln(4)
+	p.SetFont("Helvetica", "B", 12)
+	p.CellFormat(0, 8, p.tr(s), "B", 1, "L", false, 0, "")
+	p.Ln(2)
+	p.SetFont("Helvetica", "", pdfFontSize)
+}
+
+// table writes a table with the columns spread evenly over the page width.
+func (p *pdfWriter) table(header []string, rows [][]string) {
+	width := p.contentWidth() / float64(len(header))
+	p.SetFont("Helvetica", "B", pdfFontSize)
+	p.SetFillColor(45, 55, 72)
+	p.SetTextColor(247, 250, 252)
+	for _, h := range header {
+		p.CellFormat(width, pdfLineHeight+1, p.tr(h), "", 0, "L", true, 0, "")
+	}
+	p.Ln(-1)
+	p.SetFont("Helvetica", "", pdfFontSize)
+	p.SetTextColor(26, 32, 44)
+	for _, row := range rows {
+		for _, v := range row {
+			p.CellFormat(width, pdfLineHeight, p.tr(v), "B", 0, "L", false, 0, "")
+		}
+		p.Ln(-1)
+	}
+}
+
+// keyValues writes a two column table with keys in bold.
+func (p *pdfWriter) keyValues(kv [][2]string) {
+	for _, row := range kv {
+		p.SetFont("Helvetica", "B", pdfFontSize)
+		p.CellFormat(40, pdfLineHeight, p.tr(row[0]), "", 0, "L", false, 0, "")
+		p.SetFont("Helvetica", "", pdfFontSize)
+		p.CellFormat(0, pdfLineHeight, p.tr(row[1]), "", 1, "L", false, 0, "")
+	}
+}
+
+// barChart draws a horizontal bar chart.
+func (p *pdfWriter) barChart(c barChart) {
+	const (
+		labelWidth = 45.0
+		valueWidth = 20.0
+		barHeight  = 4.0
+		barGap     = 1.5
+	)
+	barWidth := p.contentWidth() - labelWidth - valueWidth
+	maxValue := c.max()
+
+	p.ensureSpace(10 + barHeight + barGap)
+	p.SetFont("Helvetica", "B", 10)
+	p.CellFormat(0, 7, p.tr(fmt.Sprintf("%s (%s)", c.Title, c.Unit)), "", 1, "L", false, 0, "")
+	p.SetFont("Helvetica", "", 8)
+	p.SetFillColor(43, 108, 176)
+	for i, v := range c.Values {
+		p.ensureSpace(barHeight + barGap)
+		x, _ := p.GetXY()
+		y := p.GetY()
+		w := 0.0
+		if maxValue > 0 && !math.IsNaN(v) {
+			w = barWidth * v / maxValue
+		}
+		p.CellFormat(labelWidth-2, barHeight, p.tr(c.Labels[i]), "", 0, "R", false, 0, "")
+		if w > 0 {
+			p.Rect(x+labelWidth, y, w, barHeight, "F")
+		}
+		p.SetXY(x+labelWidth+w+1, y)
+		p.CellFormat(valueWidth, barHeight, formatFloat(v, 2), "", 0, "L", false, 0, "")
+		p.SetXY(x, y+barHeight+barGap)
+	}
+	p.Ln(2)
+}
+
+// WritePDF writes the report as a PDF document.
+func (r Report) WritePDF(w io.Writer) error {
+	p := newPdfWriter()
+	p.SetTitle(fmt.Sprintf("QC report: %s", r.Run.RunID), true)
+	p.SetCreator(fmt.Sprintf("cleve %s", r.CleveVersion), true)
+	p.SetFooterFunc(func() {
+		p.SetY(-12)
+		p.SetFont("Helvetica", "I", 7)
+		p.SetTextColor(113, 128, 150)
+		p.CellFormat(0, 4, p.tr(fmt.Sprintf("%s - generated %s by cleve %s", r.Run.RunID, formatTime(r.Generated), r.CleveVersion)), "", 0, "L", false, 0, "")
+		p.CellFormat(0, 4, fmt.Sprintf("Page %d", p.PageNo()), "", 0, "R", false, 0, "")
+	})
+	p.AddPage()
+
+	p.SetFont("Helvetica", "B", 16)
+	p.CellFormat(0, 10, p.tr(fmt.Sprintf("Sequencing QC report: %s", r.Run.RunID)), "", 1, "L", false, 0, "")
+	p.SetFont("Helvetica", "", 11)
+	p.CellFormat(0, 7, fmt.Sprintf("Overall QC status: %s", r.Status()), "", 1, "L", false, 0, "")
+
+	p.heading("Run")
+	p.keyValues([][2]string{
+		{"Run ID", r.Run.RunID},
+		{"Experiment name", r.Run.ExperimentName},
+		{"Platform", r.Run.Platform},
+		{"Instrument", r.Run.RunInfo.InstrumentId},
+		{"Sequencing date", r.Run.RunInfo.Date.Format("2006-01-02")},
+		{"Flowcell", fmt.Sprintf("%s (%s)", r.Run.RunInfo.FlowcellName, r.Run.RunInfo.FlowcellId)},
+		{"State", r.Run.StateHistory.LastState().String()},
+		{"Path", r.Run.Path},
+	})
+
+	p.heading("Consumables")
+	var rows [][]string
+	for _, c := range r.Consumables() {
+		expiration := ""
+		if !c.ExpirationDate.IsZero() {
+			expiration = c.ExpirationDate.Format("2006-01-02")
+		}
+		rows = append(rows, []string{c.Type, c.Name, c.SerialNumber, c.PartNumber, c.LotNumber, expiration})
+	}
+	p.table([]string{"Type", "Name", "Serial number", "Part number", "Lot number", "Expiration date"}, rows)
+
+	if len(r.Run.RunParameters.Software) > 0 {
+		p.heading("Software")
+		rows = nil
+		for _, s := range r.Run.RunParameters.Software {
+			rows = append(rows, []string{s.Name, s.Version})
+		}
+		p.table([]string{"Software", "Version"}, rows)
+	}
+
+	p.heading("QC verdicts")
+	rows = nil
+	for _, v := range r.Verdicts {
+		rows = append(rows, []string{
+			v.Metric,
+			formatFloat(v.Value, v.Precision),
+			fmt.Sprintf("%s %.*f", v.Comparison(), v.Precision, v.Threshold),
+			v.Status.String(),
+		})
+	}
+	p.table([]string{"Metric", "Value", "Requirement", "Status"}, rows)
+
+	if mismatches := r.SexMismatches(); len(mismatches) > 0 {
+		p.heading("Sex mismatches")
+		rows = nil
+		for _, c := range mismatches {
+			rows = append(rows, []string{c.SampleId, string(c.Declared), string(c.Inferred), string(c.Method)})
+		}
+		p.table([]string{"Sample", "Declared sex", "Inferred sex", "Inferred from"}, rows)
+	}
+
+	rs := r.Qc.RunSummary
+	p.heading("Run summary")
+	p.table(
+		[]string{"Yield (Gbp)", "%>=Q30", "% PF", "% occupied", "Density (K/mm2)", "Error rate (%)", "% aligned"},
+		[][]string{{
+			formatFloat(float64(rs.Yield)*1e-9, 2),
+			formatFloat(float64(rs.PercentQ30), 2),
+			formatFloat(float64(rs.PercentPf), 2),
+			formatFloat(float64(rs.PercentOccupied), 2),
+			formatFloat(float64(rs.Density), 0),
+			formatFloat(float64(rs.ErrorRate), 2),
+			formatFloat(float64(rs.PercentAligned), 2),
+		}},
+	)
+
+	p.heading("Lane summary")
+	rows = nil
+	for _, l := range r.Qc.LaneSummary {
+		rows = append(rows, []string{
+			fmt.Sprint(l.Lane),
+			formatFloat(float64(l.Yield)*1e-9, 2),
+			formatFloat(float64(l.Density), 0),
+			formatFloat(float64(l.ErrorRate), 2),
+		})
+	}
+	p.table([]string{"Lane", "Yield (Gbp)", "Density (K/mm2)", "Error rate (%)"}, rows)
+
+	p.heading("Read summary")
+	rows = nil
+	for _, rs := range r.Qc.ReadSummary {
+		rows = append(rows, []string{
+			fmt.Sprint(rs.Read),
+			fmt.Sprint(rs.Lane),
+			formatFloat(rs.PercentQ30, 2),
+			formatFloat(float64(rs.ErrorRate), 2),
+			formatFloat(float64(rs.PercentAligned), 2),
+		})
+	}
+	p.table([]string{"Read", "Lane", "%>=Q30", "Error rate (%)", "% aligned"}, rows)
+
+	if is := r.Qc.IndexSummary; len(is.Indexes) > 0 {
+		p.heading("Index distribution")
+		p.table(
+			[]string{"Total reads (M)", "PF reads (M)", "% identified", "% undetermined"},
+			[][]string{{
+				formatFloat(float64(is.TotalReads)*1e-6, 2),
+				formatFloat(float64(is.PfReads)*1e-6, 2),
+				formatFloat(float64(is.PercentId), 2),
+				formatFloat(float64(is.PercentUndetermined), 2),
+			}},
+		)
+		p.Ln(3)
+		rows = nil
+		for _, i := range is.Indexes {
+			rows = append(rows, []string{
+				i.Sample,
+				i.Index,
+				formatFloat(float64(i.ReadCount)*1e-6, 2),
+				formatFloat(i.PercentReads, 2),
+			})
+		}
+		p.table([]string{"Sample", "Index", "Reads (M)", "% reads"}, rows)
+	}
+
+	if charts := r.charts(); len(charts) > 0 {
+		p.heading("Charts")
+		for _, c := range charts {
+			p.barChart(c)
+		}
+	}
+
+	p.Ln(6)
+	p.SetFont("Helvetica", "", 8)
+	p.keyValues([][2]string{
+		{"QC computed at", formatTime(r.Qc.ComputedAt)},
+		{"Report generated at", formatTime(r.Generated)},
+		{"cleve version", r.CleveVersion},
+	})
+
+	return p.Output(w)
+}
@@ -0,0 +1,300 @@
+// Package report generates printable QC reports for sequencing runs.
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+	"github.com/spf13/viper"
+)
+
+// Format is the output format of a report.
+type Format int
+
+const (
+	_ Format = iota
+	FormatHTML
+	FormatPDF
+)
+
+// FormatFromString returns the report format represented by the string. Valid
+// formats are "html" and "pdf".
+func FormatFromString(s string) (Format, error) {
+	switch s {
+	case "html":
+		return FormatHTML, nil
+	case "pdf":
+		return FormatPDF, nil
+	default:
+		return 0, fmt.Errorf("invalid report format %q", s)
+	}
+}
+
+// Thresholds are the limits that decide whether a run passes QC or not.
+type Thresholds struct {
+	MinPercentQ30          float64 `mapstructure:"min_percent_q30"`
+	MinPercentPf           float64 `mapstructure:"min_percent_pf"`
+	MaxErrorRate           float64 `mapstructure:"max_error_rate"`
+	MaxPercentUndetermined float64 `mapstructure:"max_percent_undetermined"`
+	MaxPercentHopped       float64 `mapstructure:"max_percent_hopped"`
+	// MaxSexMismatches is the number of samples whose declared sex may differ from the
+	// inferred sex.
+	MaxSexMismatches int `mapstructure:"max_sex_mismatches"`
+}
+
+// DefaultThresholds returns the thresholds used when nothing else has been configured.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MinPercentQ30:          75,
+		MinPercentPf:           60,
+		MaxErrorRate:           2,
+		MaxPercentUndetermined: 10,
+		MaxPercentHopped:       2,
+		MaxSexMismatches:       0,
+	}
+}
+
+// ThresholdsFromConfig returns the thresholds defined under `qc_thresholds` in the
+// configuration. Thresholds that are not configured get their default values.
+func ThresholdsFromConfig() (Thresholds, error) {
+	t := DefaultThresholds()
+	if !viper.IsSet("qc_thresholds") {
+		return t, nil
+	}
+	if err := viper.UnmarshalKey("qc_thresholds", &t); err != nil {
+		return t, fmt.Errorf("invalid qc thresholds: %w", err)
+	}
+	return t, nil
+}
+
+// VerdictStatus is the outcome of a QC check.
+type VerdictStatus int
+
+const (
+	VerdictNotAvailable VerdictStatus = iota
+	VerdictPass
+	VerdictFail
+)
+
+func (s VerdictStatus) String() string {
+	switch s {
+	case VerdictPass:
+		return "pass"
+	case VerdictFail:
+		return "fail"
+	default:
+		return "n/a"
+	}
+}
+
+// Verdict is the outcome of comparing a QC metric to a threshold.
+type Verdict struct {
+	Metric    string
+	Value     float64
+	Threshold float64
+	// Minimum is true if the threshold is a lower limit, and false if it is an upper limit.
+	Minimum bool
+	// Precision is the number of decimals used when presenting the value and threshold.
+	Precision int
+	Status    VerdictStatus
+}
+
+// Comparison returns the comparison that has to be true for the check to pass.
+func (v Verdict) Comparison() string {
+	if v.Minimum {
+		return ">="
+	}
+	return "<="
+}
+
+func newVerdict(metric string, value interop.OptionalFloat, threshold float64, minimum bool) Verdict {
+	v := Verdict{
+		Metric:    metric,
+		Value:     float64(value),
+		Threshold: threshold,
+		Minimum:   minimum,
+		Precision: 2,
+	}
+	switch {
+	case math.IsNaN(v.Value):
+		v.Status = VerdictNotAvailable
+	case minimum && v.Value >= threshold, !minimum && v.Value <= threshold:
+		v.Status = VerdictPass
+	default:
+		v.Status = VerdictFail
+	}
+	return v
+}
+
+// Verdicts checks the run QC against the thresholds. Index and index hopping
+// checks are only included if there is data for them.
+func Verdicts(qc interop.InteropSummary, t Thresholds) []Verdict {
+	verdicts := []Verdict{
+		newVerdict("%>=Q30", qc.RunSummary.PercentQ30, t.MinPercentQ30, true),
+		newVerdict("% passing filter", qc.RunSummary.PercentPf, t.MinPercentPf, true),
+		newVerdict("Error rate (%)", qc.RunSummary.ErrorRate, t.MaxErrorRate, false),
+	}
+	if len(qc.IndexSummary.Indexes) > 0 {
+		verdicts = append(verdicts, newVerdict("% undetermined reads", qc.IndexSummary.PercentUndetermined, t.MaxPercentUndetermined, false))
+	}
+	if len(qc.IndexHopping.Lanes) > 0 {
+		verdicts = append(verdicts, newVerdict("% hopped reads", qc.IndexHopping.PercentHopped, t.MaxPercentHopped, false))
+	}
+	return verdicts
+}
+
+// sexCheckVerdict checks that the declared sex of the samples agrees with the sex
+// inferred from their analyses. The check is not available unless the sex could be
+// compared for at least one sample.
+func sexCheckVerdict(checks []cleve.SexCheck, t Thresholds) Verdict {
+	value := math.NaN()
+	for _, c := range checks {
+		switch c.Status {
+		case cleve.SexCheckMatch:
+			if math.IsNaN(value) {
+				value = 0
+			}
+		case cleve.SexCheckMismatch:
+			if math.IsNaN(value) {
+				value = 0
+			}
+			value++
+		}
+	}
+	v := newVerdict("Sex mismatches", interop.OptionalFloat(value), float64(t.MaxSexMismatches), false)
+	v.Precision = 0
+	return v
+}
+
+// Report is a QC report for a sequencing run.
+type Report struct {
+	Run          *cleve.Run
+	Qc           interop.InteropSummary
+	Verdicts     []Verdict
+	Thresholds   Thresholds
+	SexChecks    []cleve.SexCheck
+	CleveVersion string
+	Generated    time.Time
+}
+
+// New creates a QC report for a run.
+func New(run *cleve.Run, qc interop.InteropSummary, t Thresholds) Report {
+	return Report{
+		Run:          run,
+		Qc:           qc,
+		Verdicts:     Verdicts(qc, t),
+		Thresholds:   t,
+		CleveVersion: cleve.GetVersion(),
+		Generated:    time.Now(),
+	}
+}
+
+// WithSexChecks adds the comparisons of declared and inferred sex for the samples in
+// the run to the report, together with a verdict on the number of mismatches.
+func (r Report) WithSexChecks(checks []cleve.SexCheck) Report {
+	r.SexChecks = checks
+	r.Verdicts = append(slices.Clone(r.Verdicts), sexCheckVerdict(checks, r.Thresholds))
+	return r
+}
+
+// SexMismatches returns the sex checks where the declared and inferred sex differ.
+func (r Report) SexMismatches() []cleve.SexCheck {
+	var mismatches []cleve.SexCheck
+	for _, c := range r.SexChecks {
+		if c.Status == cleve.SexCheckMismatch {
+			mismatches = append(mismatches, c)
+		}
+	}
+	return mismatches
+}
+
+// Status returns the overall QC status of the run. The run fails if any of the
+// checks fail, and passes if at least one check passes and none fail.
+func (r Report) Status() VerdictStatus {
+	status := VerdictNotAvailable
+	for _, v := range r.Verdicts {
+		switch v.Status {
+		case VerdictFail:
+			return VerdictFail
+		case VerdictPass:
+			status = VerdictPass
+		}
+	}
+	return status
+}
+
+// Consumables returns the flowcell followed by the other consumables used for the run.
+func (r Report) Consumables() []interop.Consumable {
+	consumables := []interop.Consumable{r.Run.RunParameters.Flowcell}
+	return append(consumables, r.Run.RunParameters.Consumables...)
+}
+
+// Write writes the report to w in the given format.
+func (r Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatHTML:
+		return r.WriteHTML(w)
+	case FormatPDF:
+		return r.WritePDF(w)
+	default:
+		return fmt.Errorf("invalid report format")
+	}
+}
+
+// barChart is a static bar chart that can be rendered both in HTML and PDF reports.
+type barChart struct {
+	Title  string
+	Unit   string
+	Labels []string
+	Values []float64
+}
+
+func (c barChart) max() float64 {
+	m := 0.0
+	for _, v := range c.Values {
+		if !math.IsNaN(v) {
+			m = max(m, v)
+		}
+	}
+	return m
+}
+
+func (r Report) charts() []barChart {
+	var charts []barChart
+	if len(r.Qc.LaneSummary) > 0 {
+		c := barChart{Title: "Yield per lane", Unit: "Gbp"}
+		for _, l := range r.Qc.LaneSummary {
+			c.Labels = append(c.Labels, fmt.Sprintf("Lane %d", l.Lane))
+			c.Values = append(c.Values, float64(l.Yield)*1e-9)
+		}
+		charts = append(charts, c)
+	}
+	if len(r.Qc.IndexSummary.Indexes) > 0 {
+		c := barChart{Title: "Index distribution", Unit: "% reads"}
+		for _, i := range r.Qc.IndexSummary.Indexes {
+			c.Labels = append(c.Labels, i.Sample)
+			c.Values = append(c.Values, i.PercentReads)
+		}
+		charts = append(charts, c)
+	}
+	return charts
+}
+
+func formatFloat(v float64, precision int) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.*f", precision, v)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format("2006-01-02 15:04:05 MST")
+}
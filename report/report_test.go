@@ -0,0 +1,271 @@
+package report
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/interop"
+)
+
+func testReport(t *testing.T) Report {
+	t.Helper()
+	run := &cleve.Run{
+		RunID:          "20250101_LH00001_0001_A22ABCDEF",
+		ExperimentName: "experiment <1>",
+		Platform:       "NovaSeq X Plus",
+		RunInfo: interop.RunInfo{
+			FlowcellName: "10B",
+			Date:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		RunParameters: interop.RunParameters{
+			Flowcell: interop.Consumable{Type: "FlowCell", SerialNumber: "22ABCDEF"},
+			Software: []interop.Software{{Name: "Control software", Version: "1.2.0"}},
+		},
+	}
+	qc := interop.InteropSummary{
+		RunId: run.RunID,
+		RunSummary: interop.RunSummary{
+			Yield:      100_000_000_000,
+			PercentQ30: 91.5,
+			PercentPf:  80.1,
+			ErrorRate:  interop.OptionalFloat(math.NaN()),
+		},
+		LaneSummary: []interop.LaneSummary{
+			{Lane: 1, Yield: 50_000_000_000},
+			{Lane: 2, Yield: 50_000_000_000},
+		},
+		IndexSummary: interop.IndexSummary{
+			PercentUndetermined: 12,
+			Indexes: []interop.IndexSummaryRecord{
+				{Sample: "sample1", Index: "ACGT-TGCA", ReadCount: 1_000_000, PercentReads: 60},
+				{Sample: "sample2", Index: "GGGG-CCCC", ReadCount: 800_000, PercentReads: 40},
+			},
+		},
+		ComputedAt: time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC),
+	}
+	return New(run, qc, DefaultThresholds()).WithSexChecks([]cleve.SexCheck{
+		{SampleId: "sample1", Declared: cleve.SexFemale, Inferred: cleve.SexFemale, Status: cleve.SexCheckMatch},
+		{SampleId: "sample2", Declared: cleve.SexFemale, Inferred: cleve.SexMale, Method: cleve.SexMethodCoverage, Status: cleve.SexCheckMismatch},
+	})
+}
+
+func TestVerdicts(t *testing.T) {
+	cases := []struct {
+		name     string
+		qc       interop.InteropSummary
+		expected []VerdictStatus
+		status   VerdictStatus
+	}{
+		{
+			name: "all pass",
+			qc: interop.InteropSummary{
+				RunSummary: interop.RunSummary{PercentQ30: 90, PercentPf: 80, ErrorRate: 0.5},
+			},
+			expected: []VerdictStatus{VerdictPass, VerdictPass, VerdictPass},
+			status:   VerdictPass,
+		},
+		{
+			name: "low q30",
+			qc: interop.InteropSummary{
+				RunSummary: interop.RunSummary{PercentQ30: 70, PercentPf: 80, ErrorRate: 0.5},
+			},
+			expected: []VerdictStatus{VerdictFail, VerdictPass, VerdictPass},
+			status:   VerdictFail,
+		},
+		{
+			name: "missing error rate",
+			qc: interop.InteropSummary{
+				RunSummary: interop.RunSummary{PercentQ30: 90, PercentPf: 80, ErrorRate: interop.OptionalFloat(math.NaN())},
+			},
+			expected: []VerdictStatus{VerdictPass, VerdictPass, VerdictNotAvailable},
+			status:   VerdictPass,
+		},
+		{
+			name: "no data",
+			qc: interop.InteropSummary{
+				RunSummary: interop.RunSummary{
+					PercentQ30: interop.OptionalFloat(math.NaN()),
+					PercentPf:  interop.OptionalFloat(math.NaN()),
+					ErrorRate:  interop.OptionalFloat(math.NaN()),
+				},
+			},
+			expected: []VerdictStatus{VerdictNotAvailable, VerdictNotAvailable, VerdictNotAvailable},
+			status:   VerdictNotAvailable,
+		},
+		{
+			name: "high undetermined and hopping",
+			qc: interop.InteropSummary{
+				RunSummary: interop.RunSummary{PercentQ30: 90, PercentPf: 80, ErrorRate: 0.5},
+				IndexSummary: interop.IndexSummary{
+					PercentUndetermined: 20,
+					Indexes:             []interop.IndexSummaryRecord{{Sample: "sample1"}},
+				},
+				IndexHopping: interop.IndexHoppingSummary{
+					PercentHopped: 1,
+					Lanes:         []interop.LaneIndexHopping{{Lane: 1}},
+				},
+			},
+			expected: []VerdictStatus{VerdictPass, VerdictPass, VerdictPass, VerdictFail, VerdictPass},
+			status:   VerdictFail,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := New(&cleve.Run{}, c.qc, DefaultThresholds())
+			if len(r.Verdicts) != len(c.expected) {
+				t.Fatalf("expected %d verdicts, got %d", len(c.expected), len(r.Verdicts))
+			}
+			for i, v := range r.Verdicts {
+				if v.Status != c.expected[i] {
+					t.Errorf("expected %s to be %s, got %s", v.Metric, c.expected[i], v.Status)
+				}
+			}
+			if r.Status() != c.status {
+				t.Errorf("expected overall status %s, got %s", c.status, r.Status())
+			}
+		})
+	}
+}
+
+func TestWithSexChecks(t *testing.T) {
+	cases := []struct {
+		name          string
+		checks        []cleve.SexCheck
+		maxMismatches int
+		value         float64
+		status        VerdictStatus
+	}{
+		{
+			name:   "no checks",
+			value:  math.NaN(),
+			status: VerdictNotAvailable,
+		},
+		{
+			name: "unknown sex",
+			checks: []cleve.SexCheck{
+				{SampleId: "sample1", Status: cleve.SexCheckUnknown},
+			},
+			value:  math.NaN(),
+			status: VerdictNotAvailable,
+		},
+		{
+			name: "all match",
+			checks: []cleve.SexCheck{
+				{SampleId: "sample1", Status: cleve.SexCheckMatch},
+				{SampleId: "sample2", Status: cleve.SexCheckUnknown},
+			},
+			value:  0,
+			status: VerdictPass,
+		},
+		{
+			name: "mismatches",
+			checks: []cleve.SexCheck{
+				{SampleId: "sample1", Status: cleve.SexCheckMismatch},
+				{SampleId: "sample2", Status: cleve.SexCheckMatch},
+				{SampleId: "sample3", Status: cleve.SexCheckMismatch},
+			},
+			value:  2,
+			status: VerdictFail,
+		},
+		{
+			name: "mismatches within the limit",
+			checks: []cleve.SexCheck{
+				{SampleId: "sample1", Status: cleve.SexCheckMismatch},
+				{SampleId: "sample2", Status: cleve.SexCheckMatch},
+			},
+			maxMismatches: 1,
+			value:         1,
+			status:        VerdictPass,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			thresholds := DefaultThresholds()
+			thresholds.MaxSexMismatches = c.maxMismatches
+			base := New(&cleve.Run{}, interop.InteropSummary{}, thresholds)
+			r := base.WithSexChecks(c.checks)
+			if len(r.Verdicts) != len(base.Verdicts)+1 {
+				t.Fatalf("expected %d verdicts, got %d", len(base.Verdicts)+1, len(r.Verdicts))
+			}
+			v := r.Verdicts[len(r.Verdicts)-1]
+			if v.Value != c.value && !(math.IsNaN(v.Value) && math.IsNaN(c.value)) {
+				t.Errorf("expected value %f, got %f", c.value, v.Value)
+			}
+			if v.Status != c.status {
+				t.Errorf("expected status %s, got %s", c.status, v.Status)
+			}
+			if v.Precision != 0 {
+				t.Errorf("expected precision 0, got %d", v.Precision)
+			}
+			if r.Status() != c.status && c.status == VerdictFail {
+				t.Errorf("expected overall status %s, got %s", c.status, r.Status())
+			}
+		})
+	}
+}
+
+func TestFormatFromString(t *testing.T) {
+	cases := []struct {
+		format   string
+		expected Format
+		error    bool
+	}{
+		{"html", FormatHTML, false},
+		{"pdf", FormatPDF, false},
+		{"docx", 0, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format, func(t *testing.T) {
+			f, err := FormatFromString(c.format)
+			if (err != nil) != c.error {
+				t.Fatalf("expected error %t, got %v", c.error, err)
+			}
+			if f != c.expected {
+				t.Errorf("expected format %d, got %d", c.expected, f)
+			}
+		})
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	r := testReport(t)
+	var b bytes.Buffer
+	if err := r.Write(&b, FormatHTML); err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+	for _, s := range []string{
+		r.Run.RunID,
+		"experiment &lt;1&gt;",
+		"Control software",
+		"22ABCDEF",
+		"ACGT-TGCA",
+		"<svg",
+		"Yield per lane",
+		"2025-01-02 12:00:00 UTC",
+		`<span class="fail">fail</span>`,
+		"Sex mismatches",
+	} {
+		if !strings.Contains(html, s) {
+			t.Errorf("expected report to contain %q", s)
+		}
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	r := testReport(t)
+	var b bytes.Buffer
+	if err := r.Write(&b, FormatPDF); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b.Bytes(), []byte("%PDF-")) {
+		t.Errorf("expected output to be a PDF")
+	}
+}
@@ -1,5 +1,7 @@
 package cleve
 
+import "slices"
+
 // Sample represents a sequenced sample with associated analyses.
 type Sample struct {
 	// Sample name. If missing it should be set to the sample ID.
@@ -10,6 +12,116 @@ type Sample struct {
 	Fastq []string `bson:"fastq" json:"fastq"`
 	// Analyses associated with the sample.
 	Analyses []*SampleAnalysis `bson:"analyses" json:"analyses"`
+	// Project as listed in the most recent samplesheet the sample appeared in.
+	Project string `bson:"project,omitempty" json:"project,omitempty"`
+	// Runs that the sample is listed in the samplesheet of, one entry per lane.
+	Runs []SampleRun `bson:"runs,omitempty" json:"runs,omitempty"`
+	// Type of sample, e.g. blood or fresh frozen tissue. Together with the project it
+	// determines which metadata schemas apply to the sample.
+	Type string `bson:"type,omitempty" json:"type,omitempty"`
+	// Metadata are custom attributes, validated against the metadata schemas that
+	// apply to the sample, see [MetadataSchema].
+	Metadata map[string]any `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	// Panels are the gene panels, and versions, that the sample is interpreted with.
+	// Analyses of the sample can refer to panels as well.
+	Panels []PanelRef `bson:"panels,omitempty" json:"panels,omitempty"`
+	// TargetReads is the number of reads that the sample should be sequenced to in
+	// total, or 0 if there is no target.
+	TargetReads int `bson:"target_reads,omitempty" json:"target_reads,omitempty"`
+	// Reads and Yield are the totals over all runs, see [Sample.SetReads].
+	Reads int `bson:"-" json:"reads"`
+	Yield int `bson:"-" json:"yield"`
+	// RemainingReads is the number of reads needed to reach the target, or nil if the
+	// sample has no target.
+	RemainingReads *int `bson:"-" json:"remaining_reads,omitempty"`
+}
+
+// SampleUpdate is a partial update of a sample. Fields that are nil are left as they
+// are, and empty values remove the field from the sample. A target of 0 removes the
+// target.
+type SampleUpdate struct {
+	TargetReads *int
+	Metadata    map[string]any
+	Panels      []PanelRef
+}
+
+// SetReads sets the read counts and yields of the runs of the sample, and the totals
+// of the sample. Read counts of a run entry are summed over all lanes if the entry is
+// not for a specific lane. For each run, demultiplexing statistics take precedence
+// over index metrics.
+func (s *Sample) SetReads(reads []SampleLaneReads) {
+	sources := make(map[string]ReadsSource)
+	for _, r := range reads {
+		if r.SampleId == s.Id && (sources[r.RunId] == "" || r.Source == ReadsFromDemuxStats) {
+			sources[r.RunId] = r.Source
+		}
+	}
+	s.Reads = 0
+	s.Yield = 0
+	for i := range s.Runs {
+		run := &s.Runs[i]
+		run.Reads = 0
+		run.Yield = 0
+		run.ReadsSource = sources[run.RunId]
+		for _, r := range reads {
+			if r.SampleId != s.Id || r.RunId != run.RunId || r.Source != run.ReadsSource {
+				continue
+			}
+			if run.Lane != 0 && r.Lane != run.Lane {
+				continue
+			}
+			run.Reads += r.Reads
+			run.Yield += r.Yield
+		}
+		s.Reads += run.Reads
+		s.Yield += run.Yield
+	}
+	s.RemainingReads = nil
+	if s.TargetReads > 0 {
+		remaining := max(s.TargetReads-s.Reads, 0)
+		s.RemainingReads = &remaining
+	}
+}
+
+// PanelRefs returns the references to a panel from the sample and its analyses. Each
+// version of the panel is only listed once.
+func (s Sample) PanelRefs(panelId string) []PanelRef {
+	var refs []PanelRef
+	add := func(panels []PanelRef) {
+		for _, p := range panels {
+			if p.Id == panelId && !slices.Contains(refs, p) {
+				refs = append(refs, p)
+			}
+		}
+	}
+	add(s.Panels)
+	for _, a := range s.Analyses {
+		if a != nil {
+			add(a.Panels)
+		}
+	}
+	return refs
+}
+
+// SampleRun is a run, and lane, that a sample is listed on in the samplesheet of
+// the run.
+type SampleRun struct {
+	RunId string `bson:"run_id" json:"run_id"`
+	// Lane the sample was sequenced on, or 0 if the sample is in all lanes.
+	Lane     int    `bson:"lane,omitempty" json:"lane,omitempty"`
+	Project  string `bson:"project,omitempty" json:"project,omitempty"`
+	IndexId  string `bson:"index_id,omitempty" json:"index_id,omitempty"`
+	Index    string `bson:"index,omitempty" json:"index,omitempty"`
+	Index2Id string `bson:"index2_id,omitempty" json:"index2_id,omitempty"`
+	Index2   string `bson:"index2,omitempty" json:"index2,omitempty"`
+	// Applications are the samplesheet applications, e.g. DragenGermline, that the
+	// sample is configured for.
+	Applications []string `bson:"applications,omitempty" json:"applications,omitempty"`
+	// Reads and Yield are the number of reads and the yield of the sample on the run,
+	// and lane, see [Sample.SetReads].
+	Reads       int         `bson:"-" json:"reads,omitempty"`
+	Yield       int         `bson:"-" json:"yield,omitempty"`
+	ReadsSource ReadsSource `bson:"-" json:"reads_source,omitempty"`
 }
 
 type SampleResult struct {
@@ -28,6 +140,8 @@ type Pipeline struct {
 type SampleAnalysis struct {
 	Pipeline `bson:"pipeline" json:"pipeline"`
 	Results  []SampleAnalysisResult `bson:"path" json:"path"`
+	// Panels are the gene panels, and versions, used in the analysis.
+	Panels []PanelRef `bson:"panels,omitempty" json:"panels,omitempty"`
 }
 
 // SampleAnalysisResult is a specific result from an analysis pipeline.
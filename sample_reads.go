@@ -0,0 +1,188 @@
+package cleve
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gmc-norr/cleve/interop"
+)
+
+// ReadsSource is the source of the read counts of a sample.
+type ReadsSource string
+
+const (
+	// Read counts from the index metrics of the run.
+	ReadsFromIndexMetrics ReadsSource = "index_metrics"
+	// Read counts from the demultiplexing statistics of BCL Convert. These take
+	// precedence over index metrics, since they are what ends up in the fastq files.
+	ReadsFromDemuxStats ReadsSource = "demux_stats"
+)
+
+// SampleLaneReads is the number of reads, and the yield in bases, of a sample in a
+// lane of a run.
+type SampleLaneReads struct {
+	RunId    string      `bson:"run_id" json:"run_id"`
+	Lane     int         `bson:"lane" json:"lane"`
+	SampleId string      `bson:"sample_id" json:"sample_id"`
+	Reads    int         `bson:"reads" json:"reads"`
+	Yield    int         `bson:"yield" json:"yield"`
+	Source   ReadsSource `bson:"source" json:"source"`
+}
+
+// SampleReadsFromIndexSummary returns the read counts of the samples in each lane of a
+// run from the index summary of the run QC.
+func SampleReadsFromIndexSummary(runId string, summary interop.IndexSummary) []SampleLaneReads {
+	reads := make([]SampleLaneReads, 0, len(summary.Lanes))
+	for _, l := range summary.Lanes {
+		reads = append(reads, SampleLaneReads{
+			RunId:    runId,
+			Lane:     l.Lane,
+			SampleId: l.Sample,
+			Reads:    l.ReadCount,
+			Yield:    l.Yield,
+			Source:   ReadsFromIndexMetrics,
+		})
+	}
+	return reads
+}
+
+// csvRecords reads a csv file with a header, and returns the rows as maps from column
+// name to value.
+func csvRecords(r io.Reader) ([]map[string]string, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("empty csv file")
+	}
+	records := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(row))
+		for i, column := range rows[0] {
+			record[column] = row[i]
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ReadDemuxStats reads the read counts of the samples in each lane from the
+// Demultiplex_Stats.csv file written by BCL Convert. If quality metrics are given, from
+// Quality_Metrics.csv, the yield is the sum of the yield of the non-index reads.
+// Undetermined reads are not included.
+func ReadDemuxStats(runId string, demuxStats io.Reader, qualityMetrics io.Reader) ([]SampleLaneReads, error) {
+	type laneSample struct {
+		lane   int
+		sample string
+	}
+
+	records, err := csvRecords(demuxStats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read demultiplexing stats: %w", err)
+	}
+	var reads []SampleLaneReads
+	index := make(map[laneSample]int)
+	for _, record := range records {
+		if record["SampleID"] == "Undetermined" {
+			continue
+		}
+		lane, err := strconv.Atoi(record["Lane"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid lane %q in demultiplexing stats", record["Lane"])
+		}
+		n, err := strconv.Atoi(record["# Reads"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid read count %q in demultiplexing stats", record["# Reads"])
+		}
+		key := laneSample{lane, record["SampleID"]}
+		if i, ok := index[key]; ok {
+			// The same sample can be listed once per index in a lane
+			reads[i].Reads += n
+			continue
+		}
+		index[key] = len(reads)
+		reads = append(reads, SampleLaneReads{
+			RunId:    runId,
+			Lane:     lane,
+			SampleId: record["SampleID"],
+			Reads:    n,
+			Source:   ReadsFromDemuxStats,
+		})
+	}
+
+	if qualityMetrics == nil {
+		return reads, nil
+	}
+	records, err = csvRecords(qualityMetrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quality metrics: %w", err)
+	}
+	for _, record := range records {
+		if _, err := strconv.Atoi(record["ReadNumber"]); err != nil {
+			// Index reads are named I1 and I2
+			continue
+		}
+		lane, err := strconv.Atoi(record["Lane"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid lane %q in quality metrics", record["Lane"])
+		}
+		i, ok := index[laneSample{lane, record["SampleID"]}]
+		if !ok {
+			continue
+		}
+		yield, err := strconv.Atoi(record["Yield"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid yield %q in quality metrics", record["Yield"])
+		}
+		reads[i].Yield += yield
+	}
+	return reads, nil
+}
+
+// DemuxStatsFromAnalysis reads the read counts of the samples demultiplexed by a Dragen
+// BCL Convert analysis, see [ReadDemuxStats].
+func DemuxStatsFromAnalysis(analysis *Analysis) ([]SampleLaneReads, error) {
+	if !strings.Contains(strings.ToLower(analysis.Software), "bclconvert") {
+		return nil, fmt.Errorf("demultiplexing stats are only available for bcl convert analyses")
+	}
+	if len(analysis.Runs) == 0 {
+		return nil, fmt.Errorf("analysis is not associated with a run")
+	}
+	f, err := os.Open(filepath.Join(analysis.Path, "Manifest.tsv"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	manifest, err := ReadDragenManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dragen manifest: %w", err)
+	}
+
+	demuxStatsPath, err := manifest.FindFile("Demultiplex_Stats.csv")
+	if err != nil {
+		return nil, err
+	}
+	demuxStats, err := os.Open(filepath.Join(analysis.Path, demuxStatsPath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = demuxStats.Close() }()
+
+	var qualityMetrics io.Reader
+	if qualityMetricsPath, err := manifest.FindFile("Quality_Metrics.csv"); err == nil {
+		f, err := os.Open(filepath.Join(analysis.Path, qualityMetricsPath))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		qualityMetrics = f
+	}
+
+	return ReadDemuxStats(analysis.Runs[0], demuxStats, qualityMetrics)
+}
@@ -0,0 +1,152 @@
+package cleve
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDemuxStats(t *testing.T) {
+	demuxStats := `Lane,SampleID,Sample_Project,Index,# Reads,# Perfect Index Reads,# One Mismatch Index Reads,# Two Mismatch Index Reads,% Reads,% Perfect Index Reads,% One Mismatch Index Reads,% Two Mismatch Index Reads
+1,S1,P1,AAAA-CCCC,100,95,5,0,0.5,0.95,0.05,0
+1,S1,P1,GGGG-TTTT,20,20,0,0,0.1,1,0,0
+1,S2,P1,ACGT-ACGT,60,60,0,0,0.3,1,0,0
+1,Undetermined,,,20,20,0,0,0.1,1,0,0
+2,S1,P1,AAAA-CCCC,50,50,0,0,1,1,0,0
+`
+	qualityMetrics := `Lane,SampleID,index,index2,ReadNumber,Yield,YieldQ30,QualityScoreSum,Mean Quality Score (PF),% Q30
+1,S1,AAAA,CCCC,1,1000,900,0,0,0.9
+1,S1,AAAA,CCCC,2,1000,900,0,0,0.9
+1,S1,AAAA,CCCC,I1,400,400,0,0,1
+1,S2,ACGT,ACGT,1,600,600,0,0,1
+`
+
+	cases := []struct {
+		name           string
+		qualityMetrics string
+		expected       []SampleLaneReads
+	}{
+		{
+			name: "without quality metrics",
+			expected: []SampleLaneReads{
+				{RunId: "run1", Lane: 1, SampleId: "S1", Reads: 120, Source: ReadsFromDemuxStats},
+				{RunId: "run1", Lane: 1, SampleId: "S2", Reads: 60, Source: ReadsFromDemuxStats},
+				{RunId: "run1", Lane: 2, SampleId: "S1", Reads: 50, Source: ReadsFromDemuxStats},
+			},
+		},
+		{
+			name:           "with quality metrics",
+			qualityMetrics: qualityMetrics,
+			expected: []SampleLaneReads{
+				{RunId: "run1", Lane: 1, SampleId: "S1", Reads: 120, Yield: 2000, Source: ReadsFromDemuxStats},
+				{RunId: "run1", Lane: 1, SampleId: "S2", Reads: 60, Yield: 600, Source: ReadsFromDemuxStats},
+				{RunId: "run1", Lane: 2, SampleId: "S1", Reads: 50, Source: ReadsFromDemuxStats},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var reads []SampleLaneReads
+			var err error
+			if c.qualityMetrics == "" {
+				reads, err = ReadDemuxStats("run1", strings.NewReader(demuxStats), nil)
+			} else {
+				reads, err = ReadDemuxStats("run1", strings.NewReader(demuxStats), strings.NewReader(c.qualityMetrics))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(reads, c.expected) {
+				t.Errorf("expected\n%+v\ngot\n%+v", c.expected, reads)
+			}
+		})
+	}
+}
+
+func TestSampleSetReads(t *testing.T) {
+	reads := []SampleLaneReads{
+		{RunId: "run1", Lane: 1, SampleId: "S1", Reads: 100, Yield: 1000, Source: ReadsFromIndexMetrics},
+		{RunId: "run1", Lane: 2, SampleId: "S1", Reads: 110, Yield: 1100, Source: ReadsFromIndexMetrics},
+		{RunId: "run1", Lane: 1, SampleId: "S2", Reads: 500, Yield: 5000, Source: ReadsFromIndexMetrics},
+		{RunId: "run2", Lane: 1, SampleId: "S1", Reads: 300, Yield: 3000, Source: ReadsFromIndexMetrics},
+		{RunId: "run2", Lane: 1, SampleId: "S1", Reads: 280, Yield: 2800, Source: ReadsFromDemuxStats},
+	}
+
+	cases := []struct {
+		name      string
+		sample    Sample
+		reads     int
+		yield     int
+		runReads  []int
+		remaining *int
+	}{
+		{
+			name: "no target",
+			sample: Sample{
+				Id:   "S1",
+				Runs: []SampleRun{{RunId: "run1"}, {RunId: "run2", Lane: 1}},
+			},
+			reads:    490,
+			yield:    4900,
+			runReads: []int{210, 280},
+		},
+		{
+			name: "target not reached",
+			sample: Sample{
+				Id:          "S1",
+				Runs:        []SampleRun{{RunId: "run1", Lane: 1}, {RunId: "run1", Lane: 2}},
+				TargetReads: 300,
+			},
+			reads:     210,
+			yield:     2100,
+			runReads:  []int{100, 110},
+			remaining: func() *int { r := 90; return &r }(),
+		},
+		{
+			name: "target exceeded",
+			sample: Sample{
+				Id:          "S2",
+				Runs:        []SampleRun{{RunId: "run1"}},
+				TargetReads: 300,
+			},
+			reads:     500,
+			yield:     5000,
+			runReads:  []int{500},
+			remaining: func() *int { r := 0; return &r }(),
+		},
+		{
+			name: "no reads",
+			sample: Sample{
+				Id:          "S3",
+				Runs:        []SampleRun{{RunId: "run1"}},
+				TargetReads: 300,
+			},
+			runReads:  []int{0},
+			remaining: func() *int { r := 300; return &r }(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.sample.SetReads(reads)
+			if c.sample.Reads != c.reads {
+				t.Errorf("expected %d reads, got %d", c.reads, c.sample.Reads)
+			}
+			if c.sample.Yield != c.yield {
+				t.Errorf("expected yield %d, got %d", c.yield, c.sample.Yield)
+			}
+			for i, r := range c.sample.Runs {
+				if r.Reads != c.runReads[i] {
+					t.Errorf("expected %d reads for run %s lane %d, got %d", c.runReads[i], r.RunId, r.Lane, r.Reads)
+				}
+			}
+			if (c.remaining == nil) != (c.sample.RemainingReads == nil) {
+				t.Fatalf("expected remaining reads %v, got %v", c.remaining, c.sample.RemainingReads)
+			}
+			if c.remaining != nil && *c.remaining != *c.sample.RemainingReads {
+				t.Errorf("expected %d remaining reads, got %d", *c.remaining, *c.sample.RemainingReads)
+			}
+		})
+	}
+}
@@ -85,6 +85,14 @@ type SampleSheet struct {
 	UUID     *uuid.UUID        `bson:"uuid" json:"uuid"`
 	Files    []SampleSheetInfo `bson:"files" json:"files"`
 	Sections []Section         `bson:"sections" json:"sections"`
+	// AnalysisId is set if the samplesheet is the one used by a specific analysis,
+	// e.g. a re-run of BCLConvert, rather than the samplesheet of the run.
+	AnalysisId *uuid.UUID `bson:"analysis_id,omitempty" json:"analysis_id,omitempty"`
+}
+
+type SampleSheetResult struct {
+	PaginationMetadata `bson:"metadata" json:"metadata"`
+	SampleSheets       []SampleSheet `bson:"samplesheets" json:"samplesheets"`
 }
 
 func (s SampleSheet) Section(name string) *Section {
@@ -96,9 +104,22 @@ func (s SampleSheet) Section(name string) *Section {
 	return nil
 }
 
+// Version returns the format version of the samplesheet. Samplesheets created by
+// Illumina Experiment Manager do not have a FileFormatVersion, but are identified by
+// IEMFileVersion and are considered to be version 1. If the version cannot be
+// determined, 0 is returned.
 func (s SampleSheet) Version() int {
-	v, _ := s.Section("Header").GetInt("FileFormatVersion")
-	return v
+	header := s.Section("Header")
+	if header == nil {
+		return 0
+	}
+	if v, err := header.GetInt("FileFormatVersion"); err == nil {
+		return v
+	}
+	if _, err := header.Get("IEMFileVersion"); err == nil {
+		return 1
+	}
+	return 0
 }
 
 func (s SampleSheet) IsValid() bool {
@@ -118,6 +139,19 @@ func (s SampleSheet) LastModified() (time.Time, error) {
 	return mostRecent, nil
 }
 
+// IsModified returns true if the file is not one of the files of the samplesheet, or if
+// it has been modified since the samplesheet was read from it. Modification times are
+// compared with millisecond precision, since that is how they are stored in the
+// database.
+func (s SampleSheet) IsModified(file SampleSheetInfo) bool {
+	for _, f := range s.Files {
+		if f.Path == file.Path {
+			return file.ModificationTime.Truncate(time.Millisecond).After(f.ModificationTime)
+		}
+	}
+	return true
+}
+
 // Merge two sample sheets. Merging is only allowed if the UUIDs of the sample
 // sheets are the same, and the run IDs are the same. An exception to this is if
 // the run ID of the current sample sheet is nil. If the run ID in the current
@@ -150,6 +184,12 @@ func (s SampleSheet) Merge(other *SampleSheet) (*SampleSheet, error) {
 		mergedSampleSheet.RunID = s.RunID
 	}
 
+	if s.AnalysisId == nil {
+		mergedSampleSheet.AnalysisId = other.AnalysisId
+	} else {
+		mergedSampleSheet.AnalysisId = s.AnalysisId
+	}
+
 	sampleSheetFiles := make(map[string]time.Time)
 
 	for _, f := range append(s.Files, other.Files...) {
@@ -462,6 +502,10 @@ func ParseSampleSheet(r *bufio.Reader) (SampleSheet, error) {
 			return sheet, fmt.Errorf("parsing error: empty section")
 		}
 
+		if s.Type == SettingsSection {
+			s.Rows = trimSettingsPadding(s.Rows)
+		}
+
 		rowItemCount := len(s.Rows[0])
 		if s.Type == SettingsSection && rowItemCount > 2 {
 			return sheet, fmt.Errorf("parsing error: expected at most 2 items per row in section %q", s.Name)
@@ -491,6 +535,40 @@ func ParseSampleSheet(r *bufio.Reader) (SampleSheet, error) {
 	return sheet, nil
 }
 
+// trimSettingsPadding removes empty trailing columns from settings sections. Samplesheets
+// that have been edited in a spreadsheet, which is common for IEM samplesheets, are
+// padded with commas so that all rows have as many columns as the widest data section.
+// Columns are only removed if they are empty for all rows.
+func trimSettingsPadding(rows [][]string) [][]string {
+	width := len(rows[0])
+	for _, row := range rows {
+		if len(row) != width {
+			return rows
+		}
+	}
+	trimmed := width
+	for trimmed > 1 {
+		empty := true
+		for _, row := range rows {
+			if row[trimmed-1] != "" {
+				empty = false
+				break
+			}
+		}
+		if !empty {
+			break
+		}
+		trimmed--
+	}
+	if trimmed == width {
+		return rows
+	}
+	for i, row := range rows {
+		rows[i] = row[:trimmed]
+	}
+	return rows
+}
+
 func ReadSampleSheet(filename string) (SampleSheet, error) {
 	f, err := os.Open(filename)
 	if err != nil {
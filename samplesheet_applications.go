@@ -0,0 +1,400 @@
+package cleve
+
+import (
+	"slices"
+	"strings"
+)
+
+// ApplicationField is a settings key or a data column of a samplesheet application.
+type ApplicationField struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required,omitempty"`
+	// Values are the allowed values, compared case insensitively. Any value is
+	// allowed if there are no values.
+	Values []string `json:"values,omitempty"`
+}
+
+// SampleSheetApplication describes an application in a v2 samplesheet. An
+// application is configured by the <Name>_Settings and <Name>_Data sections.
+type SampleSheetApplication struct {
+	Name string `json:"name"`
+	// Secondary is true if the application performs secondary analysis of the
+	// samples listed in its data section.
+	Secondary bool               `json:"secondary"`
+	Settings  []ApplicationField `json:"settings"`
+	Columns   []ApplicationField `json:"columns"`
+	// SettingsPrefix makes all settings keys with this prefix known, for
+	// applications where key names depend on other applications.
+	SettingsPrefix string `json:"settings_prefix,omitempty"`
+}
+
+var (
+	booleanValues       = []string{"true", "false"}
+	alignmentFormats    = []string{"none", "bam", "cram"}
+	variantCallingModes = []string{"None", "SmallVariantCaller", "AllVariantCallers"}
+)
+
+// dragenFields are the settings shared by the Dragen secondary analysis applications.
+var dragenFields = []ApplicationField{
+	{Name: "SoftwareVersion", Required: true},
+	{Name: "AppVersion"},
+	{Name: "ReferenceGenomeDir"},
+	{Name: "MapAlignOutFormat", Values: alignmentFormats},
+	{Name: "KeepFastq", Values: booleanValues},
+	{Name: "FastqCompressionFormat", Values: []string{"gzip", "dragen"}},
+}
+
+var sampleSheetApplications = []SampleSheetApplication{
+	{
+		Name: "BCLConvert",
+		Settings: []ApplicationField{
+			{Name: "SoftwareVersion", Required: true},
+			{Name: "AdapterRead1"},
+			{Name: "AdapterRead2"},
+			{Name: "AdapterBehavior", Values: []string{"trim", "mask"}},
+			{Name: "AdapterStringency"},
+			{Name: "BarcodeMismatchesIndex1"},
+			{Name: "BarcodeMismatchesIndex2"},
+			{Name: "MinimumTrimmedReadLength"},
+			{Name: "MinimumAdapterOverlap"},
+			{Name: "MaskShortReads"},
+			{Name: "OverrideCycles"},
+			{Name: "TrimUMI", Values: []string{"0", "1"}},
+			{Name: "CreateFastqForIndexReads", Values: []string{"0", "1"}},
+			{Name: "NoLaneSplitting", Values: booleanValues},
+			{Name: "FastqCompressionFormat", Values: []string{"gzip", "dragen", "dragen-interleaved"}},
+			{Name: "FindAdaptersWithIndels", Values: booleanValues},
+			{Name: "IndependentIndexCollisionCheck"},
+		},
+		Columns: []ApplicationField{
+			{Name: "Lane"},
+			{Name: "Sample_ID", Required: true},
+			{Name: "Sample_Name"},
+			{Name: "Sample_Project"},
+			{Name: "Index"},
+			{Name: "Index2"},
+			{Name: "OverrideCycles"},
+			{Name: "BarcodeMismatchesIndex1"},
+			{Name: "BarcodeMismatchesIndex2"},
+			{Name: "AdapterRead1"},
+			{Name: "AdapterRead2"},
+			{Name: "AdapterBehavior", Values: []string{"trim", "mask"}},
+			{Name: "AdapterStringency"},
+		},
+	},
+	{
+		Name:      "DragenGermline",
+		Secondary: true,
+		Settings: append(slices.Clone(dragenFields),
+			ApplicationField{Name: "VariantCallingMode", Values: variantCallingModes},
+		),
+		Columns: []ApplicationField{
+			{Name: "Sample_ID", Required: true},
+			{Name: "ReferenceGenomeDir"},
+			{Name: "VariantCallingMode", Values: variantCallingModes},
+			{Name: "QcCoverage1BedFile"},
+			{Name: "QcCoverage2BedFile"},
+			{Name: "QcCoverage3BedFile"},
+		},
+	},
+	{
+		Name:      "DragenEnrichment",
+		Secondary: true,
+		Settings: append(slices.Clone(dragenFields),
+			ApplicationField{Name: "VariantCallingMode", Values: variantCallingModes},
+			ApplicationField{Name: "GermlineOrSomatic", Values: []string{"germline", "somatic"}},
+			ApplicationField{Name: "BedFile"},
+			ApplicationField{Name: "AuxNoiseBaselineFile"},
+			ApplicationField{Name: "AuxCnvPanelOfNormalsFile"},
+			ApplicationField{Name: "AuxGermlineTaggingFile"},
+		),
+		Columns: []ApplicationField{
+			{Name: "Sample_ID", Required: true},
+			{Name: "ReferenceGenomeDir"},
+			{Name: "BedFile"},
+			{Name: "GermlineOrSomatic", Values: []string{"germline", "somatic"}},
+			{Name: "VariantCallingMode", Values: variantCallingModes},
+			{Name: "AuxNoiseBaselineFile"},
+			{Name: "AuxCnvPanelOfNormalsFile"},
+			{Name: "AuxGermlineTaggingFile"},
+		},
+	},
+	{
+		Name:      "DragenRNA",
+		Secondary: true,
+		Settings: append(slices.Clone(dragenFields),
+			ApplicationField{Name: "RnaGeneAnnotationFile"},
+			ApplicationField{Name: "RnaPipelineMode"},
+			ApplicationField{Name: "DownSampleNumReads"},
+			ApplicationField{Name: "DifferentialExpressionEnable", Values: booleanValues},
+		),
+		Columns: []ApplicationField{
+			{Name: "Sample_ID", Required: true},
+			{Name: "ReferenceGenomeDir"},
+			{Name: "RnaGeneAnnotationFile"},
+			{Name: "Comparison1"},
+			{Name: "Comparison2"},
+			{Name: "Comparison3"},
+		},
+	},
+	{
+		Name: "Cloud",
+		Settings: []ApplicationField{
+			{Name: "GeneratedVersion"},
+			{Name: "Cloud_Workflow"},
+		},
+		SettingsPrefix: "Cloud_",
+		Columns: []ApplicationField{
+			{Name: "Sample_ID", Required: true},
+			{Name: "ProjectName"},
+			{Name: "LibraryName"},
+			{Name: "LibraryPrepKitUrn"},
+			{Name: "LibraryPrepKitName"},
+			{Name: "IndexAdapterKitUrn"},
+			{Name: "IndexAdapterKitName"},
+		},
+	},
+}
+
+// SampleSheetApplications returns the samplesheet applications with known settings
+// and columns.
+func SampleSheetApplications() []SampleSheetApplication {
+	return slices.Clone(sampleSheetApplications)
+}
+
+// LookupSampleSheetApplication returns the known application with the given name.
+func LookupSampleSheetApplication(name string) (SampleSheetApplication, bool) {
+	for _, app := range sampleSheetApplications {
+		if app.Name == name {
+			return app, true
+		}
+	}
+	return SampleSheetApplication{}, false
+}
+
+// isSecondaryApplication returns true if the application performs secondary analysis.
+// Applications that are not known, e.g. TSO500L, are assumed to be secondary analyses.
+func isSecondaryApplication(name string) bool {
+	if app, ok := LookupSampleSheetApplication(name); ok {
+		return app.Secondary
+	}
+	return true
+}
+
+// sampleIdIndex returns the index of the Sample_ID column in the header of a data
+// section, or -1 if there is no such column.
+func sampleIdIndex(header []string) int {
+	return slices.IndexFunc(header, func(column string) bool { return strings.EqualFold(column, "Sample_ID") })
+}
+
+// findField finds a setting or column by name. Names are matched case insensitively,
+// like BCL Convert and Dragen do.
+func findField(fields []ApplicationField, name string) (ApplicationField, bool) {
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return ApplicationField{}, false
+}
+
+// allows returns true if the value is allowed for the field. Empty values are always
+// allowed, since they mean that the default is used.
+func (f ApplicationField) allows(value string) bool {
+	if value == "" || len(f.Values) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(f.Values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
+
+// SampleApplication is the configuration of an application for a single sample. The
+// settings are those of the settings section of the application, overridden by the
+// non-empty values of the sample's row in the data section.
+type SampleApplication struct {
+	Application string            `bson:"application" json:"application"`
+	Settings    map[string]string `bson:"settings,omitempty" json:"settings,omitempty"`
+}
+
+// SampleSheetSampleApplications are the secondary analysis applications that a sample
+// is configured for in a samplesheet.
+type SampleSheetSampleApplications struct {
+	SampleId     string              `bson:"sample_id" json:"sample_id"`
+	Applications []SampleApplication `bson:"applications" json:"applications"`
+}
+
+// ApplicationsBySample returns the secondary analysis applications that each sample is
+// configured for, i.e. the applications with a data section listing the sample. The
+// sample section and non-secondary applications such as Cloud are not included.
+// Samples are returned in the order they first appear in an application data section.
+func (s SampleSheet) ApplicationsBySample() []SampleSheetSampleApplications {
+	var result []SampleSheetSampleApplications
+	bySample := map[string]int{}
+	sampleSection := s.SampleSection()
+	for _, section := range s.Sections {
+		if section.Type != DataSection || len(section.Rows) == 0 || (sampleSection != nil && section.Name == sampleSection.Name) {
+			continue
+		}
+		app := sectionApplication(section.Name)
+		if app == section.Name || !isSecondaryApplication(app) {
+			continue
+		}
+		idColumn := sampleIdIndex(section.Rows[0])
+		if idColumn < 0 {
+			continue
+		}
+		settings := map[string]string{}
+		if settingsSection := s.Section(app + "_Settings"); settingsSection != nil && settingsSection.Type == SettingsSection {
+			for _, row := range settingsSection.Rows {
+				if len(row) > 1 {
+					settings[row[0]] = row[1]
+				}
+			}
+		}
+		for _, row := range section.Rows[1:] {
+			id := row[idColumn]
+			if id == "" {
+				continue
+			}
+			i, ok := bySample[id]
+			if !ok {
+				i = len(result)
+				bySample[id] = i
+				result = append(result, SampleSheetSampleApplications{SampleId: id})
+			}
+			if slices.ContainsFunc(result[i].Applications, func(a SampleApplication) bool { return a.Application == app }) {
+				continue
+			}
+			sampleSettings := make(map[string]string, len(settings)+len(row))
+			for k, v := range settings {
+				sampleSettings[k] = v
+			}
+			for j, column := range section.Rows[0] {
+				if j != idColumn && row[j] != "" {
+					sampleSettings[column] = row[j]
+				}
+			}
+			if len(sampleSettings) == 0 {
+				sampleSettings = nil
+			}
+			result[i].Applications = append(result[i].Applications, SampleApplication{
+				Application: app,
+				Settings:    sampleSettings,
+			})
+		}
+	}
+	return result
+}
+
+// validateApplications checks the settings and data sections of known applications.
+// Unknown keys and columns give warnings, while missing required keys and columns,
+// invalid values and samples that are not in BCLConvert_Data give errors. Required
+// keys and columns of BCLConvert are checked together with the rest of the
+// demultiplexing settings, and are not checked here.
+func (f *ValidationFindings) validateApplications(s SampleSheet) {
+	sampleSection := s.Section(sampleColumnsByVersion[2].section)
+	demuxApp := sectionApplication(sampleColumnsByVersion[2].section)
+	samples := map[string]bool{}
+	if sampleSection != nil {
+		if ids, err := sampleSection.GetColumn("Sample_ID"); err == nil {
+			for _, id := range ids {
+				samples[id] = true
+			}
+		}
+	}
+
+	for _, section := range s.Sections {
+		app, ok := LookupSampleSheetApplication(sectionApplication(section.Name))
+		if !ok {
+			continue
+		}
+		checkRequired := app.Name != demuxApp
+		switch section.Name {
+		case app.Name + "_Settings":
+			f.validateApplicationSettings(app, section, checkRequired)
+			if app.Secondary && s.Section(app.Name+"_Data") == nil {
+				f.add(SeverityWarning, FindingMissingSection, app.Name+"_Data", 0, "section is missing, no samples will be analysed with %s", app.Name)
+			}
+		case app.Name + "_Data":
+			f.validateApplicationData(app, section, checkRequired)
+			if app.Name == demuxApp {
+				continue
+			}
+			if app.Secondary && s.Section(app.Name+"_Settings") == nil {
+				f.add(SeverityError, FindingMissingSection, app.Name+"_Settings", 0, "section is required when %s is present", section.Name)
+			}
+			if sampleSection == nil || len(section.Rows) == 0 {
+				continue
+			}
+			idColumn := sampleIdIndex(section.Rows[0])
+			if idColumn < 0 {
+				continue
+			}
+			for i, row := range section.Rows[1:] {
+				if id := row[idColumn]; id != "" && !samples[id] {
+					f.add(SeverityError, FindingUnknownSample, section.Name, i+1, "sample %q is not defined in %s", id, sampleSection.Name)
+				}
+			}
+		}
+	}
+}
+
+func (f *ValidationFindings) validateApplicationSettings(app SampleSheetApplication, section Section, checkRequired bool) {
+	if section.Type != SettingsSection {
+		return
+	}
+	keys := map[string]bool{}
+	for _, row := range section.Rows {
+		key := row[0]
+		keys[strings.ToLower(key)] = true
+		field, ok := findField(app.Settings, key)
+		if !ok {
+			if app.SettingsPrefix == "" || !strings.HasPrefix(key, app.SettingsPrefix) {
+				f.add(SeverityWarning, FindingUnknownKey, section.Name, 0, "%s is not a known setting for %s", key, app.Name)
+			}
+			continue
+		}
+		if len(row) > 1 && !field.allows(row[1]) {
+			f.add(SeverityError, FindingInvalidValue, section.Name, 0, "%s must be one of %s, got %q", key, strings.Join(field.Values, ", "), row[1])
+		}
+	}
+	if !checkRequired {
+		return
+	}
+	for _, field := range app.Settings {
+		if field.Required && !keys[strings.ToLower(field.Name)] {
+			f.add(SeverityError, FindingMissingKey, section.Name, 0, "%s is missing", field.Name)
+		}
+	}
+}
+
+func (f *ValidationFindings) validateApplicationData(app SampleSheetApplication, section Section, checkRequired bool) {
+	if section.Type != DataSection || len(section.Rows) == 0 {
+		return
+	}
+	header := section.Rows[0]
+	fields := make([]*ApplicationField, len(header))
+	for i, column := range header {
+		field, ok := findField(app.Columns, column)
+		if !ok {
+			f.add(SeverityWarning, FindingUnknownColumn, section.Name, 0, "%s is not a known column for %s", column, app.Name)
+			continue
+		}
+		fields[i] = &field
+	}
+	if checkRequired {
+		for _, field := range app.Columns {
+			if field.Required && !slices.ContainsFunc(header, func(column string) bool { return strings.EqualFold(column, field.Name) }) {
+				f.add(SeverityError, FindingMissingColumn, section.Name, 0, "%s column is missing", field.Name)
+			}
+		}
+	}
+	for i, row := range section.Rows[1:] {
+		for j, field := range fields {
+			if field != nil && !field.allows(row[j]) {
+				f.add(SeverityError, FindingInvalidValue, section.Name, i+1, "%s must be one of %s, got %q", field.Name, strings.Join(field.Values, ", "), row[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cleve
+
+import (
+	"bufio"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestApplicationsBySample(t *testing.T) {
+	data := v2SampleSheet + `[DragenGermline_Settings]
+SoftwareVersion,4.2.7
+MapAlignOutFormat,cram
+[DragenGermline_Data]
+Sample_ID,ReferenceGenomeDir,VariantCallingMode
+S1,hg38,
+S3,hg19,SmallVariantCaller
+[TSO500L_Data]
+Sample_ID,Sample_Type
+S1,DNA
+`
+	sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SampleSheetSampleApplications{
+		{
+			SampleId: "S1",
+			Applications: []SampleApplication{
+				{
+					Application: "DragenGermline",
+					Settings: map[string]string{
+						"SoftwareVersion":    "4.2.7",
+						"MapAlignOutFormat":  "cram",
+						"ReferenceGenomeDir": "hg38",
+					},
+				},
+				{
+					Application: "TSO500L",
+					Settings:    map[string]string{"Sample_Type": "DNA"},
+				},
+			},
+		},
+		{
+			SampleId: "S3",
+			Applications: []SampleApplication{
+				{
+					Application: "DragenGermline",
+					Settings: map[string]string{
+						"SoftwareVersion":    "4.2.7",
+						"MapAlignOutFormat":  "cram",
+						"ReferenceGenomeDir": "hg19",
+						"VariantCallingMode": "SmallVariantCaller",
+					},
+				},
+			},
+		},
+	}
+
+	applications := sheet.ApplicationsBySample()
+	if !reflect.DeepEqual(applications, expected) {
+		t.Errorf("expected applications\n%+v\ngot\n%+v", expected, applications)
+	}
+}
+
+func TestApplicationSectionsWithoutSampleId(t *testing.T) {
+	cases := []struct {
+		name          string
+		data          string
+		missingColumn bool
+	}{
+		{
+			name: "known application with sample id",
+			data: v2SampleSheet + "[DragenGermline_Data]\nSample_ID,ReferenceGenomeDir\nS1,hg38\n",
+		},
+		{
+			name:          "known application without sample id",
+			data:          v2SampleSheet + "[DragenGermline_Data]\nReferenceGenomeDir\nhg38\n",
+			missingColumn: true,
+		},
+		{
+			name:          "cloud data without sample id",
+			data:          validationHeader + "[Cloud_Data]\nProjectName\nproject1\n",
+			missingColumn: true,
+		},
+		{
+			name: "unknown application without sample id",
+			data: v2SampleSheet + "[TSO500S_Data]\nSample_Type\nDNA\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(c.data)))
+			if err != nil {
+				t.Fatalf("expected the samplesheet to be parsed, got %v", err)
+			}
+			missingColumn := slices.ContainsFunc(sheet.Validate(), func(f ValidationFinding) bool {
+				return f.Code == FindingMissingColumn && f.Severity == SeverityError
+			})
+			if missingColumn != c.missingColumn {
+				t.Errorf("expected missing column finding to be %t, got %t", c.missingColumn, missingColumn)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package cleve
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var invalidRunNameCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// iemSettings maps settings in IEM samplesheets to their BCLConvert_Settings
+// counterparts.
+var iemSettings = map[string]string{
+	"Adapter":      "AdapterRead1",
+	"AdapterRead2": "AdapterRead2",
+}
+
+// ConvertToV2 converts the samplesheet to a v2 samplesheet for demultiplexing with BCL
+// Convert, using bclConvertVersion as the software version. A v2 samplesheet is
+// returned as is.
+//
+// For IEM samplesheets, the experiment name and description become RunName and
+// RunDescription, index cycles are inferred from the index lengths, and adapter
+// settings are kept. Sample names and index IDs have no counterpart in BCLConvert_Data
+// and are dropped. Indexes are copied as they are, so the orientation of index2 should
+// be checked for the target instrument.
+func (s SampleSheet) ConvertToV2(bclConvertVersion string) (SampleSheet, error) {
+	switch v := s.Version(); v {
+	case 2:
+		return s, nil
+	case 1:
+	default:
+		return SampleSheet{}, fmt.Errorf("cannot convert samplesheet of version %d to version 2", v)
+	}
+
+	samples, err := s.Samples()
+	if err != nil {
+		return SampleSheet{}, err
+	}
+
+	converted := SampleSheet{
+		RunID: s.RunID,
+		UUID:  s.UUID,
+	}
+
+	header := Section{Name: "Header", Type: SettingsSection, Rows: [][]string{{"FileFormatVersion", "2"}}}
+	v1Header := s.Section("Header")
+	if name, err := v1Header.Get("Experiment Name"); err == nil && name != "" {
+		header.Rows = append(header.Rows, []string{"RunName", invalidRunNameCharacters.ReplaceAllString(name, "_")})
+	}
+	if description, err := v1Header.Get("Description"); err == nil && description != "" {
+		header.Rows = append(header.Rows, []string{"RunDescription", description})
+	}
+	converted.Sections = append(converted.Sections, header)
+
+	reads := Section{Name: "Reads", Type: SettingsSection}
+	for i, row := range s.Section("Reads").Rows {
+		if i > 1 {
+			break
+		}
+		cycles, err := strconv.Atoi(row[0])
+		if err != nil {
+			return SampleSheet{}, fmt.Errorf("invalid number of cycles %q in section \"Reads\"", row[0])
+		}
+		reads.Rows = append(reads.Rows, []string{fmt.Sprintf("Read%dCycles", i+1), strconv.Itoa(cycles)})
+	}
+	index1Cycles, index2Cycles := 0, 0
+	hasLane, hasProject := false, false
+	for _, sample := range samples {
+		index1Cycles = max(index1Cycles, len(sample.Index))
+		index2Cycles = max(index2Cycles, len(sample.Index2))
+		hasLane = hasLane || sample.Lane != 0
+		hasProject = hasProject || sample.Project != ""
+	}
+	if index1Cycles > 0 {
+		reads.Rows = append(reads.Rows, []string{"Index1Cycles", strconv.Itoa(index1Cycles)})
+	}
+	if index2Cycles > 0 {
+		reads.Rows = append(reads.Rows, []string{"Index2Cycles", strconv.Itoa(index2Cycles)})
+	}
+	converted.Sections = append(converted.Sections, reads)
+
+	settings := map[string]string{}
+	if bclConvertVersion != "" {
+		settings["SoftwareVersion"] = bclConvertVersion
+	}
+	if v1Settings := s.Section("Settings"); v1Settings != nil {
+		for key, v2Key := range iemSettings {
+			if v, err := v1Settings.Get(key); err == nil && v != "" {
+				settings[v2Key] = v
+			}
+		}
+	}
+	converted.Sections = append(converted.Sections, settingsSection("BCLConvert_Settings", settings))
+
+	if len(samples) == 0 {
+		return converted, nil
+	}
+
+	data := Section{Name: "BCLConvert_Data", Type: DataSection}
+	columns := []string{}
+	if hasLane {
+		columns = append(columns, "Lane")
+	}
+	columns = append(columns, "Sample_ID", "Index")
+	if index2Cycles > 0 {
+		columns = append(columns, "Index2")
+	}
+	if hasProject {
+		columns = append(columns, "Sample_Project")
+	}
+	data.Rows = append(data.Rows, columns)
+	for _, sample := range samples {
+		row := []string{}
+		if hasLane {
+			lane := ""
+			if sample.Lane != 0 {
+				lane = strconv.Itoa(sample.Lane)
+			}
+			row = append(row, lane)
+		}
+		row = append(row, sample.SampleId, sample.Index)
+		if index2Cycles > 0 {
+			row = append(row, sample.Index2)
+		}
+		if hasProject {
+			row = append(row, sample.Project)
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	converted.Sections = append(converted.Sections, data)
+
+	return converted, nil
+}
@@ -0,0 +1,354 @@
+package cleve
+
+import (
+	"slices"
+	"strings"
+)
+
+// DiffChange describes how an item differs between two samplesheets.
+type DiffChange string
+
+const (
+	DiffAdded    DiffChange = "added"
+	DiffRemoved  DiffChange = "removed"
+	DiffModified DiffChange = "modified"
+)
+
+// SampleSheetDiff is the difference between two samplesheets, section by section.
+type SampleSheetDiff struct {
+	Sections []SectionDiff `json:"sections"`
+	// Critical is the number of changes that affect demultiplexing, i.e. changed
+	// sample IDs, lanes or indexes, and added or removed samples in the sample section.
+	Critical int `json:"critical"`
+}
+
+// Equal returns true if there are no differences.
+func (d SampleSheetDiff) Equal() bool {
+	return len(d.Sections) == 0
+}
+
+// SectionDiff is the difference for a single section. For settings sections, changed
+// keys are listed in Settings, and for data sections changed rows are listed in Rows.
+type SectionDiff struct {
+	Name           string        `json:"name"`
+	Change         DiffChange    `json:"change"`
+	Settings       []SettingDiff `json:"settings,omitempty"`
+	ColumnsAdded   []string      `json:"columns_added,omitempty"`
+	ColumnsRemoved []string      `json:"columns_removed,omitempty"`
+	Rows           []RowDiff     `json:"rows,omitempty"`
+}
+
+// SettingDiff is a changed key in a settings section.
+type SettingDiff struct {
+	Key    string     `json:"key"`
+	Change DiffChange `json:"change"`
+	Old    string     `json:"old,omitempty"`
+	New    string     `json:"new,omitempty"`
+}
+
+// RowDiff is a changed row in a data section. Rows are matched by sample ID and lane
+// if possible, otherwise by position. OldRow and NewRow are 1-based row numbers among
+// the data rows, or 0 if the row does not exist in that samplesheet.
+type RowDiff struct {
+	SampleId string      `json:"sample_id,omitempty"`
+	Change   DiffChange  `json:"change"`
+	OldRow   int         `json:"old_row,omitempty"`
+	NewRow   int         `json:"new_row,omitempty"`
+	Fields   []FieldDiff `json:"fields,omitempty"`
+	Critical bool        `json:"critical"`
+}
+
+// FieldDiff is a changed value in a data row. Critical is true if the column affects
+// demultiplexing.
+type FieldDiff struct {
+	Column   string `json:"column"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+	Critical bool   `json:"critical"`
+}
+
+// criticalColumns are the data columns, in lower case, that affect demultiplexing.
+var criticalColumns = []string{"sample_id", "lane", "index", "index2"}
+
+// isSampleSectionName returns true if the section with the given name defines the
+// samples used for demultiplexing.
+func isSampleSectionName(name string) bool {
+	for _, columns := range sampleColumnsByVersion {
+		if columns.section == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffSampleSheets compares two samplesheets and returns the differences going from
+// old to new. Sections are compared by name, and are listed in the order they appear
+// in the new samplesheet, followed by removed sections.
+func DiffSampleSheets(old, new SampleSheet) SampleSheetDiff {
+	diff := SampleSheetDiff{Sections: []SectionDiff{}}
+	for _, section := range new.Sections {
+		oldSection := old.Section(section.Name)
+		if oldSection == nil {
+			d := SectionDiff{Name: section.Name, Change: DiffAdded}
+			if section.Type == DataSection {
+				d.Rows = diffRows(Section{Name: section.Name, Type: DataSection}, section)
+			}
+			diff.add(d)
+			continue
+		}
+		var d SectionDiff
+		if section.Type == DataSection && oldSection.Type == DataSection {
+			d = diffDataSections(*oldSection, section)
+		} else {
+			d = diffSettingsSections(*oldSection, section)
+		}
+		if d.Change != "" {
+			diff.add(d)
+		}
+	}
+	for _, section := range old.Sections {
+		if new.Section(section.Name) == nil {
+			d := SectionDiff{Name: section.Name, Change: DiffRemoved}
+			if section.Type == DataSection {
+				d.Rows = diffRows(section, Section{Name: section.Name, Type: DataSection})
+			}
+			diff.add(d)
+		}
+	}
+	return diff
+}
+
+func (d *SampleSheetDiff) add(section SectionDiff) {
+	for _, row := range section.Rows {
+		if row.Critical {
+			d.Critical++
+		}
+	}
+	d.Sections = append(d.Sections, section)
+}
+
+func diffSettingsSections(old, new Section) SectionDiff {
+	d := SectionDiff{Name: new.Name}
+	oldValues := settingsValues(old)
+	newValues := settingsValues(new)
+	for _, row := range new.Rows {
+		key := row[0]
+		oldValue, ok := oldValues[key]
+		switch {
+		case !ok:
+			d.Settings = append(d.Settings, SettingDiff{Key: key, Change: DiffAdded, New: newValues[key]})
+		case oldValue != newValues[key]:
+			d.Settings = append(d.Settings, SettingDiff{Key: key, Change: DiffModified, Old: oldValue, New: newValues[key]})
+		}
+	}
+	for _, row := range old.Rows {
+		if _, ok := newValues[row[0]]; !ok {
+			d.Settings = append(d.Settings, SettingDiff{Key: row[0], Change: DiffRemoved, Old: oldValues[row[0]]})
+		}
+	}
+	if len(d.Settings) > 0 {
+		d.Change = DiffModified
+	}
+	return d
+}
+
+func settingsValues(s Section) map[string]string {
+	values := make(map[string]string, len(s.Rows))
+	for _, row := range s.Rows {
+		if len(row) > 1 {
+			values[row[0]] = row[1]
+		} else {
+			values[row[0]] = row[0]
+		}
+	}
+	return values
+}
+
+func diffDataSections(old, new Section) SectionDiff {
+	d := SectionDiff{Name: new.Name}
+	var oldColumns, newColumns []string
+	if len(old.Rows) > 0 {
+		oldColumns = old.Rows[0]
+	}
+	if len(new.Rows) > 0 {
+		newColumns = new.Rows[0]
+	}
+	for _, c := range newColumns {
+		if !slices.Contains(oldColumns, c) {
+			d.ColumnsAdded = append(d.ColumnsAdded, c)
+		}
+	}
+	for _, c := range oldColumns {
+		if !slices.Contains(newColumns, c) {
+			d.ColumnsRemoved = append(d.ColumnsRemoved, c)
+		}
+	}
+	d.Rows = diffRows(old, new)
+	if len(d.Rows) > 0 || len(d.ColumnsAdded) > 0 || len(d.ColumnsRemoved) > 0 {
+		d.Change = DiffModified
+	}
+	return d
+}
+
+// dataRow is a row in a data section with the values keyed by column name.
+type dataRow struct {
+	number int
+	key    string
+	values map[string]string
+}
+
+func dataRows(s Section) []dataRow {
+	if len(s.Rows) < 2 {
+		return nil
+	}
+	header := s.Rows[0]
+	rows := make([]dataRow, 0, len(s.Rows)-1)
+	for i, row := range s.Rows[1:] {
+		r := dataRow{number: i + 1, values: make(map[string]string, len(header))}
+		for j, c := range header {
+			if j < len(row) {
+				r.values[c] = row[j]
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// sampleIdColumn returns the name of the sample ID column, if any.
+func sampleIdColumn(s Section) string {
+	if len(s.Rows) == 0 {
+		return ""
+	}
+	for _, c := range s.Rows[0] {
+		if strings.EqualFold(c, "Sample_ID") {
+			return c
+		}
+	}
+	return ""
+}
+
+func laneColumn(s Section) string {
+	if len(s.Rows) == 0 {
+		return ""
+	}
+	for _, c := range s.Rows[0] {
+		if strings.EqualFold(c, "Lane") {
+			return c
+		}
+	}
+	return ""
+}
+
+// diffRows compares the rows of two data sections. Rows are first matched by sample ID
+// and lane. Remaining rows are matched by position, which catches renamed samples, and
+// any rows left after that are added or removed.
+func diffRows(old, new Section) []RowDiff {
+	oldRows, newRows := dataRows(old), dataRows(new)
+	critical := isSampleSectionName(new.Name)
+	oldId, newId := sampleIdColumn(old), sampleIdColumn(new)
+	oldLane, newLane := laneColumn(old), laneColumn(new)
+	byKey := oldId != "" && newId != ""
+	if byKey {
+		for i := range oldRows {
+			oldRows[i].key = oldRows[i].values[oldId] + "\x00" + oldRows[i].values[oldLane]
+		}
+		for i := range newRows {
+			newRows[i].key = newRows[i].values[newId] + "\x00" + newRows[i].values[newLane]
+		}
+	}
+
+	sampleId := func(r dataRow, column string) string {
+		if column == "" {
+			return ""
+		}
+		return r.values[column]
+	}
+
+	var diffs []RowDiff
+	matchedOld := make([]bool, len(oldRows))
+	matchedNew := make([]bool, len(newRows))
+	if byKey {
+		oldIndex := make(map[string]int, len(oldRows))
+		for i, r := range oldRows {
+			if _, ok := oldIndex[r.key]; !ok {
+				oldIndex[r.key] = i
+			}
+		}
+		for i, r := range newRows {
+			j, ok := oldIndex[r.key]
+			if !ok || matchedOld[j] {
+				continue
+			}
+			matchedOld[j], matchedNew[i] = true, true
+			if d, changed := diffRow(oldRows[j], r, critical); changed {
+				d.SampleId = sampleId(r, newId)
+				diffs = append(diffs, d)
+			}
+		}
+	}
+
+	for i, r := range newRows {
+		if matchedNew[i] || i >= len(oldRows) || matchedOld[i] {
+			continue
+		}
+		matchedOld[i], matchedNew[i] = true, true
+		if d, changed := diffRow(oldRows[i], r, critical); changed {
+			d.SampleId = sampleId(r, newId)
+			diffs = append(diffs, d)
+		}
+	}
+
+	for i, r := range newRows {
+		if !matchedNew[i] {
+			diffs = append(diffs, RowDiff{
+				SampleId: sampleId(r, newId),
+				Change:   DiffAdded,
+				NewRow:   r.number,
+				Critical: critical,
+			})
+		}
+	}
+	for i, r := range oldRows {
+		if !matchedOld[i] {
+			diffs = append(diffs, RowDiff{
+				SampleId: sampleId(r, oldId),
+				Change:   DiffRemoved,
+				OldRow:   r.number,
+				Critical: critical,
+			})
+		}
+	}
+
+	slices.SortStableFunc(diffs, func(a, b RowDiff) int {
+		return max(a.NewRow, a.OldRow) - max(b.NewRow, b.OldRow)
+	})
+
+	return diffs
+}
+
+// diffRow compares two matched rows. If critical is true, changes to the sample ID,
+// lane or indexes are marked as critical.
+func diffRow(old, new dataRow, critical bool) (RowDiff, bool) {
+	d := RowDiff{Change: DiffModified, OldRow: old.number, NewRow: new.number}
+	columns := []string{}
+	for c := range new.values {
+		columns = append(columns, c)
+	}
+	for c := range old.values {
+		if _, ok := new.values[c]; !ok {
+			columns = append(columns, c)
+		}
+	}
+	slices.Sort(columns)
+	for _, c := range columns {
+		oldValue, newValue := old.values[c], new.values[c]
+		if oldValue == newValue {
+			continue
+		}
+		f := FieldDiff{Column: c, Old: oldValue, New: newValue, Critical: critical && slices.Contains(criticalColumns, strings.ToLower(c))}
+		d.Fields = append(d.Fields, f)
+		d.Critical = d.Critical || f.Critical
+	}
+	return d, len(d.Fields) > 0
+}
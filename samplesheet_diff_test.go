@@ -0,0 +1,181 @@
+package cleve
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffSampleSheets(t *testing.T) {
+	base := `[Header]
+FileFormatVersion,2
+RunName,run1
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+[BCLConvert_Data]
+Lane,Sample_ID,Index
+1,sample1,ACGTACGT
+1,sample2,TGCATGCA
+1,sample3,GGGGCCCC
+`
+	cases := []struct {
+		name     string
+		new      string
+		sections []string
+		critical int
+		check    func(*testing.T, SampleSheetDiff)
+	}{
+		{
+			name: "identical",
+			new:  base,
+		},
+		{
+			name:     "changed setting",
+			new:      strings.Replace(base, "RunName,run1", "RunName,run2\nRunDescription,desc", 1),
+			sections: []string{"Header"},
+			check: func(t *testing.T, d SampleSheetDiff) {
+				s := d.Sections[0].Settings
+				if len(s) != 2 || s[0].Change != DiffModified || s[0].Old != "run1" || s[0].New != "run2" || s[1].Change != DiffAdded {
+					t.Errorf("unexpected settings diff: %+v", s)
+				}
+			},
+		},
+		{
+			name:     "changed index",
+			new:      strings.Replace(base, "1,sample2,TGCATGCA", "1,sample2,TGCATGCC", 1),
+			sections: []string{"BCLConvert_Data"},
+			critical: 1,
+			check: func(t *testing.T, d SampleSheetDiff) {
+				rows := d.Sections[0].Rows
+				if len(rows) != 1 || rows[0].SampleId != "sample2" || rows[0].OldRow != 2 || len(rows[0].Fields) != 1 {
+					t.Fatalf("unexpected row diff: %+v", rows)
+				}
+				f := rows[0].Fields[0]
+				if f.Column != "Index" || f.Old != "TGCATGCA" || f.New != "TGCATGCC" || !f.Critical {
+					t.Errorf("unexpected field diff: %+v", f)
+				}
+			},
+		},
+		{
+			name:     "renamed sample",
+			new:      strings.Replace(base, "1,sample2,TGCATGCA", "1,sample2b,TGCATGCA", 1),
+			sections: []string{"BCLConvert_Data"},
+			critical: 1,
+			check: func(t *testing.T, d SampleSheetDiff) {
+				rows := d.Sections[0].Rows
+				if len(rows) != 1 || rows[0].Change != DiffModified || rows[0].Fields[0].Column != "Sample_ID" {
+					t.Errorf("unexpected row diff: %+v", rows)
+				}
+			},
+		},
+		{
+			name:     "reordered and removed samples",
+			new:      strings.Replace(base, "1,sample1,ACGTACGT\n1,sample2,TGCATGCA\n1,sample3,GGGGCCCC\n", "1,sample3,GGGGCCCC\n1,sample1,ACGTACGT\n", 1),
+			sections: []string{"BCLConvert_Data"},
+			critical: 1,
+			check: func(t *testing.T, d SampleSheetDiff) {
+				rows := d.Sections[0].Rows
+				if len(rows) != 1 || rows[0].Change != DiffRemoved || rows[0].SampleId != "sample2" {
+					t.Errorf("unexpected row diff: %+v", rows)
+				}
+			},
+		},
+		{
+			name:     "added section",
+			new:      base + "[Cloud_Data]\nSample_ID,ProjectName\nsample1,proj\n",
+			sections: []string{"Cloud_Data"},
+		},
+		{
+			name:     "removed section",
+			new:      strings.Replace(base, "[BCLConvert_Settings]\nSoftwareVersion,4.2.7\n", "", 1),
+			sections: []string{"BCLConvert_Settings"},
+		},
+	}
+
+	old, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(base)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			new, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(c.new)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := DiffSampleSheets(old, new)
+			if d.Equal() != (len(c.sections) == 0) {
+				t.Errorf("expected equal to be %t", len(c.sections) == 0)
+			}
+			if len(d.Sections) != len(c.sections) {
+				t.Fatalf("expected %d changed sections, got %+v", len(c.sections), d.Sections)
+			}
+			for i, s := range d.Sections {
+				if s.Name != c.sections[i] {
+					t.Errorf("expected section %q, got %q", c.sections[i], s.Name)
+				}
+			}
+			if d.Critical != c.critical {
+				t.Errorf("expected %d critical changes, got %d", c.critical, d.Critical)
+			}
+			if c.check != nil {
+				c.check(t, d)
+			}
+		})
+	}
+}
+
+func TestSampleSheetChecksum(t *testing.T) {
+	checksum := func(s SampleSheet) string {
+		t.Helper()
+		sum, err := s.Checksum()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sum
+	}
+	a := SampleSheet{Sections: []Section{
+		{Name: "Header", Type: SettingsSection, Rows: [][]string{{"FileFormatVersion", "2"}}},
+		{Name: "Reads", Type: SettingsSection, Rows: [][]string{{"Read1Cycles", "151"}}},
+	}}
+	b := SampleSheet{Sections: []Section{a.Sections[1], a.Sections[0]}}
+	if checksum(a) != checksum(b) {
+		t.Error("expected checksum to be independent of section order")
+	}
+	apps := SampleSheet{Sections: append(slices.Clone(a.Sections),
+		Section{Name: "TSO500L_Data", Type: DataSection, Rows: [][]string{{"Sample_ID"}, {"s1"}}},
+		Section{Name: "DragenGermline_Data", Type: DataSection, Rows: [][]string{{"Sample_ID"}, {"s1"}}},
+	)}
+	reorderedApps := SampleSheet{Sections: []Section{apps.Sections[3], apps.Sections[0], apps.Sections[2], apps.Sections[1]}}
+	if checksum(apps) != checksum(reorderedApps) {
+		t.Error("expected checksum to be independent of the order of application sections")
+	}
+	c := SampleSheet{Sections: []Section{
+		a.Sections[0],
+		{Name: "Reads", Type: SettingsSection, Rows: [][]string{{"Read1Cycles", "101"}}},
+	}}
+	if checksum(a) == checksum(c) {
+		t.Error("expected different checksums for different content")
+	}
+
+	r, err := NewSampleSheetRevision(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Checksum != checksum(a) || r.File != nil {
+		t.Errorf("unexpected revision: %+v", r)
+	}
+
+	invalid := SampleSheet{Sections: []Section{
+		{Name: "Header", Type: SettingsSection, Rows: [][]string{{"RunName", "a,b"}}},
+	}}
+	if _, err := invalid.Checksum(); err == nil {
+		t.Error("expected an error for a samplesheet that cannot be written")
+	}
+	if _, err := NewSampleSheetRevision(invalid); err == nil {
+		t.Error("expected an error when creating a revision of a samplesheet that cannot be written")
+	}
+}
@@ -0,0 +1,260 @@
+package cleve
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// SampleSheetPlan is a description of a sequencing run from which a v2 samplesheet
+// can be generated.
+type SampleSheetPlan struct {
+	RunName            string    `json:"run_name" yaml:"run_name"`
+	RunDescription     string    `json:"run_description,omitempty" yaml:"run_description"`
+	InstrumentPlatform string    `json:"instrument_platform,omitempty" yaml:"instrument_platform"`
+	InstrumentType     string    `json:"instrument_type,omitempty" yaml:"instrument_type"`
+	Reads              PlanReads `json:"reads" yaml:"reads"`
+	// IndexKit is the default index kit used to look up sample indexes.
+	IndexKit string `json:"index_kit,omitempty" yaml:"index_kit"`
+	// BCLConvert are the settings for the BCLConvert_Settings section.
+	BCLConvert map[string]string `json:"bclconvert,omitempty" yaml:"bclconvert"`
+	// Applications are the settings for additional applications, keyed by
+	// application name. Each application gets its own <Application>_Settings section.
+	Applications map[string]map[string]string `json:"applications,omitempty" yaml:"applications"`
+	Samples      []PlanSample                 `json:"samples" yaml:"samples"`
+}
+
+// PlanReads are the number of cycles for each read in a run plan.
+type PlanReads struct {
+	Read1Cycles  int `json:"read1_cycles" yaml:"read1_cycles"`
+	Read2Cycles  int `json:"read2_cycles,omitempty" yaml:"read2_cycles"`
+	Index1Cycles int `json:"index1_cycles,omitempty" yaml:"index1_cycles"`
+	Index2Cycles int `json:"index2_cycles,omitempty" yaml:"index2_cycles"`
+}
+
+// PlanSample is a sample in a run plan. Indexes are either given explicitly, or looked
+// up by ID in an index kit.
+type PlanSample struct {
+	SampleId string `json:"sample_id" yaml:"sample_id"`
+	Lane     int    `json:"lane,omitempty" yaml:"lane"`
+	Project  string `json:"project,omitempty" yaml:"project"`
+	IndexId  string `json:"index_id,omitempty" yaml:"index_id"`
+	// IndexKit overrides the default index kit of the plan for this sample.
+	IndexKit string `json:"index_kit,omitempty" yaml:"index_kit"`
+	Index    string `json:"index,omitempty" yaml:"index"`
+	Index2   string `json:"index2,omitempty" yaml:"index2"`
+	// Applications are sample specific settings for additional applications, keyed by
+	// application name. These end up as columns in the <Application>_Data section.
+	Applications map[string]map[string]string `json:"applications,omitempty" yaml:"applications"`
+}
+
+// SampleSheet generates a v2 samplesheet from the plan. The kits function is used for
+// retrieving index kits by name when samples reference indexes by ID. An error is
+// returned if an index cannot be resolved. Sections without settings are left out. The
+// resulting samplesheet is not validated, use [ValidateSampleSheetCSV] on the written
+// samplesheet for this.
+func (p SampleSheetPlan) SampleSheet(kits func(name string) (IndexKit, error)) (SampleSheet, error) {
+	var sheet SampleSheet
+
+	header := Section{Name: "Header", Type: SettingsSection, Rows: [][]string{{"FileFormatVersion", "2"}}}
+	for _, kv := range [][2]string{
+		{"RunName", p.RunName},
+		{"RunDescription", p.RunDescription},
+		{"InstrumentPlatform", p.InstrumentPlatform},
+		{"InstrumentType", p.InstrumentType},
+	} {
+		if kv[1] != "" {
+			header.Rows = append(header.Rows, []string{kv[0], kv[1]})
+		}
+	}
+	sheet.Sections = append(sheet.Sections, header)
+
+	reads := Section{Name: "Reads", Type: SettingsSection}
+	for _, kv := range []struct {
+		key    string
+		cycles int
+	}{
+		{"Read1Cycles", p.Reads.Read1Cycles},
+		{"Read2Cycles", p.Reads.Read2Cycles},
+		{"Index1Cycles", p.Reads.Index1Cycles},
+		{"Index2Cycles", p.Reads.Index2Cycles},
+	} {
+		if kv.cycles != 0 {
+			reads.Rows = append(reads.Rows, []string{kv.key, strconv.Itoa(kv.cycles)})
+		}
+	}
+	sheet.Sections = append(sheet.Sections, reads)
+
+	// Empty sections are not accepted by the parser, so they are left out
+	if len(p.BCLConvert) > 0 {
+		sheet.Sections = append(sheet.Sections, settingsSection("BCLConvert_Settings", p.BCLConvert))
+	}
+
+	if len(p.Samples) > 0 {
+		data, err := p.bclConvertData(kits)
+		if err != nil {
+			return sheet, err
+		}
+		sheet.Sections = append(sheet.Sections, data)
+	}
+
+	apps := slices.Collect(maps.Keys(p.Applications))
+	for _, s := range p.Samples {
+		for app := range s.Applications {
+			if !slices.Contains(apps, app) {
+				apps = append(apps, app)
+			}
+		}
+	}
+	slices.Sort(apps)
+	for _, app := range apps {
+		if app == "BCLConvert" {
+			return sheet, fmt.Errorf("BCLConvert settings should be given with the bclconvert key, not as an application")
+		}
+		if settings := p.Applications[app]; len(settings) > 0 {
+			sheet.Sections = append(sheet.Sections, settingsSection(app+"_Settings", settings))
+		}
+		if data := p.applicationData(app); data != nil {
+			sheet.Sections = append(sheet.Sections, *data)
+		}
+	}
+
+	return sheet, nil
+}
+
+// settingsSection creates a settings section with the keys in lexicographic order,
+// except SoftwareVersion which always comes first.
+func settingsSection(name string, settings map[string]string) Section {
+	section := Section{Name: name, Type: SettingsSection}
+	keys := slices.Sorted(maps.Keys(settings))
+	if i := slices.Index(keys, "SoftwareVersion"); i > 0 {
+		keys = slices.Insert(slices.Delete(keys, i, i+1), 0, "SoftwareVersion")
+	}
+	for _, k := range keys {
+		section.Rows = append(section.Rows, []string{k, settings[k]})
+	}
+	return section
+}
+
+func (p SampleSheetPlan) bclConvertData(kits func(name string) (IndexKit, error)) (Section, error) {
+	section := Section{Name: "BCLConvert_Data", Type: DataSection}
+
+	hasLane, hasIndex2, hasProject := false, false, false
+	loaded := map[string]IndexKit{}
+	indexes := make([][2]string, len(p.Samples))
+	for i, s := range p.Samples {
+		index, index2 := s.Index, s.Index2
+		if s.IndexId != "" {
+			if index != "" || index2 != "" {
+				return section, fmt.Errorf("sample %q: index_id cannot be combined with explicit indexes", s.SampleId)
+			}
+			kitName := s.IndexKit
+			if kitName == "" {
+				kitName = p.IndexKit
+			}
+			if kitName == "" {
+				return section, fmt.Errorf("sample %q: no index kit given for index %q", s.SampleId, s.IndexId)
+			}
+			kit, ok := loaded[kitName]
+			if !ok {
+				if kits == nil {
+					return section, fmt.Errorf("sample %q: no index kits available", s.SampleId)
+				}
+				var err error
+				kit, err = kits(kitName)
+				if err != nil {
+					return section, fmt.Errorf("sample %q: failed to get index kit %q: %w", s.SampleId, kitName, err)
+				}
+				loaded[kitName] = kit
+			}
+			idx, ok := kit.Lookup(s.IndexId)
+			if !ok {
+				return section, fmt.Errorf("sample %q: index %q not found in index kit %q", s.SampleId, s.IndexId, kitName)
+			}
+			if idx.Index == "" {
+				return section, fmt.Errorf("sample %q: index %q in index kit %q has no i7 index", s.SampleId, s.IndexId, kitName)
+			}
+			index, index2 = idx.Index, idx.Index2
+		}
+		indexes[i] = [2]string{index, index2}
+		hasLane = hasLane || s.Lane != 0
+		hasIndex2 = hasIndex2 || index2 != ""
+		hasProject = hasProject || s.Project != ""
+	}
+
+	header := []string{}
+	if hasLane {
+		header = append(header, "Lane")
+	}
+	header = append(header, "Sample_ID", "Index")
+	if hasIndex2 {
+		header = append(header, "Index2")
+	}
+	if hasProject {
+		header = append(header, "Sample_Project")
+	}
+	section.Rows = append(section.Rows, header)
+
+	for i, s := range p.Samples {
+		row := []string{}
+		if hasLane {
+			lane := ""
+			if s.Lane != 0 {
+				lane = strconv.Itoa(s.Lane)
+			}
+			row = append(row, lane)
+		}
+		row = append(row, s.SampleId, indexes[i][0])
+		if hasIndex2 {
+			row = append(row, indexes[i][1])
+		}
+		if hasProject {
+			row = append(row, s.Project)
+		}
+		section.Rows = append(section.Rows, row)
+	}
+
+	return section, nil
+}
+
+// applicationData creates the data section for an application, with one row per sample
+// that has settings for the application. If no samples have settings for the
+// application, nil is returned.
+func (p SampleSheetPlan) applicationData(app string) *Section {
+	var columns []string
+	seen := map[string]bool{}
+	for _, s := range p.Samples {
+		for k := range s.Applications[app] {
+			if !slices.Contains(columns, k) {
+				columns = append(columns, k)
+			}
+		}
+		if _, ok := s.Applications[app]; ok {
+			seen[s.SampleId] = false
+		}
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+	slices.Sort(columns)
+
+	section := Section{
+		Name: app + "_Data",
+		Type: DataSection,
+		Rows: [][]string{append([]string{"Sample_ID"}, columns...)},
+	}
+	for _, s := range p.Samples {
+		settings, ok := s.Applications[app]
+		if !ok || seen[s.SampleId] {
+			continue
+		}
+		seen[s.SampleId] = true
+		row := []string{s.SampleId}
+		for _, c := range columns {
+			row = append(row, settings[c])
+		}
+		section.Rows = append(section.Rows, row)
+	}
+	return &section
+}
@@ -0,0 +1,216 @@
+package cleve
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testIndexKits(name string) (IndexKit, error) {
+	kits := map[string]IndexKit{
+		"udi": {Name: "udi", Indexes: []IndexKitIndex{
+			{Id: "UDP0001", Index: "GAACTGAGCG", Index2: "TCGTGGAGCG"},
+			{Id: "UDP0002", Index: "AGGTCAGATA", Index2: "CTACAAGATA"},
+		}},
+		"single": {Name: "single", Indexes: []IndexKitIndex{
+			{Id: "D701", Index: "ATTACTCG"},
+		}},
+	}
+	kit, ok := kits[name]
+	if !ok {
+		return kit, fmt.Errorf("no such kit")
+	}
+	return kit, nil
+}
+
+func TestSampleSheetPlan(t *testing.T) {
+	cases := []struct {
+		name     string
+		plan     SampleSheetPlan
+		expected string
+		valid    bool
+		err      bool
+	}{
+		{
+			name: "index kit with applications",
+			plan: SampleSheetPlan{
+				RunName:    "run1",
+				Reads:      PlanReads{Read1Cycles: 151, Read2Cycles: 151, Index1Cycles: 10, Index2Cycles: 10},
+				IndexKit:   "udi",
+				BCLConvert: map[string]string{"SoftwareVersion": "4.2.7", "AdapterRead1": "CTGTCTCTTATACACATCT"},
+				Applications: map[string]map[string]string{
+					"DragenGermline": {"SoftwareVersion": "4.2.7"},
+				},
+				Samples: []PlanSample{
+					{
+						SampleId: "sample1",
+						IndexId:  "UDP0001",
+						Project:  "proj",
+						Applications: map[string]map[string]string{
+							"DragenGermline": {"ReferenceGenomeDir": "hg38"},
+						},
+					},
+					{
+						SampleId: "sample2",
+						IndexId:  "UDP0002",
+						Project:  "proj",
+						Applications: map[string]map[string]string{
+							"DragenGermline": {"ReferenceGenomeDir": "hg38", "KeepFastq": "true"},
+						},
+					},
+				},
+			},
+			expected: `[Header]
+FileFormatVersion,2
+RunName,run1
+
+[Reads]
+Read1Cycles,151
+Read2Cycles,151
+Index1Cycles,10
+Index2Cycles,10
+
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+AdapterRead1,CTGTCTCTTATACACATCT
+
+[BCLConvert_Data]
+Sample_ID,Index,Index2,Sample_Project
+sample1,GAACTGAGCG,TCGTGGAGCG,proj
+sample2,AGGTCAGATA,CTACAAGATA,proj
+
+[DragenGermline_Settings]
+SoftwareVersion,4.2.7
+
+[DragenGermline_Data]
+Sample_ID,KeepFastq,ReferenceGenomeDir
+sample1,,hg38
+sample2,true,hg38
+`,
+			valid: true,
+		},
+		{
+			name: "explicit indexes and lanes",
+			plan: SampleSheetPlan{
+				Reads:      PlanReads{Read1Cycles: 151, Index1Cycles: 8},
+				BCLConvert: map[string]string{"SoftwareVersion": "4.2.7"},
+				Samples: []PlanSample{
+					{SampleId: "sample1", Lane: 1, IndexKit: "single", IndexId: "D701"},
+					{SampleId: "sample2", Lane: 2, Index: "TCCGGAGA"},
+				},
+			},
+			expected: `[Header]
+FileFormatVersion,2
+
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+
+[BCLConvert_Data]
+Lane,Sample_ID,Index
+1,sample1,ATTACTCG
+2,sample2,TCCGGAGA
+`,
+			valid: true,
+		},
+		{
+			name: "colliding indexes",
+			plan: SampleSheetPlan{
+				Reads:      PlanReads{Read1Cycles: 151, Index1Cycles: 8},
+				BCLConvert: map[string]string{"SoftwareVersion": "4.2.7"},
+				Samples: []PlanSample{
+					{SampleId: "sample1", Index: "ATTACTCG"},
+					{SampleId: "sample2", Index: "ATTACTCG"},
+				},
+			},
+			valid: false,
+		},
+		{
+			name: "no bclconvert settings",
+			plan: SampleSheetPlan{
+				Reads:   PlanReads{Read1Cycles: 151, Index1Cycles: 8},
+				Samples: []PlanSample{{SampleId: "sample1", Index: "ATTACTCG"}},
+			},
+			expected: `[Header]
+FileFormatVersion,2
+
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+
+[BCLConvert_Data]
+Sample_ID,Index
+sample1,ATTACTCG
+`,
+			valid: false,
+		},
+		{
+			name: "unknown index",
+			plan: SampleSheetPlan{
+				Reads:    PlanReads{Read1Cycles: 151},
+				IndexKit: "udi",
+				Samples:  []PlanSample{{SampleId: "sample1", IndexId: "UDP0003"}},
+			},
+			err: true,
+		},
+		{
+			name: "unknown index kit",
+			plan: SampleSheetPlan{
+				Reads:   PlanReads{Read1Cycles: 151},
+				Samples: []PlanSample{{SampleId: "sample1", IndexKit: "missing", IndexId: "UDP0001"}},
+			},
+			err: true,
+		},
+		{
+			name: "no index kit",
+			plan: SampleSheetPlan{
+				Reads:   PlanReads{Read1Cycles: 151},
+				Samples: []PlanSample{{SampleId: "sample1", IndexId: "UDP0001"}},
+			},
+			err: true,
+		},
+		{
+			name: "index id and explicit index",
+			plan: SampleSheetPlan{
+				Reads:    PlanReads{Read1Cycles: 151},
+				IndexKit: "udi",
+				Samples:  []PlanSample{{SampleId: "sample1", IndexId: "UDP0001", Index: "ACGT"}},
+			},
+			err: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sheet, err := c.plan.SampleSheet(testIndexKits)
+			if (err != nil) != c.err {
+				t.Fatalf("expected error to be %t, got %v", c.err, err)
+			}
+			if c.err {
+				return
+			}
+			var b strings.Builder
+			if err := sheet.WriteCSV(&b); err != nil {
+				t.Fatal(err)
+			}
+			findings := ValidateSampleSheetCSV(strings.NewReader(b.String()))
+			if findings.Valid() != c.valid {
+				t.Errorf("expected valid to be %t, got %t: %v", c.valid, findings.Valid(), findings)
+			}
+			for _, f := range findings {
+				if f.Code == FindingParseError {
+					t.Errorf("generated samplesheet cannot be parsed: %s", f.Message)
+				}
+			}
+			if c.expected == "" {
+				return
+			}
+			if b.String() != c.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", c.expected, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package cleve
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SampleSheetRevision is an immutable snapshot of a samplesheet as it was ingested.
+// Revisions are numbered from 1 for each samplesheet, and a new revision is only
+// created when the content of the samplesheet changes.
+type SampleSheetRevision struct {
+	RunID    *string    `bson:"run_id" json:"run_id"`
+	UUID     *uuid.UUID `bson:"uuid" json:"uuid"`
+	Revision int        `bson:"revision" json:"revision"`
+	// Checksum is the SHA-256 checksum of the samplesheet in canonical form.
+	Checksum string           `bson:"checksum" json:"checksum"`
+	File     *SampleSheetInfo `bson:"file,omitempty" json:"file,omitempty"`
+	Created  time.Time        `bson:"created" json:"created"`
+	Sections []Section        `bson:"sections,omitempty" json:"sections,omitempty"`
+}
+
+// NewSampleSheetRevision creates a revision from a samplesheet. The revision number is
+// not set. If the samplesheet has been read from a file, the most recently modified
+// file is recorded with the revision. An error is returned if the checksum of the
+// samplesheet cannot be computed.
+func NewSampleSheetRevision(s SampleSheet) (SampleSheetRevision, error) {
+	checksum, err := s.Checksum()
+	if err != nil {
+		return SampleSheetRevision{}, err
+	}
+	r := SampleSheetRevision{
+		RunID:    s.RunID,
+		UUID:     s.UUID,
+		Checksum: checksum,
+		Created:  time.Now(),
+		Sections: s.Sections,
+	}
+	for i, f := range s.Files {
+		if i == 0 || f.ModificationTime.After(r.File.ModificationTime) {
+			r.File = &s.Files[i]
+		}
+	}
+	return r, nil
+}
+
+// SampleSheet returns the samplesheet of the revision.
+func (r SampleSheetRevision) SampleSheet() SampleSheet {
+	s := SampleSheet{
+		RunID:    r.RunID,
+		UUID:     r.UUID,
+		Sections: r.Sections,
+	}
+	if r.File != nil {
+		s.Files = []SampleSheetInfo{*r.File}
+	}
+	return s
+}
+
+// Checksum returns the SHA-256 checksum of the samplesheet in canonical form. Two
+// samplesheets with the same content have the same checksum regardless of the order of
+// the sections. An error is returned if the samplesheet cannot be written.
+func (s SampleSheet) Checksum() (string, error) {
+	h := sha256.New()
+	if err := s.WriteCSV(h); err != nil {
+		return "", fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,226 @@
+package cleve
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SampleSheetSample is a sample in the data section of a samplesheet. It gives a uniform
+// view of samples regardless of the samplesheet version.
+type SampleSheetSample struct {
+	SampleId   string `bson:"sample_id" json:"sample_id"`
+	SampleName string `bson:"sample_name,omitempty" json:"sample_name,omitempty"`
+	Project    string `bson:"project,omitempty" json:"project,omitempty"`
+	// Lane is the lane the sample was sequenced on, or 0 if the sample is in all lanes.
+	Lane     int    `bson:"lane,omitempty" json:"lane,omitempty"`
+	IndexId  string `bson:"index_id,omitempty" json:"index_id,omitempty"`
+	Index    string `bson:"index,omitempty" json:"index,omitempty"`
+	Index2Id string `bson:"index2_id,omitempty" json:"index2_id,omitempty"`
+	Index2   string `bson:"index2,omitempty" json:"index2,omitempty"`
+}
+
+// sampleColumns are the names of the sample data section and its columns for a
+// specific samplesheet version. Column names are matched case insensitively.
+type sampleColumns struct {
+	section    string
+	sampleId   string
+	sampleName string
+	project    string
+	lane       string
+	indexId    string
+	index      string
+	index2Id   string
+	index2     string
+}
+
+var sampleColumnsByVersion = map[int]sampleColumns{
+	1: {
+		section:    "Data",
+		sampleId:   "Sample_ID",
+		sampleName: "Sample_Name",
+		project:    "Sample_Project",
+		lane:       "Lane",
+		indexId:    "I7_Index_ID",
+		index:      "index",
+		index2Id:   "I5_Index_ID",
+		index2:     "index2",
+	},
+	2: {
+		section:    "BCLConvert_Data",
+		sampleId:   "Sample_ID",
+		sampleName: "Sample_Name",
+		project:    "Sample_Project",
+		lane:       "Lane",
+		index:      "Index",
+		index2:     "Index2",
+	},
+}
+
+// SampleSection returns the section that defines the samples of the samplesheet, i.e.
+// Data for IEM samplesheets and BCLConvert_Data for v2 samplesheets. If the samplesheet
+// version is not supported or the section does not exist, nil is returned.
+func (s SampleSheet) SampleSection() *Section {
+	columns, ok := sampleColumnsByVersion[s.Version()]
+	if !ok {
+		return nil
+	}
+	return s.Section(columns.section)
+}
+
+// Samples returns the samples defined in the samplesheet. Samples that are sequenced on
+// multiple lanes are listed once per lane. If there is no sample name, the sample ID is
+// used as the name. For v2 samplesheets the project is taken from the Cloud_Data
+// section if it is not defined in BCLConvert_Data. If the samplesheet has no samples,
+// an empty slice is returned.
+func (s SampleSheet) Samples() ([]SampleSheetSample, error) {
+	version := s.Version()
+	columns, ok := sampleColumnsByVersion[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported samplesheet version %d", version)
+	}
+	section := s.Section(columns.section)
+	if section == nil || len(section.Rows) < 2 {
+		return []SampleSheetSample{}, nil
+	}
+
+	header := make(map[string]int, len(section.Rows[0]))
+	for i, c := range section.Rows[0] {
+		header[strings.ToLower(c)] = i
+	}
+	if _, ok := header[strings.ToLower(columns.sampleId)]; !ok {
+		return nil, fmt.Errorf("column %q not found in section %q", columns.sampleId, section.Name)
+	}
+	value := func(row []string, column string) string {
+		if column == "" {
+			return ""
+		}
+		if i, ok := header[strings.ToLower(column)]; ok {
+			return row[i]
+		}
+		return ""
+	}
+
+	var cloudProjects map[string]string
+	if version == 2 {
+		cloudProjects = s.cloudProjects()
+	}
+
+	samples := make([]SampleSheetSample, 0, len(section.Rows)-1)
+	for i, row := range section.Rows[1:] {
+		sample := SampleSheetSample{
+			SampleId:   value(row, columns.sampleId),
+			SampleName: value(row, columns.sampleName),
+			Project:    value(row, columns.project),
+			IndexId:    value(row, columns.indexId),
+			Index:      value(row, columns.index),
+			Index2Id:   value(row, columns.index2Id),
+			Index2:     value(row, columns.index2),
+		}
+		if sample.SampleName == "" {
+			sample.SampleName = sample.SampleId
+		}
+		if sample.Project == "" {
+			sample.Project = cloudProjects[sample.SampleId]
+		}
+		if lane := value(row, columns.lane); lane != "" {
+			n, err := strconv.Atoi(lane)
+			if err != nil {
+				return nil, fmt.Errorf("invalid lane %q on row %d in section %q", lane, i+1, section.Name)
+			}
+			sample.Lane = n
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+// cloudProjects returns the project names from the Cloud_Data section, keyed by
+// sample ID.
+func (s SampleSheet) cloudProjects() map[string]string {
+	projects := map[string]string{}
+	section := s.Section("Cloud_Data")
+	if section == nil {
+		return projects
+	}
+	ids, err := section.GetColumn("Sample_ID")
+	if err != nil {
+		return projects
+	}
+	names, err := section.GetColumn("ProjectName")
+	if err != nil {
+		return projects
+	}
+	for i, id := range ids {
+		projects[id] = names[i]
+	}
+	return projects
+}
+
+// SampleRecords returns the samples of the samplesheet as sample records linked to the
+// run of the samplesheet. Samples are taken from the sample section, with one run entry
+// per lane, and from the data sections of secondary analysis applications, such as
+// DragenGermline_Data, see [SampleSheet.ApplicationsBySample]. The applications that a
+// sample is listed in are recorded on each of its run entries. Samples are returned in
+// the order they first appear.
+func (s SampleSheet) SampleRecords() ([]Sample, error) {
+	if s.RunID == nil {
+		return nil, fmt.Errorf("samplesheet is not associated with a run")
+	}
+	samples, err := s.Samples()
+	if err != nil {
+		return nil, err
+	}
+
+	applications := map[string][]string{}
+	order := []string{}
+	seen := map[string]bool{}
+	for _, sample := range samples {
+		if !seen[sample.SampleId] {
+			seen[sample.SampleId] = true
+			order = append(order, sample.SampleId)
+		}
+	}
+	for _, sample := range s.ApplicationsBySample() {
+		for _, app := range sample.Applications {
+			applications[sample.SampleId] = append(applications[sample.SampleId], app.Application)
+		}
+		if !seen[sample.SampleId] {
+			seen[sample.SampleId] = true
+			order = append(order, sample.SampleId)
+		}
+	}
+
+	records := make(map[string]*Sample, len(order))
+	for _, id := range order {
+		records[id] = &Sample{Id: id, Name: id}
+	}
+	for _, sample := range samples {
+		r := records[sample.SampleId]
+		r.Name = sample.SampleName
+		if sample.Project != "" {
+			r.Project = sample.Project
+		}
+		r.Runs = append(r.Runs, SampleRun{
+			RunId:        *s.RunID,
+			Lane:         sample.Lane,
+			Project:      sample.Project,
+			IndexId:      sample.IndexId,
+			Index:        sample.Index,
+			Index2Id:     sample.Index2Id,
+			Index2:       sample.Index2,
+			Applications: applications[sample.SampleId],
+		})
+	}
+
+	result := make([]Sample, 0, len(order))
+	for _, id := range order {
+		r := records[id]
+		if len(r.Runs) == 0 {
+			r.Runs = []SampleRun{{RunId: *s.RunID, Applications: applications[id]}}
+		}
+		result = append(result, *r)
+	}
+	return result, nil
+}
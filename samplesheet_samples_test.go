@@ -0,0 +1,239 @@
+package cleve
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const iemSampleSheet = `[Header],,,,,,,,,
+IEMFileVersion,4,,,,,,,,
+Investigator Name,Jane Doe,,,,,,,,
+Experiment Name,MiSeq run 42,,,,,,,,
+Date,,,,,,,,,
+Workflow,GenerateFASTQ,,,,,,,,
+Application,FASTQ Only,,,,,,,,
+Instrument Type,MiSeq,,,,,,,,
+Description,a1b2c3,,,,,,,,
+,,,,,,,,,
+[Reads],,,,,,,,,
+151,,,,,,,,,
+151,,,,,,,,,
+,,,,,,,,,
+[Settings],,,,,,,,,
+ReverseComplement,0,,,,,,,,
+Adapter,CTGTCTCTTATACACATCT,,,,,,,,
+,,,,,,,,,
+[Data],,,,,,,,,
+Sample_ID,Sample_Name,Sample_Plate,Sample_Well,I7_Index_ID,index,I5_Index_ID,index2,Sample_Project,Description
+S1,Sample 1,,A01,N701,TAAGGCGA,S502,CTCTCTAT,proj1,
+S2,Sample 2,,B01,N702,CGTACTAG,S502,CTCTCTAT,proj1,
+`
+
+const v2SampleSheet = `[Header]
+FileFormatVersion,2
+RunName,run42
+[Reads]
+Read1Cycles,151
+Read2Cycles,151
+Index1Cycles,8
+Index2Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+[BCLConvert_Data]
+Lane,Sample_ID,Index,Index2
+1,S1,TAAGGCGA,CTCTCTAT
+2,S1,TAAGGCGA,CTCTCTAT
+1,S2,CGTACTAG,CTCTCTAT
+[Cloud_Data]
+Sample_ID,ProjectName,LibraryName
+S1,proj1,S1_lib
+S2,proj2,S2_lib
+`
+
+func TestSampleSheetSamples(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		version  int
+		section  string
+		expected []SampleSheetSample
+	}{
+		{
+			name:    "iem v1",
+			data:    iemSampleSheet,
+			version: 1,
+			section: "Data",
+			expected: []SampleSheetSample{
+				{SampleId: "S1", SampleName: "Sample 1", Project: "proj1", IndexId: "N701", Index: "TAAGGCGA", Index2Id: "S502", Index2: "CTCTCTAT"},
+				{SampleId: "S2", SampleName: "Sample 2", Project: "proj1", IndexId: "N702", Index: "CGTACTAG", Index2Id: "S502", Index2: "CTCTCTAT"},
+			},
+		},
+		{
+			name:    "v2",
+			data:    v2SampleSheet,
+			version: 2,
+			section: "BCLConvert_Data",
+			expected: []SampleSheetSample{
+				{SampleId: "S1", SampleName: "S1", Project: "proj1", Lane: 1, Index: "TAAGGCGA", Index2: "CTCTCTAT"},
+				{SampleId: "S1", SampleName: "S1", Project: "proj1", Lane: 2, Index: "TAAGGCGA", Index2: "CTCTCTAT"},
+				{SampleId: "S2", SampleName: "S2", Project: "proj2", Lane: 1, Index: "CGTACTAG", Index2: "CTCTCTAT"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(c.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sheet.Version() != c.version {
+				t.Errorf("expected version %d, got %d", c.version, sheet.Version())
+			}
+			if section := sheet.SampleSection(); section == nil || section.Name != c.section {
+				t.Errorf("expected sample section %q, got %v", c.section, section)
+			}
+			samples, err := sheet.Samples()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(samples, c.expected) {
+				t.Errorf("expected samples\n%+v\ngot\n%+v", c.expected, samples)
+			}
+		})
+	}
+}
+
+func TestSampleSheetSampleRecords(t *testing.T) {
+	data := v2SampleSheet + `[DragenGermline_Settings]
+SoftwareVersion,4.2.7
+[DragenGermline_Data]
+Sample_ID,ReferenceGenomeDir
+S1,hg38
+S3,hg38
+`
+	sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sheet.SampleRecords(); err == nil {
+		t.Fatal("expected error for samplesheet without run id")
+	}
+
+	runId := "run1"
+	sheet.RunID = &runId
+	records, err := sheet.SampleRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Sample{
+		{
+			Id:      "S1",
+			Name:    "S1",
+			Project: "proj1",
+			Runs: []SampleRun{
+				{RunId: "run1", Lane: 1, Project: "proj1", Index: "TAAGGCGA", Index2: "CTCTCTAT", Applications: []string{"DragenGermline"}},
+				{RunId: "run1", Lane: 2, Project: "proj1", Index: "TAAGGCGA", Index2: "CTCTCTAT", Applications: []string{"DragenGermline"}},
+			},
+		},
+		{
+			Id:      "S2",
+			Name:    "S2",
+			Project: "proj2",
+			Runs: []SampleRun{
+				{RunId: "run1", Lane: 1, Project: "proj2", Index: "CGTACTAG", Index2: "CTCTCTAT"},
+			},
+		},
+		{
+			Id:   "S3",
+			Name: "S3",
+			Runs: []SampleRun{
+				{RunId: "run1", Applications: []string{"DragenGermline"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected records\n%+v\ngot\n%+v", expected, records)
+	}
+}
+
+func TestParsePaddedSettings(t *testing.T) {
+	sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(iemSampleSheet)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := sheet.Section("Reads").Rows; !reflect.DeepEqual(rows, [][]string{{"151"}, {"151"}}) {
+		t.Errorf("unexpected rows in Reads: %v", rows)
+	}
+	if v, err := sheet.Section("Header").Get("Date"); err != nil || v != "" {
+		t.Errorf("expected empty Date, got %q (%v)", v, err)
+	}
+}
+
+func TestConvertToV2(t *testing.T) {
+	sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(iemSampleSheet)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := sheet.ConvertToV2("4.2.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[Header]
+FileFormatVersion,2
+RunName,MiSeq_run_42
+RunDescription,a1b2c3
+
+[Reads]
+Read1Cycles,151
+Read2Cycles,151
+Index1Cycles,8
+Index2Cycles,8
+
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+AdapterRead1,CTGTCTCTTATACACATCT
+
+[BCLConvert_Data]
+Sample_ID,Index,Index2,Sample_Project
+S1,TAAGGCGA,CTCTCTAT,proj1
+S2,CGTACTAG,CTCTCTAT,proj1
+`
+	if s := converted.String(); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+	if findings := converted.Validate(); !findings.Valid() || len(findings) != 0 {
+		t.Errorf("expected converted samplesheet to be valid, got %v", findings)
+	}
+
+	samples, _ := sheet.Samples()
+	convertedSamples, err := converted.Samples()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range convertedSamples {
+		if s.SampleId != samples[i].SampleId || s.Index != samples[i].Index || s.Index2 != samples[i].Index2 || s.Project != samples[i].Project {
+			t.Errorf("sample %d differs after conversion: %+v != %+v", i, s, samples[i])
+		}
+	}
+
+	v2, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(v2SampleSheet)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	same, err := v2.ConvertToV2("4.2.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(same, v2) {
+		t.Error("expected v2 samplesheet to be unchanged")
+	}
+
+	if _, err := (SampleSheet{}).ConvertToV2("4.2.7"); err == nil {
+		t.Error("expected error for samplesheet without version")
+	}
+}
@@ -798,6 +798,46 @@ func TestLastModified(t *testing.T) {
 	}
 }
 
+func TestSampleSheetIsModified(t *testing.T) {
+	modtime := time.Date(2024, 9, 18, 12, 8, 0, 0, time.Local)
+	ss := SampleSheet{Files: []SampleSheetInfo{{Path: "/runs/run1/SampleSheet.csv", ModificationTime: modtime}}}
+
+	testcases := []struct {
+		name     string
+		file     SampleSheetInfo
+		expected bool
+	}{
+		{
+			name:     "unchanged",
+			file:     SampleSheetInfo{Path: "/runs/run1/SampleSheet.csv", ModificationTime: modtime},
+			expected: false,
+		},
+		{
+			name:     "sub-millisecond difference",
+			file:     SampleSheetInfo{Path: "/runs/run1/SampleSheet.csv", ModificationTime: modtime.Add(time.Microsecond)},
+			expected: false,
+		},
+		{
+			name:     "modified",
+			file:     SampleSheetInfo{Path: "/runs/run1/SampleSheet.csv", ModificationTime: modtime.Add(time.Second)},
+			expected: true,
+		},
+		{
+			name:     "unknown file",
+			file:     SampleSheetInfo{Path: "/runs/run1/SampleSheet_v2.csv", ModificationTime: modtime},
+			expected: true,
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			if modified := ss.IsModified(c.file); modified != c.expected {
+				t.Errorf("expected modified to be %t, got %t", c.expected, modified)
+			}
+		})
+	}
+}
+
 func TestMergeSampleSheets(t *testing.T) {
 	run1_1 := "run1"
 	run1_2 := "run1"
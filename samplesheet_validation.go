@@ -0,0 +1,530 @@
+package cleve
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gmc-norr/cleve/interop"
+)
+
+// Severity is the severity of a samplesheet validation finding.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Finding codes for samplesheet validation.
+const (
+	FindingParseError          = "parse_error"
+	FindingUnsupportedVersion  = "unsupported_version"
+	FindingMissingSection      = "missing_section"
+	FindingMissingKey          = "missing_key"
+	FindingInvalidValue        = "invalid_value"
+	FindingMissingColumn       = "missing_column"
+	FindingInvalidSampleId     = "invalid_sample_id"
+	FindingDuplicateSample     = "duplicate_sample"
+	FindingInvalidIndex        = "invalid_index"
+	FindingIndexLengthMismatch = "index_length_mismatch"
+	FindingIndexCollision      = "index_collision"
+	FindingUnknownKey          = "unknown_key"
+	FindingUnknownColumn       = "unknown_column"
+	FindingUnknownSample       = "unknown_sample"
+	FindingRunInfoMismatch     = "run_info_mismatch"
+)
+
+// ValidationFinding is a single problem found when validating a samplesheet.
+type ValidationFinding struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Section  string   `json:"section,omitempty"`
+	// Row is the 1-based row number among the data rows of the section, or 0 if the
+	// finding does not concern a specific row.
+	Row     int    `json:"row,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f ValidationFinding) String() string {
+	location := f.Section
+	if f.Row > 0 {
+		location = fmt.Sprintf("%s, row %d", location, f.Row)
+	}
+	if location != "" {
+		return fmt.Sprintf("%s: [%s] %s", f.Severity, location, f.Message)
+	}
+	return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+}
+
+// ValidationFindings is the result of validating a samplesheet.
+type ValidationFindings []ValidationFinding
+
+// Valid returns true if there are no findings with error severity.
+func (f ValidationFindings) Valid() bool {
+	for _, finding := range f {
+		if finding.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// Errors returns the number of findings with error severity.
+func (f ValidationFindings) Errors() int {
+	n := 0
+	for _, finding := range f {
+		if finding.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// Warnings returns the number of findings with warning severity.
+func (f ValidationFindings) Warnings() int {
+	return len(f) - f.Errors()
+}
+
+func (f *ValidationFindings) add(severity Severity, code, section string, row int, format string, a ...any) {
+	*f = append(*f, ValidationFinding{
+		Severity: severity,
+		Code:     code,
+		Section:  section,
+		Row:      row,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+var (
+	validSampleId = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	validIndex    = regexp.MustCompile(`^[ACGTN]+$`)
+)
+
+// Maximum length of a sample ID accepted by BCL Convert.
+const maxSampleIdLength = 100
+
+// ValidateSampleSheetCSV parses and validates a samplesheet. This is used for checking
+// generated samplesheets as they are written, since a section that can be represented
+// in memory is not necessarily accepted by the parser. If the samplesheet cannot be
+// parsed, the parse error is returned as the only finding.
+func ValidateSampleSheetCSV(r io.Reader) ValidationFindings {
+	sheet, err := ParseSampleSheet(bufio.NewReader(r))
+	if err != nil {
+		var findings ValidationFindings
+		findings.add(SeverityError, FindingParseError, "", 0, "%s", err.Error())
+		return findings
+	}
+	return sheet.Validate()
+}
+
+// Validate checks that the samplesheet is a valid v2 samplesheet that can be used for
+// demultiplexing with BCL Convert. Sample IDs, indexes and index collisions are checked
+// in the BCLConvert_Data section. Sections of known applications are checked against
+// their settings and columns, see [SampleSheetApplications].
+func (s SampleSheet) Validate() ValidationFindings {
+	var findings ValidationFindings
+
+	header := s.Section("Header")
+	if header == nil {
+		findings.add(SeverityError, FindingMissingSection, "Header", 0, "section is missing")
+	} else if v, err := header.Get("FileFormatVersion"); s.Version() == 1 || (err != nil && s.Section("Data") != nil) {
+		// IEM samplesheets have no FileFormatVersion, but they are recognised by
+		// IEMFileVersion or by their Data section.
+		findings.add(SeverityError, FindingUnsupportedVersion, "Header", 0, "version 1 (IEM) samplesheets are not supported, convert it to version 2 with `cleve samplesheet convert`")
+		return findings
+	} else if err != nil {
+		findings.add(SeverityError, FindingMissingKey, "Header", 0, "FileFormatVersion is missing")
+	} else if v != "2" {
+		findings.add(SeverityError, FindingUnsupportedVersion, "Header", 0, "unsupported file format version %q, only version 2 can be validated", v)
+		return findings
+	}
+
+	reads := s.Section("Reads")
+	readCycles := make(map[string]int)
+	if reads == nil {
+		findings.add(SeverityError, FindingMissingSection, "Reads", 0, "section is missing")
+	} else {
+		for _, key := range []string{"Read1Cycles", "Read2Cycles", "Index1Cycles", "Index2Cycles"} {
+			v, err := reads.Get(key)
+			if err != nil {
+				if key == "Read1Cycles" {
+					findings.add(SeverityError, FindingMissingKey, "Reads", 0, "%s is missing", key)
+				}
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				findings.add(SeverityError, FindingInvalidValue, "Reads", 0, "%s must be a positive integer, got %q", key, v)
+				continue
+			}
+			readCycles[key] = n
+		}
+	}
+
+	settings := s.Section("BCLConvert_Settings")
+	data := s.Section("BCLConvert_Data")
+	if data == nil {
+		findings.add(SeverityWarning, FindingMissingSection, "BCLConvert_Data", 0, "section is missing, no samples will be demultiplexed")
+		findings.validateApplications(s)
+		return findings
+	}
+	if settings == nil {
+		findings.add(SeverityError, FindingMissingSection, "BCLConvert_Settings", 0, "section is required when BCLConvert_Data is present")
+	} else if _, err := settings.Get("SoftwareVersion"); err != nil {
+		findings.add(SeverityError, FindingMissingKey, "BCLConvert_Settings", 0, "SoftwareVersion is missing")
+	}
+
+	// Number of index cycles of each index read, as given by OverrideCycles. Nil if
+	// OverrideCycles is not set.
+	var indexCycles map[string]int
+	if settings != nil {
+		if v, err := settings.Get("OverrideCycles"); err == nil {
+			indexCycles = findings.validateOverrideCycles(v, readCycles)
+		}
+	}
+
+	mismatches := [2]int{1, 1}
+	for i, key := range []string{"BarcodeMismatchesIndex1", "BarcodeMismatchesIndex2"} {
+		if settings == nil {
+			break
+		}
+		v, err := settings.Get(key)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 2 {
+			findings.add(SeverityError, FindingInvalidValue, "BCLConvert_Settings", 0, "%s must be 0, 1 or 2, got %q", key, v)
+			continue
+		}
+		mismatches[i] = n
+	}
+
+	samples := findings.validateData(*data, readCycles, indexCycles)
+	findings.validateIndexCollisions(data.Name, samples, mismatches)
+	findings.validateApplications(s)
+
+	return findings
+}
+
+// sampleSheetReads are the keys of the Reads section in the order that the reads are
+// sequenced, which is also the order of the reads in OverrideCycles.
+var sampleSheetReads = []string{"Read1Cycles", "Index1Cycles", "Index2Cycles", "Read2Cycles"}
+
+var overrideCyclesRead = regexp.MustCompile(`^([YNIU][0-9]+)+$`)
+
+// overrideCycles is a read in an OverrideCycles value, e.g. `U8Y143` or `I8`.
+type overrideCycles struct {
+	cycles      int
+	indexCycles int
+}
+
+// parseOverrideCycles parses an OverrideCycles value such as `Y151;I8;I8;Y151` into
+// its reads.
+func parseOverrideCycles(value string) ([]overrideCycles, error) {
+	var reads []overrideCycles
+	for _, read := range strings.Split(value, ";") {
+		read = strings.TrimSpace(read)
+		if !overrideCyclesRead.MatchString(read) {
+			return nil, fmt.Errorf("invalid read %q", read)
+		}
+		var r overrideCycles
+		for i := 0; i < len(read); {
+			j := i + 1
+			for j < len(read) && read[j] >= '0' && read[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(read[i+1 : j])
+			r.cycles += n
+			if read[i] == 'I' {
+				r.indexCycles += n
+			}
+			i = j
+		}
+		reads = append(reads, r)
+	}
+	return reads, nil
+}
+
+// validateOverrideCycles checks that OverrideCycles is well formed and that it agrees
+// with the cycles of the Reads section. The number of index cycles of each index read
+// is returned, or nil if OverrideCycles is invalid.
+func (f *ValidationFindings) validateOverrideCycles(value string, readCycles map[string]int) map[string]int {
+	reads, err := parseOverrideCycles(value)
+	if err != nil {
+		f.add(SeverityError, FindingInvalidValue, "BCLConvert_Settings", 0, "OverrideCycles %q is invalid: %s", value, err)
+		return nil
+	}
+	var keys []string
+	for _, key := range sampleSheetReads {
+		if _, ok := readCycles[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(reads) != len(keys) {
+		f.add(SeverityError, FindingInvalidValue, "BCLConvert_Settings", 0, "OverrideCycles %q has %d reads, but the Reads section has %d", value, len(reads), len(keys))
+		return nil
+	}
+	indexCycles := make(map[string]int)
+	for i, key := range keys {
+		if reads[i].cycles != readCycles[key] {
+			f.add(SeverityWarning, FindingInvalidValue, "BCLConvert_Settings", 0, "read %d of OverrideCycles has %d cycles, but %s is %d", i+1, reads[i].cycles, key, readCycles[key])
+		}
+		if strings.HasPrefix(key, "Index") {
+			indexCycles[key] = reads[i].indexCycles
+		}
+	}
+	return indexCycles
+}
+
+// ValidateRunInfo validates the samplesheet like [SampleSheet.Validate], and also checks
+// that the reads of the samplesheet agree with the reads of the run. The reads of the
+// Reads section cannot be longer than the reads of the run, and OverrideCycles must
+// account for every cycle of the run.
+func (s SampleSheet) ValidateRunInfo(runInfo interop.RunInfo) ValidationFindings {
+	findings := s.Validate()
+	if s.Version() != 2 {
+		return findings
+	}
+
+	// Reads of the run keyed as in the Reads section, in the order they are sequenced
+	runReads := make(map[string]int)
+	var runKeys []string
+	nonIndex, index := 0, 0
+	for _, r := range runInfo.Reads {
+		var key string
+		if r.IsIndex {
+			index++
+			key = fmt.Sprintf("Index%dCycles", index)
+		} else {
+			nonIndex++
+			key = fmt.Sprintf("Read%dCycles", nonIndex)
+		}
+		runReads[key] = r.Cycles
+		runKeys = append(runKeys, key)
+	}
+
+	if reads := s.Section("Reads"); reads != nil {
+		for _, key := range sampleSheetReads {
+			n, err := reads.GetInt(key)
+			if err != nil {
+				continue
+			}
+			runCycles, ok := runReads[key]
+			switch {
+			case !ok:
+				findings.add(SeverityError, FindingRunInfoMismatch, "Reads", 0, "%s is defined, but run %s has no such read", key, runInfo.RunId)
+			case n > runCycles:
+				findings.add(SeverityError, FindingRunInfoMismatch, "Reads", 0, "%s is %d, but the read only has %d cycles in run %s", key, n, runCycles, runInfo.RunId)
+			}
+		}
+	}
+
+	settings := s.Section("BCLConvert_Settings")
+	if settings == nil {
+		return findings
+	}
+	v, err := settings.Get("OverrideCycles")
+	if err != nil {
+		return findings
+	}
+	reads, err := parseOverrideCycles(v)
+	if err != nil {
+		// Already reported by Validate
+		return findings
+	}
+	if len(reads) != len(runKeys) {
+		findings.add(SeverityError, FindingRunInfoMismatch, "BCLConvert_Settings", 0, "OverrideCycles %q has %d reads, but run %s has %d", v, len(reads), runInfo.RunId, len(runKeys))
+		return findings
+	}
+	for i, key := range runKeys {
+		if reads[i].cycles != runReads[key] {
+			findings.add(SeverityError, FindingRunInfoMismatch, "BCLConvert_Settings", 0, "read %d of OverrideCycles has %d cycles, but it has %d cycles in run %s", i+1, reads[i].cycles, runReads[key], runInfo.RunId)
+		}
+	}
+	return findings
+}
+
+// validatedSample is a row in a data section with the values needed for checking
+// index collisions.
+type validatedSample struct {
+	row      int
+	sampleId string
+	lane     string
+	index    string
+	index2   string
+}
+
+func (f *ValidationFindings) validateData(data Section, readCycles map[string]int, indexCycles map[string]int) []validatedSample {
+	if len(data.Rows) < 2 {
+		f.add(SeverityWarning, FindingInvalidValue, data.Name, 0, "section has no samples")
+		return nil
+	}
+
+	columns := make(map[string]int)
+	for i, c := range data.Rows[0] {
+		columns[c] = i
+	}
+	if _, ok := columns["Sample_ID"]; !ok {
+		f.add(SeverityError, FindingMissingColumn, data.Name, 0, "Sample_ID column is missing")
+		return nil
+	}
+	if _, ok := columns["Index"]; !ok && len(data.Rows) > 2 {
+		f.add(SeverityError, FindingMissingColumn, data.Name, 0, "Index column is required when there is more than one sample")
+	}
+	if _, ok := columns["Index2"]; ok && readCycles["Index2Cycles"] == 0 {
+		f.add(SeverityError, FindingIndexLengthMismatch, data.Name, 0, "Index2 column is present but Index2Cycles is not defined in the Reads section")
+	}
+
+	value := func(row []string, column string) string {
+		if i, ok := columns[column]; ok {
+			return row[i]
+		}
+		return ""
+	}
+
+	seen := make(map[[2]string]int)
+	samples := make([]validatedSample, 0, len(data.Rows)-1)
+	for i, row := range data.Rows[1:] {
+		sample := validatedSample{
+			row:      i + 1,
+			sampleId: value(row, "Sample_ID"),
+			lane:     value(row, "Lane"),
+			index:    value(row, "Index"),
+			index2:   value(row, "Index2"),
+		}
+
+		switch {
+		case sample.sampleId == "":
+			f.add(SeverityError, FindingInvalidSampleId, data.Name, sample.row, "sample ID is empty")
+		case len(sample.sampleId) > maxSampleIdLength:
+			f.add(SeverityError, FindingInvalidSampleId, data.Name, sample.row, "sample ID %q is longer than %d characters", sample.sampleId, maxSampleIdLength)
+		case !validSampleId.MatchString(sample.sampleId):
+			f.add(SeverityError, FindingInvalidSampleId, data.Name, sample.row, "sample ID %q contains illegal characters, only alphanumeric characters, dashes and underscores are allowed", sample.sampleId)
+		}
+
+		key := [2]string{sample.sampleId, sample.lane}
+		if first, ok := seen[key]; ok {
+			if sample.lane != "" {
+				f.add(SeverityError, FindingDuplicateSample, data.Name, sample.row, "sample %q in lane %s is also defined on row %d", sample.sampleId, sample.lane, first)
+			} else {
+				f.add(SeverityError, FindingDuplicateSample, data.Name, sample.row, "sample %q is also defined on row %d", sample.sampleId, first)
+			}
+		} else {
+			seen[key] = sample.row
+		}
+
+		for _, idx := range []struct {
+			column string
+			cycles string
+			value  string
+		}{
+			{"Index", "Index1Cycles", sample.index},
+			{"Index2", "Index2Cycles", sample.index2},
+		} {
+			if idx.value == "" {
+				continue
+			}
+			if !validIndex.MatchString(idx.value) {
+				f.add(SeverityError, FindingInvalidIndex, data.Name, sample.row, "%s %q contains characters other than A, C, G, T and N", idx.column, idx.value)
+				continue
+			}
+			cycles, ok := readCycles[idx.cycles]
+			if !ok {
+				continue
+			}
+			switch {
+			case len(idx.value) > cycles:
+				f.add(SeverityError, FindingIndexLengthMismatch, data.Name, sample.row, "%s %q is longer than %s (%d)", idx.column, idx.value, idx.cycles, cycles)
+			case indexCycles != nil:
+				if n, ok := indexCycles[idx.cycles]; ok && len(idx.value) != n {
+					f.add(SeverityError, FindingIndexLengthMismatch, data.Name, sample.row, "%s %q does not match the %d index cycles of OverrideCycles", idx.column, idx.value, n)
+				}
+			case len(idx.value) < cycles:
+				f.add(SeverityWarning, FindingIndexLengthMismatch, data.Name, sample.row, "%s %q is shorter than %s (%d) and OverrideCycles is not set", idx.column, idx.value, idx.cycles, cycles)
+			}
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples
+}
+
+// validateIndexCollisions checks that the indexes of all samples in the same lane are
+// far enough apart given the allowed number of mismatches. Two samples collide if a
+// read could be within the mismatch tolerance of both samples for all indexes, i.e. if
+// the Hamming distance is at most twice the number of allowed mismatches for each index.
+func (f *ValidationFindings) validateIndexCollisions(section string, samples []validatedSample, mismatches [2]int) {
+	lanes := make(map[string][]validatedSample)
+	laneOrder := []string{}
+	for _, s := range samples {
+		if s.index == "" {
+			continue
+		}
+		if _, ok := lanes[s.lane]; !ok {
+			laneOrder = append(laneOrder, s.lane)
+		}
+		lanes[s.lane] = append(lanes[s.lane], s)
+	}
+
+	for _, lane := range laneOrder {
+		laneSamples := lanes[lane]
+		for i := 0; i < len(laneSamples); i++ {
+			for j := i + 1; j < len(laneSamples); j++ {
+				a, b := laneSamples[i], laneSamples[j]
+				d1 := hammingDistance(a.index, b.index)
+				collision := d1 <= 2*mismatches[0]
+				description := fmt.Sprintf("Index distance %d (BarcodeMismatchesIndex1 %d)", d1, mismatches[0])
+				if a.index2 != "" && b.index2 != "" {
+					d2 := hammingDistance(a.index2, b.index2)
+					collision = collision && d2 <= 2*mismatches[1]
+					description += fmt.Sprintf(", Index2 distance %d (BarcodeMismatchesIndex2 %d)", d2, mismatches[1])
+				}
+				if !collision {
+					continue
+				}
+				laneDescription := ""
+				if lane != "" {
+					laneDescription = fmt.Sprintf(" in lane %s", lane)
+				}
+				f.add(SeverityError, FindingIndexCollision, section, b.row,
+					"indexes of sample %q collide with sample %q on row %d%s: %s",
+					b.sampleId, a.sampleId, a.row, laneDescription, description,
+				)
+			}
+		}
+	}
+}
+
+// hammingDistance returns the number of positions where the two sequences differ. If the
+// sequences have different lengths, only the length of the shorter one is compared,
+// since this is what will be used for demultiplexing.
+func hammingDistance(a, b string) int {
+	n := min(len(a), len(b))
+	d := 0
+	for i := range n {
+		if a[i] != b[i] {
+			d++
+		}
+	}
+	return d
+}
@@ -0,0 +1,478 @@
+package cleve
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/gmc-norr/cleve/interop"
+)
+
+const validationHeader = `[Header]
+FileFormatVersion,2
+RunName,TestRun
+[Reads]
+Read1Cycles,151
+Read2Cycles,151
+Index1Cycles,8
+Index2Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+`
+
+func TestSampleSheetValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		valid    bool
+		warnings int
+		codes    []string
+	}{
+		{
+			name: "valid",
+			data: validationHeader + `[BCLConvert_Data]
+Lane,Sample_ID,Index,Index2
+1,sample1,ACGTACGT,TTGGCCAA
+1,sample2,TGCATGCA,GGTTAACC
+2,sample1,ACGTACGT,TTGGCCAA
+`,
+			valid: true,
+		},
+		{
+			name: "unsupported version",
+			data: `[Header]
+FileFormatVersion,1
+[Reads]
+Read1Cycles,151
+`,
+			codes: []string{FindingUnsupportedVersion},
+		},
+		{
+			name: "version 1 samplesheet",
+			data: `[Header]
+IEMFileVersion,5
+Investigator Name,Someone
+[Reads]
+151
+151
+[Data]
+Sample_ID,index,index2
+sample1,ACGTACGT,TTGGCCAA
+`,
+			codes: []string{FindingUnsupportedVersion},
+		},
+		{
+			name: "version 1 samplesheet without IEMFileVersion",
+			data: `[Header]
+Investigator Name,Someone
+[Reads]
+151
+[Data]
+Sample_ID,index,index2
+sample1,ACGTACGT,TTGGCCAA
+`,
+			codes: []string{FindingUnsupportedVersion},
+		},
+		{
+			name: "missing keys",
+			data: `[Header]
+RunName,TestRun
+[Reads]
+Read2Cycles,151
+[BCLConvert_Settings]
+AdapterRead1,ACGT
+[BCLConvert_Data]
+Sample_ID
+sample1
+`,
+			codes: []string{FindingMissingKey, FindingMissingKey, FindingMissingKey},
+		},
+		{
+			name: "no data section",
+			data: `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+`,
+			valid:    true,
+			warnings: 1,
+			codes:    []string{FindingMissingSection},
+		},
+		{
+			name: "missing sample id column",
+			data: validationHeader + `[BCLConvert_Data]
+Lane,Index,Index2
+1,ACGTACGT,TTGGCCAA
+`,
+			codes: []string{FindingMissingColumn},
+		},
+		{
+			name: "illegal sample ids",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample 1,ACGTACGT,TTGGCCAA
+sample/2,TGCATGCA,GGTTAACC
+,GGGGGGGG,CCCCCCCC
+`,
+			codes: []string{FindingInvalidSampleId, FindingInvalidSampleId, FindingInvalidSampleId},
+		},
+		{
+			name: "duplicate samples in lane",
+			data: validationHeader + `[BCLConvert_Data]
+Lane,Sample_ID,Index,Index2
+1,sample1,ACGTACGT,TTGGCCAA
+1,sample1,TGCATGCA,GGTTAACC
+`,
+			codes: []string{FindingDuplicateSample},
+		},
+		{
+			name: "invalid index characters",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGX,TTGGCCAA
+sample2,TGCATGCA,GGTTAACC
+`,
+			codes: []string{FindingInvalidIndex},
+		},
+		{
+			name: "index longer than cycles",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGTAC,TTGGCCAA
+sample2,TGCATGCATG,GGTTAACC
+`,
+			codes: []string{FindingIndexLengthMismatch, FindingIndexLengthMismatch},
+		},
+		{
+			name: "index shorter than cycles",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTAC,TTGGCCAA
+sample2,TGCATG,GGTTAACC
+`,
+			valid:    true,
+			warnings: 2,
+			codes:    []string{FindingIndexLengthMismatch, FindingIndexLengthMismatch},
+		},
+		{
+			name: "override cycles",
+			data: validationHeader + `OverrideCycles,Y151;I6N2;I8;Y151
+[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTAC,TTGGCCAA
+sample2,TGCATG,GGTTAACC
+`,
+			valid: true,
+		},
+		{
+			name: "index does not match override cycles",
+			data: validationHeader + `OverrideCycles,Y151;I6N2;I8;Y151
+[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+`,
+			codes: []string{FindingIndexLengthMismatch},
+		},
+		{
+			name: "invalid override cycles",
+			data: validationHeader + `OverrideCycles,Y151;I8;I8;X151
+[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+`,
+			codes: []string{FindingInvalidValue},
+		},
+		{
+			name: "override cycles with too few reads",
+			data: validationHeader + `OverrideCycles,Y151;I8;Y151
+[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+`,
+			codes: []string{FindingInvalidValue},
+		},
+		{
+			name: "override cycles disagree with reads",
+			data: validationHeader + `OverrideCycles,U8Y140;I8;I8;Y151
+[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+`,
+			valid:    true,
+			warnings: 1,
+			codes:    []string{FindingInvalidValue},
+		},
+		{
+			name: "index2 without cycles",
+			data: `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+`,
+			codes: []string{FindingIndexLengthMismatch},
+		},
+		{
+			name: "index collision within lane",
+			data: validationHeader + `[BCLConvert_Data]
+Lane,Sample_ID,Index,Index2
+1,sample1,ACGTACGT,TTGGCCAA
+1,sample2,ACGTACGA,TTGGCCAT
+2,sample3,ACGTACGA,TTGGCCAT
+`,
+			codes: []string{FindingIndexCollision},
+		},
+		{
+			name: "no collision with zero mismatches",
+			data: `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+Index2Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+BarcodeMismatchesIndex1,0
+BarcodeMismatchesIndex2,0
+[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+sample2,ACGTACGA,TTGGCCAT
+`,
+			valid: true,
+		},
+		{
+			name: "second index resolves collision",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+sample2,ACGTACGT,GGTTAACC
+`,
+			valid: true,
+		},
+		{
+			name: "invalid mismatches",
+			data: `[Header]
+FileFormatVersion,2
+[Reads]
+Read1Cycles,151
+Index1Cycles,8
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+BarcodeMismatchesIndex1,3
+[BCLConvert_Data]
+Sample_ID,Index
+sample1,ACGTACGT
+`,
+			codes: []string{FindingInvalidValue},
+		},
+		{
+			name: "valid application sections",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+sample2,TGCATGCA,GGTTAACC
+[DragenGermline_Settings]
+SoftwareVersion,4.2.7
+MapAlignOutFormat,cram
+[DragenGermline_Data]
+Sample_ID,ReferenceGenomeDir
+sample1,hg38
+[Cloud_Settings]
+GeneratedVersion,1.0
+Cloud_DragenGermline_Pipeline,urn:pipeline
+[Cloud_Data]
+Sample_ID,ProjectName
+sample1,project1
+sample2,project1
+`,
+			valid: true,
+		},
+		{
+			name: "application columns in other case",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+[DragenGermline_Settings]
+softwareversion,4.2.7
+[DragenGermline_Data]
+sample_id,referencegenomedir
+sample1,hg38
+`,
+			valid: true,
+		},
+		{
+			name: "application data without sample id",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+[DragenGermline_Settings]
+SoftwareVersion,4.2.7
+[DragenGermline_Data]
+ReferenceGenomeDir
+hg38
+`,
+			codes: []string{FindingMissingColumn},
+		},
+		{
+			name: "invalid application sections",
+			data: validationHeader + `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+[DragenGermline_Settings]
+MapAlignOutFormat,sam
+NoSuchSetting,1
+[DragenGermline_Data]
+Sample_ID,VariantCallingMode,NoSuchColumn
+sample1,AllVariantCallers,x
+sample3,SomeVariantCaller,x
+[DragenRNA_Data]
+Sample_ID
+sample1
+[DragenEnrichment_Settings]
+SoftwareVersion,4.2.7
+`,
+			warnings: 3,
+			codes: []string{
+				FindingInvalidValue,
+				FindingUnknownKey,
+				FindingMissingKey,
+				FindingUnknownColumn,
+				FindingInvalidValue,
+				FindingUnknownSample,
+				FindingMissingSection,
+				FindingMissingSection,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(c.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings := sheet.Validate()
+			if findings.Valid() != c.valid {
+				t.Errorf("expected valid to be %t, got %t: %v", c.valid, findings.Valid(), findings)
+			}
+			if findings.Warnings() != c.warnings {
+				t.Errorf("expected %d warnings, got %d: %v", c.warnings, findings.Warnings(), findings)
+			}
+			if len(findings) != len(c.codes) {
+				t.Fatalf("expected %d findings, got %d: %v", len(c.codes), len(findings), findings)
+			}
+			for i, f := range findings {
+				if f.Code != c.codes[i] {
+					t.Errorf("expected finding %d to be %s, got %s", i, c.codes[i], f.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleSheetValidateRunInfo(t *testing.T) {
+	runInfo := interop.RunInfo{
+		RunId: "run1",
+		Reads: []interop.ReadInfo{
+			{Number: 1, Cycles: 151},
+			{Number: 2, Cycles: 10, IsIndex: true},
+			{Number: 3, Cycles: 10, IsIndex: true},
+			{Number: 4, Cycles: 151},
+		},
+	}
+	// The Reads section covers all cycles of the run
+	header := strings.ReplaceAll(validationHeader, "Cycles,8", "Cycles,10")
+	data := `[BCLConvert_Data]
+Sample_ID,Index,Index2
+sample1,ACGTACGT,TTGGCCAA
+`
+
+	cases := []struct {
+		name    string
+		runInfo interop.RunInfo
+		data    string
+		codes   []string
+	}{
+		{
+			name:    "matching override cycles",
+			runInfo: runInfo,
+			data:    header + "OverrideCycles,Y151;I8N2;I8N2;Y151\n" + data,
+		},
+		{
+			name:    "override cycles do not cover the run",
+			runInfo: runInfo,
+			data:    header + "OverrideCycles,Y151;I8;I8;Y151\n" + data,
+			codes:   []string{FindingInvalidValue, FindingInvalidValue, FindingRunInfoMismatch, FindingRunInfoMismatch},
+		},
+		{
+			name: "reads longer than the run",
+			runInfo: interop.RunInfo{
+				RunId: "run1",
+				Reads: []interop.ReadInfo{
+					{Number: 1, Cycles: 101},
+					{Number: 2, Cycles: 8, IsIndex: true},
+					{Number: 3, Cycles: 8, IsIndex: true},
+					{Number: 4, Cycles: 101},
+				},
+			},
+			data:  validationHeader + data,
+			codes: []string{FindingRunInfoMismatch, FindingRunInfoMismatch},
+		},
+		{
+			name: "single index run",
+			runInfo: interop.RunInfo{
+				RunId: "run1",
+				Reads: []interop.ReadInfo{
+					{Number: 1, Cycles: 151},
+					{Number: 2, Cycles: 8, IsIndex: true},
+					{Number: 3, Cycles: 151},
+				},
+			},
+			data:  validationHeader + data,
+			codes: []string{FindingRunInfoMismatch},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(c.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings := sheet.ValidateRunInfo(c.runInfo)
+			if len(findings) != len(c.codes) {
+				t.Fatalf("expected %d findings, got %d: %v", len(c.codes), len(findings), findings)
+			}
+			for i, f := range findings {
+				if f.Code != c.codes[i] {
+					t.Errorf("expected finding %d to be %s, got %s", i, c.codes[i], f.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"ACGT", "ACGT", 0},
+		{"ACGT", "ACGA", 1},
+		{"ACGT", "TGCA", 4},
+		{"ACGTAC", "ACGT", 0},
+		{"", "ACGT", 0},
+	}
+
+	for _, c := range cases {
+		if d := hammingDistance(c.a, c.b); d != c.expected {
+			t.Errorf("expected distance between %q and %q to be %d, got %d", c.a, c.b, c.expected, d)
+		}
+	}
+}
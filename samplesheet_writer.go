@@ -0,0 +1,118 @@
+package cleve
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// sectionApplication returns the application part of a section name, e.g.
+// "BCLConvert" for "BCLConvert_Settings".
+func sectionApplication(name string) string {
+	for _, suffix := range []string{"_Settings", "_Data"} {
+		if app, ok := strings.CutSuffix(name, suffix); ok {
+			return app
+		}
+	}
+	return name
+}
+
+// sectionRank decides the order of sections in a canonical v2 samplesheet.
+func sectionRank(name string) int {
+	switch sectionApplication(name) {
+	case "Header":
+		return 0
+	case "Reads":
+		return 1
+	case "Sequencing":
+		return 2
+	case "BCLConvert":
+		return 3
+	case "Cloud":
+		return 5
+	default:
+		return 4
+	}
+}
+
+// canonicalSections returns the sections of the samplesheet in canonical order:
+// Header, Reads, Sequencing_Settings and BCLConvert sections first, followed by
+// application sections sorted by name and finally Cloud sections. Settings sections
+// come before the data sections of the same application, so the order does not depend
+// on the order of the sections in the samplesheet.
+func (s SampleSheet) canonicalSections() []Section {
+	sections := slices.Clone(s.Sections)
+	slices.SortStableFunc(sections, func(a, b Section) int {
+		if c := sectionRank(a.Name) - sectionRank(b.Name); c != 0 {
+			return c
+		}
+		if c := strings.Compare(sectionApplication(a.Name), sectionApplication(b.Name)); c != 0 {
+			return c
+		}
+		aData := strings.HasSuffix(a.Name, "_Data")
+		bData := strings.HasSuffix(b.Name, "_Data")
+		switch {
+		case !aData && bData:
+			return -1
+		case aData && !bData:
+			return 1
+		}
+		return 0
+	})
+	return sections
+}
+
+// WriteCSV writes the samplesheet as a canonical v2 samplesheet. Sections are written
+// in canonical order with an empty line between them, and FileFormatVersion is always
+// the first key of the Header section. Values cannot contain commas or newlines, since
+// samplesheets do not support quoting.
+func (s SampleSheet) WriteCSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for i, section := range s.canonicalSections() {
+		if i > 0 {
+			if _, err := bw.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(bw, "[%s]\n", section.Name); err != nil {
+			return err
+		}
+		rows := section.Rows
+		if section.Name == "Header" {
+			rows = slices.Clone(rows)
+			slices.SortStableFunc(rows, func(a, b []string) int {
+				switch {
+				case a[0] == "FileFormatVersion" && b[0] != "FileFormatVersion":
+					return -1
+				case a[0] != "FileFormatVersion" && b[0] == "FileFormatVersion":
+					return 1
+				}
+				return 0
+			})
+		}
+		for _, row := range rows {
+			for _, v := range row {
+				if strings.ContainsAny(v, ",\r\n") {
+					return fmt.Errorf("invalid value %q in section %q: values cannot contain commas or newlines", v, section.Name)
+				}
+			}
+			if _, err := bw.WriteString(strings.Join(row, ",") + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// String returns the samplesheet as a canonical v2 samplesheet. It is meant for
+// display only: if the samplesheet cannot be written, an empty string is returned. Use
+// [SampleSheet.WriteCSV] whenever the error matters.
+func (s SampleSheet) String() string {
+	var b strings.Builder
+	if err := s.WriteCSV(&b); err != nil {
+		return ""
+	}
+	return b.String()
+}
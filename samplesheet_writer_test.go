@@ -0,0 +1,145 @@
+package cleve
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteSampleSheet(t *testing.T) {
+	cases := []struct {
+		name     string
+		sheet    SampleSheet
+		expected string
+		err      bool
+	}{
+		{
+			name: "canonical order",
+			sheet: SampleSheet{Sections: []Section{
+				{Name: "Cloud_Data", Type: DataSection, Rows: [][]string{{"Sample_ID", "ProjectName"}, {"s1", "p1"}}},
+				{Name: "TSO500L_Data", Type: DataSection, Rows: [][]string{{"Sample_ID", "Sample_Type"}, {"s1", "DNA"}}},
+				{Name: "BCLConvert_Data", Type: DataSection, Rows: [][]string{{"Sample_ID", "Index"}, {"s1", "ACGT"}}},
+				{Name: "Reads", Type: SettingsSection, Rows: [][]string{{"Read1Cycles", "151"}}},
+				{Name: "TSO500L_Settings", Type: SettingsSection, Rows: [][]string{{"SoftwareVersion", "2.5.2"}}},
+				{Name: "Header", Type: SettingsSection, Rows: [][]string{{"RunName", "run1"}, {"FileFormatVersion", "2"}}},
+				{Name: "BCLConvert_Settings", Type: SettingsSection, Rows: [][]string{{"SoftwareVersion", "4.2.7"}}},
+			}},
+			expected: `[Header]
+FileFormatVersion,2
+RunName,run1
+
+[Reads]
+Read1Cycles,151
+
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+
+[BCLConvert_Data]
+Sample_ID,Index
+s1,ACGT
+
+[TSO500L_Settings]
+SoftwareVersion,2.5.2
+
+[TSO500L_Data]
+Sample_ID,Sample_Type
+s1,DNA
+
+[Cloud_Data]
+Sample_ID,ProjectName
+s1,p1
+`,
+		},
+		{
+			name: "comma in value",
+			sheet: SampleSheet{Sections: []Section{
+				{Name: "Header", Type: SettingsSection, Rows: [][]string{{"RunDescription", "a, b"}}},
+			}},
+			err: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var b strings.Builder
+			err := c.sheet.WriteCSV(&b)
+			if (err != nil) != c.err {
+				t.Fatalf("expected error to be %t, got %v", c.err, err)
+			}
+			if c.err {
+				return
+			}
+			if b.String() != c.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", c.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestWriteSampleSheetRoundTrip(t *testing.T) {
+	data := `[Header]
+RunName,TestRun
+FileFormatVersion,2
+InstrumentPlatform,NovaSeqXSeries
+[Reads]
+Read1Cycles,151
+Read2Cycles,151
+Index1Cycles,10
+Index2Cycles,10
+[BCLConvert_Settings]
+SoftwareVersion,4.2.7
+AdapterRead1,CTGTCTCTTATACACATCT
+[BCLConvert_Data]
+Lane,Sample_ID,Index,Index2
+1,sample1,ACGTACGTAC,TTGGCCAATT
+1,sample2,TGCATGCATG,GGTTAACCGG
+[Cloud_Data]
+Sample_ID,ProjectName,LibraryName
+sample1,project1,sample1_lib
+sample2,project1,sample2_lib
+[DragenGermline_Settings]
+SoftwareVersion,4.2.7
+MapAlignOutFormat,cram
+[DragenGermline_Data]
+Sample_ID,ReferenceGenomeDir
+sample1,hg38
+sample2,hg38
+`
+	sheet, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := sheet.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSampleSheet(bufio.NewReader(strings.NewReader(b.String())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Sections) != len(sheet.Sections) {
+		t.Fatalf("expected %d sections, got %d", len(sheet.Sections), len(parsed.Sections))
+	}
+	for _, section := range sheet.Sections {
+		other := parsed.Section(section.Name)
+		if other == nil {
+			t.Fatalf("section %q missing after round trip", section.Name)
+		}
+		if section.Type != other.Type {
+			t.Errorf("expected %q to be of type %s, got %s", section.Name, section.Type, other.Type)
+		}
+		if section.Name != "Header" && !reflect.DeepEqual(section.Rows, other.Rows) {
+			t.Errorf("rows of %q differ after round trip:\n%v\n%v", section.Name, section.Rows, other.Rows)
+		}
+	}
+	if v, _ := parsed.Section("Header").Get("FileFormatVersion"); v != "2" {
+		t.Errorf("expected FileFormatVersion 2, got %q", v)
+	}
+	if parsed.Sections[len(parsed.Sections)-1].Name != "Cloud_Data" {
+		t.Errorf("expected Cloud_Data to be the last section, got %q", parsed.Sections[len(parsed.Sections)-1].Name)
+	}
+	if b.String() != parsed.String() {
+		t.Error("writing a parsed canonical samplesheet is not idempotent")
+	}
+}
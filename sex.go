@@ -0,0 +1,235 @@
+package cleve
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Limits used when inferring sex from coverage. The ratios are the average coverage
+// of X and Y relative to the average autosomal coverage, which is about 1 and 0 for
+// females, and about 0.5 and 0.5 for males.
+const (
+	femaleMinXRatio = 0.8
+	femaleMaxYRatio = 0.1
+	maleMaxXRatio   = 0.65
+	maleMinYRatio   = 0.2
+)
+
+// Limits used when inferring sex from the fraction of heterozygous variants on the
+// non-pseudoautosomal part of X, which should be close to 0 for males.
+const (
+	maleMaxXHeterozygosity   = 0.15
+	femaleMinXHeterozygosity = 0.3
+	minXVariants             = 20
+)
+
+// The non-pseudoautosomal region of X, in coordinates that exclude the pseudoautosomal
+// regions in both GRCh37 and GRCh38.
+const (
+	xNonParStart = 2781480
+	xNonParEnd   = 154931043
+)
+
+// SexMethod is the data that a sex was inferred from.
+type SexMethod string
+
+const (
+	SexMethodCoverage       SexMethod = "coverage"
+	SexMethodHeterozygosity SexMethod = "x_heterozygosity"
+)
+
+// InferSexFromCoverage infers sex from the X and Y coverage relative to autosomal
+// coverage. If the Y ratio is not available, for example for panels without targets
+// on Y, the sex is inferred from the X ratio alone. If the ratios are ambiguous,
+// [SexUnknown] is returned.
+func InferSexFromCoverage(xRatio, yRatio float64) Sex {
+	if math.IsNaN(xRatio) {
+		return SexUnknown
+	}
+	if math.IsNaN(yRatio) {
+		switch {
+		case xRatio >= femaleMinXRatio:
+			return SexFemale
+		case xRatio <= maleMaxXRatio:
+			return SexMale
+		}
+		return SexUnknown
+	}
+	switch {
+	case xRatio >= femaleMinXRatio && yRatio <= femaleMaxYRatio:
+		return SexFemale
+	case xRatio <= maleMaxXRatio && yRatio >= maleMinYRatio:
+		return SexMale
+	}
+	return SexUnknown
+}
+
+// InferSexFromHeterozygosity infers sex from the fraction of heterozygous variants on
+// the non-pseudoautosomal part of X. If there are too few variants, or if the fraction
+// is ambiguous, [SexUnknown] is returned.
+func InferSexFromHeterozygosity(heterozygosity float64, variants int) Sex {
+	if variants < minXVariants || math.IsNaN(heterozygosity) {
+		return SexUnknown
+	}
+	switch {
+	case heterozygosity <= maleMaxXHeterozygosity:
+		return SexMale
+	case heterozygosity >= femaleMinXHeterozygosity:
+		return SexFemale
+	}
+	return SexUnknown
+}
+
+// XHeterozygosity returns the fraction of heterozygous variants among the variant
+// genotypes of a sample on the non-pseudoautosomal part of X, together with the number
+// of variant genotypes. Only records that passed filtering are considered. Haploid
+// alternate calls, which callers emit for the hemizygous X of males, count as
+// homozygous alternate.
+func XHeterozygosity(r io.Reader, sampleId string) (float64, int, error) {
+	sampleCol := -1
+	het, homAlt := 0, 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "##") || line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#CHROM") {
+			col, err := VcfSampleColumn(line, sampleId)
+			if err != nil {
+				return math.NaN(), 0, err
+			}
+			sampleCol = col
+			continue
+		}
+		if sampleCol < 0 {
+			return math.NaN(), 0, fmt.Errorf("missing vcf header")
+		}
+		chrom, rest, _ := strings.Cut(line, "\t")
+		if strings.TrimPrefix(chrom, "chr") != "X" {
+			continue
+		}
+		fields := strings.SplitN(rest, "\t", sampleCol+1)
+		if len(fields) < sampleCol {
+			continue
+		}
+		pos, err := strconv.Atoi(fields[0])
+		if err != nil || pos < xNonParStart || pos > xNonParEnd {
+			continue
+		}
+		if filter := fields[5]; filter != "PASS" && filter != "." {
+			continue
+		}
+		alleles := VcfGenotype(fields[7], fields[sampleCol-1])
+		if len(alleles) == 1 {
+			if alleles[0] != "." && alleles[0] != "0" {
+				homAlt++
+			}
+			continue
+		}
+		if len(alleles) != 2 || alleles[0] == "." || alleles[1] == "." {
+			continue
+		}
+		switch {
+		case alleles[0] != alleles[1]:
+			het++
+		case alleles[0] != "0":
+			homAlt++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return math.NaN(), 0, err
+	}
+	n := het + homAlt
+	if n == 0 {
+		return math.NaN(), 0, nil
+	}
+	return float64(het) / float64(n), n, nil
+}
+
+// DeclaredSex returns the sex of the sample as declared in the `sex` metadata
+// property. If the sex has not been declared, [SexUnknown] is returned.
+func (s Sample) DeclaredSex() Sex {
+	v, ok := s.Metadata["sex"].(string)
+	if !ok {
+		return SexUnknown
+	}
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "male", "m":
+		return SexMale
+	case "female", "f":
+		return SexFemale
+	}
+	return SexUnknown
+}
+
+// SexCheckStatus is the outcome of comparing the declared and inferred sex of a sample.
+type SexCheckStatus string
+
+const (
+	SexCheckMatch    SexCheckStatus = "match"
+	SexCheckMismatch SexCheckStatus = "mismatch"
+	// SexCheckUnknown means that either the declared or the inferred sex is unknown.
+	SexCheckUnknown SexCheckStatus = "unknown"
+)
+
+// SexCheck is the comparison of the declared sex of a sample with the sex inferred
+// from an analysis of the sample.
+type SexCheck struct {
+	SampleId   string         `json:"sample_id"`
+	RunId      string         `json:"run_id"`
+	AnalysisId string         `json:"analysis_id"`
+	Declared   Sex            `json:"declared"`
+	Inferred   Sex            `json:"inferred"`
+	Method     SexMethod      `json:"method,omitempty"`
+	Status     SexCheckStatus `json:"status"`
+}
+
+// NewSexCheck compares the declared sex with the sex inferred in the sample metrics.
+func NewSexCheck(declared Sex, metrics DragenSampleMetrics) SexCheck {
+	inferred := metrics.InferredSex
+	if inferred == "" {
+		inferred = SexUnknown
+	}
+	c := SexCheck{
+		SampleId:   metrics.SampleId,
+		RunId:      metrics.RunId,
+		AnalysisId: metrics.AnalysisId.String(),
+		Declared:   declared,
+		Inferred:   inferred,
+		Method:     metrics.SexMethod,
+		Status:     SexCheckUnknown,
+	}
+	if declared != SexUnknown && inferred != SexUnknown {
+		if declared == inferred {
+			c.Status = SexCheckMatch
+		} else {
+			c.Status = SexCheckMismatch
+		}
+	}
+	return c
+}
+
+// SexChecks compares the declared sex of the samples with the inferred sex in the
+// sample metrics. There is one check per sample metrics, in the same order. Metrics
+// for samples that are not in samples have an unknown declared sex.
+func SexChecks(metrics []DragenSampleMetrics, samples []Sample) []SexCheck {
+	declared := make(map[string]Sex, len(samples))
+	for _, s := range samples {
+		declared[s.Id] = s.DeclaredSex()
+	}
+	checks := make([]SexCheck, len(metrics))
+	for i, m := range metrics {
+		sex, ok := declared[m.SampleId]
+		if !ok {
+			sex = SexUnknown
+		}
+		checks[i] = NewSexCheck(sex, m)
+	}
+	return checks
+}
@@ -0,0 +1,173 @@
+package cleve
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// mockXVcf returns a VCF with the given number of heterozygous and homozygous
+// alternate calls on X. Every other homozygous alternate call is haploid, as for the
+// hemizygous X of males.
+func mockXVcf(sample string, het, homAlt int) string {
+	var b strings.Builder
+	b.WriteString("##fileformat=VCFv4.2\n")
+	b.WriteString("#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\t" + sample + "\n")
+	// Variants in the pseudoautosomal region and on autosomes should be ignored
+	b.WriteString("chrX\t100000\t.\tA\tG\t50\tPASS\t.\tGT:DP\t0/1:30\n")
+	b.WriteString("chr1\t100000\t.\tA\tG\t50\tPASS\t.\tGT:DP\t0/1:30\n")
+	b.WriteString("chrX\t3000000\t.\tA\tG\t50\tLowQual\t.\tGT:DP\t0/1:30\n")
+	pos := 3000000
+	for range het {
+		pos += 1000
+		fmt.Fprintf(&b, "chrX\t%d\t.\tA\tG\t50\tPASS\t.\tGT:DP\t0/1:30\n", pos)
+	}
+	for i := range homAlt {
+		pos += 1000
+		gt := "1|1"
+		if i%2 == 1 {
+			gt = "1"
+		}
+		fmt.Fprintf(&b, "chrX\t%d\t.\tA\tG\t50\tPASS\t.\tGT:DP\t%s:30\n", pos, gt)
+	}
+	// Haploid reference and missing calls are not variant genotypes
+	fmt.Fprintf(&b, "chrX\t%d\t.\tA\tG\t50\tPASS\t.\tGT:DP\t0:30\n", pos+1000)
+	fmt.Fprintf(&b, "chrX\t%d\t.\tA\tG\t50\tPASS\t.\tGT:DP\t.:30\n", pos+2000)
+	return b.String()
+}
+
+func TestInferSexFromCoverage(t *testing.T) {
+	testcases := []struct {
+		name string
+		x    float64
+		y    float64
+		sex  Sex
+	}{
+		{"female", 1.0, 0.002, SexFemale},
+		{"male", 0.5, 0.45, SexMale},
+		{"female without y", 0.95, math.NaN(), SexFemale},
+		{"male without y", 0.5, math.NaN(), SexMale},
+		{"ambiguous", 0.75, 0.1, SexUnknown},
+		{"xxy", 1.0, 0.5, SexUnknown},
+		{"no coverage", math.NaN(), math.NaN(), SexUnknown},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			if sex := InferSexFromCoverage(c.x, c.y); sex != c.sex {
+				t.Errorf("expected %q, got %q", c.sex, sex)
+			}
+		})
+	}
+}
+
+func TestXHeterozygosity(t *testing.T) {
+	testcases := []struct {
+		name           string
+		het            int
+		homAlt         int
+		heterozygosity float64
+		sex            Sex
+	}{
+		{"female", 30, 30, 0.5, SexFemale},
+		{"male", 1, 39, 0.025, SexMale},
+		{"male with haploid calls only", 0, 2 * minXVariants, 0, SexMale},
+		{"too few variants", 5, 5, 0.5, SexUnknown},
+		{"no variants", 0, 0, math.NaN(), SexUnknown},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			het, n, err := XHeterozygosity(strings.NewReader(mockXVcf("sample1", c.het, c.homAlt)), "sample1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != c.het+c.homAlt {
+				t.Errorf("expected %d variants, got %d", c.het+c.homAlt, n)
+			}
+			if het != c.heterozygosity && !(math.IsNaN(het) && math.IsNaN(c.heterozygosity)) {
+				t.Errorf("expected heterozygosity %f, got %f", c.heterozygosity, het)
+			}
+			if sex := InferSexFromHeterozygosity(het, n); sex != c.sex {
+				t.Errorf("expected %q, got %q", c.sex, sex)
+			}
+		})
+	}
+}
+
+func TestAddXHeterozygosity(t *testing.T) {
+	m := NewDragenSampleMetrics("sample1")
+	if err := m.AddXHeterozygosity(strings.NewReader(mockXVcf("sample1", 1, 39))); err != nil {
+		t.Fatal(err)
+	}
+	if m.InferredSex != SexMale || m.SexMethod != SexMethodHeterozygosity {
+		t.Errorf("expected male from heterozygosity, got %q from %q", m.InferredSex, m.SexMethod)
+	}
+
+	// Coverage takes precedence
+	m = NewDragenSampleMetrics("sample1")
+	m.AddSexChromosomeCoverage(DragenMetrics{
+		{Section: "COVERAGE SUMMARY", Name: "XAvgCov/AutosomalAvgCov ratio over genome", Value: "0.99"},
+		{Section: "COVERAGE SUMMARY", Name: "YAvgCov/AutosomalAvgCov ratio over genome", Value: "0.00"},
+	})
+	if err := m.AddXHeterozygosity(strings.NewReader(mockXVcf("sample1", 1, 39))); err != nil {
+		t.Fatal(err)
+	}
+	if m.InferredSex != SexFemale || m.SexMethod != SexMethodCoverage {
+		t.Errorf("expected female from coverage, got %q from %q", m.InferredSex, m.SexMethod)
+	}
+}
+
+func TestDeclaredSex(t *testing.T) {
+	testcases := []struct {
+		metadata map[string]any
+		sex      Sex
+	}{
+		{map[string]any{"sex": "female"}, SexFemale},
+		{map[string]any{"sex": "M"}, SexMale},
+		{map[string]any{"sex": " Male "}, SexMale},
+		{map[string]any{"sex": "other"}, SexUnknown},
+		{map[string]any{"sex": 1}, SexUnknown},
+		{nil, SexUnknown},
+	}
+	for _, c := range testcases {
+		s := Sample{Id: "sample1", Metadata: c.metadata}
+		if sex := s.DeclaredSex(); sex != c.sex {
+			t.Errorf("%v: expected %q, got %q", c.metadata, c.sex, sex)
+		}
+	}
+}
+
+func TestSexChecks(t *testing.T) {
+	metrics := []DragenSampleMetrics{
+		{SampleId: "sample1", InferredSex: SexFemale},
+		{SampleId: "sample2", InferredSex: SexFemale},
+		{SampleId: "sample3", InferredSex: SexUnknown},
+		{SampleId: "sample4", InferredSex: SexMale},
+		{SampleId: "sample5"},
+	}
+	samples := []Sample{
+		{Id: "sample1", Metadata: map[string]any{"sex": "female"}},
+		{Id: "sample2", Metadata: map[string]any{"sex": "male"}},
+		{Id: "sample3", Metadata: map[string]any{"sex": "male"}},
+		{Id: "sample5", Metadata: map[string]any{"sex": "male"}},
+	}
+	expected := []SexCheckStatus{SexCheckMatch, SexCheckMismatch, SexCheckUnknown, SexCheckUnknown, SexCheckUnknown}
+	checks := SexChecks(metrics, samples)
+	if len(checks) != len(expected) {
+		t.Fatalf("expected %d checks, got %d", len(expected), len(checks))
+	}
+	for i, c := range checks {
+		if c.SampleId != metrics[i].SampleId {
+			t.Errorf("expected check %d for %s, got %s", i, metrics[i].SampleId, c.SampleId)
+		}
+		if c.Status != expected[i] {
+			t.Errorf("%s: expected %q, got %q", c.SampleId, expected[i], c.Status)
+		}
+	}
+	if checks[3].Declared != SexUnknown {
+		t.Errorf("expected unknown declared sex for sample without metadata, got %q", checks[3].Declared)
+	}
+	if checks[4].Inferred != SexUnknown {
+		t.Errorf("expected unknown inferred sex for old metrics, got %q", checks[4].Inferred)
+	}
+}
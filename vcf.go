@@ -0,0 +1,76 @@
+package cleve
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type vcfFile struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (f vcfFile) Close() error {
+	var err error
+	for _, c := range f.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// OpenVcf opens a VCF file for reading. Files that are gzip or bgzip compressed are
+// decompressed transparently.
+func OpenVcf(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return vcfFile{Reader: gz, closers: []io.Closer{gz, f}}, nil
+	}
+	return vcfFile{Reader: br, closers: []io.Closer{f}}, nil
+}
+
+// VcfSampleColumn returns the index of the column for a sample given the #CHROM
+// header line of a VCF file. If the VCF has a single sample it is used regardless
+// of its name.
+func VcfSampleColumn(header string, sampleId string) (int, error) {
+	columns := strings.Split(header, "\t")
+	if len(columns) < 10 {
+		return -1, fmt.Errorf("vcf has no samples")
+	}
+	if len(columns) == 10 {
+		return 9, nil
+	}
+	for i, name := range columns[9:] {
+		if name == sampleId {
+			return i + 9, nil
+		}
+	}
+	return -1, fmt.Errorf("sample %s not found in vcf", sampleId)
+}
+
+// VcfGenotype returns the alleles of the GT field of a sample, given the FORMAT
+// column and the sample column of a VCF record.
+func VcfGenotype(format, sample string) []string {
+	keys := strings.Split(format, ":")
+	values := strings.Split(sample, ":")
+	for i, k := range keys {
+		if k == "GT" && i < len(values) {
+			return strings.FieldsFunc(values[i], func(r rune) bool { return r == '/' || r == '|' })
+		}
+	}
+	return nil
+}
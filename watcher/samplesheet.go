@@ -0,0 +1,252 @@
+package watcher
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mongo"
+)
+
+type sampleSheetHandler interface {
+	SampleSheet(...mongo.SampleSheetOption) (cleve.SampleSheet, error)
+}
+
+// SampleSheetWatcherEvent is emitted for a run when samplesheet files have been added
+// or modified since they were last ingested. Files are sorted by modification time,
+// oldest first. Analyses are the Dragen analyses of the run whose samplesheets have
+// been added or modified.
+type SampleSheetWatcherEvent struct {
+	RunId    string
+	Path     string
+	Files    []cleve.SampleSheetInfo
+	Analyses []*cleve.Analysis
+}
+
+// SampleSheetWatcher watches for new or modified samplesheets in run directories, i.e.
+// `<run>/SampleSheet*.csv`, and in the Data directories of the Dragen analyses of the
+// runs. The samplesheets of analyses belong to the analyses, and are reported
+// separately from those of the run. Runs that are being moved or have been moved are
+// not checked, and neither are runs sequenced longer than MaxAge ago.
+type SampleSheetWatcher struct {
+	PollInterval time.Duration
+	// MaxAge is how far back in time runs are checked. If zero, all runs are checked.
+	MaxAge time.Duration
+
+	store interface {
+		runHandler
+		analysisHandler
+		sampleSheetHandler
+	}
+	runFilter cleve.RunFilter
+	logger    *slog.Logger
+
+	quit chan struct{}
+	done chan struct{}
+	emit chan []SampleSheetWatcherEvent
+}
+
+// NewSampleSheetWatcher creates a new SampleSheetWatcher.
+func NewSampleSheetWatcher(
+	pollInterval time.Duration,
+	maxAge time.Duration,
+	db interface {
+		runHandler
+		analysisHandler
+		sampleSheetHandler
+	},
+	logger *slog.Logger,
+) SampleSheetWatcher {
+	filter := cleve.NewRunFilter()
+	filter.PageSize = 30
+	return SampleSheetWatcher{
+		PollInterval: pollInterval,
+		MaxAge:       maxAge,
+		store:        db,
+		runFilter:    filter,
+		logger:       logger,
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+		emit:         make(chan []SampleSheetWatcherEvent, 1),
+	}
+}
+
+func (w *SampleSheetWatcher) Start() chan []SampleSheetWatcherEvent {
+	w.logger.Info("starting samplesheet watcher", "poll_interval", w.PollInterval, "max_age", w.MaxAge)
+	go w.start()
+	return w.emit
+}
+
+func (w *SampleSheetWatcher) start() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.Poll()
+		case <-w.quit:
+			close(w.emit)
+			return
+		}
+	}
+}
+
+func (w *SampleSheetWatcher) Stop() {
+	w.logger.Info("stopping samplesheet watcher, waiting for current poll (if any) finishes")
+	close(w.quit)
+	<-w.done
+	w.logger.Info("samplesheet watcher stopped")
+}
+
+func (w *SampleSheetWatcher) Poll() {
+	w.logger.Debug("samplesheet watcher start poll")
+	w.runFilter.Page = 1
+	if w.MaxAge > 0 {
+		w.runFilter.From = time.Now().Add(-w.MaxAge)
+	}
+	events := make([]SampleSheetWatcherEvent, 0)
+	for {
+		w.logger.Debug("fetching runs", "page", w.runFilter.Page)
+		runs, err := w.store.Runs(w.runFilter)
+		if err != nil {
+			w.logger.Error("failed to get runs", "error", err)
+		}
+		if runs.Count == 0 {
+			w.logger.Debug("no runs, bail out")
+			break
+		}
+		for _, r := range runs.Runs {
+			if r.StateHistory.LastState().IsMoved() || r.Path == "" {
+				continue
+			}
+			files, err := w.changedSampleSheets(r)
+			if err != nil {
+				w.logger.Error("failed to check samplesheets", "run_id", r.RunID, "error", err)
+				continue
+			}
+			analyses, err := w.changedAnalysisSampleSheets(r)
+			if err != nil {
+				w.logger.Error("failed to check analysis samplesheets", "run_id", r.RunID, "error", err)
+			}
+			if len(files) > 0 || len(analyses) > 0 {
+				w.logger.Info("samplesheet changes found", "run_id", r.RunID, "files", len(files), "analyses", len(analyses))
+				events = append(events, SampleSheetWatcherEvent{
+					RunId:    r.RunID,
+					Path:     r.Path,
+					Files:    files,
+					Analyses: analyses,
+				})
+			}
+		}
+		if w.runFilter.Page >= runs.TotalPages {
+			break
+		}
+		w.runFilter.Page += 1
+	}
+	if len(events) > 0 {
+		w.logger.Debug("emitting samplesheet watcher events", "count", len(events))
+		w.emit <- events
+	}
+	w.logger.Debug("samplesheet watcher end poll")
+}
+
+// changedSampleSheets returns the samplesheet files for a run that are either unknown
+// or have been modified since they were last ingested.
+func (w *SampleSheetWatcher) changedSampleSheets(r *cleve.Run) ([]cleve.SampleSheetInfo, error) {
+	sampleSheet, err := w.store.SampleSheet(mongo.SampleSheetWithRunId(r.RunID))
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	files, err := SampleSheetFiles(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	changed := make([]cleve.SampleSheetInfo, 0)
+	for _, f := range files {
+		if !sampleSheet.IsModified(f) {
+			continue
+		}
+		w.logger.Debug("changed samplesheet", "run_id", r.RunID, "path", f.Path, "modification_time", f.ModificationTime)
+		changed = append(changed, f)
+	}
+	return changed, nil
+}
+
+// changedAnalysisSampleSheets returns the analyses of a run whose samplesheets are
+// either unknown or have been modified since they were last ingested.
+func (w *SampleSheetWatcher) changedAnalysisSampleSheets(r *cleve.Run) ([]*cleve.Analysis, error) {
+	filter := cleve.NewAnalysisFilter()
+	filter.PageSize = 0 // Disable pagination
+	filter.RunId = r.RunID
+	analyses, err := w.store.Analyses(filter)
+	if err != nil {
+		return nil, err
+	}
+	changed := make([]*cleve.Analysis, 0)
+	for _, a := range analyses.Analyses {
+		path := a.SampleSheetPath()
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			path, err = filepath.Abs(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var stored cleve.SampleSheet
+		if a.SampleSheetId != nil {
+			stored, err = w.store.SampleSheet(mongo.SampleSheetWithUuid(a.SampleSheetId.String()))
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, err
+			}
+		}
+		if !stored.IsModified(cleve.SampleSheetInfo{Path: path, ModificationTime: info.ModTime()}) {
+			continue
+		}
+		w.logger.Debug("changed analysis samplesheet", "run_id", r.RunID, "analysis_id", a.AnalysisId, "path", path, "modification_time", info.ModTime())
+		changed = append(changed, a)
+	}
+	return changed, nil
+}
+
+// SampleSheetFiles finds all samplesheets in the run directory. Samplesheets of Dragen
+// analyses are not included, since they may only cover the samples of the analysis.
+// Paths are absolute, and the files are sorted by modification time, oldest first.
+func SampleSheetFiles(runPath string) ([]cleve.SampleSheetInfo, error) {
+	matches, err := filepath.Glob(filepath.Join(runPath, "SampleSheet*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]cleve.SampleSheetInfo, 0)
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		path, err := filepath.Abs(m)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, cleve.SampleSheetInfo{Path: path, ModificationTime: info.ModTime()})
+	}
+	slices.SortStableFunc(files, func(a, b cleve.SampleSheetInfo) int {
+		return a.ModificationTime.Compare(b.ModificationTime)
+	})
+	return files, nil
+}
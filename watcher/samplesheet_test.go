@@ -0,0 +1,219 @@
+package watcher
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gmc-norr/cleve"
+	"github.com/gmc-norr/cleve/mock"
+	"github.com/gmc-norr/cleve/mongo"
+	"github.com/google/uuid"
+)
+
+// writeSampleSheets writes two samplesheets to the run directory, and one to the Data
+// directory of an analysis. Only the paths of the samplesheets in the run directory are
+// returned.
+func writeSampleSheets(t *testing.T, runPath string, modtime time.Time) []string {
+	t.Helper()
+	paths := []string{
+		filepath.Join(runPath, "SampleSheet.csv"),
+		filepath.Join(runPath, "SampleSheet_updated.csv"),
+		filepath.Join(runPath, "Analysis", "1", "Data", "SampleSheet.csv"),
+	}
+	for i, p := range paths {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("[Header]\nFileFormatVersion,2\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mt := modtime.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(p, mt, mt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths[:2]
+}
+
+func TestSampleSheetFiles(t *testing.T) {
+	runPath := t.TempDir()
+	modtime := time.Now().Add(-time.Hour)
+	paths := writeSampleSheets(t, runPath, modtime)
+	if err := os.WriteFile(filepath.Join(runPath, "RunInfo.xml"), []byte{}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := SampleSheetFiles(runPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(paths) {
+		t.Fatalf("expected %d files, got %d", len(paths), len(files))
+	}
+	for i, f := range files {
+		if f.Path != paths[i] {
+			t.Errorf("expected file %d to be %q, got %q", i, paths[i], f.Path)
+		}
+	}
+}
+
+func TestSampleSheetWatcher(t *testing.T) {
+	modtime := time.Now().Add(-time.Hour)
+
+	testcases := []struct {
+		name     string
+		stored   func(paths []string) []cleve.SampleSheetInfo
+		state    cleve.State
+		noPath   bool
+		changed  int
+		analyses int
+	}{
+		{
+			name:     "no stored samplesheet",
+			state:    cleve.StateReady,
+			changed:  2,
+			analyses: 1,
+		},
+		{
+			name:  "unchanged samplesheets",
+			state: cleve.StateReady,
+			stored: func(paths []string) []cleve.SampleSheetInfo {
+				return []cleve.SampleSheetInfo{
+					{Path: paths[0], ModificationTime: modtime.Truncate(time.Millisecond)},
+					{Path: paths[1], ModificationTime: modtime.Add(time.Minute).Truncate(time.Millisecond)},
+					{Path: paths[2], ModificationTime: modtime.Add(2 * time.Minute).Truncate(time.Millisecond)},
+				}
+			},
+			changed: 0,
+		},
+		{
+			name:  "one modified samplesheet",
+			state: cleve.StateReady,
+			stored: func(paths []string) []cleve.SampleSheetInfo {
+				return []cleve.SampleSheetInfo{
+					{Path: paths[0], ModificationTime: modtime.Truncate(time.Millisecond)},
+					{Path: paths[1], ModificationTime: modtime.Truncate(time.Millisecond)},
+					{Path: paths[2], ModificationTime: modtime.Add(2 * time.Minute).Truncate(time.Millisecond)},
+				}
+			},
+			changed: 1,
+		},
+		{
+			name:  "one new samplesheet",
+			state: cleve.StateReady,
+			stored: func(paths []string) []cleve.SampleSheetInfo {
+				return []cleve.SampleSheetInfo{
+					{Path: paths[0], ModificationTime: modtime.Truncate(time.Millisecond)},
+					{Path: paths[2], ModificationTime: modtime.Add(2 * time.Minute).Truncate(time.Millisecond)},
+				}
+			},
+			changed: 1,
+		},
+		{
+			name:  "modified analysis samplesheet",
+			state: cleve.StateReady,
+			stored: func(paths []string) []cleve.SampleSheetInfo {
+				return []cleve.SampleSheetInfo{
+					{Path: paths[0], ModificationTime: modtime.Truncate(time.Millisecond)},
+					{Path: paths[1], ModificationTime: modtime.Add(time.Minute).Truncate(time.Millisecond)},
+					{Path: paths[2], ModificationTime: modtime.Truncate(time.Millisecond)},
+				}
+			},
+			analyses: 1,
+		},
+		{
+			name:    "moved run",
+			state:   cleve.StateMoved,
+			changed: 0,
+		},
+		{
+			name:    "run without path",
+			state:   cleve.StateReady,
+			noPath:  true,
+			changed: 0,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			runPath := t.TempDir()
+			paths := writeSampleSheets(t, runPath, modtime)
+			run := &cleve.Run{
+				RunID:        "run1",
+				Path:         runPath,
+				StateHistory: cleve.StateHistory{{Time: time.Now(), State: c.state}},
+			}
+			if c.noPath {
+				run.Path = ""
+			}
+			sampleSheetId := uuid.New()
+			analysis := &cleve.Analysis{
+				AnalysisId:    uuid.New(),
+				Runs:          []string{run.RunID},
+				Path:          filepath.Join(runPath, "Analysis", "1"),
+				Software:      "Dragen BCLConvert",
+				SampleSheetId: &sampleSheetId,
+			}
+			paths = append(paths, filepath.Join(analysis.Path, "Data", "SampleSheet.csv"))
+
+			db := mock.SampleSheetHandler{}
+			db.RunsFn = func(filter cleve.RunFilter) (cleve.RunResult, error) {
+				return cleve.RunResult{
+					PaginationMetadata: cleve.PaginationMetadata{
+						Count:      1,
+						TotalCount: 1,
+						Page:       filter.Page,
+						PageSize:   filter.PageSize,
+						TotalPages: 1,
+					},
+					Runs: []*cleve.Run{run},
+				}, nil
+			}
+			db.SampleSheetFn = func(opts ...mongo.SampleSheetOption) (cleve.SampleSheet, error) {
+				if c.stored == nil {
+					return cleve.SampleSheet{}, mongo.ErrNoDocuments
+				}
+				return cleve.SampleSheet{Files: c.stored(paths)}, nil
+			}
+			db.AnalysesFn = func(filter cleve.AnalysisFilter) (cleve.AnalysisResult, error) {
+				if filter.RunId != run.RunID {
+					return cleve.AnalysisResult{}, nil
+				}
+				return cleve.AnalysisResult{Analyses: []*cleve.Analysis{analysis}}, nil
+			}
+
+			w := NewSampleSheetWatcher(time.Minute, 0, &db, logger)
+			eventCh := w.Start()
+			defer w.Stop()
+
+			go w.Poll()
+			events, err := tryConsumeChannel(eventCh, 10, 10*time.Millisecond)
+			if c.changed == 0 && c.analyses == 0 {
+				if err == nil {
+					t.Fatalf("expected no events, got %d", len(events))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(events))
+			}
+			if events[0].RunId != "run1" {
+				t.Errorf("expected run id run1, got %s", events[0].RunId)
+			}
+			if len(events[0].Files) != c.changed {
+				t.Errorf("expected %d changed files, got %d", c.changed, len(events[0].Files))
+			}
+			if len(events[0].Analyses) != c.analyses {
+				t.Errorf("expected %d changed analysis samplesheets, got %d", c.analyses, len(events[0].Analyses))
+			}
+		})
+	}
+}
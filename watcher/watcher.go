@@ -1,6 +1,7 @@
 package watcher
 
 import (
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gmc-norr/cleve"
+	"github.com/spf13/viper"
 )
 
 type runHandler interface {
@@ -135,37 +137,106 @@ type AnalysisWatcherEvent struct {
 	StateChanged bool
 }
 
-type DragenAnalysisWatcher struct {
+// AnalysisRoot is a directory where the analysis watcher looks for analyses.
+type AnalysisRoot struct {
+	// Path is the directory to look in, and it may contain glob patterns. Relative
+	// paths are resolved against the directory of each ready run, and the analyses
+	// found there belong to the run. Absolute paths are not associated with any run.
+	Path string `mapstructure:"path"`
+	// Detectors are the names of the analysis detectors to try for each directory, in
+	// order. The first detector that matches a directory is used.
+	Detectors []string `mapstructure:"detectors"`
+	// Depth is the number of levels below the root where analyses are looked for.
+	// The default is 1, i.e. only the directories directly under the root.
+	Depth int `mapstructure:"depth"`
+}
+
+// Validate checks that the root has a path and that all of its detectors are
+// registered. A depth of 0 is replaced by the default depth.
+func (r *AnalysisRoot) Validate() error {
+	if r.Path == "" {
+		return fmt.Errorf("analysis root is missing a path")
+	}
+	if len(r.Detectors) == 0 {
+		return fmt.Errorf("analysis root %s has no detectors", r.Path)
+	}
+	for _, name := range r.Detectors {
+		if _, ok := cleve.AnalysisDetectorByName(name); !ok {
+			return fmt.Errorf("analysis root %s: unknown detector %q, valid detectors are %s", r.Path, name, strings.Join(cleve.AnalysisDetectorNames(), ", "))
+		}
+	}
+	if r.Depth < 0 {
+		return fmt.Errorf("analysis root %s: depth must be positive", r.Path)
+	}
+	if r.Depth == 0 {
+		r.Depth = 1
+	}
+	return nil
+}
+
+// DefaultAnalysisRoots returns the roots used when nothing else has been configured,
+// which are the Dragen BCLConvert analyses in the Analysis directory of the runs.
+func DefaultAnalysisRoots() []AnalysisRoot {
+	return []AnalysisRoot{
+		{Path: "Analysis", Detectors: []string{"dragen_bclconvert"}, Depth: 1},
+	}
+}
+
+// AnalysisRootsFromConfig returns the roots defined under `analysis_roots` in the
+// configuration. If no roots are configured, the default roots are returned.
+func AnalysisRootsFromConfig() ([]AnalysisRoot, error) {
+	if !viper.IsSet("analysis_roots") {
+		return DefaultAnalysisRoots(), nil
+	}
+	var roots []AnalysisRoot
+	if err := viper.UnmarshalKey("analysis_roots", &roots); err != nil {
+		return nil, fmt.Errorf("invalid analysis roots: %w", err)
+	}
+	for i := range roots {
+		if err := roots[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return roots, nil
+}
+
+// AnalysisWatcher looks for new analyses, and for state changes of existing
+// analyses, in a set of analysis roots. The kinds of analyses are decided by the
+// analysis detectors of each root.
+type AnalysisWatcher struct {
 	PollInterval time.Duration
 
 	store interface {
 		runHandler
 		analysisHandler
 	}
-	analysisRoot string
-	runFilter    cleve.RunFilter
-	logger       *slog.Logger
+	roots     []AnalysisRoot
+	runFilter cleve.RunFilter
+	logger    *slog.Logger
 
 	quit chan struct{}
 	done chan struct{}
 	emit chan []AnalysisWatcherEvent
 }
 
-func NewDragenAnalysisWatcher(
+// NewAnalysisWatcher creates a new AnalysisWatcher. The roots are assumed to be valid,
+// see [AnalysisRoot.Validate].
+func NewAnalysisWatcher(
 	pollInterval time.Duration,
 	db interface {
 		runHandler
 		analysisHandler
 	},
+	roots []AnalysisRoot,
 	logger *slog.Logger,
-) DragenAnalysisWatcher {
+) AnalysisWatcher {
 	filter := cleve.NewRunFilter()
 	filter.PageSize = 30
 	filter.State = cleve.StateReady.String()
-	return DragenAnalysisWatcher{
+	return AnalysisWatcher{
 		PollInterval: pollInterval,
 		store:        db,
-		analysisRoot: "Analysis",
+		roots:        roots,
 		runFilter:    filter,
 		logger:       logger,
 		quit:         make(chan struct{}),
@@ -174,13 +245,13 @@ func NewDragenAnalysisWatcher(
 	}
 }
 
-func (w *DragenAnalysisWatcher) Start() chan []AnalysisWatcherEvent {
-	w.logger.Info("starting dragen analysis watcher", "poll_interval", w.PollInterval)
+func (w *AnalysisWatcher) Start() chan []AnalysisWatcherEvent {
+	w.logger.Info("starting analysis watcher", "poll_interval", w.PollInterval)
 	go w.start()
 	return w.emit
 }
 
-func (w *DragenAnalysisWatcher) start() {
+func (w *AnalysisWatcher) start() {
 	defer close(w.done)
 
 	ticker := time.NewTicker(w.PollInterval)
@@ -197,15 +268,40 @@ func (w *DragenAnalysisWatcher) start() {
 	}
 }
 
-func (w *DragenAnalysisWatcher) Stop() {
-	w.logger.Info("stopping dragen analysis watcher, waiting for current poll (if any) finishes")
+func (w *AnalysisWatcher) Stop() {
+	w.logger.Info("stopping analysis watcher, waiting for current poll (if any) finishes")
 	close(w.quit)
 	<-w.done
-	w.logger.Info("dragen analysis watcher stopped")
+	w.logger.Info("analysis watcher stopped")
 }
 
-func (w *DragenAnalysisWatcher) Poll() {
-	w.logger.Debug("dragen analysis watcher start poll")
+func (w *AnalysisWatcher) Poll() {
+	w.logger.Debug("analysis watcher start poll")
+	var runRoots, absRoots []AnalysisRoot
+	for _, root := range w.roots {
+		if filepath.IsAbs(root.Path) {
+			absRoots = append(absRoots, root)
+		} else {
+			runRoots = append(runRoots, root)
+		}
+	}
+	events := make([]AnalysisWatcherEvent, 0)
+	if len(runRoots) > 0 {
+		events = append(events, w.pollRuns(runRoots)...)
+	}
+	for _, root := range absRoots {
+		events = append(events, w.pollRoot(root)...)
+	}
+	if len(events) > 0 {
+		w.logger.Debug("emitting analysis watcher events", "count", len(events))
+		w.emit <- events
+	}
+	w.logger.Debug("analysis watcher end poll")
+}
+
+// pollRuns checks the state of the existing analyses of all ready runs, and looks
+// for new analyses in the roots relative to the run directories.
+func (w *AnalysisWatcher) pollRuns(roots []AnalysisRoot) []AnalysisWatcherEvent {
 	w.runFilter.Page = 1
 	events := make([]AnalysisWatcherEvent, 0)
 	for {
@@ -233,63 +329,153 @@ func (w *DragenAnalysisWatcher) Poll() {
 			analysisPaths := make([]string, 0)
 			for _, a := range analyses.Analyses {
 				if !strings.HasPrefix(a.Path, r.Path) {
-					// If the analysis is not a direct child of this run, skip it.
-					// This should normally not happen for Dragen analyses.
+					// If the analysis is not located in the run directory, skip it.
 					continue
 				}
-				s := a.StateHistory.LastState()
-				currentState := a.DetectState()
-				slog.Debug("analysis state", "analysis_id", a.AnalysisId, "analysis_path", a.Path, "known_state", s, "current_state", currentState)
-				if currentState != s {
-					w.logger.Info("analysis state changed", "id", a.AnalysisId, "old_state", s, "new_state", currentState)
-					events = append(events, AnalysisWatcherEvent{
-						Analysis:     a,
-						State:        currentState,
-						StateChanged: true,
-					})
+				if e, ok := w.stateChange(a); ok {
+					events = append(events, e)
 				}
 				analysisPaths = append(analysisPaths, a.Path)
 			}
 
-			analysisRoot := filepath.Join(r.Path, w.analysisRoot)
-			w.logger.Debug("looking for new analyses", "run_id", r.RunID, "path", analysisRoot)
-			err = filepath.WalkDir(analysisRoot, func(path string, d fs.DirEntry, err error) error {
-				if err != nil {
-					w.logger.Warn("failed to read directory", "path", path, "error", err)
-					return filepath.SkipDir
-				}
-				if !d.IsDir() || path == analysisRoot {
-					return nil
-				}
-				w.logger.Debug("checking potential analysis directory", "path", path)
-				if slices.Contains(analysisPaths, path) {
-					w.logger.Debug("analysis already added to database", "path", path)
-					return filepath.SkipDir
+			for _, root := range roots {
+				pattern := filepath.Join(r.Path, root.Path)
+				events = append(events, w.findAnalyses(pattern, root, r, func(path string) bool {
+					return slices.Contains(analysisPaths, path)
+				})...)
+			}
+		}
+		if w.runFilter.Page >= runs.TotalPages {
+			break
+		}
+		w.runFilter.Page += 1
+	}
+	return events
+}
+
+// pollRoot looks for analyses in a root that is not associated with a run. The known
+// analyses below the root are fetched once, and since they are matched by path, state
+// changes are only detected for analyses that are still found in the root.
+func (w *AnalysisWatcher) pollRoot(root AnalysisRoot) []AnalysisWatcherEvent {
+	filter := cleve.NewAnalysisFilter()
+	filter.PageSize = 0 // Disable pagination
+	filter.PathPrefix = globBase(root.Path)
+	analyses, err := w.store.Analyses(filter)
+	if err != nil {
+		// Without the known analyses, existing analyses would be added again
+		w.logger.Error("failed to get analyses", "path", root.Path, "error", err)
+		return nil
+	}
+	knownAnalyses := make(map[string]*cleve.Analysis, len(analyses.Analyses))
+	for _, a := range analyses.Analyses {
+		knownAnalyses[a.Path] = a
+	}
+
+	events := make([]AnalysisWatcherEvent, 0)
+	newEvents := w.findAnalyses(root.Path, root, nil, func(path string) bool {
+		a, ok := knownAnalyses[path]
+		if !ok {
+			return false
+		}
+		if e, ok := w.stateChange(a); ok {
+			events = append(events, e)
+		}
+		return true
+	})
+	return append(events, newEvents...)
+}
+
+// globBase returns the leading part of a path pattern that does not contain any
+// glob meta characters.
+func globBase(pattern string) string {
+	base := filepath.Clean(pattern)
+	for strings.ContainsAny(base, `*?[\`) {
+		base = filepath.Dir(base)
+	}
+	return base
+}
+
+// stateChange returns a state change event if the current state of the analysis
+// differs from the last known state.
+func (w *AnalysisWatcher) stateChange(a *cleve.Analysis) (AnalysisWatcherEvent, bool) {
+	s := a.StateHistory.LastState()
+	currentState := a.DetectState()
+	w.logger.Debug("analysis state", "analysis_id", a.AnalysisId, "analysis_path", a.Path, "known_state", s, "current_state", currentState)
+	if currentState == s {
+		return AnalysisWatcherEvent{}, false
+	}
+	w.logger.Info("analysis state changed", "id", a.AnalysisId, "old_state", s, "new_state", currentState)
+	return AnalysisWatcherEvent{
+		Analysis:     a,
+		State:        currentState,
+		StateChanged: true,
+	}, true
+}
+
+// findAnalyses looks for new analyses in the directories matching the pattern of a
+// root. Directories for which known returns true are skipped, as are directories
+// below an analysis.
+func (w *AnalysisWatcher) findAnalyses(pattern string, root AnalysisRoot, run *cleve.Run, known func(string) bool) []AnalysisWatcherEvent {
+	var detectors []cleve.AnalysisDetector
+	for _, name := range root.Detectors {
+		d, ok := cleve.AnalysisDetectorByName(name)
+		if !ok {
+			w.logger.Error("unknown analysis detector", "detector", name, "root", root.Path)
+			continue
+		}
+		detectors = append(detectors, d)
+	}
+	depth := max(root.Depth, 1)
+
+	baseDirs, err := filepath.Glob(pattern)
+	if err != nil {
+		w.logger.Error("invalid analysis root", "path", pattern, "error", err)
+		return nil
+	}
+
+	events := make([]AnalysisWatcherEvent, 0)
+	for _, baseDir := range baseDirs {
+		w.logger.Debug("looking for new analyses", "path", baseDir)
+		err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				w.logger.Warn("failed to read directory", "path", path, "error", err)
+				return filepath.SkipDir
+			}
+			if !d.IsDir() || path == baseDir {
+				return nil
+			}
+			w.logger.Debug("checking potential analysis directory", "path", path)
+			if known(path) {
+				w.logger.Debug("analysis already added to database", "path", path)
+				return filepath.SkipDir
+			}
+			for _, detector := range detectors {
+				if !detector.Match(path) {
+					continue
 				}
-				w.logger.Debug("new analysis found", "run_id", r.RunID, "path", path)
-				newAnalysis, err := cleve.NewDragenAnalysis(path, r)
+				w.logger.Debug("new analysis found", "path", path, "detector", detector.Name())
+				newAnalysis, err := detector.New(path, run)
 				if err != nil {
-					w.logger.Error("failed to read analysis", "path", path, "error", err)
+					w.logger.Error("failed to read analysis", "path", path, "detector", detector.Name(), "error", err)
+					return filepath.SkipDir
 				}
+				newAnalysis.Detector = detector.Name()
 				events = append(events, AnalysisWatcherEvent{
 					Analysis: &newAnalysis,
 					State:    newAnalysis.StateHistory.LastState(),
 					New:      true,
 				})
 				return filepath.SkipDir
-			})
-			if err != nil {
-				w.logger.Error("failed to walk analyses", "path", analysisRoot)
 			}
+			rel, err := filepath.Rel(baseDir, path)
+			if err == nil && len(strings.Split(rel, string(filepath.Separator))) >= depth {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			w.logger.Error("failed to walk analyses", "path", baseDir, "error", err)
 		}
-		if w.runFilter.Page >= runs.TotalPages {
-			break
-		}
-		w.runFilter.Page += 1
-	}
-	if len(events) > 0 {
-		w.logger.Debug("emitting dragen analysis watcher events", "count", len(events))
-		w.emit <- events
 	}
-	w.logger.Debug("dragen analysis watcher end poll")
+	return events
 }
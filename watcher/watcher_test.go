@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -433,7 +434,7 @@ func TestDragenAnalysisWatcher(t *testing.T) {
 				t.Fatal("runs and disk analyses must have the same length, fix the test!")
 			}
 
-			w := NewDragenAnalysisWatcher(1*time.Minute, &db, logger)
+			w := NewAnalysisWatcher(1*time.Minute, &db, DefaultAnalysisRoots(), logger)
 			eventCh := w.Start()
 			defer w.Stop()
 
@@ -454,3 +455,162 @@ func TestDragenAnalysisWatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalysisRootValidate(t *testing.T) {
+	testcases := []struct {
+		name        string
+		root        AnalysisRoot
+		depth       int
+		shouldError bool
+	}{
+		{
+			name:  "default depth",
+			root:  AnalysisRoot{Path: "/data/nextflow", Detectors: []string{"nextflow"}},
+			depth: 1,
+		},
+		{
+			name:  "explicit depth",
+			root:  AnalysisRoot{Path: "Analysis", Detectors: []string{"dragen_bclconvert"}, Depth: 3},
+			depth: 3,
+		},
+		{
+			name:        "missing path",
+			root:        AnalysisRoot{Detectors: []string{"nextflow"}},
+			shouldError: true,
+		},
+		{
+			name:        "missing detectors",
+			root:        AnalysisRoot{Path: "/data/nextflow"},
+			shouldError: true,
+		},
+		{
+			name:        "unknown detector",
+			root:        AnalysisRoot{Path: "/data/snakemake", Detectors: []string{"snakemake"}},
+			shouldError: true,
+		},
+		{
+			name:        "negative depth",
+			root:        AnalysisRoot{Path: "/data/nextflow", Detectors: []string{"nextflow"}, Depth: -1},
+			shouldError: true,
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.root.Validate()
+			if (err != nil) != c.shouldError {
+				t.Fatalf("expected error %t, got %v", c.shouldError, err)
+			}
+			if err == nil && c.root.Depth != c.depth {
+				t.Errorf("expected depth %d, got %d", c.depth, c.root.Depth)
+			}
+		})
+	}
+}
+
+func TestAnalysisWatcherAbsoluteRoot(t *testing.T) {
+	root := t.TempDir()
+	trace := "task_id\tname\tstatus\n1\tMULTIQC\tCOMPLETED\n"
+	files := []string{
+		// Pipeline output two levels below the root
+		"project1/sarek/pipeline_info/execution_trace_2025-01-01_10-00-00.txt",
+		// Already known pipeline output that has finished
+		"project2/pipeline_info/execution_trace_2025-01-01_10-00-00.txt",
+		"project2/pipeline_info/execution_report_2025-01-01_10-00-00.html",
+		// Not a pipeline output, and too deep below the root
+		"project3/a/b/pipeline_info/execution_trace_2025-01-01_10-00-00.txt",
+	}
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(trace), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	known := &cleve.Analysis{
+		AnalysisId:   uuid.New(),
+		Path:         filepath.Join(root, "project2"),
+		Software:     "nf-core/rnaseq",
+		Detector:     "nextflow",
+		StateHistory: cleve.StateHistory{{Time: time.Now(), State: cleve.StatePending}},
+	}
+
+	db := struct {
+		mock.RunHandler
+		mock.AnalysesHandler
+	}{}
+	db.RunsFn = func(filter cleve.RunFilter) (cleve.RunResult, error) {
+		t.Error("runs should not be fetched for absolute roots")
+		return cleve.RunResult{}, nil
+	}
+	queries := 0
+	db.AnalysesFn = func(filter cleve.AnalysisFilter) (cleve.AnalysisResult, error) {
+		queries++
+		var analyses []*cleve.Analysis
+		if strings.HasPrefix(known.Path, filter.PathPrefix+string(filepath.Separator)) {
+			analyses = append(analyses, known)
+		}
+		return cleve.AnalysisResult{Analyses: analyses}, nil
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	roots := []AnalysisRoot{{Path: root, Detectors: []string{"nextflow"}, Depth: 2}}
+	w := NewAnalysisWatcher(1*time.Minute, &db, roots, logger)
+	eventCh := w.Start()
+	defer w.Stop()
+
+	go w.Poll()
+	events, err := tryConsumeChannel(eventCh, 10, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		for i, e := range events {
+			t.Logf("event %d: %+v", i+1, e)
+		}
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if queries != 1 {
+		t.Errorf("expected known analyses to be fetched once, got %d queries", queries)
+	}
+	for _, e := range events {
+		switch e.Analysis.Path {
+		case known.Path:
+			if e.New || !e.StateChanged || e.State != cleve.StateReady {
+				t.Errorf("expected known analysis to change state to ready, got %+v", e)
+			}
+		case filepath.Join(root, "project1", "sarek"):
+			if !e.New || e.State != cleve.StatePending {
+				t.Errorf("expected new pending analysis, got %+v", e)
+			}
+			if e.Analysis.Detector != "nextflow" {
+				t.Errorf("expected detector nextflow, got %q", e.Analysis.Detector)
+			}
+			if len(e.Analysis.Runs) != 0 {
+				t.Errorf("expected analysis without runs, got %v", e.Analysis.Runs)
+			}
+		default:
+			t.Errorf("unexpected event for %s", e.Analysis.Path)
+		}
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	testcases := []struct {
+		pattern string
+		base    string
+	}{
+		{"/data/nextflow", "/data/nextflow"},
+		{"/data/nextflow/", "/data/nextflow"},
+		{"/data/*/results", "/data"},
+		{"/data/project[12]/results", "/data"},
+		{"/*", "/"},
+	}
+	for _, c := range testcases {
+		if base := globBase(c.pattern); base != c.base {
+			t.Errorf("expected base of %q to be %q, got %q", c.pattern, c.base, base)
+		}
+	}
+}
@@ -36,6 +36,7 @@ const (
 	UnitInvalid MessageUnit = iota
 	UnitRun
 	UnitAnalysis
+	UnitSample
 )
 
 func (u MessageUnit) String() string {
@@ -44,6 +45,8 @@ func (u MessageUnit) String() string {
 		return "run"
 	case UnitAnalysis:
 		return "analysis"
+	case UnitSample:
+		return "sample"
 	}
 	return "undefined"
 }
@@ -56,12 +59,18 @@ type MessageType int
 
 const (
 	MessageStateUpdate MessageType = iota
+	MessageSampleSheetUpdate
+	MessageIdentityAlert
 )
 
 func (t MessageType) String() string {
 	switch t {
 	case MessageStateUpdate:
 		return "state_update"
+	case MessageSampleSheetUpdate:
+		return "samplesheet_updated"
+	case MessageIdentityAlert:
+		return "identity_alert"
 	}
 	return "undefined"
 }
@@ -125,3 +134,13 @@ func NewAnalysisMessage(analysis *Analysis, message string, messageType MessageT
 		Time:        time.Now().Local(),
 	}
 }
+
+func NewSampleMessage(sampleId string, message string, messageType MessageType) WebhookMessage {
+	return WebhookMessage{
+		Unit:        UnitSample,
+		Id:          sampleId,
+		Message:     message,
+		MessageType: messageType,
+		Time:        time.Now().Local(),
+	}
+}